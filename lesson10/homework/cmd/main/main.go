@@ -3,12 +3,20 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"homework10/internal/adapters/accounts"
+	"homework10/internal/adapters/adcache"
 	"homework10/internal/adapters/adfilter"
-	"homework10/internal/adapters/adrepo"
-	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/adstats"
+	"homework10/internal/adapters/contentcheck"
+	"homework10/internal/adapters/mailer"
+	"homework10/internal/adapters/notifier"
+	"homework10/internal/adapters/snowflake"
+	"homework10/internal/adapters/webhooks"
 	"homework10/internal/app"
 	"homework10/internal/ports/httpgin"
+	"homework10/internal/tenant"
 	"log"
 	"net"
 	"net/http"
@@ -17,8 +25,11 @@ import (
 	"syscall"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	grpcPorts "homework10/internal/ports/grpc"
 )
 
@@ -28,17 +39,119 @@ const (
 )
 const httpShutdownTime = 30 * time.Second
 
+const (
+	adShards    = 16
+	adCacheSize = 10000
+	adCacheTTL  = time.Minute
+)
+
+var (
+	dataDir          = flag.String("data-dir", "", "directory for write-ahead logs and snapshots, in-memory only if empty; messaging is disabled and -tenants is unsupported if set")
+	walSync          = flag.String("wal-sync", "interval", "write-ahead log fsync policy: always, interval or never")
+	snapshotInterval = flag.Duration("snapshot-interval", 10*time.Minute, "how often write-ahead logs are compacted")
+	postgresDSN      = flag.String("postgres-dsn", "", "PostgreSQL connection string, overrides -data-dir if set")
+	mailFile         = flag.String("mail-file", "", "file to write verification emails to, email verification is off if empty")
+	verificationTTL  = flag.Duration("verification-ttl", 24*time.Hour, "how long email verification tokens are valid")
+	nodeID           = flag.Int64("node-id", 0, "ID of this instance, instances sharing storage must have different IDs")
+	adminKey         = flag.String("admin-key", "", "key admins pass to create users with given IDs, import ads, moderate and manage webhooks, disabled if empty")
+	trashRetention   = flag.Duration("trash-retention", app.DefaultRetention, "how long deleted ads can be restored")
+	purgeInterval    = flag.Duration("purge-interval", time.Hour, "how often ads are purged from the trash")
+	cacheLogInterval = flag.Duration("cache-log-interval", 10*time.Minute, "how often ad cache stats are logged, never if 0")
+	idempotencyTTL   = flag.Duration("idempotency-ttl", app.DefaultIdempotencyTTL, "how long responses are kept for retries with the same Idempotency-Key")
+	viewWindow       = flag.Duration("view-window", adstats.DefaultWindow, "time during which repeated views of an ad by the same viewer count once")
+	tenantsFile      = flag.String("tenants", "", "YAML file with tenants served by the instance, single-tenant if empty; needs in-memory or PostgreSQL storage, can't be combined with -data-dir")
+	contentPolicy    = flag.String("content-policy", "", "YAML file with content rules ads are checked against, unchecked if empty")
+	hideThreshold    = flag.Int("hide-threshold", 0, "number of open reports which hide an ad until moderators review it, never hidden if 0, the default, as reporters aren't authenticated")
+	outboxLimit      = flag.Int("outbox-limit", notifier.DefaultLimit, "number of undelivered saved search notifications kept, older ones are dropped")
+	webhookWorkers   = flag.Int("webhook-workers", webhooks.DefaultWorkers, "number of webhook requests made at once")
+	webhookAttempts  = flag.Int("webhook-attempts", webhooks.DefaultMaxAttempts, "attempts after which a failing webhook delivery goes to the dead-letter list")
+)
+
 func main() {
+	flag.Parse()
+
 	lis, err := net.Listen("tcp", grpcPort)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New())
+	tenants := []tenant.ID{tenant.Default}
+	var registry *tenant.Registry
+	if *tenantsFile != "" {
+		registry, err = tenant.Load(*tenantsFile)
+		if err != nil {
+			log.Fatalf("failed to load tenants: %v", err)
+		}
+		tenants = registry.IDs()
+	}
+
+	var st storage
+	if *postgresDSN != "" {
+		st, err = openSQLStorage(context.Background(), *postgresDSN)
+	} else {
+		st, err = openStorage(*dataDir, *walSync, registry != nil)
+	}
+	if err != nil {
+		log.Fatalf("failed to open storage: %v", err)
+	}
+	defer st.close()
+	// closed before the storage, so the last views are flushed to it
+	views := adstats.NewCounter(st.stats, adstats.Options{Window: *viewWindow})
+	defer views.Close()
+	// closed before the storage too, so outcomes of the last attempts are logged to it
+	hooks := webhooks.NewDispatcher(st.webhooks, st.deliveries, webhooks.Options{Workers: *webhookWorkers,
+		MaxAttempts: *webhookAttempts})
+	defer hooks.Close()
+
+	// TODO: deliver notifications to users, for now the outbox keeps the latest ones
+	outbox := notifier.NewOutbox(*outboxLimit)
+	ids, err := snowflake.New(*nodeID)
+	if err != nil {
+		log.Fatalf("failed to create id generator: %v", err)
+	}
+	opts := []app.Option{app.WithFavorites(st.favorites), app.WithSavedSearches(st.searches, outbox),
+		app.WithAccounts(ids, accounts.NewBcrypt(bcrypt.DefaultCost), st.credentials, st.sessions),
+		app.WithAdminKey(*adminKey), app.WithRetention(*trashRetention),
+		app.WithExternalIDs(st.externalIDs), app.WithIdempotencyKeys(st.idemKeys, *idempotencyTTL),
+		app.WithAuditLog(st.auditLog), app.WithStats(st.stats, views),
+		app.WithReports(st.reports, *hideThreshold), app.WithWebhooks(st.webhooks, st.deliveries, hooks)}
+	if st.messages != nil {
+		opts = append(opts, app.WithMessages(st.messages, st.blocks))
+	} else {
+		log.Printf("messaging is disabled, as messages aren't kept in write-ahead logs")
+	}
+	if registry != nil {
+		opts = append(opts, app.WithTenants(registry))
+	}
+	if *contentPolicy != "" {
+		p, err := contentcheck.Load(*contentPolicy)
+		if err != nil {
+			log.Fatalf("failed to load content policy: %v", err)
+		}
+		opts = append(opts, app.WithContentPolicy(p))
+	}
+	if *mailFile != "" {
+		// TODO: send emails over SMTP, for now they are written to a file
+		opts = append(opts, app.WithVerification(st.tokens, mailer.NewFile(*mailFile), *verificationTTL))
+	}
+	var a app.App
+	if st.uow != nil {
+		// the cache can't see transactions, so it is used only with in-memory storage
+		a = app.NewApp(st.repo, st.users, adfilter.New(), append(opts, app.WithUnitOfWork(st.uow))...)
+	} else if st.cacheStats != nil {
+		a = app.NewApp(st.repo, st.users, adfilter.New(), opts...)
+	} else {
+		cache := adcache.New(st.repo, adCacheSize, adCacheTTL)
+		st.cacheStats = cache.Stats
+		a = app.NewApp(cache, st.users, adfilter.New(), opts...)
+	}
 
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPorts.UnaryInterceptor, grpcPorts.RecoveryInterceptor))
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPorts.UnaryInterceptor, grpcPorts.RecoveryInterceptor,
+		grpcPorts.LocaleInterceptor, grpcPorts.TenantInterceptor(a), grpcPorts.IdempotencyInterceptor, grpcPorts.RequestInterceptor))
 	grpcService := grpcPorts.NewService(a)
 	grpcPorts.RegisterAdServiceServer(grpcServer, grpcService)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 
 	httpServer := httpgin.NewHTTPServer(httpPort, a)
 
@@ -58,6 +171,57 @@ func main() {
 		}
 	})
 
+	eg.Go(func() error {
+		ticker := time.NewTicker(*snapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := st.snapshot(); err != nil {
+					log.Printf("can't take snapshot: %s", err.Error())
+				}
+			case <-ctx.Done():
+				return st.snapshot()
+			}
+		}
+	})
+
+	if st.cacheStats != nil && *cacheLogInterval > 0 {
+		eg.Go(func() error {
+			ticker := time.NewTicker(*cacheLogInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					s := st.cacheStats()
+					log.Printf("ad cache: %d hits, %d misses, %d evictions, %d invalidations",
+						s.Hits, s.Misses, s.Evictions, s.Invalidations)
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		})
+	}
+
+	eg.Go(func() error {
+		ticker := time.NewTicker(*purgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				for _, id := range tenants {
+					if n, err := a.PurgeTrash(tenant.NewContext(ctx, id)); err != nil {
+						log.Printf("can't purge trash of %s: %s", id, err.Error())
+					} else if n > 0 {
+						log.Printf("purged %d ads of %s from trash", n, id)
+					}
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+
 	eg.Go(func() error {
 		log.Printf("starting grpc server, listening on %s\n", grpcPort)
 		defer log.Printf("close grpc server listening on %s\n", grpcPort)
@@ -65,6 +229,8 @@ func main() {
 		errCh := make(chan error)
 
 		defer func() {
+			// lets load balancers stop routing to the instance before it goes away
+			healthServer.Shutdown()
 			grpcServer.GracefulStop()
 			_ = lis.Close()
 
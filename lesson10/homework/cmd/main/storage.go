@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"homework10/internal/adapters/accounts"
+	"homework10/internal/adapters/adcache"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/adstats"
+	"homework10/internal/adapters/auditlog"
+	"homework10/internal/adapters/chat"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/extids"
+	"homework10/internal/adapters/favorites"
+	"homework10/internal/adapters/idemkeys"
+	"homework10/internal/adapters/reports"
+	"homework10/internal/adapters/searches"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/adapters/tenancy"
+	"homework10/internal/adapters/tokens"
+	"homework10/internal/adapters/wal"
+	"homework10/internal/adapters/webhooks"
+	"homework10/internal/app"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+const walSyncInterval = time.Second
+
+type storage struct {
+	repo        app.Repository
+	users       app.Users
+	uow         app.UnitOfWork
+	favorites   app.Favorites
+	searches    app.SavedSearches
+	messages    app.Messages
+	blocks      app.Blocks
+	tokens      app.VerificationTokens
+	credentials app.Credentials
+	sessions    app.Sessions
+	externalIDs app.ExternalIDs
+	idemKeys    app.IdempotencyKeys
+	auditLog    app.AuditLog
+	stats       app.Stats
+	reports     app.Reports
+	webhooks    app.Webhooks
+	deliveries  app.WebhookDeliveries
+	// cacheStats is set if the repository already has caches in front of it
+	cacheStats func() adcache.Stats
+	snapshot   func() error
+	close      func()
+}
+
+// cacheGroup keeps the ad caches of all tenants, so that their stats are logged together.
+type cacheGroup struct {
+	mx     sync.Mutex
+	caches []*adcache.CachedRepo
+}
+
+func (d *cacheGroup) add(c *adcache.CachedRepo) *adcache.CachedRepo {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.caches = append(d.caches, c)
+	return c
+}
+
+func (d *cacheGroup) stats() adcache.Stats {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	res := adcache.Stats{}
+	for _, c := range d.caches {
+		s := c.Stats()
+		res.Hits += s.Hits
+		res.Misses += s.Misses
+		res.Evictions += s.Evictions
+		res.Invalidations += s.Invalidations
+	}
+	return res
+}
+
+// openStorage keeps everything in memory if dir is empty, otherwise ads, users, credentials and
+// external IDs are recovered from and logged to write-ahead logs in dir and messaging is disabled.
+// With multiTenant every tenant gets its own in-memory ads and users, write-ahead logs aren't
+// supported then.
+func openStorage(dir string, syncPolicy string, multiTenant bool) (storage, error) {
+	if dir == "" && multiTenant {
+		caches := &cacheGroup{}
+		return storage{
+			// every tenant has its own cache, as ad IDs repeat across tenants
+			repo: tenancy.NewRepository(func() app.Repository {
+				return caches.add(adcache.New(adrepo.NewSharded(adShards), adCacheSize, adCacheTTL))
+			}),
+			users:       tenancy.NewUsers(customer.New),
+			cacheStats:  caches.stats,
+			favorites:   tenancy.NewFavorites(favorites.New),
+			searches:    tenancy.NewSavedSearches(searches.New),
+			messages:    tenancy.NewMessages(chat.New),
+			blocks:      tenancy.NewBlocks(chat.NewBlocks),
+			tokens:      tokens.New(),
+			credentials: accounts.NewCredentials(),
+			sessions:    accounts.NewSessions(),
+			externalIDs: tenancy.NewExternalIDs(extids.New),
+			idemKeys:    idemkeys.New(),
+			auditLog:    auditlog.New(),
+			stats:       adstats.New(),
+			reports:     reports.New(),
+			webhooks:    webhooks.New(),
+			deliveries:  webhooks.NewDeliveries(),
+			snapshot:    func() error { return nil },
+			close:       func() {},
+		}, nil
+	}
+	if multiTenant {
+		// TODO: keep a write-ahead log per tenant
+		return storage{}, fmt.Errorf("tenants aren't supported with write-ahead logs yet")
+	}
+	if dir == "" {
+		return storage{
+			repo:        adrepo.NewSharded(adShards),
+			users:       customer.New(),
+			favorites:   favorites.New(),
+			searches:    searches.New(),
+			messages:    chat.New(),
+			blocks:      chat.NewBlocks(),
+			tokens:      tokens.New(),
+			credentials: accounts.NewCredentials(),
+			sessions:    accounts.NewSessions(),
+			externalIDs: extids.New(),
+			idemKeys:    idemkeys.New(),
+			auditLog:    auditlog.New(),
+			stats:       adstats.New(),
+			reports:     reports.New(),
+			webhooks:    webhooks.New(),
+			deliveries:  webhooks.NewDeliveries(),
+			snapshot:    func() error { return nil },
+			close:       func() {},
+		}, nil
+	}
+
+	opts := wal.Options{SyncInterval: walSyncInterval}
+	switch syncPolicy {
+	case "always":
+		opts.Sync = wal.SyncAlways
+	case "interval":
+		opts.Sync = wal.SyncInterval
+	case "never":
+		opts.Sync = wal.SyncNever
+	default:
+		return storage{}, fmt.Errorf("unknown wal sync policy %q", syncPolicy)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return storage{}, err
+	}
+	adsLog, err := wal.Open(filepath.Join(dir, "ads"), opts)
+	if err != nil {
+		return storage{}, err
+	}
+	usersLog, err := wal.Open(filepath.Join(dir, "users"), opts)
+	if err != nil {
+		_ = adsLog.Close()
+		return storage{}, err
+	}
+	credentialsLog, err := wal.Open(filepath.Join(dir, "credentials"), opts)
+	if err != nil {
+		_ = adsLog.Close()
+		_ = usersLog.Close()
+		return storage{}, err
+	}
+	externalIDsLog, err := wal.Open(filepath.Join(dir, "external_ids"), opts)
+	if err != nil {
+		_ = adsLog.Close()
+		_ = usersLog.Close()
+		_ = credentialsLog.Close()
+		return storage{}, err
+	}
+	closeLogs := func() {
+		for _, l := range []*wal.Log{adsLog, usersLog, credentialsLog, externalIDsLog} {
+			if err := l.Close(); err != nil {
+				log.Printf("can't close write-ahead log: %s", err.Error())
+			}
+		}
+	}
+
+	repo, err := adrepo.NewPersistent(adsLog)
+	if err != nil {
+		closeLogs()
+		return storage{}, fmt.Errorf("can't recover ads: %w", err)
+	}
+	users, err := customer.NewPersistent(usersLog)
+	if err != nil {
+		closeLogs()
+		return storage{}, fmt.Errorf("can't recover users: %w", err)
+	}
+	credentials, err := accounts.NewPersistentCredentials(credentialsLog)
+	if err != nil {
+		closeLogs()
+		return storage{}, fmt.Errorf("can't recover credentials: %w", err)
+	}
+	externalIDs, err := extids.NewPersistent(externalIDsLog)
+	if err != nil {
+		closeLogs()
+		return storage{}, fmt.Errorf("can't recover external IDs: %w", err)
+	}
+	auditLog, err := auditlog.NewFile(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		closeLogs()
+		return storage{}, fmt.Errorf("can't open audit log: %w", err)
+	}
+
+	// messages aren't kept in write-ahead logs, so messaging is off instead of losing them on restart;
+	// sessions and verification tokens aren't either, after a restart users log in and request verification again
+	return storage{
+		repo:        repo,
+		users:       users,
+		favorites:   favorites.New(),
+		searches:    searches.New(),
+		tokens:      tokens.New(),
+		credentials: credentials,
+		sessions:    accounts.NewSessions(),
+		externalIDs: externalIDs,
+		idemKeys:    idemkeys.New(),
+		auditLog:    auditLog,
+		stats:       adstats.New(),
+		reports:     reports.New(),
+		webhooks:    webhooks.New(),
+		deliveries:  webhooks.NewDeliveries(),
+		snapshot: func() error {
+			if err := repo.Snapshot(); err != nil {
+				return err
+			}
+			if err := users.Snapshot(); err != nil {
+				return err
+			}
+			if err := credentials.Snapshot(); err != nil {
+				return err
+			}
+			return externalIDs.Snapshot()
+		},
+		close: closeLogs,
+	}, nil
+}
+
+// openSQLStorage keeps ads and users in PostgreSQL, multi-step operations run in transactions.
+// Every store is scoped to the tenant of the context.
+func openSQLStorage(ctx context.Context, dsn string) (storage, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return storage{}, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return storage{}, err
+	}
+	if err := sqlstore.Migrate(ctx, db); err != nil {
+		_ = db.Close()
+		return storage{}, fmt.Errorf("can't migrate database: %w", err)
+	}
+	return storage{
+		repo:        sqlstore.NewAdRepo(db),
+		users:       sqlstore.NewUsers(db),
+		uow:         sqlstore.NewUnitOfWork(db),
+		favorites:   sqlstore.NewFavorites(db),
+		searches:    sqlstore.NewSavedSearches(db),
+		messages:    sqlstore.NewMessages(db),
+		blocks:      sqlstore.NewBlocks(db),
+		tokens:      sqlstore.NewVerificationTokens(db),
+		credentials: sqlstore.NewCredentials(db),
+		sessions:    sqlstore.NewSessions(db),
+		externalIDs: sqlstore.NewExternalIDs(db),
+		idemKeys:    sqlstore.NewIdempotencyKeys(db),
+		auditLog:    sqlstore.NewAuditLog(db),
+		stats:       sqlstore.NewAdStats(db),
+		reports:     sqlstore.NewReports(db),
+		webhooks:    sqlstore.NewWebhooks(db),
+		deliveries:  sqlstore.NewWebhookDeliveries(db),
+		snapshot:    func() error { return nil },
+		close: func() {
+			if err := db.Close(); err != nil {
+				log.Printf("can't close database: %s", err.Error())
+			}
+		},
+	}, nil
+}
@@ -0,0 +1,30 @@
+package accounts
+
+import (
+	"homework10/internal/adapters/wal"
+	"homework10/internal/app"
+	"homework10/internal/session"
+	"sync"
+)
+
+func NewCredentials() app.Credentials {
+	return &MapCredentials{mx: &sync.RWMutex{}, mp: map[userKey][]byte{}}
+}
+
+// NewPersistentCredentials recovers credentials from the log and appends every further mutation to it.
+func NewPersistentCredentials(log *wal.Log) (*MapCredentials, error) {
+	d := &MapCredentials{mx: &sync.RWMutex{}, mp: map[userKey][]byte{}, log: log}
+	if err := log.Replay(d.apply); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func NewSessions() app.Sessions {
+	return &MapSessions{mx: &sync.RWMutex{}, mp: map[string]session.Session{}}
+}
+
+// NewBcrypt hashes passwords with bcrypt of the given cost, see bcrypt.DefaultCost.
+func NewBcrypt(cost int) app.PasswordHasher {
+	return &Bcrypt{cost: cost}
+}
@@ -0,0 +1,15 @@
+package accounts
+
+import "golang.org/x/crypto/bcrypt"
+
+type Bcrypt struct {
+	cost int
+}
+
+func (d *Bcrypt) Hash(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), d.cost)
+}
+
+func (d *Bcrypt) Compare(hash []byte, password string) error {
+	return bcrypt.CompareHashAndPassword(hash, []byte(password))
+}
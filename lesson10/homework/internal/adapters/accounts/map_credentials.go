@@ -0,0 +1,51 @@
+package accounts
+
+import (
+	"context"
+	"homework10/internal/adapters/wal"
+	"homework10/internal/tenant"
+	"sync"
+)
+
+// userKey identifies a user, IDs of users repeat across tenants.
+type userKey struct {
+	tenant tenant.ID
+	userID int64
+}
+
+type MapCredentials struct {
+	mx  *sync.RWMutex
+	mp  map[userKey][]byte
+	log *wal.Log
+}
+
+func (d *MapCredentials) Set(ctx context.Context, userID int64, hash []byte) error {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	hash = append([]byte{}, hash...)
+	if err := d.persist(credentialRecord{Op: opSet, Tenant: tenantID, UserID: userID, Hash: hash}); err != nil {
+		return err
+	}
+	d.mp[userKey{tenant: tenantID, userID: userID}] = hash
+	return nil
+}
+
+func (d *MapCredentials) Get(ctx context.Context, userID int64) ([]byte, bool) {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	hash, ok := d.mp[userKey{tenant: tenantID, userID: userID}]
+	return hash, ok
+}
+
+func (d *MapCredentials) DeleteByUser(ctx context.Context, userID int64) error {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if err := d.persist(credentialRecord{Op: opDelete, Tenant: tenantID, UserID: userID}); err != nil {
+		return err
+	}
+	delete(d.mp, userKey{tenant: tenantID, userID: userID})
+	return nil
+}
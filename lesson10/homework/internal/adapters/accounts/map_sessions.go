@@ -0,0 +1,42 @@
+package accounts
+
+import (
+	"context"
+	"homework10/internal/session"
+	"homework10/internal/tenant"
+	"sync"
+)
+
+type MapSessions struct {
+	mx *sync.RWMutex
+	mp map[string]session.Session
+}
+
+func (d *MapSessions) Add(ctx context.Context, s session.Session) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.mp[s.Token] = s
+	return nil
+}
+
+func (d *MapSessions) Find(ctx context.Context, token string) (session.Session, bool) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	s, ok := d.mp[token]
+	if !ok || s.Tenant != tenant.FromContext(ctx) {
+		return session.Session{}, false
+	}
+	return s, true
+}
+
+func (d *MapSessions) DeleteByUser(ctx context.Context, userID int64) error {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for token, s := range d.mp {
+		if s.Tenant == tenantID && s.UserID == userID {
+			delete(d.mp, token)
+		}
+	}
+	return nil
+}
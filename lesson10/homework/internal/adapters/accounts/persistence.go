@@ -0,0 +1,69 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework10/internal/tenant"
+)
+
+const (
+	opSet    = "set"
+	opDelete = "delete"
+)
+
+type credentialRecord struct {
+	Op     string    `json:"op"`
+	Tenant tenant.ID `json:"tenant"`
+	UserID int64     `json:"user_id"`
+	Hash   []byte    `json:"hash,omitempty"`
+}
+
+// persist writes the mutation to the log before it is applied, it's a no-op for in-memory only stores.
+func (d *MapCredentials) persist(rec credentialRecord) error {
+	if d.log == nil {
+		return nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return d.log.Append(data)
+}
+
+func (d *MapCredentials) apply(data []byte) error {
+	var rec credentialRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+	key := userKey{tenant: rec.Tenant, userID: rec.UserID}
+	switch rec.Op {
+	case opSet:
+		d.mp[key] = rec.Hash
+	case opDelete:
+		delete(d.mp, key)
+	default:
+		return fmt.Errorf("accounts: unknown log record %q", rec.Op)
+	}
+	return nil
+}
+
+// Snapshot compacts the log into a snapshot of the current state.
+func (d *MapCredentials) Snapshot() error {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	if d.log == nil {
+		return nil
+	}
+	return d.log.Snapshot(func(emit func(rec []byte) error) error {
+		for key, hash := range d.mp {
+			data, err := json.Marshal(credentialRecord{Op: opSet, Tenant: key.tenant, UserID: key.userID, Hash: hash})
+			if err != nil {
+				return err
+			}
+			if err := emit(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,18 @@
+package adcache
+
+import (
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"sync"
+	"time"
+)
+
+func New(repo app.Repository, size int, ttl time.Duration) *CachedRepo {
+	return &CachedRepo{
+		repo:   repo,
+		mx:     &sync.Mutex{},
+		ads:    newLRU[int64, ads.Ad](size, ttl),
+		lists:  newLRU[string, listEntry](size, ttl),
+		titles: newLRU[string, titleEntry](size, ttl),
+	}
+}
@@ -0,0 +1,291 @@
+package adcache
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Stats struct {
+	Hits          int64
+	Misses        int64
+	Evictions     int64
+	Invalidations int64
+}
+
+type listEntry struct {
+	pattern adpattern.AdPattern
+	ads     []ads.Ad
+}
+
+type titleEntry struct {
+	title string
+	ads   []ads.Ad
+}
+
+// CachedRepo is a read-through cache for Find, GetAllByTemplate and GetByTitle.
+// Every mutation invalidates only the entries the changed ad could belong to.
+type CachedRepo struct {
+	repo   app.Repository
+	mx     *sync.Mutex
+	ads    *lru[int64, ads.Ad]
+	lists  *lru[string, listEntry]
+	titles *lru[string, titleEntry]
+	// gen is bumped on every invalidation, so a fill that raced with a write is dropped
+	gen uint64
+
+	hits          int64
+	misses        int64
+	evictions     int64
+	invalidations int64
+}
+
+func (d *CachedRepo) Stats() Stats {
+	return Stats{
+		Hits:          atomic.LoadInt64(&d.hits),
+		Misses:        atomic.LoadInt64(&d.misses),
+		Evictions:     atomic.LoadInt64(&d.evictions),
+		Invalidations: atomic.LoadInt64(&d.invalidations),
+	}
+}
+
+func (d *CachedRepo) Find(ctx context.Context, adID int64) (ads.Ad, bool) {
+	d.mx.Lock()
+	if ad, ok := d.ads.get(adID, time.Now()); ok {
+		d.mx.Unlock()
+		atomic.AddInt64(&d.hits, 1)
+		return ad, true
+	}
+	gen := d.gen
+	d.mx.Unlock()
+	atomic.AddInt64(&d.misses, 1)
+
+	ad, isFound := d.repo.Find(ctx, adID)
+	if isFound {
+		d.mx.Lock()
+		if d.gen == gen {
+			d.evicted(d.ads.put(adID, ad, time.Now()))
+		}
+		d.mx.Unlock()
+	}
+	return ad, isFound
+}
+
+func (d *CachedRepo) GetAllByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error) {
+	key := patternKey(adp)
+	d.mx.Lock()
+	if entry, ok := d.lists.get(key, time.Now()); ok {
+		d.mx.Unlock()
+		atomic.AddInt64(&d.hits, 1)
+		return copyAds(entry.ads), nil
+	}
+	gen := d.gen
+	d.mx.Unlock()
+	atomic.AddInt64(&d.misses, 1)
+
+	res, err := d.repo.GetAllByTemplate(ctx, adp)
+	if err != nil {
+		return res, err
+	}
+	d.mx.Lock()
+	if d.gen == gen {
+		d.evicted(d.lists.put(key, listEntry{pattern: adp, ads: copyAds(res)}, time.Now()))
+	}
+	d.mx.Unlock()
+	return res, nil
+}
+
+func (d *CachedRepo) GetByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
+	d.mx.Lock()
+	if entry, ok := d.titles.get(title, time.Now()); ok {
+		d.mx.Unlock()
+		atomic.AddInt64(&d.hits, 1)
+		return copyAds(entry.ads), nil
+	}
+	gen := d.gen
+	d.mx.Unlock()
+	atomic.AddInt64(&d.misses, 1)
+
+	res, err := d.repo.GetByTitle(ctx, title)
+	if err != nil {
+		return res, err
+	}
+	d.mx.Lock()
+	if d.gen == gen {
+		d.evicted(d.titles.put(title, titleEntry{title: title, ads: copyAds(res)}, time.Now()))
+	}
+	d.mx.Unlock()
+	return res, nil
+}
+
+func (d *CachedRepo) Add(ctx context.Context, title string, text string, userID int64) (int64, error) {
+	adID, err := d.repo.Add(ctx, title, text, userID)
+	if err != nil {
+		return adID, err
+	}
+	d.invalidate(adID, d.versions(ctx, adID)...)
+	return adID, nil
+}
+
+func (d *CachedRepo) Insert(ctx context.Context, ad ads.Ad) error {
+	old := d.versions(ctx, ad.ID)
+	err := d.repo.Insert(ctx, ad)
+	d.invalidate(ad.ID, append(old, ad)...)
+	return err
+}
+
+func (d *CachedRepo) SetTitle(ctx context.Context, adID int64, title string) error {
+	return d.update(ctx, adID, func() error {
+		return d.repo.SetTitle(ctx, adID, title)
+	})
+}
+
+func (d *CachedRepo) SetText(ctx context.Context, adID int64, text string) error {
+	return d.update(ctx, adID, func() error {
+		return d.repo.SetText(ctx, adID, text)
+	})
+}
+
+func (d *CachedRepo) SetStatus(ctx context.Context, adID int64, status bool) error {
+	return d.update(ctx, adID, func() error {
+		return d.repo.SetStatus(ctx, adID, status)
+	})
+}
+
+func (d *CachedRepo) SetHidden(ctx context.Context, adID int64, hidden bool) error {
+	return d.update(ctx, adID, func() error {
+		return d.repo.SetHidden(ctx, adID, hidden)
+	})
+}
+
+func (d *CachedRepo) SetLang(ctx context.Context, adID int64, lang string, declared bool) error {
+	return d.update(ctx, adID, func() error {
+		return d.repo.SetLang(ctx, adID, lang, declared)
+	})
+}
+
+func (d *CachedRepo) Delete(ctx context.Context, adID int64) error {
+	old := d.versions(ctx, adID)
+	err := d.repo.Delete(ctx, adID)
+	d.invalidate(adID, old...)
+	return err
+}
+
+func (d *CachedRepo) DeleteByAuthor(ctx context.Context, userID int64) error {
+	err := d.repo.DeleteByAuthor(ctx, userID)
+
+	byAuthor := func(list []ads.Ad) bool {
+		for _, ad := range list {
+			if ad.AuthorID == userID {
+				return true
+			}
+		}
+		return false
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.gen++
+	removed := d.ads.removeIf(func(_ int64, ad ads.Ad) bool {
+		return ad.AuthorID == userID
+	})
+	removed += d.lists.removeIf(func(_ string, entry listEntry) bool {
+		return byAuthor(entry.ads)
+	})
+	removed += d.titles.removeIf(func(_ string, entry titleEntry) bool {
+		return byAuthor(entry.ads)
+	})
+	atomic.AddInt64(&d.invalidations, int64(removed))
+	return err
+}
+
+func (d *CachedRepo) FindDeleted(ctx context.Context, adID int64) (ads.Ad, bool) {
+	return d.repo.FindDeleted(ctx, adID)
+}
+
+func (d *CachedRepo) ListDeleted(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	return d.repo.ListDeleted(ctx, userID)
+}
+
+func (d *CachedRepo) Restore(ctx context.Context, adID int64) error {
+	err := d.repo.Restore(ctx, adID)
+	d.invalidate(adID, d.versions(ctx, adID)...)
+	return err
+}
+
+// Purge doesn't touch the cache, trashed ads were already invalidated on deletion.
+func (d *CachedRepo) Purge(ctx context.Context, before time.Time) ([]int64, error) {
+	return d.repo.Purge(ctx, before)
+}
+
+// update runs a single-ad mutation and drops the cached entries matching the ad
+// both before and after the change.
+func (d *CachedRepo) update(ctx context.Context, adID int64, mutate func() error) error {
+	old := d.versions(ctx, adID)
+	err := mutate()
+	d.invalidate(adID, append(old, d.versions(ctx, adID)...)...)
+	return err
+}
+
+func (d *CachedRepo) versions(ctx context.Context, adID int64) []ads.Ad {
+	if ad, isFound := d.repo.Find(ctx, adID); isFound {
+		return []ads.Ad{ad}
+	}
+	return nil
+}
+
+func (d *CachedRepo) invalidate(adID int64, versions ...ads.Ad) {
+	matches := func(list []ads.Ad, pred func(ad ads.Ad) bool) bool {
+		for _, ad := range list {
+			if ad.ID == adID {
+				return true
+			}
+		}
+		for _, ad := range versions {
+			if pred(ad) {
+				return true
+			}
+		}
+		return false
+	}
+
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.gen++
+	removed := 0
+	if d.ads.remove(adID) {
+		removed++
+	}
+	removed += d.lists.removeIf(func(_ string, entry listEntry) bool {
+		return matches(entry.ads, func(ad ads.Ad) bool {
+			return app.CheckAd(ad, entry.pattern)
+		})
+	})
+	removed += d.titles.removeIf(func(_ string, entry titleEntry) bool {
+		return matches(entry.ads, func(ad ads.Ad) bool {
+			return strings.HasPrefix(ad.Title, entry.title)
+		})
+	})
+	atomic.AddInt64(&d.invalidations, int64(removed))
+}
+
+func (d *CachedRepo) evicted(ok bool) {
+	if ok {
+		atomic.AddInt64(&d.evictions, 1)
+	}
+}
+
+func patternKey(adp adpattern.AdPattern) string {
+	return fmt.Sprintf("%#v", adp)
+}
+
+func copyAds(list []ads.Ad) []ads.Ad {
+	res := make([]ads.Ad, len(list))
+	copy(res, list)
+	return res
+}
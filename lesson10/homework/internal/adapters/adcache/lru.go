@@ -0,0 +1,92 @@
+package adcache
+
+import (
+	"container/list"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// lru is not safe for concurrent use, CachedRepo guards it with its own mutex.
+type lru[K comparable, V any] struct {
+	size  int
+	ttl   time.Duration
+	order *list.List
+	items map[K]*list.Element
+}
+
+func newLRU[K comparable, V any](size int, ttl time.Duration) *lru[K, V] {
+	return &lru[K, V]{size: size, ttl: ttl, order: list.New(), items: map[K]*list.Element{}}
+}
+
+func (d *lru[K, V]) get(key K, now time.Time) (V, bool) {
+	var zero V
+	el, ok := d.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*lruEntry[K, V])
+	if d.ttl > 0 && now.After(entry.expiresAt) {
+		d.removeElement(el)
+		return zero, false
+	}
+	d.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// put returns true if some other entry was evicted to keep the cache size-bounded.
+func (d *lru[K, V]) put(key K, value V, now time.Time) bool {
+	if d.size <= 0 {
+		return false
+	}
+	if el, ok := d.items[key]; ok {
+		entry := el.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expiresAt = now.Add(d.ttl)
+		d.order.MoveToFront(el)
+		return false
+	}
+	d.items[key] = d.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: now.Add(d.ttl)})
+	if d.order.Len() > d.size {
+		d.removeElement(d.order.Back())
+		return true
+	}
+	return false
+}
+
+func (d *lru[K, V]) remove(key K) bool {
+	el, ok := d.items[key]
+	if !ok {
+		return false
+	}
+	d.removeElement(el)
+	return true
+}
+
+// removeIf drops every entry matching pred and returns how many were dropped.
+func (d *lru[K, V]) removeIf(pred func(key K, value V) bool) int {
+	removed := 0
+	for el := d.order.Front(); el != nil; {
+		next := el.Next()
+		entry := el.Value.(*lruEntry[K, V])
+		if pred(entry.key, entry.value) {
+			d.removeElement(el)
+			removed++
+		}
+		el = next
+	}
+	return removed
+}
+
+func (d *lru[K, V]) len() int {
+	return d.order.Len()
+}
+
+func (d *lru[K, V]) removeElement(el *list.Element) {
+	d.order.Remove(el)
+	delete(d.items, el.Value.(*lruEntry[K, V]).key)
+}
@@ -2,64 +2,65 @@ package adfilter
 
 import (
 	"context"
+	"homework10/internal/adexpr"
 	"homework10/internal/adpattern"
 	"homework10/internal/app"
-	"sync"
 	"time"
 )
 
+// BasicFilter is an immutable value, every setter returns a changed copy, so a filter
+// shared by the app is never modified by concurrent requests.
 type BasicFilter struct {
-	mx      *sync.RWMutex
 	pattern adpattern.AdPattern
 }
 
-func (d *BasicFilter) BasicConfig(ctx context.Context) (app.Filter, error) {
-	d.mx.Lock()
-	defer d.mx.Unlock()
-
-	d.pattern.IsLTimeSet = false
-	d.pattern.IsRTimeSet = false
-	d.pattern.PublishedOnly = true
-	return d, nil
+func (d BasicFilter) BasicConfig(ctx context.Context) (app.Filter, error) {
+	return BasicFilter{pattern: adpattern.AdPattern{PublishedOnly: true}}, nil
 }
 
-func (d *BasicFilter) SetStatus(ctx context.Context, publishedOnly bool) (app.Filter, error) {
-	d.mx.Lock()
-	defer d.mx.Unlock()
-
+func (d BasicFilter) SetStatus(ctx context.Context, publishedOnly bool) (app.Filter, error) {
 	d.pattern.PublishedOnly = publishedOnly
 	return d, nil
 }
 
-func (d *BasicFilter) SetAuthor(ctx context.Context, userID int64) (app.Filter, error) {
-	d.mx.Lock()
-	defer d.mx.Unlock()
-
+func (d BasicFilter) SetAuthor(ctx context.Context, userID int64) (app.Filter, error) {
 	d.pattern.AuthorID = userID
 	return d, nil
 }
 
-func (d *BasicFilter) SetLTime(ctx context.Context, l time.Time) (app.Filter, error) {
-	d.mx.Lock()
-	defer d.mx.Unlock()
+func (d BasicFilter) SetLang(ctx context.Context, lang string) (app.Filter, error) {
+	d.pattern.Lang = lang
+	return d, nil
+}
 
+func (d BasicFilter) SetLTime(ctx context.Context, l time.Time) (app.Filter, error) {
 	d.pattern.IsLTimeSet = true
 	d.pattern.LDate = l
 	return d, nil
 }
 
-func (d *BasicFilter) SetRTime(ctx context.Context, r time.Time) (app.Filter, error) {
-	d.mx.Lock()
-	defer d.mx.Unlock()
-
+func (d BasicFilter) SetRTime(ctx context.Context, r time.Time) (app.Filter, error) {
 	d.pattern.IsRTimeSet = true
 	d.pattern.RDate = r
 	return d, nil
 }
 
-func (d *BasicFilter) GetPattern(ctx context.Context) (adpattern.AdPattern, error) {
-	d.mx.RLock()
-	defer d.mx.RUnlock()
+func (d BasicFilter) SetSort(ctx context.Context, order []adpattern.OrderBy) (app.Filter, error) {
+	if err := adpattern.ValidateSort(order); err != nil {
+		return d, err
+	}
+	// the caller may reuse its slice
+	d.pattern.Sort = append([]adpattern.OrderBy(nil), order...)
+	return d, nil
+}
+
+func (d BasicFilter) SetExpr(ctx context.Context, expr adexpr.Expr) (app.Filter, error) {
+	d.pattern.Expr = expr
+	return d, nil
+}
 
-	return d.pattern, nil
+func (d BasicFilter) GetPattern(ctx context.Context) (adpattern.AdPattern, error) {
+	adp := d.pattern
+	adp.Sort = append([]adpattern.OrderBy(nil), d.pattern.Sort...)
+	return adp, nil
 }
@@ -2,19 +2,29 @@ package adrepo
 
 import (
 	"context"
+	"homework10/internal/adapters/wal"
 	"homework10/internal/adpattern"
 	"homework10/internal/ads"
 	"homework10/internal/app"
+	"math"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 )
 
+// MapRepo keeps ads in a map together with secondary indexes,
+// so queries run in time proportional to the result instead of the whole map.
+// Deleted ads are moved out of the indexes to the trash until they are restored or purged.
 type MapRepo struct {
-	mx    *sync.RWMutex
-	mp    map[int64]ads.Ad
-	curID int64
+	mx        *sync.RWMutex
+	mp        map[int64]ads.Ad
+	trash     map[int64]ads.Ad
+	curID     int64
+	log       *wal.Log
+	byDate    *skiplist
+	byAuthor  map[int64]*skiplist
+	published *skiplist
+	titles    *trie
 }
 
 func (d *MapRepo) Find(ctx context.Context, adID int64) (ads.Ad, bool) {
@@ -31,43 +41,77 @@ func (d *MapRepo) Add(ctx context.Context, title string, text string, userID int
 	defer d.mx.Unlock()
 
 	for {
-		if _, ok := d.mp[d.curID]; !ok {
+		if !d.exists(d.curID) {
 			break
 		}
 		d.curID++
 	}
-	d.mp[d.curID] = ads.Ad{ID: d.curID, Title: title, Text: text, AuthorID: userID,
+	ad := ads.Ad{ID: d.curID, Title: title, Text: text, AuthorID: userID,
 		Published: false, CreationDate: time.Now().UTC(), UpdateDate: time.Now().UTC()}
+	if err := d.persist(adRecord{Op: opPut, Ad: ad}); err != nil {
+		return 0, err
+	}
+	d.insert(ad)
 	return d.curID, nil
 }
 
-func (d *MapRepo) SetTitle(ctx context.Context, adID int64, title string) error {
+// Insert stores ad as is, replacing the ad with the same ID. An ad with DeletionDate set goes to the trash.
+func (d *MapRepo) Insert(ctx context.Context, ad ads.Ad) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-	cur := d.mp[adID]
-	cur.Title = title
-	cur.UpdateDate = time.Now().UTC()
-	d.mp[adID] = cur
+	if err := d.persist(adRecord{Op: opPut, Ad: ad}); err != nil {
+		return err
+	}
+	d.put(ad)
 	return nil
 }
 
+func (d *MapRepo) SetTitle(ctx context.Context, adID int64, title string) error {
+	return d.update(adID, func(ad *ads.Ad) {
+		ad.Title = title
+	})
+}
+
 func (d *MapRepo) SetText(ctx context.Context, adID int64, text string) error {
-	d.mx.Lock()
-	defer d.mx.Unlock()
-	cur := d.mp[adID]
-	cur.Text = text
-	cur.UpdateDate = time.Now().UTC()
-	d.mp[adID] = cur
-	return nil
+	return d.update(adID, func(ad *ads.Ad) {
+		ad.Text = text
+	})
 }
 
 func (d *MapRepo) SetStatus(ctx context.Context, adID int64, status bool) error {
+	return d.update(adID, func(ad *ads.Ad) {
+		ad.Published = status
+	})
+}
+
+func (d *MapRepo) SetHidden(ctx context.Context, adID int64, hidden bool) error {
+	return d.update(adID, func(ad *ads.Ad) {
+		ad.Hidden = hidden
+	})
+}
+
+func (d *MapRepo) SetLang(ctx context.Context, adID int64, lang string, declared bool) error {
+	return d.update(adID, func(ad *ads.Ad) {
+		ad.Lang = lang
+		ad.LangDeclared = declared
+	})
+}
+
+func (d *MapRepo) update(adID int64, change func(ad *ads.Ad)) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-	cur := d.mp[adID]
-	cur.Published = status
-	cur.UpdateDate = time.Now().UTC()
-	d.mp[adID] = cur
+	cur, ok := d.mp[adID]
+	if !ok {
+		return nil
+	}
+	next := cur
+	change(&next)
+	next.UpdateDate = time.Now().UTC()
+	if err := d.persist(adRecord{Op: opPut, Ad: next}); err != nil {
+		return err
+	}
+	d.remove(cur)
+	d.insert(next)
 	return nil
 }
 
@@ -75,52 +119,213 @@ func (d *MapRepo) GetAllByTemplate(ctx context.Context, adp adpattern.AdPattern)
 	d.mx.RLock()
 	defer d.mx.RUnlock()
 	res := []ads.Ad{}
-	for _, ad := range d.mp {
-		if app.CheckAd(ad, adp) {
-			res = append(res, ad)
+
+	if adp.Title != "" {
+		for _, adID := range d.titles.collect(adp.Title) {
+			if ad := d.mp[adID]; app.CheckAd(ad, adp) {
+				res = append(res, ad)
+			}
+		}
+		adpattern.SortAds(res, adp.Sort)
+		return res, nil
+	}
+
+	index := d.byDate
+	if adp.AuthorID != 0 {
+		index = d.byAuthor[adp.AuthorID]
+		if index == nil {
+			return res, nil
 		}
 	}
-	sort.SliceStable(res, func(i, j int) bool {
-		return res[i].CreationDate.Before(res[j].CreationDate)
+	if adp.PublishedOnly && d.published.len() < index.len() {
+		index = d.published
+	}
+
+	from := minDateKey
+	if adp.IsLTimeSet {
+		from = dateKey{nano: unixNano(adp.LDate), id: math.MinInt64}
+	}
+	index.ascend(from, func(key dateKey) bool {
+		if adp.IsRTimeSet && key.nano > unixNano(adp.RDate) {
+			return false
+		}
+		if ad := d.mp[key.id]; app.CheckAd(ad, adp) {
+			res = append(res, ad)
+		}
+		return true
 	})
+	// the index walk already yields the default order
+	if len(adp.Sort) > 0 {
+		adpattern.SortAds(res, adp.Sort)
+	}
 	return res, nil
 }
 
 func (d *MapRepo) GetByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
+	return d.GetAllByTemplate(ctx, adpattern.AdPattern{Title: title})
+}
+
+func (d *MapRepo) Delete(ctx context.Context, adID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	cur, ok := d.mp[adID]
+	if !ok {
+		return nil
+	}
+	next := cur
+	next.DeletionDate = time.Now().UTC()
+	if err := d.persist(adRecord{Op: opPut, Ad: next}); err != nil {
+		return err
+	}
+	d.remove(cur)
+	d.trash[adID] = next
+	return nil
+}
+
+func (d *MapRepo) DeleteByAuthor(ctx context.Context, userID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	if d.byAuthor[userID] == nil {
+		return nil
+	}
+	date := time.Now().UTC()
+	if err := d.persist(adRecord{Op: opTrashByAuthor, UserID: userID, Date: &date}); err != nil {
+		return err
+	}
+	d.trashByAuthor(userID, date)
+	return nil
+}
+
+func (d *MapRepo) FindDeleted(ctx context.Context, adID int64) (ads.Ad, bool) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	ad, ok := d.trash[adID]
+	return ad, ok
+}
+
+// ListDeleted returns the trash of the user, the most recently deleted ads go first.
+func (d *MapRepo) ListDeleted(ctx context.Context, userID int64) ([]ads.Ad, error) {
 	d.mx.RLock()
 	defer d.mx.RUnlock()
 	res := []ads.Ad{}
-	for _, ad := range d.mp {
-		if strings.HasPrefix(ad.Title, title) {
+	for _, ad := range d.trash {
+		if ad.AuthorID == userID {
 			res = append(res, ad)
 		}
 	}
-	sort.SliceStable(res, func(i, j int) bool {
-		return res[i].CreationDate.Before(res[j].CreationDate)
-	})
+	sortDeleted(res)
 	return res, nil
 }
 
-func (d *MapRepo) Delete(ctx context.Context, adID int64) error {
+func (d *MapRepo) Restore(ctx context.Context, adID int64) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-	delete(d.mp, adID)
+	cur, ok := d.trash[adID]
+	if !ok {
+		return nil
+	}
+	next := cur
+	next.DeletionDate = time.Time{}
+	if err := d.persist(adRecord{Op: opPut, Ad: next}); err != nil {
+		return err
+	}
+	delete(d.trash, adID)
+	d.insert(next)
 	return nil
 }
 
-func (d *MapRepo) DeleteByAuthor(ctx context.Context, userID int64) error {
+// Purge permanently removes ads deleted before the given time.
+func (d *MapRepo) Purge(ctx context.Context, before time.Time) ([]int64, error) {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-
-	keysToDelete := []int64{}
-	for key, val := range d.mp {
-		if val.AuthorID == userID {
-			keysToDelete = append(keysToDelete, key)
+	res := []int64{}
+	for adID, ad := range d.trash {
+		if !ad.DeletionDate.Before(before) {
+			continue
 		}
+		if err := d.persist(adRecord{Op: opDelete, Ad: ads.Ad{ID: adID}}); err != nil {
+			return res, err
+		}
+		delete(d.trash, adID)
+		res = append(res, adID)
 	}
+	return res, nil
+}
+
+func (d *MapRepo) exists(adID int64) bool {
+	if _, ok := d.mp[adID]; ok {
+		return true
+	}
+	_, ok := d.trash[adID]
+	return ok
+}
+
+// put replaces the ad with the same ID, wherever it is, with ad.
+func (d *MapRepo) put(ad ads.Ad) {
+	if cur, ok := d.mp[ad.ID]; ok {
+		d.remove(cur)
+	}
+	delete(d.trash, ad.ID)
+	if ad.IsDeleted() {
+		d.trash[ad.ID] = ad
+	} else {
+		d.insert(ad)
+	}
+}
+
+func (d *MapRepo) trashByAuthor(userID int64, date time.Time) {
+	index := d.byAuthor[userID]
+	if index == nil {
+		return
+	}
+	keysToDelete := []int64{}
+	index.ascend(minDateKey, func(key dateKey) bool {
+		keysToDelete = append(keysToDelete, key.id)
+		return true
+	})
 
 	for _, key := range keysToDelete {
-		delete(d.mp, key)
+		ad := d.mp[key]
+		d.remove(ad)
+		ad.DeletionDate = date
+		d.trash[key] = ad
 	}
-	return nil
+}
+
+func (d *MapRepo) insert(ad ads.Ad) {
+	d.mp[ad.ID] = ad
+	key := newDateKey(ad.CreationDate, ad.ID)
+	d.byDate.insert(key)
+	if d.byAuthor[ad.AuthorID] == nil {
+		d.byAuthor[ad.AuthorID] = newSkiplist()
+	}
+	d.byAuthor[ad.AuthorID].insert(key)
+	if ad.Published && !ad.Hidden {
+		d.published.insert(key)
+	}
+	d.titles.insert(ad.Title, ad.ID)
+}
+
+func (d *MapRepo) remove(ad ads.Ad) {
+	delete(d.mp, ad.ID)
+	key := newDateKey(ad.CreationDate, ad.ID)
+	d.byDate.remove(key)
+	if index := d.byAuthor[ad.AuthorID]; index != nil {
+		index.remove(key)
+		if index.len() == 0 {
+			delete(d.byAuthor, ad.AuthorID)
+		}
+	}
+	d.published.remove(key)
+	d.titles.remove(ad.Title, ad.ID)
+}
+
+func sortDeleted(list []ads.Ad) {
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].DeletionDate.Equal(list[j].DeletionDate) {
+			return list[i].DeletionDate.After(list[j].DeletionDate)
+		}
+		return list[i].ID < list[j].ID
+	})
 }
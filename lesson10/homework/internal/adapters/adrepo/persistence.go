@@ -0,0 +1,102 @@
+package adrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework10/internal/ads"
+	"time"
+)
+
+const (
+	opPut            = "put"
+	opDelete         = "delete"
+	opDeleteByAuthor = "delete_by_author"
+	opTrashByAuthor  = "trash_by_author"
+	opNextID         = "next_id"
+)
+
+type adRecord struct {
+	Op     string     `json:"op"`
+	Ad     ads.Ad     `json:"ad"`
+	UserID int64      `json:"user_id,omitempty"`
+	NextID int64      `json:"next_id,omitempty"`
+	Date   *time.Time `json:"date,omitempty"`
+}
+
+// persist writes the mutation to the log before it is applied, it's a no-op for in-memory only repos.
+func (d *MapRepo) persist(rec adRecord) error {
+	if d.log == nil {
+		return nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return d.log.Append(data)
+}
+
+func (d *MapRepo) apply(data []byte) error {
+	var rec adRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+	switch rec.Op {
+	case opPut:
+		d.put(rec.Ad)
+		if rec.Ad.ID > d.curID {
+			d.curID = rec.Ad.ID
+		}
+	case opDelete:
+		if cur, ok := d.mp[rec.Ad.ID]; ok {
+			d.remove(cur)
+		}
+		delete(d.trash, rec.Ad.ID)
+	case opTrashByAuthor:
+		if rec.Date == nil {
+			return fmt.Errorf("adrepo: %q record without date", rec.Op)
+		}
+		d.trashByAuthor(rec.UserID, *rec.Date)
+	case opDeleteByAuthor:
+		// written before ads were moved to the trash on deletion
+		for _, cur := range d.mp {
+			if cur.AuthorID == rec.UserID {
+				d.remove(cur)
+			}
+		}
+	case opNextID:
+		d.curID = rec.NextID
+	default:
+		return fmt.Errorf("adrepo: unknown log record %q", rec.Op)
+	}
+	return nil
+}
+
+// Snapshot compacts the log into a snapshot of the current state.
+func (d *MapRepo) Snapshot() error {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	if d.log == nil {
+		return nil
+	}
+	return d.log.Snapshot(func(emit func(rec []byte) error) error {
+		data, err := json.Marshal(adRecord{Op: opNextID, NextID: d.curID})
+		if err != nil {
+			return err
+		}
+		if err := emit(data); err != nil {
+			return err
+		}
+		for _, mp := range []map[int64]ads.Ad{d.mp, d.trash} {
+			for _, ad := range mp {
+				data, err := json.Marshal(adRecord{Op: opPut, Ad: ad})
+				if err != nil {
+					return err
+				}
+				if err := emit(data); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -1,11 +1,35 @@
 package adrepo
 
 import (
+	"homework10/internal/adapters/wal"
 	"homework10/internal/ads"
 	"homework10/internal/app"
 	"sync"
 )
 
 func New() app.Repository {
-	return &MapRepo{mx: &sync.RWMutex{}, mp: map[int64]ads.Ad{}} // TODO: реализовать
+	return newMapRepo() // TODO: реализовать
+}
+
+func NewSharded(shards int) app.Repository {
+	res := &ShardedRepo{shards: make([]*MapRepo, shards)}
+	for i := range res.shards {
+		res.shards[i] = newMapRepo()
+	}
+	return res
+}
+
+// NewPersistent recovers the repository from the log and appends every further mutation to it.
+func NewPersistent(log *wal.Log) (*MapRepo, error) {
+	d := newMapRepo()
+	d.log = log
+	if err := log.Replay(d.apply); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func newMapRepo() *MapRepo {
+	return &MapRepo{mx: &sync.RWMutex{}, mp: map[int64]ads.Ad{}, trash: map[int64]ads.Ad{}, byDate: newSkiplist(),
+		byAuthor: map[int64]*skiplist{}, published: newSkiplist(), titles: newTrie()}
 }
@@ -0,0 +1,183 @@
+package adrepo
+
+import (
+	"container/heap"
+	"context"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"sync/atomic"
+	"time"
+)
+
+// ShardedRepo splits ads between several MapRepo shards by ID, so writes to
+// different ads don't contend for the same lock. IDs come from an atomic counter.
+type ShardedRepo struct {
+	shards []*MapRepo
+	nextID int64
+}
+
+func (d *ShardedRepo) shard(adID int64) *MapRepo {
+	return d.shards[uint64(adID)%uint64(len(d.shards))]
+}
+
+func (d *ShardedRepo) Find(ctx context.Context, adID int64) (ads.Ad, bool) {
+	return d.shard(adID).Find(ctx, adID)
+}
+
+func (d *ShardedRepo) Add(ctx context.Context, title string, text string, userID int64) (int64, error) {
+	adID := atomic.AddInt64(&d.nextID, 1) - 1
+	shard := d.shard(adID)
+	shard.mx.Lock()
+	defer shard.mx.Unlock()
+	shard.insert(ads.Ad{ID: adID, Title: title, Text: text, AuthorID: userID,
+		Published: false, CreationDate: time.Now().UTC(), UpdateDate: time.Now().UTC()})
+	return adID, nil
+}
+
+func (d *ShardedRepo) Insert(ctx context.Context, ad ads.Ad) error {
+	for {
+		next := atomic.LoadInt64(&d.nextID)
+		if ad.ID < next || atomic.CompareAndSwapInt64(&d.nextID, next, ad.ID+1) {
+			break
+		}
+	}
+	return d.shard(ad.ID).Insert(ctx, ad)
+}
+
+func (d *ShardedRepo) SetTitle(ctx context.Context, adID int64, title string) error {
+	return d.shard(adID).SetTitle(ctx, adID, title)
+}
+
+func (d *ShardedRepo) SetText(ctx context.Context, adID int64, text string) error {
+	return d.shard(adID).SetText(ctx, adID, text)
+}
+
+func (d *ShardedRepo) SetStatus(ctx context.Context, adID int64, status bool) error {
+	return d.shard(adID).SetStatus(ctx, adID, status)
+}
+
+func (d *ShardedRepo) SetHidden(ctx context.Context, adID int64, hidden bool) error {
+	return d.shard(adID).SetHidden(ctx, adID, hidden)
+}
+
+func (d *ShardedRepo) SetLang(ctx context.Context, adID int64, lang string, declared bool) error {
+	return d.shard(adID).SetLang(ctx, adID, lang, declared)
+}
+
+func (d *ShardedRepo) Delete(ctx context.Context, adID int64) error {
+	return d.shard(adID).Delete(ctx, adID)
+}
+
+func (d *ShardedRepo) DeleteByAuthor(ctx context.Context, userID int64) error {
+	for _, shard := range d.shards {
+		if err := shard.DeleteByAuthor(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *ShardedRepo) FindDeleted(ctx context.Context, adID int64) (ads.Ad, bool) {
+	return d.shard(adID).FindDeleted(ctx, adID)
+}
+
+func (d *ShardedRepo) ListDeleted(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	res := []ads.Ad{}
+	for _, shard := range d.shards {
+		list, err := shard.ListDeleted(ctx, userID)
+		if err != nil {
+			return []ads.Ad{}, err
+		}
+		res = append(res, list...)
+	}
+	sortDeleted(res)
+	return res, nil
+}
+
+func (d *ShardedRepo) Restore(ctx context.Context, adID int64) error {
+	return d.shard(adID).Restore(ctx, adID)
+}
+
+func (d *ShardedRepo) Purge(ctx context.Context, before time.Time) ([]int64, error) {
+	res := []int64{}
+	for _, shard := range d.shards {
+		list, err := shard.Purge(ctx, before)
+		res = append(res, list...)
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+func (d *ShardedRepo) GetAllByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error) {
+	return d.merge(adp.Sort, func(shard *MapRepo) ([]ads.Ad, error) {
+		return shard.GetAllByTemplate(ctx, adp)
+	})
+}
+
+func (d *ShardedRepo) GetByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
+	return d.merge(nil, func(shard *MapRepo) ([]ads.Ad, error) {
+		return shard.GetByTitle(ctx, title)
+	})
+}
+
+// merge queries every shard and merges results already sorted by order.
+func (d *ShardedRepo) merge(order []adpattern.OrderBy, query func(shard *MapRepo) ([]ads.Ad, error)) ([]ads.Ad, error) {
+	h := &adHeap{less: adpattern.Less(order)}
+	total := 0
+	for _, shard := range d.shards {
+		list, err := query(shard)
+		if err != nil {
+			return []ads.Ad{}, err
+		}
+		if len(list) > 0 {
+			h.lists = append(h.lists, list)
+			total += len(list)
+		}
+	}
+	heap.Init(h)
+
+	res := make([]ads.Ad, 0, total)
+	for h.Len() > 0 {
+		list := h.lists[0]
+		res = append(res, list[0])
+		if len(list) == 1 {
+			heap.Pop(h)
+		} else {
+			h.lists[0] = list[1:]
+			heap.Fix(h, 0)
+		}
+	}
+	return res, nil
+}
+
+// adHeap is a min-heap of sorted non-empty lists ordered by their first ad.
+type adHeap struct {
+	lists [][]ads.Ad
+	less  func(a, b ads.Ad) bool
+}
+
+func (h *adHeap) Len() int {
+	return len(h.lists)
+}
+
+func (h *adHeap) Less(i, j int) bool {
+	return h.less(h.lists[i][0], h.lists[j][0])
+}
+
+func (h *adHeap) Swap(i, j int) {
+	h.lists[i], h.lists[j] = h.lists[j], h.lists[i]
+}
+
+func (h *adHeap) Push(x any) {
+	h.lists = append(h.lists, x.([]ads.Ad))
+}
+
+func (h *adHeap) Pop() any {
+	old := h.lists
+	n := len(old)
+	x := old[n-1]
+	h.lists = old[:n-1]
+	return x
+}
@@ -0,0 +1,138 @@
+package adrepo
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	skiplistMaxLevel = 32
+	skiplistP        = 4
+)
+
+// dateKey orders ads by creation date, ties are broken by ID.
+type dateKey struct {
+	nano int64
+	id   int64
+}
+
+var minDateKey = dateKey{nano: math.MinInt64, id: math.MinInt64}
+
+func newDateKey(date time.Time, id int64) dateKey {
+	return dateKey{nano: unixNano(date), id: id}
+}
+
+// unixNano clamps dates that don't fit into int64 nanoseconds, e.g. the zero time.Time.
+func unixNano(date time.Time) int64 {
+	if date.Before(time.Unix(0, math.MinInt64)) {
+		return math.MinInt64
+	}
+	if date.After(time.Unix(0, math.MaxInt64)) {
+		return math.MaxInt64
+	}
+	return date.UnixNano()
+}
+
+func (k dateKey) less(o dateKey) bool {
+	if k.nano != o.nano {
+		return k.nano < o.nano
+	}
+	return k.id < o.id
+}
+
+type skiplistNode struct {
+	key  dateKey
+	next []*skiplistNode
+}
+
+// skiplist is an ordered set of dateKey. It is not safe for concurrent use.
+type skiplist struct {
+	head   *skiplistNode
+	level  int
+	length int
+	seed   uint64
+}
+
+func newSkiplist() *skiplist {
+	return &skiplist{
+		head:  &skiplistNode{next: make([]*skiplistNode, skiplistMaxLevel)},
+		level: 1,
+		seed:  0x9E3779B97F4A7C15,
+	}
+}
+
+func (d *skiplist) randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel {
+		d.seed ^= d.seed << 13
+		d.seed ^= d.seed >> 7
+		d.seed ^= d.seed << 17
+		if d.seed%skiplistP != 0 {
+			break
+		}
+		level++
+	}
+	return level
+}
+
+// path fills update with the rightmost node before key on every level.
+func (d *skiplist) path(key dateKey, update []*skiplistNode) *skiplistNode {
+	cur := d.head
+	for i := d.level - 1; i >= 0; i-- {
+		for cur.next[i] != nil && cur.next[i].key.less(key) {
+			cur = cur.next[i]
+		}
+		if update != nil {
+			update[i] = cur
+		}
+	}
+	return cur.next[0]
+}
+
+func (d *skiplist) insert(key dateKey) {
+	update := make([]*skiplistNode, skiplistMaxLevel)
+	if next := d.path(key, update); next != nil && next.key == key {
+		return
+	}
+	level := d.randomLevel()
+	if level > d.level {
+		for i := d.level; i < level; i++ {
+			update[i] = d.head
+		}
+		d.level = level
+	}
+	node := &skiplistNode{key: key, next: make([]*skiplistNode, level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	d.length++
+}
+
+func (d *skiplist) remove(key dateKey) {
+	update := make([]*skiplistNode, skiplistMaxLevel)
+	node := d.path(key, update)
+	if node == nil || node.key != key {
+		return
+	}
+	for i := 0; i < len(node.next); i++ {
+		update[i].next[i] = node.next[i]
+	}
+	for d.level > 1 && d.head.next[d.level-1] == nil {
+		d.level--
+	}
+	d.length--
+}
+
+// ascend calls f for every key not less than from in ascending order until f returns false.
+func (d *skiplist) ascend(from dateKey, f func(key dateKey) bool) {
+	for node := d.path(from, nil); node != nil; node = node.next[0] {
+		if !f(node.key) {
+			return
+		}
+	}
+}
+
+func (d *skiplist) len() int {
+	return d.length
+}
@@ -0,0 +1,94 @@
+package adrepo
+
+type trieEdge struct {
+	label byte
+	node  *trieNode
+}
+
+type trieNode struct {
+	children []trieEdge
+	ids      []int64
+}
+
+func (d *trieNode) child(label byte) *trieNode {
+	for _, e := range d.children {
+		if e.label == label {
+			return e.node
+		}
+	}
+	return nil
+}
+
+// trie indexes ad IDs by title bytes, so a prefix lookup matches strings.HasPrefix.
+// It is not safe for concurrent use.
+type trie struct {
+	root *trieNode
+}
+
+func newTrie() *trie {
+	return &trie{root: &trieNode{}}
+}
+
+func (d *trie) insert(title string, id int64) {
+	cur := d.root
+	for i := 0; i < len(title); i++ {
+		next := cur.child(title[i])
+		if next == nil {
+			next = &trieNode{}
+			cur.children = append(cur.children, trieEdge{label: title[i], node: next})
+		}
+		cur = next
+	}
+	cur.ids = append(cur.ids, id)
+}
+
+func (d *trie) remove(title string, id int64) {
+	d.removeAt(d.root, title, id)
+}
+
+// removeAt reports whether node became empty and can be dropped by its parent.
+func (d *trie) removeAt(node *trieNode, title string, id int64) bool {
+	if len(title) == 0 {
+		for i, cur := range node.ids {
+			if cur == id {
+				node.ids[i] = node.ids[len(node.ids)-1]
+				node.ids = node.ids[:len(node.ids)-1]
+				break
+			}
+		}
+	} else {
+		for i, e := range node.children {
+			if e.label != title[0] {
+				continue
+			}
+			if d.removeAt(e.node, title[1:], id) {
+				node.children[i] = node.children[len(node.children)-1]
+				node.children = node.children[:len(node.children)-1]
+			}
+			break
+		}
+	}
+	return len(node.ids) == 0 && len(node.children) == 0
+}
+
+// collect returns IDs of every title starting with prefix.
+func (d *trie) collect(prefix string) []int64 {
+	cur := d.root
+	for i := 0; i < len(prefix) && cur != nil; i++ {
+		cur = cur.child(prefix[i])
+	}
+	res := []int64{}
+	if cur == nil {
+		return res
+	}
+	stack := []*trieNode{cur}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		res = append(res, node.ids...)
+		for _, e := range node.children {
+			stack = append(stack, e.node)
+		}
+	}
+	return res
+}
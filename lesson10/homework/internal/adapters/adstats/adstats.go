@@ -0,0 +1,51 @@
+package adstats
+
+import (
+	"homework10/internal/analytics"
+	"homework10/internal/app"
+	"sync"
+	"time"
+)
+
+func New() *MemoryStats {
+	return &MemoryStats{mx: &sync.RWMutex{}, views: map[viewKey]int64{}, publications: map[adKey]analytics.Publication{}}
+}
+
+const (
+	DefaultWindow        = 30 * time.Minute
+	DefaultFlushInterval = time.Second
+	DefaultBatchSize     = 1000
+	DefaultBufferSize    = 10000
+)
+
+// Options of a Counter, zero fields get default values.
+type Options struct {
+	// Window is the time during which repeated views of an ad by the same viewer count once
+	Window        time.Duration
+	FlushInterval time.Duration
+	// BatchSize is the number of distinct ad hours after which counts are flushed before the interval ends
+	BatchSize int
+	// BufferSize is the number of views waiting to be counted, Record drops views if it is full
+	BufferSize int
+}
+
+// NewCounter starts counting views into stats, Close stops it.
+func NewCounter(stats app.Stats, opts Options) *Counter {
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	d := &Counter{stats: stats, opts: opts, views: make(chan analytics.View, opts.BufferSize),
+		flushes: make(chan chan struct{}), done: make(chan struct{}), stopped: make(chan struct{}),
+		dropped: new(int64)}
+	go d.run()
+	return d
+}
@@ -0,0 +1,135 @@
+package adstats
+
+import (
+	"context"
+	"homework10/internal/analytics"
+	"homework10/internal/app"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Counter aggregates views in a single goroutine, so recording a view takes no locks.
+// Views are counted by hour and flushed to stats in batches, a viewer is counted
+// once per ad in a window of Options.Window.
+type Counter struct {
+	stats   app.Stats
+	opts    Options
+	views   chan analytics.View
+	flushes chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	dropped *int64
+}
+
+type seenKey struct {
+	adKey
+	viewer string
+	window time.Time
+}
+
+// Record enqueues the view, it is dropped if the buffer is full.
+func (d *Counter) Record(v analytics.View) {
+	select {
+	case d.views <- v:
+	default:
+		atomic.AddInt64(d.dropped, 1)
+	}
+}
+
+// Dropped returns the number of views dropped because the buffer was full.
+func (d *Counter) Dropped() int64 {
+	return atomic.LoadInt64(d.dropped)
+}
+
+// Flush counts all views recorded so far and adds them to stats.
+func (d *Counter) Flush() {
+	done := make(chan struct{})
+	select {
+	case d.flushes <- done:
+		<-done
+	case <-d.stopped:
+	}
+}
+
+// Close flushes recorded views and stops the counter.
+func (d *Counter) Close() {
+	select {
+	case <-d.done:
+	default:
+		close(d.done)
+	}
+	<-d.stopped
+}
+
+func (d *Counter) run() {
+	defer close(d.stopped)
+	ticker := time.NewTicker(d.opts.FlushInterval)
+	defer ticker.Stop()
+
+	seen := map[seenKey]bool{}
+	counts := map[viewKey]int64{}
+	count := func(v analytics.View) {
+		k := adKey{v.Tenant, v.AdID}
+		s := seenKey{k, v.Viewer, v.Time.Truncate(d.opts.Window)}
+		if seen[s] {
+			return
+		}
+		seen[s] = true
+		counts[viewKey{k, v.Time.Truncate(time.Hour)}]++
+		// counts stats didn't accept are retried by the ticker only
+		if len(counts) == d.opts.BatchSize {
+			counts = d.flush(counts)
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case v := <-d.views:
+				count(v)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case v := <-d.views:
+			count(v)
+		case <-ticker.C:
+			counts = d.flush(counts)
+			// views of past windows can't be repeated
+			current := time.Now().UTC().Truncate(d.opts.Window)
+			for s := range seen {
+				if s.window.Before(current) {
+					delete(seen, s)
+				}
+			}
+		case done := <-d.flushes:
+			drain()
+			counts = d.flush(counts)
+			close(done)
+		case <-d.done:
+			drain()
+			d.flush(counts)
+			return
+		}
+	}
+}
+
+// flush returns the counts to keep, the ones stats didn't accept are kept to be retried.
+func (d *Counter) flush(counts map[viewKey]int64) map[viewKey]int64 {
+	if len(counts) == 0 {
+		return counts
+	}
+	batch := make([]analytics.ViewCount, 0, len(counts))
+	for k, n := range counts {
+		batch = append(batch, analytics.ViewCount{Tenant: k.tenant, AdID: k.adID, Hour: k.hour, Views: n})
+	}
+	if err := d.stats.AddViews(context.Background(), batch); err != nil {
+		log.Printf("can't flush %d view counts: %s", len(batch), err.Error())
+		return counts
+	}
+	return map[viewKey]int64{}
+}
@@ -0,0 +1,81 @@
+package adstats
+
+import (
+	"context"
+	"homework10/internal/analytics"
+	"homework10/internal/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+type adKey struct {
+	tenant tenant.ID
+	adID   int64
+}
+
+type viewKey struct {
+	adKey
+	hour time.Time
+}
+
+type MemoryStats struct {
+	mx           *sync.RWMutex
+	views        map[viewKey]int64
+	publications map[adKey]analytics.Publication
+}
+
+func (d *MemoryStats) AddViews(ctx context.Context, counts []analytics.ViewCount) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for _, c := range counts {
+		d.views[viewKey{adKey{c.Tenant, c.AdID}, c.Hour}] += c.Views
+	}
+	return nil
+}
+
+func (d *MemoryStats) ViewCounts(ctx context.Context, adIDs []int64, from, to time.Time) ([]analytics.ViewCount, error) {
+	tenantID := tenant.FromContext(ctx)
+	ids := map[int64]bool{}
+	for _, adID := range adIDs {
+		ids[adID] = true
+	}
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	res := []analytics.ViewCount{}
+	for k, n := range d.views {
+		if k.tenant == tenantID && ids[k.adID] && !k.hour.Before(from) && k.hour.Before(to) {
+			res = append(res, analytics.ViewCount{Tenant: k.tenant, AdID: k.adID, Hour: k.hour, Views: n})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Hour.Equal(res[j].Hour) {
+			return res[i].Hour.Before(res[j].Hour)
+		}
+		return res[i].AdID < res[j].AdID
+	})
+	return res, nil
+}
+
+func (d *MemoryStats) AddPublication(ctx context.Context, p analytics.Publication) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	k := adKey{p.Tenant, p.AdID}
+	if _, ok := d.publications[k]; !ok {
+		d.publications[k] = p
+	}
+	return nil
+}
+
+func (d *MemoryStats) Publications(ctx context.Context, adIDs []int64) ([]analytics.Publication, error) {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	res := []analytics.Publication{}
+	for _, adID := range adIDs {
+		if p, ok := d.publications[adKey{tenantID, adID}]; ok {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,20 @@
+package auditlog
+
+import (
+	"homework10/internal/audit"
+	"sync"
+)
+
+func New() *MemoryLog {
+	return &MemoryLog{mx: &sync.RWMutex{}, entries: []audit.Entry{}}
+}
+
+// NewFile appends entries to the file at path as JSON lines, it is created if it doesn't exist.
+// A line torn by a crash at the end of the file is cut off.
+func NewFile(path string) (*FileLog, error) {
+	d := &FileLog{mx: &sync.RWMutex{}, path: path}
+	if err := d.recover(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
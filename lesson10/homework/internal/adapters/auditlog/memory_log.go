@@ -0,0 +1,37 @@
+package auditlog
+
+import (
+	"context"
+	"homework10/internal/audit"
+	"homework10/internal/tenant"
+	"sync"
+)
+
+type MemoryLog struct {
+	mx      *sync.RWMutex
+	entries []audit.Entry
+}
+
+func (d *MemoryLog) Append(ctx context.Context, e audit.Entry) (audit.Entry, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	e.ID = int64(len(d.entries)) + 1
+	d.entries = append(d.entries, e)
+	return e, nil
+}
+
+func (d *MemoryLog) List(ctx context.Context, target audit.Target) ([]audit.Entry, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	return filter(d.entries, tenant.FromContext(ctx), target), nil
+}
+
+func filter(entries []audit.Entry, tenantID tenant.ID, target audit.Target) []audit.Entry {
+	res := []audit.Entry{}
+	for _, e := range entries {
+		if e.Tenant == tenantID && (target.IsZero() || e.Target == target) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
@@ -0,0 +1,15 @@
+package chat
+
+import (
+	"homework10/internal/app"
+	"sync"
+)
+
+func New() app.Messages {
+	return &MapChat{mx: &sync.RWMutex{}, threads: map[int64]*thread{}, byUser: map[int64]map[int64]struct{}{},
+		byAd: map[int64]map[int64]struct{}{}, nextThreadID: 1, nextMessageID: 1}
+}
+
+func NewBlocks() app.Blocks {
+	return &MapBlocks{mx: &sync.RWMutex{}, mp: map[int64]map[int64]struct{}{}}
+}
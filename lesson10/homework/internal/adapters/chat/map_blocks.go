@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"context"
+	"sync"
+)
+
+// MapBlocks keeps for every user the set of users they blocked.
+type MapBlocks struct {
+	mx *sync.RWMutex
+	mp map[int64]map[int64]struct{}
+}
+
+func (d *MapBlocks) Block(ctx context.Context, userID int64, blockedID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	index(d.mp, userID, blockedID)
+	return nil
+}
+
+func (d *MapBlocks) Unblock(ctx context.Context, userID int64, blockedID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	unindex(d.mp, userID, blockedID)
+	return nil
+}
+
+func (d *MapBlocks) IsBlocked(ctx context.Context, first int64, second int64) (bool, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	_, ok := d.mp[first][second]
+	if !ok {
+		_, ok = d.mp[second][first]
+	}
+	return ok, nil
+}
+
+func (d *MapBlocks) DeleteByUser(ctx context.Context, userID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	delete(d.mp, userID)
+	for blockerID := range d.mp {
+		unindex(d.mp, blockerID, userID)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package chat
+
+import (
+	"context"
+	"homework10/internal/message"
+	"sort"
+	"sync"
+	"time"
+)
+
+type thread struct {
+	info     message.Thread
+	messages []message.Message
+}
+
+// MapChat keeps threads with their messages in ascending ID order
+// together with thread indexes by member and by ad.
+type MapChat struct {
+	mx            *sync.RWMutex
+	threads       map[int64]*thread
+	byUser        map[int64]map[int64]struct{}
+	byAd          map[int64]map[int64]struct{}
+	nextThreadID  int64
+	nextMessageID int64
+}
+
+func (d *MapChat) FindThread(ctx context.Context, threadID int64) (message.Thread, bool) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	t, ok := d.threads[threadID]
+	if !ok {
+		return message.Thread{}, false
+	}
+	return t.info, true
+}
+
+func (d *MapChat) FindThreadByAd(ctx context.Context, adID int64, buyerID int64) (message.Thread, bool) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	for threadID := range d.byAd[adID] {
+		if t := d.threads[threadID]; t.info.BuyerID == buyerID {
+			return t.info, true
+		}
+	}
+	return message.Thread{}, false
+}
+
+func (d *MapChat) CreateThread(ctx context.Context, adID int64, buyerID int64, sellerID int64) (message.Thread, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	now := time.Now().UTC()
+	info := message.Thread{ID: d.nextThreadID, AdID: adID, BuyerID: buyerID, SellerID: sellerID,
+		CreationDate: now, UpdateDate: now}
+	d.nextThreadID++
+	d.threads[info.ID] = &thread{info: info}
+	index(d.byUser, buyerID, info.ID)
+	index(d.byUser, sellerID, info.ID)
+	index(d.byAd, adID, info.ID)
+	return info, nil
+}
+
+// ListThreads returns threads of the user, the most recently updated first.
+func (d *MapChat) ListThreads(ctx context.Context, userID int64) ([]message.Thread, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	res := []message.Thread{}
+	for threadID := range d.byUser[userID] {
+		res = append(res, d.threads[threadID].info)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].UpdateDate.Equal(res[j].UpdateDate) {
+			return res[i].UpdateDate.After(res[j].UpdateDate)
+		}
+		return res[i].ID > res[j].ID
+	})
+	return res, nil
+}
+
+func (d *MapChat) AddMessage(ctx context.Context, threadID int64, senderID int64, text string) (message.Message, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	t, ok := d.threads[threadID]
+	if !ok {
+		return message.Message{}, nil
+	}
+	m := message.Message{ID: d.nextMessageID, ThreadID: threadID, SenderID: senderID, Text: text,
+		CreationDate: time.Now().UTC()}
+	d.nextMessageID++
+	t.messages = append(t.messages, m)
+	t.info.UpdateDate = m.CreationDate
+	return m, nil
+}
+
+func (d *MapChat) ListMessages(ctx context.Context, threadID int64, beforeID int64, limit int) ([]message.Message, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	t, ok := d.threads[threadID]
+	if !ok {
+		return []message.Message{}, nil
+	}
+	end := len(t.messages)
+	if beforeID != 0 {
+		end = sort.Search(len(t.messages), func(i int) bool {
+			return t.messages[i].ID >= beforeID
+		})
+	}
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+	return append([]message.Message{}, t.messages[start:end]...), nil
+}
+
+func (d *MapChat) MarkRead(ctx context.Context, threadID int64, readerID int64, upToID int64) (int, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	t, ok := d.threads[threadID]
+	if !ok {
+		return 0, nil
+	}
+	now := time.Now().UTC()
+	marked := 0
+	for i := len(t.messages) - 1; i >= 0; i-- {
+		m := &t.messages[i]
+		if m.ID > upToID || m.SenderID == readerID {
+			continue
+		}
+		// everything before an already read message is read too
+		if m.IsRead() {
+			break
+		}
+		m.ReadDate = now
+		marked++
+	}
+	return marked, nil
+}
+
+func (d *MapChat) DeleteByAd(ctx context.Context, adID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for threadID := range d.byAd[adID] {
+		d.remove(threadID)
+	}
+	return nil
+}
+
+func (d *MapChat) DeleteByUser(ctx context.Context, userID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for threadID := range d.byUser[userID] {
+		d.remove(threadID)
+	}
+	return nil
+}
+
+func (d *MapChat) remove(threadID int64) {
+	t, ok := d.threads[threadID]
+	if !ok {
+		return
+	}
+	delete(d.threads, threadID)
+	unindex(d.byUser, t.info.BuyerID, threadID)
+	unindex(d.byUser, t.info.SellerID, threadID)
+	unindex(d.byAd, t.info.AdID, threadID)
+}
+
+func index(mp map[int64]map[int64]struct{}, key int64, id int64) {
+	if mp[key] == nil {
+		mp[key] = map[int64]struct{}{}
+	}
+	mp[key][id] = struct{}{}
+}
+
+func unindex(mp map[int64]map[int64]struct{}, key int64, id int64) {
+	delete(mp[key], id)
+	if len(mp[key]) == 0 {
+		delete(mp, key)
+	}
+}
@@ -0,0 +1,99 @@
+package contentcheck
+
+import (
+	"fmt"
+	"homework10/internal/policy"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxDistance is the largest number of differing simhash bits of duplicates.
+const DefaultMaxDistance = 3
+
+// New returns a pipeline running all steps in order.
+func New(steps ...Step) *Pipeline {
+	return &Pipeline{steps: append([]Step{}, steps...)}
+}
+
+func NewBannedWords(words []string) *BannedWords {
+	d := &BannedWords{phrases: map[string][][]string{}}
+	for _, w := range words {
+		phrase := tokenize(w)
+		if len(phrase) == 0 {
+			continue
+		}
+		d.phrases[phrase[0]] = append(d.phrases[phrase[0]], phrase)
+	}
+	return d
+}
+
+func NewLinks() *Links {
+	return &Links{}
+}
+
+func NewPhones() *Phones {
+	return &Phones{}
+}
+
+// NewDuplicates treats ads whose simhashes differ in at most maxDistance bits as duplicates,
+// DefaultMaxDistance is used if it isn't positive.
+func NewDuplicates(maxDistance int) *Duplicates {
+	if maxDistance <= 0 {
+		maxDistance = DefaultMaxDistance
+	}
+	return &Duplicates{maxDistance: maxDistance}
+}
+
+// Load reads a YAML file of the form
+//
+//	rules:
+//	  - rule: banned_words
+//	    severity: reject
+//	    words: [casino, "easy money"]
+//	  - rule: links
+//	    severity: flag
+//	  - rule: phones
+//	    severity: flag
+//	  - rule: duplicates
+//	    severity: reject
+//	    max_distance: 3
+func Load(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Rules []struct {
+			Rule        string   `yaml:"rule"`
+			Severity    string   `yaml:"severity"`
+			Words       []string `yaml:"words"`
+			MaxDistance int      `yaml:"max_distance"`
+		} `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("can't parse %s: %w", path, err)
+	}
+	steps := []Step{}
+	for _, r := range file.Rules {
+		severity, err := policy.ParseSeverity(r.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Rule, err)
+		}
+		var rule Rule
+		switch r.Rule {
+		case RuleBannedWords:
+			rule = NewBannedWords(r.Words)
+		case RuleLinks:
+			rule = NewLinks()
+		case RulePhones:
+			rule = NewPhones()
+		case RuleDuplicates:
+			rule = NewDuplicates(r.MaxDistance)
+		default:
+			return nil, fmt.Errorf("unknown rule %q", r.Rule)
+		}
+		steps = append(steps, Step{Rule: rule, Severity: severity})
+	}
+	return New(steps...), nil
+}
@@ -0,0 +1,68 @@
+package contentcheck
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/policy"
+	"sync"
+)
+
+// Names of rules.
+const (
+	RuleBannedWords = "banned_words"
+	RuleLinks       = "links"
+	RulePhones      = "phones"
+	RuleDuplicates  = "duplicates"
+)
+
+// Rule returns the reason the ad violates it, or an empty string if it doesn't.
+type Rule interface {
+	Name() string
+	Check(ctx context.Context, c policy.Content) (string, error)
+}
+
+type Step struct {
+	Rule     Rule
+	Severity policy.Severity
+}
+
+// Pipeline runs every rule, so the verdict lists all violations and not only the first one.
+type Pipeline struct {
+	steps []Step
+}
+
+func (d *Pipeline) Check(ctx context.Context, c policy.Content) (policy.Verdict, error) {
+	c.Siblings = once(c.Siblings)
+	res := policy.Verdict{}
+	for _, step := range d.steps {
+		reason, err := step.Rule.Check(ctx, c)
+		if err != nil {
+			return policy.Verdict{}, err
+		}
+		if reason != "" {
+			res.Violations = append(res.Violations, policy.Violation{Rule: step.Rule.Name(),
+				Severity: step.Severity, Reason: reason})
+		}
+	}
+	return res, nil
+}
+
+// once makes several rules share the siblings, which are loaded at most once.
+func once(load func() ([]ads.Ad, error)) func() ([]ads.Ad, error) {
+	if load == nil {
+		return func() ([]ads.Ad, error) {
+			return nil, nil
+		}
+	}
+	var (
+		o    sync.Once
+		list []ads.Ad
+		err  error
+	)
+	return func() ([]ads.Ad, error) {
+		o.Do(func() {
+			list, err = load()
+		})
+		return list, err
+	}
+}
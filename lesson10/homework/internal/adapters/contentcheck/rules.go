@@ -0,0 +1,150 @@
+package contentcheck
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"homework10/internal/policy"
+	"math/bits"
+	"regexp"
+	"strings"
+)
+
+// BannedWords matches whole words and phrases, so banning "ass" doesn't ban "class".
+type BannedWords struct {
+	// phrases are tokenized banned phrases by their first word
+	phrases map[string][][]string
+}
+
+func (d *BannedWords) Name() string {
+	return RuleBannedWords
+}
+
+func (d *BannedWords) Check(ctx context.Context, c policy.Content) (string, error) {
+	words := tokenize(fullText(c.Title, c.Text))
+	for i, w := range words {
+		for _, phrase := range d.phrases[w] {
+			if hasPrefix(words[i:], phrase) {
+				return fmt.Sprintf("contains banned word %q", strings.Join(phrase, " ")), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func hasPrefix(words []string, prefix []string) bool {
+	if len(words) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if words[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// linkRe matches URLs with a scheme or www and bare domains in popular zones. RE2 word boundaries
+// are ASCII only, so the end of a link is matched explicitly to find domains such as пример.рф.
+var linkRe = regexp.MustCompile(`(?i)((?:https?://|www\.)\S+|` +
+	`[\p{L}\p{N}][\p{L}\p{N}-]*(?:\.[\p{L}\p{N}-]+)*\.(?:com|net|org|info|biz|io|me|app|site|online|shop|ru|su|рф|ua|by|kz))` +
+	`(?:[^\p{L}\p{N}-]|$)`)
+
+type Links struct{}
+
+func (d *Links) Name() string {
+	return RuleLinks
+}
+
+func (d *Links) Check(ctx context.Context, c policy.Content) (string, error) {
+	if m := linkRe.FindStringSubmatch(normalize(fullText(c.Title, c.Text))); m != nil {
+		return fmt.Sprintf("contains link %q", m[1]), nil
+	}
+	return "", nil
+}
+
+// phoneRe matches runs of digits split by short separators, such as +7 (999) 123-45-67.
+var phoneRe = regexp.MustCompile(`\+?\d(?:[ ().-]{0,2}\d)+`)
+
+const (
+	minPhoneDigits = 10
+	maxPhoneDigits = 15
+)
+
+// Phones looks for numbers of 10 to 15 digits, so prices and years aren't taken for phones.
+type Phones struct{}
+
+func (d *Phones) Name() string {
+	return RulePhones
+}
+
+func (d *Phones) Check(ctx context.Context, c policy.Content) (string, error) {
+	for _, m := range phoneRe.FindAllString(normalize(fullText(c.Title, c.Text)), -1) {
+		digits := 0
+		for _, r := range m {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits >= minPhoneDigits && digits <= maxPhoneDigits {
+			return fmt.Sprintf("contains phone number %q", strings.TrimSpace(m)), nil
+		}
+	}
+	return "", nil
+}
+
+// shingleSize is the number of words of shingles duplicates are compared by.
+const shingleSize = 3
+
+// Duplicates compares simhashes of word shingles of the ad with the ones of other ads of the author.
+type Duplicates struct {
+	maxDistance int
+}
+
+func (d *Duplicates) Name() string {
+	return RuleDuplicates
+}
+
+func (d *Duplicates) Check(ctx context.Context, c policy.Content) (string, error) {
+	siblings, err := c.Siblings()
+	if err != nil {
+		return "", err
+	}
+	hash := simhash(tokenize(fullText(c.Title, c.Text)))
+	for _, ad := range siblings {
+		if bits.OnesCount64(hash^simhash(tokenize(fullText(ad.Title, ad.Text)))) <= d.maxDistance {
+			return fmt.Sprintf("duplicates ad %d", ad.ID), nil
+		}
+	}
+	return "", nil
+}
+
+// simhash sums hashes of shingles bit by bit, similar texts share most shingles and so most bits.
+func simhash(words []string) uint64 {
+	var weights [64]int
+	add := func(shingle []string) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(strings.Join(shingle, " ")))
+		sum := h.Sum64()
+		for i := range weights {
+			if sum&(1<<i) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	if len(words) < shingleSize {
+		add(words)
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		add(words[i : i+shingleSize])
+	}
+	var res uint64
+	for i, w := range weights {
+		if w > 0 {
+			res |= 1 << i
+		}
+	}
+	return res
+}
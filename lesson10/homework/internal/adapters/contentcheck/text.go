@@ -0,0 +1,26 @@
+package contentcheck
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalize makes texts differing only in case, width or composition of characters equal,
+// e.g. "ＣＡＳＩＮＯ" and "casino".
+func normalize(s string) string {
+	return cases.Fold().String(norm.NFKC.String(s))
+}
+
+// tokenize splits the normalized text into words of letters, digits and combining marks.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(normalize(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.IsMark(r)
+	})
+}
+
+func fullText(title string, text string) string {
+	return title + "\n" + text
+}
@@ -2,13 +2,18 @@ package customer
 
 import (
 	"context"
+	"homework10/internal/adapters/wal"
+	"homework10/internal/app"
+	"homework10/internal/email"
 	"homework10/internal/user"
 	"sync"
 )
 
 type BasicCustomer struct {
-	mx *sync.RWMutex
-	mp map[int64]user.User
+	mx     *sync.RWMutex
+	mp     map[int64]user.User
+	emails map[string]int64
+	log    *wal.Log
 }
 
 func (d *BasicCustomer) Find(ctx context.Context, userID int64) (user.User, bool) {
@@ -20,20 +25,58 @@ func (d *BasicCustomer) Find(ctx context.Context, userID int64) (user.User, bool
 	return d.mp[userID], true
 }
 
-func (d *BasicCustomer) ChangeInfo(ctx context.Context, userID int64, nickname, email string) error {
+func (d *BasicCustomer) FindMany(ctx context.Context, userIDs []int64) (map[int64]user.User, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	res := make(map[int64]user.User, len(userIDs))
+	for _, id := range userIDs {
+		if u, ok := d.mp[id]; ok {
+			res[id] = u
+		}
+	}
+	return res, nil
+}
+
+func (d *BasicCustomer) FindByEmail(ctx context.Context, address string) (user.User, bool) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	userID, ok := d.emails[email.Normalize(address)]
+	if !ok {
+		return user.User{}, false
+	}
+	return d.mp[userID], true
+}
+
+func (d *BasicCustomer) ChangeInfo(ctx context.Context, userID int64, nickname, address string) error {
 	d.mx.Lock()
 	defer d.mx.Unlock()
+	if d.isTaken(address, userID) {
+		return app.ErrEmailTaken
+	}
 	cur := d.mp[userID]
+	if email.Normalize(cur.Email) != email.Normalize(address) {
+		cur.Verified = false
+	}
 	cur.Nickname = nickname
-	cur.Email = email
-	d.mp[userID] = cur
+	cur.Email = address
+	if err := d.persist(userRecord{Op: opPut, User: cur}); err != nil {
+		return err
+	}
+	d.put(cur)
 	return nil
 }
 
-func (d *BasicCustomer) CreateByID(ctx context.Context, nickname string, email string, userID int64) (user.User, error) {
+func (d *BasicCustomer) CreateByID(ctx context.Context, nickname string, address string, userID int64) (user.User, error) {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-	d.mp[userID] = user.User{ID: userID, Nickname: nickname, Email: email}
+	if d.isTaken(address, userID) {
+		return user.User{}, app.ErrEmailTaken
+	}
+	u := user.User{ID: userID, Nickname: nickname, Email: address}
+	if err := d.persist(userRecord{Op: opPut, User: u}); err != nil {
+		return user.User{}, err
+	}
+	d.put(u)
 	return d.mp[userID], nil
 }
 
@@ -41,6 +84,47 @@ func (d *BasicCustomer) DeleteByID(ctx context.Context, userID int64) (user.User
 	d.mx.Lock()
 	defer d.mx.Unlock()
 	res := d.mp[userID]
-	delete(d.mp, userID)
+	if err := d.persist(userRecord{Op: opDelete, User: user.User{ID: userID}}); err != nil {
+		return user.User{}, err
+	}
+	d.remove(userID)
 	return res, nil
 }
+
+func (d *BasicCustomer) SetVerified(ctx context.Context, userID int64, verified bool) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	cur, ok := d.mp[userID]
+	if !ok {
+		return nil
+	}
+	cur.Verified = verified
+	if err := d.persist(userRecord{Op: opPut, User: cur}); err != nil {
+		return err
+	}
+	d.put(cur)
+	return nil
+}
+
+// isTaken reports whether the email belongs to a user other than userID.
+func (d *BasicCustomer) isTaken(address string, userID int64) bool {
+	ownerID, ok := d.emails[email.Normalize(address)]
+	return ok && ownerID != userID
+}
+
+func (d *BasicCustomer) put(u user.User) {
+	d.remove(u.ID)
+	d.mp[u.ID] = u
+	d.emails[email.Normalize(u.Email)] = u.ID
+}
+
+func (d *BasicCustomer) remove(userID int64) {
+	old, ok := d.mp[userID]
+	if !ok {
+		return
+	}
+	if d.emails[email.Normalize(old.Email)] == userID {
+		delete(d.emails, email.Normalize(old.Email))
+	}
+	delete(d.mp, userID)
+}
@@ -1,11 +1,21 @@
 package customer
 
 import (
+	"homework10/internal/adapters/wal"
 	"homework10/internal/app"
 	"homework10/internal/user"
 	"sync"
 )
 
 func New() app.Users {
-	return &BasicCustomer{mx: &sync.RWMutex{}, mp: map[int64]user.User{}} // TODO: реализовать
+	return &BasicCustomer{mx: &sync.RWMutex{}, mp: map[int64]user.User{}, emails: map[string]int64{}} // TODO: реализовать
+}
+
+// NewPersistent recovers users from the log and appends every further mutation to it.
+func NewPersistent(log *wal.Log) (*BasicCustomer, error) {
+	d := &BasicCustomer{mx: &sync.RWMutex{}, mp: map[int64]user.User{}, emails: map[string]int64{}, log: log}
+	if err := log.Replay(d.apply); err != nil {
+		return nil, err
+	}
+	return d, nil
 }
@@ -0,0 +1,66 @@
+package customer
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework10/internal/user"
+)
+
+const (
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+type userRecord struct {
+	Op   string    `json:"op"`
+	User user.User `json:"user"`
+}
+
+// persist writes the mutation to the log before it is applied, it's a no-op for in-memory only stores.
+func (d *BasicCustomer) persist(rec userRecord) error {
+	if d.log == nil {
+		return nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return d.log.Append(data)
+}
+
+func (d *BasicCustomer) apply(data []byte) error {
+	var rec userRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+	switch rec.Op {
+	case opPut:
+		d.put(rec.User)
+	case opDelete:
+		d.remove(rec.User.ID)
+	default:
+		return fmt.Errorf("customer: unknown log record %q", rec.Op)
+	}
+	return nil
+}
+
+// Snapshot compacts the log into a snapshot of the current state.
+func (d *BasicCustomer) Snapshot() error {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	if d.log == nil {
+		return nil
+	}
+	return d.log.Snapshot(func(emit func(rec []byte) error) error {
+		for _, u := range d.mp {
+			data, err := json.Marshal(userRecord{Op: opPut, User: u})
+			if err != nil {
+				return err
+			}
+			if err := emit(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
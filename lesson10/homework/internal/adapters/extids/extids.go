@@ -0,0 +1,20 @@
+package extids
+
+import (
+	"homework10/internal/adapters/wal"
+	"homework10/internal/app"
+	"sync"
+)
+
+func New() app.ExternalIDs {
+	return &MapExternalIDs{mx: &sync.RWMutex{}, ads: map[string]int64{}, externals: map[int64]string{}}
+}
+
+// NewPersistent recovers the mapping from the log and appends every further mutation to it.
+func NewPersistent(log *wal.Log) (*MapExternalIDs, error) {
+	d := &MapExternalIDs{mx: &sync.RWMutex{}, ads: map[string]int64{}, externals: map[int64]string{}, log: log}
+	if err := log.Replay(d.apply); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,70 @@
+package extids
+
+import (
+	"context"
+	"homework10/internal/adapters/wal"
+	"sync"
+)
+
+// MapExternalIDs keeps the mapping in both directions.
+type MapExternalIDs struct {
+	mx        *sync.RWMutex
+	ads       map[string]int64
+	externals map[int64]string
+	log       *wal.Log
+}
+
+func (d *MapExternalIDs) Set(ctx context.Context, externalID string, adID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if err := d.persist(externalIDRecord{Op: opSet, ExternalID: externalID, AdID: adID}); err != nil {
+		return err
+	}
+	d.put(externalID, adID)
+	return nil
+}
+
+func (d *MapExternalIDs) Find(ctx context.Context, externalID string) (int64, bool) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	adID, ok := d.ads[externalID]
+	return adID, ok
+}
+
+func (d *MapExternalIDs) FindByAd(ctx context.Context, adID int64) (string, bool) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	externalID, ok := d.externals[adID]
+	return externalID, ok
+}
+
+func (d *MapExternalIDs) DeleteByAd(ctx context.Context, adID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if _, ok := d.externals[adID]; !ok {
+		return nil
+	}
+	if err := d.persist(externalIDRecord{Op: opDelete, AdID: adID}); err != nil {
+		return err
+	}
+	d.remove(adID)
+	return nil
+}
+
+func (d *MapExternalIDs) put(externalID string, adID int64) {
+	if old, ok := d.externals[adID]; ok {
+		delete(d.ads, old)
+	}
+	if old, ok := d.ads[externalID]; ok {
+		delete(d.externals, old)
+	}
+	d.ads[externalID] = adID
+	d.externals[adID] = externalID
+}
+
+func (d *MapExternalIDs) remove(adID int64) {
+	if externalID, ok := d.externals[adID]; ok {
+		delete(d.ads, externalID)
+		delete(d.externals, adID)
+	}
+}
@@ -0,0 +1,66 @@
+package extids
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	opSet    = "set"
+	opDelete = "delete"
+)
+
+type externalIDRecord struct {
+	Op         string `json:"op"`
+	ExternalID string `json:"external_id,omitempty"`
+	AdID       int64  `json:"ad_id"`
+}
+
+// persist writes the mutation to the log before it is applied, it's a no-op for in-memory only stores.
+func (d *MapExternalIDs) persist(rec externalIDRecord) error {
+	if d.log == nil {
+		return nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return d.log.Append(data)
+}
+
+func (d *MapExternalIDs) apply(data []byte) error {
+	var rec externalIDRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+	switch rec.Op {
+	case opSet:
+		d.put(rec.ExternalID, rec.AdID)
+	case opDelete:
+		d.remove(rec.AdID)
+	default:
+		return fmt.Errorf("extids: unknown log record %q", rec.Op)
+	}
+	return nil
+}
+
+// Snapshot compacts the log into a snapshot of the current state.
+func (d *MapExternalIDs) Snapshot() error {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	if d.log == nil {
+		return nil
+	}
+	return d.log.Snapshot(func(emit func(rec []byte) error) error {
+		for externalID, adID := range d.ads {
+			data, err := json.Marshal(externalIDRecord{Op: opSet, ExternalID: externalID, AdID: adID})
+			if err != nil {
+				return err
+			}
+			if err := emit(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,10 @@
+package favorites
+
+import (
+	"homework10/internal/app"
+	"sync"
+)
+
+func New() app.Favorites {
+	return &MapFavorites{mx: &sync.RWMutex{}, mp: map[int64][]int64{}}
+}
@@ -0,0 +1,53 @@
+package favorites
+
+import (
+	"context"
+	"sync"
+)
+
+// MapFavorites keeps favorite ad IDs of every user in the order they were added.
+type MapFavorites struct {
+	mx *sync.RWMutex
+	mp map[int64][]int64
+}
+
+func (d *MapFavorites) Add(ctx context.Context, userID int64, adID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for _, id := range d.mp[userID] {
+		if id == adID {
+			return nil
+		}
+	}
+	d.mp[userID] = append(d.mp[userID], adID)
+	return nil
+}
+
+func (d *MapFavorites) Remove(ctx context.Context, userID int64, adID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	list := d.mp[userID]
+	for i, id := range list {
+		if id == adID {
+			d.mp[userID] = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(d.mp[userID]) == 0 {
+		delete(d.mp, userID)
+	}
+	return nil
+}
+
+func (d *MapFavorites) List(ctx context.Context, userID int64) ([]int64, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	return append([]int64{}, d.mp[userID]...), nil
+}
+
+func (d *MapFavorites) DeleteByUser(ctx context.Context, userID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	delete(d.mp, userID)
+	return nil
+}
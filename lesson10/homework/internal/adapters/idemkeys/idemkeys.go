@@ -0,0 +1,11 @@
+package idemkeys
+
+import (
+	"homework10/internal/app"
+	"homework10/internal/idempotency"
+	"sync"
+)
+
+func New() app.IdempotencyKeys {
+	return &MapKeys{mx: &sync.Mutex{}, mp: map[idempotency.Key]idempotency.Record{}}
+}
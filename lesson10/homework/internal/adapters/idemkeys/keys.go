@@ -0,0 +1,65 @@
+package idemkeys
+
+import (
+	"context"
+	"homework10/internal/idempotency"
+	"sync"
+	"time"
+)
+
+// MapKeys drops expired records as new ones are reserved. Records are kept for the same TTL,
+// so they expire in the order they are reserved and the oldest ones are checked only.
+type MapKeys struct {
+	mx    *sync.Mutex
+	mp    map[idempotency.Key]idempotency.Record
+	order []reservation
+}
+
+// reservation is left in the order when its key is released or reserved again,
+// then it no longer matches the record of the key and is dropped as it expires.
+type reservation struct {
+	key            idempotency.Key
+	expirationDate time.Time
+}
+
+func (d *MapKeys) Reserve(ctx context.Context, rec idempotency.Record) (idempotency.Record, bool, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	now := time.Now().UTC()
+	d.expire(now)
+	if old, ok := d.mp[rec.Key]; ok && !old.IsExpired(now) {
+		return old, false, nil
+	}
+	rec.Response = nil
+	d.mp[rec.Key] = rec
+	d.order = append(d.order, reservation{key: rec.Key, expirationDate: rec.ExpirationDate})
+	return rec, true, nil
+}
+
+func (d *MapKeys) Complete(ctx context.Context, key idempotency.Key, response []byte) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if rec, ok := d.mp[key]; ok {
+		rec.Response = response
+		d.mp[key] = rec
+	}
+	return nil
+}
+
+func (d *MapKeys) Release(ctx context.Context, key idempotency.Key) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	delete(d.mp, key)
+	return nil
+}
+
+func (d *MapKeys) expire(now time.Time) {
+	i := 0
+	for ; i < len(d.order) && !now.Before(d.order[i].expirationDate); i++ {
+		r := d.order[i]
+		if rec, ok := d.mp[r.key]; ok && rec.ExpirationDate.Equal(r.expirationDate) {
+			delete(d.mp, r.key)
+		}
+	}
+	d.order = d.order[i:]
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"homework10/internal/email"
+	"os"
+	"sync"
+)
+
+// FileMailer writes messages as JSON lines, it's meant for development and tests.
+type FileMailer struct {
+	mx   *sync.Mutex
+	path string
+}
+
+type fileMessage struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+func (d *FileMailer) Send(ctx context.Context, m email.Message) error {
+	data, err := json.Marshal(fileMessage{To: m.To, Subject: m.Subject, Body: m.Body})
+	if err != nil {
+		return err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	f, err := os.OpenFile(d.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
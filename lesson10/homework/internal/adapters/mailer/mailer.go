@@ -0,0 +1,15 @@
+package mailer
+
+import (
+	"homework10/internal/email"
+	"sync"
+)
+
+func NewMemory() *MemoryMailer {
+	return &MemoryMailer{mx: &sync.Mutex{}, sent: []email.Message{}}
+}
+
+// NewFile appends every message to the file at path, it is created if it doesn't exist.
+func NewFile(path string) *FileMailer {
+	return &FileMailer{mx: &sync.Mutex{}, path: path}
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"homework10/internal/email"
+	"sync"
+)
+
+// MemoryMailer keeps sent messages instead of delivering them.
+type MemoryMailer struct {
+	mx   *sync.Mutex
+	sent []email.Message
+}
+
+func (d *MemoryMailer) Send(ctx context.Context, m email.Message) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.sent = append(d.sent, m)
+	return nil
+}
+
+func (d *MemoryMailer) Sent() []email.Message {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return append([]email.Message{}, d.sent...)
+}
+
+// Last returns the latest message sent to the address.
+func (d *MemoryMailer) Last(to string) (email.Message, bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for i := len(d.sent) - 1; i >= 0; i-- {
+		if d.sent[i].To == to {
+			return d.sent[i], true
+		}
+	}
+	return email.Message{}, false
+}
@@ -0,0 +1,18 @@
+package notifier
+
+import (
+	"homework10/internal/notification"
+	"sync"
+)
+
+// DefaultLimit is how many undelivered notifications an outbox keeps.
+const DefaultLimit = 10000
+
+// NewOutbox keeps up to limit undelivered notifications, the oldest ones are dropped
+// to make room for new ones.
+func NewOutbox(limit int) *Outbox {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Outbox{mx: &sync.Mutex{}, pending: []notification.Notification{}, limit: limit}
+}
@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"context"
+	"homework10/internal/notification"
+	"sync"
+)
+
+// Outbox keeps notifications until a sender drains them, so a slow or broken
+// delivery channel never blocks the request that published an ad.
+type Outbox struct {
+	mx      *sync.Mutex
+	pending []notification.Notification
+	limit   int
+	dropped int
+}
+
+func (d *Outbox) Notify(ctx context.Context, n notification.Notification) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if len(d.pending) == d.limit {
+		// append moves the rest to a new array once the capacity runs out, so the old one is freed
+		d.pending = d.pending[1:]
+		d.dropped++
+	}
+	d.pending = append(d.pending, n)
+	return nil
+}
+
+// Dropped returns how many notifications were dropped because the outbox was full.
+func (d *Outbox) Dropped() int {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.dropped
+}
+
+// Pending returns undelivered notifications without removing them.
+func (d *Outbox) Pending() []notification.Notification {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return append([]notification.Notification{}, d.pending...)
+}
+
+// Drain removes and returns all undelivered notifications.
+func (d *Outbox) Drain() []notification.Notification {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	res := d.pending
+	d.pending = []notification.Notification{}
+	return res
+}
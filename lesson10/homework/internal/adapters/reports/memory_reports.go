@@ -0,0 +1,93 @@
+package reports
+
+import (
+	"context"
+	"homework10/internal/report"
+	"homework10/internal/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+type reporterKey struct {
+	tenant     tenant.ID
+	adID       int64
+	reporterID int64
+}
+
+type MemoryReports struct {
+	mx         *sync.RWMutex
+	mp         map[int64]report.Report
+	byReporter map[reporterKey]int64
+	lastID     int64
+}
+
+func (d *MemoryReports) Add(ctx context.Context, r report.Report) (report.Report, bool, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	key := reporterKey{tenant: r.Tenant, adID: r.AdID, reporterID: r.ReporterID}
+	if reportID, ok := d.byReporter[key]; ok {
+		return d.mp[reportID], false, nil
+	}
+	d.lastID++
+	r.ID = d.lastID
+	d.mp[r.ID] = r
+	d.byReporter[key] = r.ID
+	return r, true, nil
+}
+
+func (d *MemoryReports) Find(ctx context.Context, reportID int64) (report.Report, bool) {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	r, ok := d.mp[reportID]
+	if !ok || r.Tenant != tenantID {
+		return report.Report{}, false
+	}
+	return r, true
+}
+
+func (d *MemoryReports) List(ctx context.Context, status report.Status) ([]report.Report, error) {
+	tenantID := tenant.FromContext(ctx)
+	return d.filter(func(r report.Report) bool {
+		return r.Tenant == tenantID && (status == "" || r.Status == status)
+	}), nil
+}
+
+func (d *MemoryReports) ListByAd(ctx context.Context, adID int64) ([]report.Report, error) {
+	tenantID := tenant.FromContext(ctx)
+	return d.filter(func(r report.Report) bool {
+		return r.Tenant == tenantID && r.AdID == adID
+	}), nil
+}
+
+func (d *MemoryReports) SetStatus(ctx context.Context, reportID int64, status report.Status,
+	date time.Time) error {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	r, ok := d.mp[reportID]
+	if !ok || r.Tenant != tenantID {
+		return nil
+	}
+	r.Status = status
+	r.ResolutionDate = date
+	d.mp[reportID] = r
+	return nil
+}
+
+func (d *MemoryReports) filter(match func(r report.Report) bool) []report.Report {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	res := []report.Report{}
+	for _, r := range d.mp {
+		if match(r) {
+			res = append(res, r)
+		}
+	}
+	// IDs grow, so they keep the order reports were filed in
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
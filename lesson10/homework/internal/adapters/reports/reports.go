@@ -0,0 +1,10 @@
+package reports
+
+import (
+	"homework10/internal/report"
+	"sync"
+)
+
+func New() *MemoryReports {
+	return &MemoryReports{mx: &sync.RWMutex{}, mp: map[int64]report.Report{}, byReporter: map[reporterKey]int64{}}
+}
@@ -0,0 +1,108 @@
+package searches
+
+import (
+	"context"
+	"homework10/internal/adpattern"
+	"homework10/internal/search"
+	"sort"
+	"sync"
+)
+
+// record is a saved search as it is stored, with the pattern serialized.
+type record struct {
+	id      int64
+	userID  int64
+	name    string
+	pattern []byte
+}
+
+type MapSearches struct {
+	mx    *sync.RWMutex
+	mp    map[int64]record
+	curID int64
+}
+
+func (d *MapSearches) Add(ctx context.Context, s search.SavedSearch) (search.SavedSearch, error) {
+	pattern, err := adpattern.Marshal(s.Pattern)
+	if err != nil {
+		return search.SavedSearch{}, err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	s.ID = d.curID
+	d.curID++
+	d.mp[s.ID] = record{id: s.ID, userID: s.UserID, name: s.Name, pattern: pattern}
+	return s, nil
+}
+
+func (d *MapSearches) Find(ctx context.Context, searchID int64) (search.SavedSearch, bool) {
+	d.mx.RLock()
+	rec, ok := d.mp[searchID]
+	d.mx.RUnlock()
+	if !ok {
+		return search.SavedSearch{}, false
+	}
+	s, err := rec.decode()
+	if err != nil {
+		return search.SavedSearch{}, false
+	}
+	return s, true
+}
+
+func (d *MapSearches) Delete(ctx context.Context, searchID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	delete(d.mp, searchID)
+	return nil
+}
+
+func (d *MapSearches) ListByUser(ctx context.Context, userID int64) ([]search.SavedSearch, error) {
+	return d.list(func(rec record) bool {
+		return rec.userID == userID
+	})
+}
+
+func (d *MapSearches) DeleteByUser(ctx context.Context, userID int64) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for id, rec := range d.mp {
+		if rec.userID == userID {
+			delete(d.mp, id)
+		}
+	}
+	return nil
+}
+
+func (d *MapSearches) All(ctx context.Context) ([]search.SavedSearch, error) {
+	return d.list(func(rec record) bool {
+		return true
+	})
+}
+
+func (d *MapSearches) list(check func(rec record) bool) ([]search.SavedSearch, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	res := []search.SavedSearch{}
+	for _, rec := range d.mp {
+		if !check(rec) {
+			continue
+		}
+		s, err := rec.decode()
+		if err != nil {
+			return []search.SavedSearch{}, err
+		}
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+func (rec record) decode() (search.SavedSearch, error) {
+	adp, err := adpattern.Unmarshal(rec.pattern)
+	if err != nil {
+		return search.SavedSearch{}, err
+	}
+	return search.SavedSearch{ID: rec.id, UserID: rec.userID, Name: rec.name, Pattern: adp}, nil
+}
@@ -0,0 +1,10 @@
+package searches
+
+import (
+	"homework10/internal/app"
+	"sync"
+)
+
+func New() app.SavedSearches {
+	return &MapSearches{mx: &sync.RWMutex{}, mp: map[int64]record{}, curID: 1}
+}
@@ -0,0 +1,50 @@
+package snowflake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// An ID consists of milliseconds since epoch (41 bits), the node (10 bits) and
+// a sequence number within the millisecond (12 bits), so IDs of a node grow.
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	timeBits     = 63 - nodeBits - sequenceBits
+	maxNode      = 1<<nodeBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+)
+
+var epoch = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type Generator struct {
+	mx       *sync.Mutex
+	node     int64
+	last     int64
+	sequence int64
+	now      func() time.Time
+}
+
+// NextID never blocks: if the clock goes backwards or the sequence of the current
+// millisecond is exhausted, the generator runs ahead of the clock.
+func (d *Generator) NextID() (int64, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	ms := d.now().Sub(epoch).Milliseconds()
+	if ms <= d.last {
+		ms = d.last
+		d.sequence++
+		if d.sequence > maxSequence {
+			ms++
+			d.sequence = 0
+		}
+	} else {
+		d.sequence = 0
+	}
+	if ms < 0 || ms >= 1<<timeBits {
+		return 0, fmt.Errorf("snowflake: time is out of range")
+	}
+	d.last = ms
+	return ms<<(nodeBits+sequenceBits) | d.node<<sequenceBits | d.sequence, nil
+}
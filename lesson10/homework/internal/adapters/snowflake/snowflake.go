@@ -0,0 +1,15 @@
+package snowflake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// New returns a generator for the node, IDs of different nodes never collide.
+func New(node int64) (*Generator, error) {
+	if node < 0 || node > maxNode {
+		return nil, fmt.Errorf("snowflake: node must be in [0, %d], got %d", maxNode, node)
+	}
+	return &Generator{mx: &sync.Mutex{}, node: node, now: time.Now}, nil
+}
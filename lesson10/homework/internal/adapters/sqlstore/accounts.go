@@ -0,0 +1,61 @@
+package sqlstore
+
+import (
+	"context"
+	"homework10/internal/session"
+)
+
+type Credentials struct {
+	db querier
+}
+
+func (d *Credentials) Set(ctx context.Context, userID int64, hash []byte) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO credentials (tenant_id, user_id, password_hash) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (tenant_id, user_id) DO UPDATE SET password_hash = EXCLUDED.password_hash",
+		tenantOf(ctx), userID, hash)
+	return err
+}
+
+func (d *Credentials) Get(ctx context.Context, userID int64) ([]byte, bool) {
+	var hash []byte
+	err := d.db.QueryRowContext(ctx, "SELECT password_hash FROM credentials WHERE tenant_id = $1 AND user_id = $2",
+		tenantOf(ctx), userID).Scan(&hash)
+	if err != nil {
+		return nil, false
+	}
+	return hash, true
+}
+
+func (d *Credentials) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM credentials WHERE tenant_id = $1 AND user_id = $2", tenantOf(ctx), userID)
+	return err
+}
+
+type Sessions struct {
+	db querier
+}
+
+func (d *Sessions) Add(ctx context.Context, s session.Session) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO sessions (token, tenant_id, user_id, expiration_date) VALUES ($1, $2, $3, $4)",
+		s.Token, s.Tenant, s.UserID, s.ExpirationDate)
+	return err
+}
+
+func (d *Sessions) Find(ctx context.Context, token string) (session.Session, bool) {
+	s := session.Session{}
+	err := d.db.QueryRowContext(ctx,
+		"SELECT token, tenant_id, user_id, expiration_date FROM sessions WHERE tenant_id = $1 AND token = $2",
+		tenantOf(ctx), token).
+		Scan(&s.Token, &s.Tenant, &s.UserID, &s.ExpirationDate)
+	if err != nil {
+		return session.Session{}, false
+	}
+	return s, true
+}
+
+func (d *Sessions) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM sessions WHERE tenant_id = $1 AND user_id = $2", tenantOf(ctx), userID)
+	return err
+}
@@ -0,0 +1,69 @@
+package sqlstore
+
+import (
+	"fmt"
+	"homework10/internal/adexpr"
+	"strings"
+)
+
+var exprColumns = map[adexpr.Field]string{
+	adexpr.FieldID:        "id",
+	adexpr.FieldAuthor:    "author_id",
+	adexpr.FieldTitle:     "title",
+	adexpr.FieldText:      "text",
+	adexpr.FieldPublished: "published",
+	adexpr.FieldCreated:   "creation_date",
+	adexpr.FieldUpdated:   "update_date",
+}
+
+// compileExpr translates e to a condition, values are passed as parameters named by param.
+func compileExpr(e adexpr.Expr, param func(arg any) string) (string, error) {
+	switch e := e.(type) {
+	case adexpr.And:
+		return compileBinary(e.Left, e.Right, "AND", param)
+	case adexpr.Or:
+		return compileBinary(e.Left, e.Right, "OR", param)
+	case adexpr.Not:
+		cond, err := compileExpr(e.Expr, param)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + cond, nil
+	case adexpr.Cmp:
+		column, ok := exprColumns[e.Field]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown field %q", adexpr.ErrBadExpr, e.Field)
+		}
+		if e.Op == adexpr.OpContains {
+			return fmt.Sprintf("(strpos(lower(%s), lower(%s)) > 0)", column, param(e.Value)), nil
+		}
+		op := string(e.Op)
+		if e.Op == adexpr.OpNe {
+			op = "<>"
+		}
+		return fmt.Sprintf("(%s %s %s)", column, op, param(e.Value)), nil
+	case adexpr.In:
+		column, ok := exprColumns[e.Field]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown field %q", adexpr.ErrBadExpr, e.Field)
+		}
+		params := make([]string, 0, len(e.Values))
+		for _, v := range e.Values {
+			params = append(params, param(v))
+		}
+		return fmt.Sprintf("(%s IN (%s))", column, strings.Join(params, ", ")), nil
+	}
+	return "", fmt.Errorf("%w: unknown expression %T", adexpr.ErrBadExpr, e)
+}
+
+func compileBinary(left, right adexpr.Expr, op string, param func(arg any) string) (string, error) {
+	l, err := compileExpr(left, param)
+	if err != nil {
+		return "", err
+	}
+	r, err := compileExpr(right, param)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
@@ -0,0 +1,274 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/tenant"
+	"strings"
+	"time"
+)
+
+const adColumns = "id, title, text, author_id, published, hidden, lang, lang_declared, creation_date, update_date"
+
+// notDeleted hides ads in the trash, every query except FindDeleted and ListDeleted must include it.
+const notDeleted = "deleted_at IS NULL"
+
+// inTenant scopes a query to the tenant passed as the first argument, every query must include it.
+const inTenant = "tenant_id = $1"
+
+func tenantOf(ctx context.Context) string {
+	return string(tenant.FromContext(ctx))
+}
+
+type AdRepo struct {
+	db querier
+}
+
+func (d *AdRepo) Find(ctx context.Context, adID int64) (ads.Ad, bool) {
+	row := d.db.QueryRowContext(ctx, "SELECT "+adColumns+" FROM ads WHERE "+inTenant+" AND id = $2 AND "+notDeleted,
+		tenantOf(ctx), adID)
+	ad, err := scanAd(row)
+	if err != nil {
+		return ads.Ad{}, false
+	}
+	return ad, true
+}
+
+func (d *AdRepo) Add(ctx context.Context, title string, text string, userID int64) (int64, error) {
+	now := time.Now().UTC()
+	var adID int64
+	err := d.db.QueryRowContext(ctx,
+		"INSERT INTO ads (tenant_id, title, text, author_id, published, creation_date, update_date) "+
+			"VALUES ($1, $2, $3, $4, FALSE, $5, $5) RETURNING id",
+		tenantOf(ctx), title, text, userID, now).Scan(&adID)
+	return adID, err
+}
+
+func (d *AdRepo) Insert(ctx context.Context, ad ads.Ad) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO ads (tenant_id, "+adColumns+", deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) "+
+			"ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, text = EXCLUDED.text, "+
+			"author_id = EXCLUDED.author_id, published = EXCLUDED.published, hidden = EXCLUDED.hidden, "+
+			"lang = EXCLUDED.lang, lang_declared = EXCLUDED.lang_declared, "+
+			"creation_date = EXCLUDED.creation_date, update_date = EXCLUDED.update_date, "+
+			"deleted_at = EXCLUDED.deleted_at WHERE ads.tenant_id = EXCLUDED.tenant_id",
+		tenantOf(ctx), ad.ID, ad.Title, ad.Text, ad.AuthorID, ad.Published, ad.Hidden, ad.Lang, ad.LangDeclared,
+		ad.CreationDate,
+		ad.UpdateDate, sql.NullTime{Time: ad.DeletionDate, Valid: ad.IsDeleted()})
+	return err
+}
+
+func (d *AdRepo) SetTitle(ctx context.Context, adID int64, title string) error {
+	return d.update(ctx, "title", adID, title)
+}
+
+func (d *AdRepo) SetText(ctx context.Context, adID int64, text string) error {
+	return d.update(ctx, "text", adID, text)
+}
+
+func (d *AdRepo) SetStatus(ctx context.Context, adID int64, status bool) error {
+	return d.update(ctx, "published", adID, status)
+}
+
+func (d *AdRepo) SetHidden(ctx context.Context, adID int64, hidden bool) error {
+	return d.update(ctx, "hidden", adID, hidden)
+}
+
+func (d *AdRepo) SetLang(ctx context.Context, adID int64, lang string, declared bool) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE ads SET lang = $2, lang_declared = $3, update_date = $4 WHERE "+inTenant+" AND id = $5 AND "+notDeleted,
+		tenantOf(ctx), lang, declared, time.Now().UTC(), adID)
+	return err
+}
+
+func (d *AdRepo) update(ctx context.Context, column string, adID int64, value any) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE ads SET "+column+" = $2, update_date = $3 WHERE "+inTenant+" AND id = $4 AND "+notDeleted,
+		tenantOf(ctx), value, time.Now().UTC(), adID)
+	return err
+}
+
+func (d *AdRepo) Delete(ctx context.Context, adID int64) error {
+	_, err := d.db.ExecContext(ctx, "UPDATE ads SET deleted_at = $2 WHERE "+inTenant+" AND id = $3 AND "+notDeleted,
+		tenantOf(ctx), time.Now().UTC(), adID)
+	return err
+}
+
+func (d *AdRepo) DeleteByAuthor(ctx context.Context, userID int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE ads SET deleted_at = $2 WHERE "+inTenant+" AND author_id = $3 AND "+notDeleted,
+		tenantOf(ctx), time.Now().UTC(), userID)
+	return err
+}
+
+func (d *AdRepo) FindDeleted(ctx context.Context, adID int64) (ads.Ad, bool) {
+	row := d.db.QueryRowContext(ctx,
+		"SELECT "+adColumns+", deleted_at FROM ads WHERE "+inTenant+" AND id = $2 AND deleted_at IS NOT NULL",
+		tenantOf(ctx), adID)
+	ad, err := scanDeletedAd(row)
+	if err != nil {
+		return ads.Ad{}, false
+	}
+	return ad, true
+}
+
+func (d *AdRepo) ListDeleted(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	return d.queryWith(ctx, scanDeletedAd, "SELECT "+adColumns+", deleted_at FROM ads "+
+		"WHERE "+inTenant+" AND author_id = $2 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id",
+		tenantOf(ctx), userID)
+}
+
+func (d *AdRepo) Restore(ctx context.Context, adID int64) error {
+	_, err := d.db.ExecContext(ctx, "UPDATE ads SET deleted_at = NULL WHERE "+inTenant+" AND id = $2",
+		tenantOf(ctx), adID)
+	return err
+}
+
+func (d *AdRepo) Purge(ctx context.Context, before time.Time) ([]int64, error) {
+	rows, err := d.db.QueryContext(ctx, "DELETE FROM ads WHERE "+inTenant+" AND deleted_at < $2 RETURNING id",
+		tenantOf(ctx), before)
+	if err != nil {
+		return []int64{}, err
+	}
+	defer rows.Close()
+	res := []int64{}
+	for rows.Next() {
+		var adID int64
+		if err := rows.Scan(&adID); err != nil {
+			return res, err
+		}
+		res = append(res, adID)
+	}
+	return res, rows.Err()
+}
+
+func (d *AdRepo) GetAllByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error) {
+	if err := adpattern.ValidateSort(adp.Sort); err != nil {
+		return []ads.Ad{}, err
+	}
+	conds := []string{inTenant, notDeleted}
+	args := []any{tenantOf(ctx)}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if adp.PublishedOnly {
+		conds = append(conds, "published AND NOT hidden")
+	}
+	if adp.AuthorID != 0 {
+		add("author_id = $%d", adp.AuthorID)
+	}
+	if adp.Lang != "" {
+		add("lang = $%d", adp.Lang)
+	}
+	if adp.IsLTimeSet {
+		add("creation_date >= $%d", adp.LDate)
+	}
+	if adp.IsRTimeSet {
+		add("creation_date <= $%d", adp.RDate)
+	}
+	if adp.Title != "" {
+		add("title LIKE $%d ESCAPE '\\'", escapeLike(adp.Title)+"%")
+	}
+	if adp.Expr != nil {
+		cond, err := compileExpr(adp.Expr, func(arg any) string {
+			args = append(args, arg)
+			return fmt.Sprintf("$%d", len(args))
+		})
+		if err != nil {
+			return []ads.Ad{}, err
+		}
+		conds = append(conds, cond)
+	}
+	query := "SELECT " + adColumns + " FROM ads WHERE " + strings.Join(conds, " AND ")
+	return d.query(ctx, query+orderBy(adp.Sort), args...)
+}
+
+func (d *AdRepo) GetByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
+	return d.GetAllByTemplate(ctx, adpattern.AdPattern{Title: title})
+}
+
+// sortColumns match the ordering of adpattern.Less, titles are compared with the root ICU collation.
+var sortColumns = map[string]string{
+	adpattern.FieldCreationDate: "creation_date",
+	adpattern.FieldUpdateDate:   "update_date",
+	adpattern.FieldTitle:        `title COLLATE "und-x-icu"`,
+}
+
+func orderBy(order []adpattern.OrderBy) string {
+	keys := []string{}
+	for _, o := range order {
+		key := sortColumns[o.Field]
+		if o.Desc {
+			key += " DESC"
+		}
+		keys = append(keys, key)
+	}
+	keys = append(keys, "creation_date", "id")
+	return " ORDER BY " + strings.Join(keys, ", ")
+}
+
+func (d *AdRepo) query(ctx context.Context, query string, args ...any) ([]ads.Ad, error) {
+	return d.queryWith(ctx, scanAd, query, args...)
+}
+
+func (d *AdRepo) queryWith(ctx context.Context, scan func(s scanner) (ads.Ad, error), query string,
+	args ...any) ([]ads.Ad, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []ads.Ad{}, err
+	}
+	defer rows.Close()
+	res := []ads.Ad{}
+	for rows.Next() {
+		ad, err := scan(rows)
+		if err != nil {
+			return []ads.Ad{}, err
+		}
+		res = append(res, ad)
+	}
+	if err := rows.Err(); err != nil {
+		return []ads.Ad{}, err
+	}
+	return res, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAd(s scanner) (ads.Ad, error) {
+	var ad ads.Ad
+	err := s.Scan(&ad.ID, &ad.Title, &ad.Text, &ad.AuthorID, &ad.Published, &ad.Hidden, &ad.Lang, &ad.LangDeclared,
+		&ad.CreationDate,
+		&ad.UpdateDate)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	ad.CreationDate = ad.CreationDate.UTC()
+	ad.UpdateDate = ad.UpdateDate.UTC()
+	return ad, nil
+}
+
+func scanDeletedAd(s scanner) (ads.Ad, error) {
+	var ad ads.Ad
+	err := s.Scan(&ad.ID, &ad.Title, &ad.Text, &ad.AuthorID, &ad.Published, &ad.Hidden, &ad.Lang, &ad.LangDeclared,
+		&ad.CreationDate,
+		&ad.UpdateDate, &ad.DeletionDate)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	ad.CreationDate = ad.CreationDate.UTC()
+	ad.UpdateDate = ad.UpdateDate.UTC()
+	ad.DeletionDate = ad.DeletionDate.UTC()
+	return ad, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,85 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	"homework10/internal/analytics"
+	"homework10/internal/tenant"
+	"strings"
+	"time"
+)
+
+// AdStats adds a batch of view counts with a single statement.
+type AdStats struct {
+	db querier
+}
+
+func (d *AdStats) AddViews(ctx context.Context, counts []analytics.ViewCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(counts))
+	args := make([]any, 0, 4*len(counts))
+	for i, c := range counts {
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d)", 4*i+1, 4*i+2, 4*i+3, 4*i+4))
+		args = append(args, c.Tenant, c.AdID, c.Hour, c.Views)
+	}
+	_, err := d.db.ExecContext(ctx, "INSERT INTO ad_views (tenant_id, ad_id, hour, views) VALUES "+
+		strings.Join(values, ", ")+
+		" ON CONFLICT (tenant_id, ad_id, hour) DO UPDATE SET views = ad_views.views + EXCLUDED.views", args...)
+	return err
+}
+
+func (d *AdStats) ViewCounts(ctx context.Context, adIDs []int64, from, to time.Time) ([]analytics.ViewCount, error) {
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT ad_id, hour, views FROM ad_views
+		WHERE tenant_id = $1 AND ad_id = ANY($2) AND hour >= $3 AND hour < $4 ORDER BY hour, ad_id`,
+		tenantOf(ctx), pq.Array(adIDs), from, to)
+	if err != nil {
+		return []analytics.ViewCount{}, err
+	}
+	defer rows.Close()
+
+	res := []analytics.ViewCount{}
+	for rows.Next() {
+		c := analytics.ViewCount{Tenant: tenant.FromContext(ctx)}
+		if err := rows.Scan(&c.AdID, &c.Hour, &c.Views); err != nil {
+			return []analytics.ViewCount{}, err
+		}
+		c.Hour = c.Hour.UTC()
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+func (d *AdStats) AddPublication(ctx context.Context, p analytics.Publication) error {
+	_, err := d.db.ExecContext(ctx,
+		`INSERT INTO ad_publications (tenant_id, ad_id, creation_date, publication_date) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, ad_id) DO NOTHING`,
+		p.Tenant, p.AdID, p.CreationDate, p.PublicationDate)
+	return err
+}
+
+func (d *AdStats) Publications(ctx context.Context, adIDs []int64) ([]analytics.Publication, error) {
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT ad_id, creation_date, publication_date FROM ad_publications
+		WHERE tenant_id = $1 AND ad_id = ANY($2) ORDER BY ad_id`,
+		tenantOf(ctx), pq.Array(adIDs))
+	if err != nil {
+		return []analytics.Publication{}, err
+	}
+	defer rows.Close()
+
+	res := []analytics.Publication{}
+	for rows.Next() {
+		p := analytics.Publication{Tenant: tenant.FromContext(ctx)}
+		if err := rows.Scan(&p.AdID, &p.CreationDate, &p.PublicationDate); err != nil {
+			return []analytics.Publication{}, err
+		}
+		p.CreationDate = p.CreationDate.UTC()
+		p.PublicationDate = p.PublicationDate.UTC()
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,68 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/json"
+	"homework10/internal/audit"
+)
+
+// AuditLog never updates or deletes rows of audit_log.
+type AuditLog struct {
+	db querier
+}
+
+func (d *AuditLog) Append(ctx context.Context, e audit.Entry) (audit.Entry, error) {
+	err := d.db.QueryRowContext(ctx,
+		`INSERT INTO audit_log (tenant_id, created_at, actor, action, target_kind, target_id,
+			before_value, after_value, request_id, transport)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		e.Tenant, e.Time, e.Actor, e.Action, e.Target.Kind, e.Target.ID,
+		jsonValue(e.Before), jsonValue(e.After), e.RequestID, e.Transport).Scan(&e.ID)
+	if err != nil {
+		return audit.Entry{}, err
+	}
+	return e, nil
+}
+
+func (d *AuditLog) List(ctx context.Context, target audit.Target) ([]audit.Entry, error) {
+	query := `SELECT id, tenant_id, created_at, actor, action, target_kind, target_id,
+		before_value, after_value, request_id, transport FROM audit_log WHERE tenant_id = $1`
+	args := []any{tenantOf(ctx)}
+	if !target.IsZero() {
+		query += " AND target_kind = $2 AND target_id = $3"
+		args = append(args, target.Kind, target.ID)
+	}
+	rows, err := d.db.QueryContext(ctx, query+" ORDER BY id", args...)
+	if err != nil {
+		return []audit.Entry{}, err
+	}
+	defer rows.Close()
+
+	res := []audit.Entry{}
+	for rows.Next() {
+		var e audit.Entry
+		var before, after []byte
+		err := rows.Scan(&e.ID, &e.Tenant, &e.Time, &e.Actor, &e.Action, &e.Target.Kind, &e.Target.ID,
+			&before, &after, &e.RequestID, &e.Transport)
+		if err != nil {
+			return []audit.Entry{}, err
+		}
+		if before != nil {
+			e.Before = json.RawMessage(before)
+		}
+		if after != nil {
+			e.After = json.RawMessage(after)
+		}
+		e.Time = e.Time.UTC()
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+// jsonValue passes snapshots as text, the driver would send bytes as bytea which JSONB doesn't accept.
+func jsonValue(data json.RawMessage) any {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,205 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"homework10/internal/message"
+	"time"
+)
+
+const (
+	threadColumns  = "id, ad_id, buyer_id, seller_id, creation_date, update_date"
+	messageColumns = "id, thread_id, sender_id, text, creation_date, read_date"
+)
+
+type Messages struct {
+	db querier
+}
+
+func (d *Messages) FindThread(ctx context.Context, threadID int64) (message.Thread, bool) {
+	row := d.db.QueryRowContext(ctx, "SELECT "+threadColumns+" FROM threads WHERE tenant_id = $1 AND id = $2",
+		tenantOf(ctx), threadID)
+	t, err := scanThread(row)
+	if err != nil {
+		return message.Thread{}, false
+	}
+	return t, true
+}
+
+func (d *Messages) FindThreadByAd(ctx context.Context, adID int64, buyerID int64) (message.Thread, bool) {
+	row := d.db.QueryRowContext(ctx,
+		"SELECT "+threadColumns+" FROM threads WHERE tenant_id = $1 AND ad_id = $2 AND buyer_id = $3",
+		tenantOf(ctx), adID, buyerID)
+	t, err := scanThread(row)
+	if err != nil {
+		return message.Thread{}, false
+	}
+	return t, true
+}
+
+func (d *Messages) CreateThread(ctx context.Context, adID int64, buyerID int64,
+	sellerID int64) (message.Thread, error) {
+	now := time.Now().UTC()
+	t := message.Thread{AdID: adID, BuyerID: buyerID, SellerID: sellerID, CreationDate: now, UpdateDate: now}
+	err := d.db.QueryRowContext(ctx,
+		"INSERT INTO threads (tenant_id, ad_id, buyer_id, seller_id, creation_date, update_date) "+
+			"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		tenantOf(ctx), adID, buyerID, sellerID, now, now).Scan(&t.ID)
+	if err != nil {
+		return message.Thread{}, err
+	}
+	return t, nil
+}
+
+// ListThreads returns threads of the user, the most recently updated first.
+func (d *Messages) ListThreads(ctx context.Context, userID int64) ([]message.Thread, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+threadColumns+" FROM threads "+
+		"WHERE tenant_id = $1 AND (buyer_id = $2 OR seller_id = $2) ORDER BY update_date DESC, id DESC",
+		tenantOf(ctx), userID)
+	if err != nil {
+		return []message.Thread{}, err
+	}
+	defer rows.Close()
+	res := []message.Thread{}
+	for rows.Next() {
+		t, err := scanThread(rows)
+		if err != nil {
+			return []message.Thread{}, err
+		}
+		res = append(res, t)
+	}
+	if err := rows.Err(); err != nil {
+		return []message.Thread{}, err
+	}
+	return res, nil
+}
+
+func (d *Messages) AddMessage(ctx context.Context, threadID int64, senderID int64,
+	text string) (message.Message, error) {
+	m := message.Message{ThreadID: threadID, SenderID: senderID, Text: text, CreationDate: time.Now().UTC()}
+	// the message is only added if the thread exists, which also moves the thread up in ListThreads
+	err := d.db.QueryRowContext(ctx,
+		"WITH t AS (UPDATE threads SET update_date = $4 WHERE tenant_id = $1 AND id = $2 RETURNING id) "+
+			"INSERT INTO messages (tenant_id, thread_id, sender_id, text, creation_date) "+
+			"SELECT $1, id, $3, $5, $4 FROM t RETURNING id",
+		tenantOf(ctx), threadID, senderID, m.CreationDate, text).Scan(&m.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return message.Message{}, nil
+	}
+	if err != nil {
+		return message.Message{}, err
+	}
+	return m, nil
+}
+
+func (d *Messages) ListMessages(ctx context.Context, threadID int64, beforeID int64,
+	limit int) ([]message.Message, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+messageColumns+" FROM (SELECT "+messageColumns+" FROM messages "+
+		"WHERE tenant_id = $1 AND thread_id = $2 AND ($3::BIGINT = 0 OR id < $3) ORDER BY id DESC LIMIT $4) m "+
+		"ORDER BY id", tenantOf(ctx), threadID, beforeID, limit)
+	if err != nil {
+		return []message.Message{}, err
+	}
+	defer rows.Close()
+	res := []message.Message{}
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return []message.Message{}, err
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return []message.Message{}, err
+	}
+	return res, nil
+}
+
+func (d *Messages) MarkRead(ctx context.Context, threadID int64, readerID int64, upToID int64) (int, error) {
+	// everything before an already read message is read too
+	res, err := d.db.ExecContext(ctx,
+		"UPDATE messages SET read_date = $5 WHERE tenant_id = $1 AND thread_id = $2 AND sender_id <> $3 "+
+			"AND id <= $4 AND read_date IS NULL AND id > COALESCE((SELECT MAX(id) FROM messages "+
+			"WHERE tenant_id = $1 AND thread_id = $2 AND sender_id <> $3 AND id <= $4 AND read_date IS NOT NULL), 0)",
+		tenantOf(ctx), threadID, readerID, upToID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	marked, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(marked), nil
+}
+
+func (d *Messages) DeleteByAd(ctx context.Context, adID int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"WITH t AS (DELETE FROM threads WHERE tenant_id = $1 AND ad_id = $2 RETURNING id) "+
+			"DELETE FROM messages WHERE tenant_id = $1 AND thread_id IN (SELECT id FROM t)", tenantOf(ctx), adID)
+	return err
+}
+
+func (d *Messages) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"WITH t AS (DELETE FROM threads WHERE tenant_id = $1 AND (buyer_id = $2 OR seller_id = $2) RETURNING id) "+
+			"DELETE FROM messages WHERE tenant_id = $1 AND thread_id IN (SELECT id FROM t)", tenantOf(ctx), userID)
+	return err
+}
+
+func scanThread(s scanner) (message.Thread, error) {
+	var t message.Thread
+	if err := s.Scan(&t.ID, &t.AdID, &t.BuyerID, &t.SellerID, &t.CreationDate, &t.UpdateDate); err != nil {
+		return message.Thread{}, err
+	}
+	t.CreationDate = t.CreationDate.UTC()
+	t.UpdateDate = t.UpdateDate.UTC()
+	return t, nil
+}
+
+func scanMessage(s scanner) (message.Message, error) {
+	var m message.Message
+	var read sql.NullTime
+	if err := s.Scan(&m.ID, &m.ThreadID, &m.SenderID, &m.Text, &m.CreationDate, &read); err != nil {
+		return message.Message{}, err
+	}
+	m.CreationDate = m.CreationDate.UTC()
+	if read.Valid {
+		m.ReadDate = read.Time.UTC()
+	}
+	return m, nil
+}
+
+type Blocks struct {
+	db querier
+}
+
+func (d *Blocks) Block(ctx context.Context, userID int64, blockedID int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO blocks (tenant_id, user_id, blocked_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		tenantOf(ctx), userID, blockedID)
+	return err
+}
+
+func (d *Blocks) Unblock(ctx context.Context, userID int64, blockedID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM blocks WHERE tenant_id = $1 AND user_id = $2 AND blocked_id = $3",
+		tenantOf(ctx), userID, blockedID)
+	return err
+}
+
+func (d *Blocks) IsBlocked(ctx context.Context, first int64, second int64) (bool, error) {
+	var res bool
+	err := d.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM blocks WHERE tenant_id = $1 AND "+
+		"(user_id = $2 AND blocked_id = $3 OR user_id = $3 AND blocked_id = $2))",
+		tenantOf(ctx), first, second).Scan(&res)
+	if err != nil {
+		return false, err
+	}
+	return res, nil
+}
+
+func (d *Blocks) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM blocks WHERE tenant_id = $1 AND (user_id = $2 OR blocked_id = $2)",
+		tenantOf(ctx), userID)
+	return err
+}
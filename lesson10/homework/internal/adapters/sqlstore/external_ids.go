@@ -0,0 +1,51 @@
+package sqlstore
+
+import (
+	"context"
+)
+
+type ExternalIDs struct {
+	db querier
+	// lock makes Find take a transaction-level lock of the external ID, so that
+	// concurrent imports of an ad missing so far don't both create it
+	lock bool
+}
+
+func (d *ExternalIDs) Set(ctx context.Context, externalID string, adID int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO external_ids (tenant_id, external_id, ad_id) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (tenant_id, external_id) DO UPDATE SET ad_id = EXCLUDED.ad_id", tenantOf(ctx), externalID, adID)
+	return err
+}
+
+func (d *ExternalIDs) Find(ctx context.Context, externalID string) (int64, bool) {
+	if d.lock {
+		_, err := d.db.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1 || '/' || $2))",
+			tenantOf(ctx), externalID)
+		if err != nil {
+			return 0, false
+		}
+	}
+	var adID int64
+	err := d.db.QueryRowContext(ctx, "SELECT ad_id FROM external_ids WHERE tenant_id = $1 AND external_id = $2",
+		tenantOf(ctx), externalID).Scan(&adID)
+	if err != nil {
+		return 0, false
+	}
+	return adID, true
+}
+
+func (d *ExternalIDs) FindByAd(ctx context.Context, adID int64) (string, bool) {
+	var externalID string
+	err := d.db.QueryRowContext(ctx, "SELECT external_id FROM external_ids WHERE tenant_id = $1 AND ad_id = $2",
+		tenantOf(ctx), adID).Scan(&externalID)
+	if err != nil {
+		return "", false
+	}
+	return externalID, true
+}
+
+func (d *ExternalIDs) DeleteByAd(ctx context.Context, adID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM external_ids WHERE tenant_id = $1 AND ad_id = $2", tenantOf(ctx), adID)
+	return err
+}
@@ -0,0 +1,50 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+)
+
+type Favorites struct {
+	db querier
+}
+
+func (d *Favorites) Add(ctx context.Context, userID int64, adID int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO favorites (tenant_id, user_id, ad_id, added_at) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+		tenantOf(ctx), userID, adID, time.Now().UTC())
+	return err
+}
+
+func (d *Favorites) Remove(ctx context.Context, userID int64, adID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM favorites WHERE tenant_id = $1 AND user_id = $2 AND ad_id = $3",
+		tenantOf(ctx), userID, adID)
+	return err
+}
+
+func (d *Favorites) List(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT ad_id FROM favorites WHERE tenant_id = $1 AND user_id = $2 ORDER BY added_at, ad_id",
+		tenantOf(ctx), userID)
+	if err != nil {
+		return []int64{}, err
+	}
+	defer rows.Close()
+	res := []int64{}
+	for rows.Next() {
+		var adID int64
+		if err := rows.Scan(&adID); err != nil {
+			return []int64{}, err
+		}
+		res = append(res, adID)
+	}
+	if err := rows.Err(); err != nil {
+		return []int64{}, err
+	}
+	return res, nil
+}
+
+func (d *Favorites) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM favorites WHERE tenant_id = $1 AND user_id = $2", tenantOf(ctx), userID)
+	return err
+}
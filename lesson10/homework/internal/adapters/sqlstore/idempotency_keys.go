@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"homework10/internal/idempotency"
+	"time"
+)
+
+type IdempotencyKeys struct {
+	db querier
+}
+
+// Reserve overwrites an expired record with the key, other expired records are deleted.
+func (d *IdempotencyKeys) Reserve(ctx context.Context, rec idempotency.Record) (idempotency.Record, bool, error) {
+	now := time.Now().UTC()
+	_, err := d.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expiration_date <= $1", now)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	var fingerprint string
+	err = d.db.QueryRowContext(ctx,
+		"INSERT INTO idempotency_keys (tenant_id, user_id, key, fingerprint, expiration_date) "+
+			"VALUES ($1, $2, $3, $4, $5) ON CONFLICT (tenant_id, user_id, key) DO UPDATE SET "+
+			"fingerprint = EXCLUDED.fingerprint, response = NULL, expiration_date = EXCLUDED.expiration_date "+
+			"WHERE idempotency_keys.expiration_date <= $6 RETURNING fingerprint",
+		string(rec.Key.Tenant), rec.Key.UserID, rec.Key.Value, rec.Fingerprint, rec.ExpirationDate, now).
+		Scan(&fingerprint)
+	if err == nil {
+		rec.Response = nil
+		return rec, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return idempotency.Record{}, false, err
+	}
+
+	old := idempotency.Record{Key: rec.Key}
+	err = d.db.QueryRowContext(ctx,
+		"SELECT fingerprint, response, expiration_date FROM idempotency_keys "+
+			"WHERE tenant_id = $1 AND user_id = $2 AND key = $3",
+		string(rec.Key.Tenant), rec.Key.UserID, rec.Key.Value).
+		Scan(&old.Fingerprint, &old.Response, &old.ExpirationDate)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	return old, false, nil
+}
+
+func (d *IdempotencyKeys) Complete(ctx context.Context, key idempotency.Key, response []byte) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET response = $4 WHERE tenant_id = $1 AND user_id = $2 AND key = $3",
+		string(key.Tenant), key.UserID, key.Value, response)
+	return err
+}
+
+func (d *IdempotencyKeys) Release(ctx context.Context, key idempotency.Key) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE tenant_id = $1 AND user_id = $2 AND key = $3",
+		string(key.Tenant), key.UserID, key.Value)
+	return err
+}
@@ -0,0 +1,103 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"homework10/internal/report"
+	"time"
+)
+
+const reportColumns = "id, tenant_id, ad_id, reporter_id, reason, comment, status, creation_date, resolution_date"
+
+type Reports struct {
+	db querier
+}
+
+// Add relies on the unique index on (tenant_id, ad_id, reporter_id), so concurrent reports
+// of the same reporter store one row.
+func (d *Reports) Add(ctx context.Context, r report.Report) (report.Report, bool, error) {
+	err := d.db.QueryRowContext(ctx,
+		"INSERT INTO reports (tenant_id, ad_id, reporter_id, reason, comment, status, creation_date) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (tenant_id, ad_id, reporter_id) DO NOTHING RETURNING id",
+		r.Tenant, r.AdID, r.ReporterID, r.Reason, r.Comment, r.Status, r.CreationDate).Scan(&r.ID)
+	if err == nil {
+		return r, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return report.Report{}, false, err
+	}
+	row := d.db.QueryRowContext(ctx, "SELECT "+reportColumns+" FROM reports "+
+		"WHERE tenant_id = $1 AND ad_id = $2 AND reporter_id = $3", r.Tenant, r.AdID, r.ReporterID)
+	res, err := scanReport(row)
+	if err != nil {
+		return report.Report{}, false, err
+	}
+	return res, false, nil
+}
+
+func (d *Reports) Find(ctx context.Context, reportID int64) (report.Report, bool) {
+	row := d.db.QueryRowContext(ctx, "SELECT "+reportColumns+" FROM reports WHERE tenant_id = $1 AND id = $2",
+		tenantOf(ctx), reportID)
+	res, err := scanReport(row)
+	if err != nil {
+		return report.Report{}, false
+	}
+	return res, true
+}
+
+func (d *Reports) List(ctx context.Context, status report.Status) ([]report.Report, error) {
+	if status == "" {
+		return d.query(ctx, "SELECT "+reportColumns+" FROM reports WHERE tenant_id = $1 ORDER BY id", tenantOf(ctx))
+	}
+	return d.query(ctx, "SELECT "+reportColumns+" FROM reports WHERE tenant_id = $1 AND status = $2 ORDER BY id",
+		tenantOf(ctx), status)
+}
+
+func (d *Reports) ListByAd(ctx context.Context, adID int64) ([]report.Report, error) {
+	return d.query(ctx, "SELECT "+reportColumns+" FROM reports WHERE tenant_id = $1 AND ad_id = $2 ORDER BY id",
+		tenantOf(ctx), adID)
+}
+
+func (d *Reports) SetStatus(ctx context.Context, reportID int64, status report.Status,
+	date time.Time) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE reports SET status = $3, resolution_date = $4 WHERE tenant_id = $1 AND id = $2",
+		tenantOf(ctx), reportID, status, date)
+	return err
+}
+
+func (d *Reports) query(ctx context.Context, query string, args ...any) ([]report.Report, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []report.Report{}, err
+	}
+	defer rows.Close()
+	res := []report.Report{}
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return []report.Report{}, err
+		}
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return []report.Report{}, err
+	}
+	return res, nil
+}
+
+func scanReport(s scanner) (report.Report, error) {
+	var r report.Report
+	var resolved sql.NullTime
+	err := s.Scan(&r.ID, &r.Tenant, &r.AdID, &r.ReporterID, &r.Reason, &r.Comment, &r.Status, &r.CreationDate,
+		&resolved)
+	if err != nil {
+		return report.Report{}, err
+	}
+	r.CreationDate = r.CreationDate.UTC()
+	if resolved.Valid {
+		r.ResolutionDate = resolved.Time.UTC()
+	}
+	return r, nil
+}
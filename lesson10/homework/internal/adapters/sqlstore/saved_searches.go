@@ -0,0 +1,93 @@
+package sqlstore
+
+import (
+	"context"
+	"homework10/internal/adpattern"
+	"homework10/internal/search"
+)
+
+// SavedSearches stores patterns serialized with adpattern.Marshal.
+type SavedSearches struct {
+	db querier
+}
+
+func (d *SavedSearches) Add(ctx context.Context, s search.SavedSearch) (search.SavedSearch, error) {
+	pattern, err := adpattern.Marshal(s.Pattern)
+	if err != nil {
+		return search.SavedSearch{}, err
+	}
+	err = d.db.QueryRowContext(ctx,
+		"INSERT INTO saved_searches (tenant_id, user_id, name, pattern) VALUES ($1, $2, $3, $4) RETURNING id",
+		tenantOf(ctx), s.UserID, s.Name, pattern).Scan(&s.ID)
+	if err != nil {
+		return search.SavedSearch{}, err
+	}
+	return s, nil
+}
+
+func (d *SavedSearches) Find(ctx context.Context, searchID int64) (search.SavedSearch, bool) {
+	row := d.db.QueryRowContext(ctx,
+		"SELECT id, user_id, name, pattern FROM saved_searches WHERE tenant_id = $1 AND id = $2",
+		tenantOf(ctx), searchID)
+	s, err := scanSavedSearch(row)
+	if err != nil {
+		return search.SavedSearch{}, false
+	}
+	return s, true
+}
+
+func (d *SavedSearches) Delete(ctx context.Context, searchID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM saved_searches WHERE tenant_id = $1 AND id = $2", tenantOf(ctx), searchID)
+	return err
+}
+
+func (d *SavedSearches) ListByUser(ctx context.Context, userID int64) ([]search.SavedSearch, error) {
+	return d.query(ctx,
+		"SELECT id, user_id, name, pattern FROM saved_searches WHERE tenant_id = $1 AND user_id = $2 ORDER BY id",
+		tenantOf(ctx), userID)
+}
+
+func (d *SavedSearches) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM saved_searches WHERE tenant_id = $1 AND user_id = $2",
+		tenantOf(ctx), userID)
+	return err
+}
+
+func (d *SavedSearches) All(ctx context.Context) ([]search.SavedSearch, error) {
+	return d.query(ctx, "SELECT id, user_id, name, pattern FROM saved_searches WHERE tenant_id = $1 ORDER BY id",
+		tenantOf(ctx))
+}
+
+func (d *SavedSearches) query(ctx context.Context, query string, args ...any) ([]search.SavedSearch, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []search.SavedSearch{}, err
+	}
+	defer rows.Close()
+	res := []search.SavedSearch{}
+	for rows.Next() {
+		s, err := scanSavedSearch(rows)
+		if err != nil {
+			return []search.SavedSearch{}, err
+		}
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return []search.SavedSearch{}, err
+	}
+	return res, nil
+}
+
+func scanSavedSearch(s scanner) (search.SavedSearch, error) {
+	var res search.SavedSearch
+	var pattern []byte
+	if err := s.Scan(&res.ID, &res.UserID, &res.Name, &pattern); err != nil {
+		return search.SavedSearch{}, err
+	}
+	adp, err := adpattern.Unmarshal(pattern)
+	if err != nil {
+		return search.SavedSearch{}, err
+	}
+	res.Pattern = adp
+	return res, nil
+}
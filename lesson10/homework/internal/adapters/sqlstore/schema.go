@@ -0,0 +1,279 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	tenant_id TEXT NOT NULL DEFAULT 'default',
+	id        BIGINT NOT NULL,
+	nickname  TEXT NOT NULL,
+	email     TEXT NOT NULL,
+	verified  BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (tenant_id, id)
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+		WHERE table_name = 'users' AND constraint_name = 'users_pkey' AND column_name = 'tenant_id') THEN
+		ALTER TABLE users DROP CONSTRAINT users_pkey, ADD PRIMARY KEY (tenant_id, id);
+	END IF;
+END $$;
+
+DROP INDEX IF EXISTS users_email_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_idx ON users (tenant_id, lower(email));
+
+CREATE TABLE IF NOT EXISTS ads (
+	id            BIGSERIAL PRIMARY KEY,
+	tenant_id     TEXT NOT NULL DEFAULT 'default',
+	title         TEXT NOT NULL,
+	text          TEXT NOT NULL,
+	author_id     BIGINT NOT NULL,
+	published     BOOLEAN NOT NULL DEFAULT FALSE,
+	creation_date TIMESTAMPTZ NOT NULL,
+	update_date   TIMESTAMPTZ NOT NULL,
+	deleted_at    TIMESTAMPTZ
+);
+
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS lang TEXT NOT NULL DEFAULT '';
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS lang_declared BOOLEAN NOT NULL DEFAULT FALSE;
+
+DROP INDEX IF EXISTS ads_author_id_idx;
+DROP INDEX IF EXISTS ads_creation_date_idx;
+DROP INDEX IF EXISTS ads_title_idx;
+DROP INDEX IF EXISTS ads_deleted_at_idx;
+CREATE INDEX IF NOT EXISTS ads_tenant_deleted_at_idx ON ads (tenant_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS ads_tenant_author_id_idx ON ads (tenant_id, author_id, creation_date, id);
+CREATE INDEX IF NOT EXISTS ads_tenant_creation_date_idx ON ads (tenant_id, creation_date, id);
+CREATE INDEX IF NOT EXISTS ads_tenant_title_idx ON ads (tenant_id, title text_pattern_ops);
+
+CREATE TABLE IF NOT EXISTS external_ids (
+	tenant_id   TEXT NOT NULL DEFAULT 'default',
+	external_id TEXT NOT NULL,
+	ad_id       BIGINT NOT NULL,
+	PRIMARY KEY (tenant_id, external_id)
+);
+
+ALTER TABLE external_ids ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+		WHERE table_name = 'external_ids' AND constraint_name = 'external_ids_pkey' AND column_name = 'tenant_id') THEN
+		ALTER TABLE external_ids DROP CONSTRAINT external_ids_pkey, ADD PRIMARY KEY (tenant_id, external_id);
+	END IF;
+END $$;
+
+DROP INDEX IF EXISTS external_ids_ad_id_idx;
+CREATE INDEX IF NOT EXISTS external_ids_tenant_ad_id_idx ON external_ids (tenant_id, ad_id);
+
+CREATE TABLE IF NOT EXISTS favorites (
+	tenant_id  TEXT NOT NULL DEFAULT 'default',
+	user_id    BIGINT NOT NULL,
+	ad_id      BIGINT NOT NULL,
+	added_at   TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (tenant_id, user_id, ad_id)
+);
+
+ALTER TABLE favorites ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+		WHERE table_name = 'favorites' AND constraint_name = 'favorites_pkey' AND column_name = 'tenant_id') THEN
+		ALTER TABLE favorites DROP CONSTRAINT favorites_pkey, ADD PRIMARY KEY (tenant_id, user_id, ad_id);
+	END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS saved_searches (
+	id        BIGSERIAL PRIMARY KEY,
+	tenant_id TEXT NOT NULL DEFAULT 'default',
+	user_id   BIGINT NOT NULL,
+	name      TEXT NOT NULL,
+	pattern   BYTEA NOT NULL
+);
+
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+DROP INDEX IF EXISTS saved_searches_user_id_idx;
+CREATE INDEX IF NOT EXISTS saved_searches_tenant_user_id_idx ON saved_searches (tenant_id, user_id);
+
+CREATE TABLE IF NOT EXISTS verification_tokens (
+	token           TEXT PRIMARY KEY,
+	tenant_id       TEXT NOT NULL DEFAULT 'default',
+	user_id         BIGINT NOT NULL,
+	email           TEXT NOT NULL,
+	expiration_date TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE verification_tokens ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+DROP INDEX IF EXISTS verification_tokens_user_id_idx;
+CREATE INDEX IF NOT EXISTS verification_tokens_tenant_user_id_idx ON verification_tokens (tenant_id, user_id);
+
+CREATE TABLE IF NOT EXISTS credentials (
+	tenant_id     TEXT NOT NULL DEFAULT 'default',
+	user_id       BIGINT NOT NULL,
+	password_hash BYTEA NOT NULL,
+	PRIMARY KEY (tenant_id, user_id)
+);
+
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+		WHERE table_name = 'credentials' AND constraint_name = 'credentials_pkey' AND column_name = 'tenant_id') THEN
+		ALTER TABLE credentials DROP CONSTRAINT credentials_pkey, ADD PRIMARY KEY (tenant_id, user_id);
+	END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS sessions (
+	token           TEXT PRIMARY KEY,
+	tenant_id       TEXT NOT NULL DEFAULT 'default',
+	user_id         BIGINT NOT NULL,
+	expiration_date TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+DROP INDEX IF EXISTS sessions_user_id_idx;
+CREATE INDEX IF NOT EXISTS sessions_tenant_user_id_idx ON sessions (tenant_id, user_id);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	tenant_id       TEXT NOT NULL,
+	user_id         BIGINT NOT NULL,
+	key             TEXT NOT NULL,
+	fingerprint     TEXT NOT NULL,
+	response        BYTEA,
+	expiration_date TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (tenant_id, user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expiration_date_idx ON idempotency_keys (expiration_date);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+	id           BIGSERIAL PRIMARY KEY,
+	tenant_id    TEXT NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL,
+	actor        TEXT NOT NULL,
+	action       TEXT NOT NULL,
+	target_kind  TEXT NOT NULL,
+	target_id    BIGINT NOT NULL,
+	before_value JSONB,
+	after_value  JSONB,
+	request_id   TEXT NOT NULL,
+	transport    TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_target_idx ON audit_log (tenant_id, target_kind, target_id);
+
+CREATE TABLE IF NOT EXISTS ad_views (
+	tenant_id TEXT NOT NULL,
+	ad_id     BIGINT NOT NULL,
+	hour      TIMESTAMPTZ NOT NULL,
+	views     BIGINT NOT NULL,
+	PRIMARY KEY (tenant_id, ad_id, hour)
+);
+
+CREATE TABLE IF NOT EXISTS ad_publications (
+	tenant_id        TEXT NOT NULL,
+	ad_id            BIGINT NOT NULL,
+	creation_date    TIMESTAMPTZ NOT NULL,
+	publication_date TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (tenant_id, ad_id)
+);
+
+CREATE TABLE IF NOT EXISTS reports (
+	id              BIGSERIAL PRIMARY KEY,
+	tenant_id       TEXT NOT NULL,
+	ad_id           BIGINT NOT NULL,
+	reporter_id     BIGINT NOT NULL,
+	reason          TEXT NOT NULL,
+	comment         TEXT NOT NULL,
+	status          TEXT NOT NULL,
+	creation_date   TIMESTAMPTZ NOT NULL,
+	resolution_date TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reports_reporter_idx ON reports (tenant_id, ad_id, reporter_id);
+CREATE INDEX IF NOT EXISTS reports_status_idx ON reports (tenant_id, status, id);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id            BIGSERIAL PRIMARY KEY,
+	tenant_id     TEXT NOT NULL,
+	url           TEXT NOT NULL,
+	events        TEXT[] NOT NULL,
+	pattern       BYTEA NOT NULL,
+	secret        TEXT NOT NULL,
+	creation_date TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id              BIGSERIAL PRIMARY KEY,
+	tenant_id       TEXT NOT NULL,
+	webhook_id      BIGINT NOT NULL,
+	event           TEXT NOT NULL,
+	ad_id           BIGINT NOT NULL,
+	payload         BYTEA NOT NULL,
+	status          TEXT NOT NULL,
+	attempts        INTEGER NOT NULL,
+	last_error      TEXT NOT NULL,
+	response_status INTEGER NOT NULL,
+	creation_date   TIMESTAMPTZ NOT NULL,
+	update_date     TIMESTAMPTZ NOT NULL,
+	next_attempt    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (tenant_id, webhook_id, id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_status_idx ON webhook_deliveries (tenant_id, status, id);
+
+CREATE TABLE IF NOT EXISTS threads (
+	id            BIGSERIAL PRIMARY KEY,
+	tenant_id     TEXT NOT NULL,
+	ad_id         BIGINT NOT NULL,
+	buyer_id      BIGINT NOT NULL,
+	seller_id     BIGINT NOT NULL,
+	creation_date TIMESTAMPTZ NOT NULL,
+	update_date   TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS threads_ad_buyer_idx ON threads (tenant_id, ad_id, buyer_id);
+CREATE INDEX IF NOT EXISTS threads_buyer_idx ON threads (tenant_id, buyer_id);
+CREATE INDEX IF NOT EXISTS threads_seller_idx ON threads (tenant_id, seller_id);
+
+CREATE TABLE IF NOT EXISTS messages (
+	id            BIGSERIAL PRIMARY KEY,
+	tenant_id     TEXT NOT NULL,
+	thread_id     BIGINT NOT NULL,
+	sender_id     BIGINT NOT NULL,
+	text          TEXT NOT NULL,
+	creation_date TIMESTAMPTZ NOT NULL,
+	read_date     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS messages_thread_idx ON messages (tenant_id, thread_id, id);
+
+CREATE TABLE IF NOT EXISTS blocks (
+	tenant_id  TEXT NOT NULL,
+	user_id    BIGINT NOT NULL,
+	blocked_id BIGINT NOT NULL,
+	PRIMARY KEY (tenant_id, user_id, blocked_id)
+);
+`
+
+// querier is implemented by both *sql.DB and *sql.Tx, so the same repositories
+// work inside and outside of a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
@@ -0,0 +1,80 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+)
+
+func NewAdRepo(db *sql.DB) *AdRepo {
+	return &AdRepo{db: db}
+}
+
+func NewUsers(db *sql.DB) *Users {
+	return &Users{db: db}
+}
+
+func NewFavorites(db *sql.DB) *Favorites {
+	return &Favorites{db: db}
+}
+
+func NewSavedSearches(db *sql.DB) *SavedSearches {
+	return &SavedSearches{db: db}
+}
+
+func NewVerificationTokens(db *sql.DB) *VerificationTokens {
+	return &VerificationTokens{db: db}
+}
+
+func NewCredentials(db *sql.DB) *Credentials {
+	return &Credentials{db: db}
+}
+
+func NewSessions(db *sql.DB) *Sessions {
+	return &Sessions{db: db}
+}
+
+func NewExternalIDs(db *sql.DB) *ExternalIDs {
+	return &ExternalIDs{db: db}
+}
+
+func NewIdempotencyKeys(db *sql.DB) *IdempotencyKeys {
+	return &IdempotencyKeys{db: db}
+}
+
+func NewAuditLog(db *sql.DB) *AuditLog {
+	return &AuditLog{db: db}
+}
+
+func NewAdStats(db *sql.DB) *AdStats {
+	return &AdStats{db: db}
+}
+
+func NewReports(db *sql.DB) *Reports {
+	return &Reports{db: db}
+}
+
+func NewWebhooks(db *sql.DB) *Webhooks {
+	return &Webhooks{db: db}
+}
+
+func NewWebhookDeliveries(db *sql.DB) *WebhookDeliveries {
+	return &WebhookDeliveries{db: db}
+}
+
+func NewMessages(db *sql.DB) *Messages {
+	return &Messages{db: db}
+}
+
+func NewBlocks(db *sql.DB) *Blocks {
+	return &Blocks{db: db}
+}
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Migrate creates the tables if they don't exist yet.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, schema)
+	return err
+}
@@ -0,0 +1,52 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"homework10/internal/app"
+)
+
+// UnitOfWork runs fn in a database transaction with repositories bound to it.
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+func (d *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repo app.Repository, users app.Users) error) error {
+	return d.DoImport(ctx, func(ctx context.Context, repo app.Repository, users app.Users, _ app.ExternalIDs) error {
+		return fn(ctx, repo, users)
+	})
+}
+
+// DoImport binds external IDs to the transaction too, a lookup of an external ID
+// waits for other transactions which looked it up to end.
+func (d *UnitOfWork) DoImport(ctx context.Context, fn func(ctx context.Context, repo app.Repository, users app.Users,
+	externalIDs app.ExternalIDs) error) error {
+	return d.do(ctx, func(tx *sql.Tx) error {
+		return fn(ctx, &AdRepo{db: tx}, &Users{db: tx}, &ExternalIDs{db: tx, lock: true})
+	})
+}
+
+// DoAccounts binds the stores keeping data of users to the transaction too.
+func (d *UnitOfWork) DoAccounts(ctx context.Context, fn func(ctx context.Context, repo app.Repository, users app.Users,
+	stores app.AccountStores) error) error {
+	return d.do(ctx, func(tx *sql.Tx) error {
+		return fn(ctx, &AdRepo{db: tx}, &Users{db: tx}, app.AccountStores{Credentials: &Credentials{db: tx},
+			Sessions: &Sessions{db: tx}, Tokens: &VerificationTokens{db: tx}, Favorites: &Favorites{db: tx},
+			Searches: &SavedSearches{db: tx}, Messages: &Messages{db: tx}, Blocks: &Blocks{db: tx}})
+	})
+}
+
+func (d *UnitOfWork) do(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w, rollback: %v", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,105 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"homework10/internal/app"
+	"homework10/internal/user"
+)
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+type Users struct {
+	db querier
+}
+
+// emailError reports a violation of the unique email index as app.ErrEmailTaken.
+func emailError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return app.ErrEmailTaken
+	}
+	return err
+}
+
+func (d *Users) Find(ctx context.Context, userID int64) (user.User, bool) {
+	u := user.User{}
+	err := d.db.QueryRowContext(ctx, "SELECT id, nickname, email, verified FROM users WHERE "+inTenant+" AND id = $2",
+		tenantOf(ctx), userID).
+		Scan(&u.ID, &u.Nickname, &u.Email, &u.Verified)
+	if err != nil {
+		return user.User{}, false
+	}
+	return u, true
+}
+
+func (d *Users) FindMany(ctx context.Context, userIDs []int64) (map[int64]user.User, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT id, nickname, email, verified FROM users WHERE "+inTenant+" AND id = ANY($2)",
+		tenantOf(ctx), pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[int64]user.User, len(userIDs))
+	for rows.Next() {
+		u := user.User{}
+		if err := rows.Scan(&u.ID, &u.Nickname, &u.Email, &u.Verified); err != nil {
+			return nil, err
+		}
+		res[u.ID] = u
+	}
+	return res, rows.Err()
+}
+
+func (d *Users) FindByEmail(ctx context.Context, email string) (user.User, bool) {
+	u := user.User{}
+	err := d.db.QueryRowContext(ctx,
+		"SELECT id, nickname, email, verified FROM users WHERE "+inTenant+" AND lower(email) = lower($2)",
+		tenantOf(ctx), email).
+		Scan(&u.ID, &u.Nickname, &u.Email, &u.Verified)
+	if err != nil {
+		return user.User{}, false
+	}
+	return u, true
+}
+
+func (d *Users) CreateByID(ctx context.Context, nickname, email string, userID int64) (user.User, error) {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO users (tenant_id, id, nickname, email) VALUES ($1, $2, $3, $4) "+
+			"ON CONFLICT (tenant_id, id) DO UPDATE SET nickname = EXCLUDED.nickname, email = EXCLUDED.email, "+
+			"verified = FALSE",
+		tenantOf(ctx), userID, nickname, email)
+	if err != nil {
+		return user.User{}, emailError(err)
+	}
+	return user.User{ID: userID, Nickname: nickname, Email: email}, nil
+}
+
+func (d *Users) DeleteByID(ctx context.Context, userID int64) (user.User, error) {
+	u := user.User{}
+	err := d.db.QueryRowContext(ctx,
+		"DELETE FROM users WHERE "+inTenant+" AND id = $2 RETURNING id, nickname, email, verified",
+		tenantOf(ctx), userID).Scan(&u.ID, &u.Nickname, &u.Email, &u.Verified)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return user.User{}, err
+	}
+	return u, nil
+}
+
+func (d *Users) ChangeInfo(ctx context.Context, userID int64, nickname, email string) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE users SET nickname = $2, email = $3, verified = verified AND lower(email) = lower($3) "+
+			"WHERE "+inTenant+" AND id = $4",
+		tenantOf(ctx), nickname, email, userID)
+	return emailError(err)
+}
+
+func (d *Users) SetVerified(ctx context.Context, userID int64, verified bool) error {
+	_, err := d.db.ExecContext(ctx, "UPDATE users SET verified = $2 WHERE "+inTenant+" AND id = $3",
+		tenantOf(ctx), verified, userID)
+	return err
+}
@@ -0,0 +1,35 @@
+package sqlstore
+
+import (
+	"context"
+	"homework10/internal/verification"
+)
+
+type VerificationTokens struct {
+	db querier
+}
+
+func (d *VerificationTokens) Add(ctx context.Context, t verification.Token) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO verification_tokens (token, tenant_id, user_id, email, expiration_date) VALUES ($1, $2, $3, $4, $5)",
+		t.Value, t.Tenant, t.UserID, t.Email, t.ExpirationDate)
+	return err
+}
+
+func (d *VerificationTokens) Take(ctx context.Context, value string) (verification.Token, bool) {
+	t := verification.Token{}
+	err := d.db.QueryRowContext(ctx,
+		"DELETE FROM verification_tokens WHERE token = $1 AND tenant_id = $2 "+
+			"RETURNING token, tenant_id, user_id, email, expiration_date", value, tenantOf(ctx)).
+		Scan(&t.Value, &t.Tenant, &t.UserID, &t.Email, &t.ExpirationDate)
+	if err != nil {
+		return verification.Token{}, false
+	}
+	return t, true
+}
+
+func (d *VerificationTokens) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM verification_tokens WHERE tenant_id = $1 AND user_id = $2",
+		tenantOf(ctx), userID)
+	return err
+}
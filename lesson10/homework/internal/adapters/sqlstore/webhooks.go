@@ -0,0 +1,175 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"homework10/internal/adpattern"
+	"homework10/internal/webhook"
+	"time"
+)
+
+const webhookColumns = "id, tenant_id, url, events, pattern, secret, creation_date"
+
+// Webhooks stores patterns serialized with adpattern.Marshal.
+type Webhooks struct {
+	db querier
+}
+
+func (d *Webhooks) Add(ctx context.Context, s webhook.Subscription) (webhook.Subscription, error) {
+	pattern, err := adpattern.Marshal(s.Pattern)
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+	events := make([]string, 0, len(s.Events))
+	for _, e := range s.Events {
+		events = append(events, string(e))
+	}
+	err = d.db.QueryRowContext(ctx,
+		"INSERT INTO webhooks (tenant_id, url, events, pattern, secret, creation_date) "+
+			"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		s.Tenant, s.URL, pq.Array(events), pattern, s.Secret, s.CreationDate).Scan(&s.ID)
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+	return s, nil
+}
+
+func (d *Webhooks) Find(ctx context.Context, webhookID int64) (webhook.Subscription, bool) {
+	row := d.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE tenant_id = $1 AND id = $2",
+		tenantOf(ctx), webhookID)
+	s, err := scanWebhook(row)
+	if err != nil {
+		return webhook.Subscription{}, false
+	}
+	return s, true
+}
+
+func (d *Webhooks) List(ctx context.Context) ([]webhook.Subscription, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE tenant_id = $1 ORDER BY id",
+		tenantOf(ctx))
+	if err != nil {
+		return []webhook.Subscription{}, err
+	}
+	defer rows.Close()
+	res := []webhook.Subscription{}
+	for rows.Next() {
+		s, err := scanWebhook(rows)
+		if err != nil {
+			return []webhook.Subscription{}, err
+		}
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return []webhook.Subscription{}, err
+	}
+	return res, nil
+}
+
+func (d *Webhooks) Delete(ctx context.Context, webhookID int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM webhooks WHERE tenant_id = $1 AND id = $2", tenantOf(ctx), webhookID)
+	return err
+}
+
+func scanWebhook(s scanner) (webhook.Subscription, error) {
+	var res webhook.Subscription
+	var events []string
+	var pattern []byte
+	err := s.Scan(&res.ID, &res.Tenant, &res.URL, pq.Array(&events), &pattern, &res.Secret, &res.CreationDate)
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+	adp, err := adpattern.Unmarshal(pattern)
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+	res.Pattern = adp
+	for _, e := range events {
+		res.Events = append(res.Events, webhook.Event(e))
+	}
+	res.CreationDate = res.CreationDate.UTC()
+	return res, nil
+}
+
+const deliveryColumns = "id, tenant_id, webhook_id, event, ad_id, payload, status, attempts, last_error, " +
+	"response_status, creation_date, update_date, next_attempt"
+
+type WebhookDeliveries struct {
+	db querier
+}
+
+func (d *WebhookDeliveries) Add(ctx context.Context, dl webhook.Delivery) (webhook.Delivery, error) {
+	err := d.db.QueryRowContext(ctx,
+		"INSERT INTO webhook_deliveries (tenant_id, webhook_id, event, ad_id, payload, status, attempts, "+
+			"last_error, response_status, creation_date, update_date, next_attempt) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id",
+		dl.Tenant, dl.SubscriptionID, dl.Event, dl.AdID, dl.Payload, dl.Status, dl.Attempts, dl.LastError,
+		dl.ResponseStatus, dl.CreationDate, dl.UpdateDate, nullTime(dl.NextAttempt)).Scan(&dl.ID)
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+	return dl, nil
+}
+
+func (d *WebhookDeliveries) Update(ctx context.Context, dl webhook.Delivery) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = $3, attempts = $4, last_error = $5, response_status = $6, "+
+			"update_date = $7, next_attempt = $8 WHERE tenant_id = $1 AND id = $2",
+		tenantOf(ctx), dl.ID, dl.Status, dl.Attempts, dl.LastError, dl.ResponseStatus, dl.UpdateDate,
+		nullTime(dl.NextAttempt))
+	return err
+}
+
+func (d *WebhookDeliveries) Find(ctx context.Context, deliveryID int64) (webhook.Delivery, bool) {
+	row := d.db.QueryRowContext(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE tenant_id = $1 AND id = $2", tenantOf(ctx), deliveryID)
+	dl, err := scanDelivery(row)
+	if err != nil {
+		return webhook.Delivery{}, false
+	}
+	return dl, true
+}
+
+func (d *WebhookDeliveries) List(ctx context.Context, webhookID int64,
+	status webhook.Status) ([]webhook.Delivery, error) {
+	// zero values of the filters match everything
+	rows, err := d.db.QueryContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries "+
+		"WHERE tenant_id = $1 AND ($2::BIGINT = 0 OR webhook_id = $2) AND ($3::TEXT = '' OR status = $3) ORDER BY id",
+		tenantOf(ctx), webhookID, status)
+	if err != nil {
+		return []webhook.Delivery{}, err
+	}
+	defer rows.Close()
+	res := []webhook.Delivery{}
+	for rows.Next() {
+		dl, err := scanDelivery(rows)
+		if err != nil {
+			return []webhook.Delivery{}, err
+		}
+		res = append(res, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return []webhook.Delivery{}, err
+	}
+	return res, nil
+}
+
+func scanDelivery(s scanner) (webhook.Delivery, error) {
+	var dl webhook.Delivery
+	var next sql.NullTime
+	err := s.Scan(&dl.ID, &dl.Tenant, &dl.SubscriptionID, &dl.Event, &dl.AdID, &dl.Payload, &dl.Status, &dl.Attempts,
+		&dl.LastError, &dl.ResponseStatus, &dl.CreationDate, &dl.UpdateDate, &next)
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+	dl.CreationDate = dl.CreationDate.UTC()
+	dl.UpdateDate = dl.UpdateDate.UTC()
+	if next.Valid {
+		dl.NextAttempt = next.Time.UTC()
+	}
+	return dl, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,67 @@
+package tenancy
+
+import (
+	"context"
+	"homework10/internal/app"
+	"homework10/internal/message"
+)
+
+type Messages struct {
+	stores *stores[app.Messages]
+}
+
+func (d *Messages) FindThread(ctx context.Context, threadID int64) (message.Thread, bool) {
+	return d.stores.get(ctx).FindThread(ctx, threadID)
+}
+
+func (d *Messages) FindThreadByAd(ctx context.Context, adID int64, buyerID int64) (message.Thread, bool) {
+	return d.stores.get(ctx).FindThreadByAd(ctx, adID, buyerID)
+}
+
+func (d *Messages) CreateThread(ctx context.Context, adID int64, buyerID int64, sellerID int64) (message.Thread, error) {
+	return d.stores.get(ctx).CreateThread(ctx, adID, buyerID, sellerID)
+}
+
+func (d *Messages) ListThreads(ctx context.Context, userID int64) ([]message.Thread, error) {
+	return d.stores.get(ctx).ListThreads(ctx, userID)
+}
+
+func (d *Messages) AddMessage(ctx context.Context, threadID int64, senderID int64, text string) (message.Message, error) {
+	return d.stores.get(ctx).AddMessage(ctx, threadID, senderID, text)
+}
+
+func (d *Messages) ListMessages(ctx context.Context, threadID int64, beforeID int64, limit int) ([]message.Message, error) {
+	return d.stores.get(ctx).ListMessages(ctx, threadID, beforeID, limit)
+}
+
+func (d *Messages) MarkRead(ctx context.Context, threadID int64, readerID int64, upToID int64) (int, error) {
+	return d.stores.get(ctx).MarkRead(ctx, threadID, readerID, upToID)
+}
+
+func (d *Messages) DeleteByAd(ctx context.Context, adID int64) error {
+	return d.stores.get(ctx).DeleteByAd(ctx, adID)
+}
+
+func (d *Messages) DeleteByUser(ctx context.Context, userID int64) error {
+	return d.stores.get(ctx).DeleteByUser(ctx, userID)
+}
+
+type Blocks struct {
+	stores *stores[app.Blocks]
+}
+
+func (d *Blocks) Block(ctx context.Context, userID int64, blockedID int64) error {
+	return d.stores.get(ctx).Block(ctx, userID, blockedID)
+}
+
+func (d *Blocks) Unblock(ctx context.Context, userID int64, blockedID int64) error {
+	return d.stores.get(ctx).Unblock(ctx, userID, blockedID)
+}
+
+func (d *Blocks) IsBlocked(ctx context.Context, first int64, second int64) (bool, error) {
+	return d.stores.get(ctx).IsBlocked(ctx, first, second)
+}
+
+func (d *Blocks) DeleteByUser(ctx context.Context, userID int64) error {
+	return d.stores.get(ctx).DeleteByUser(ctx, userID)
+}
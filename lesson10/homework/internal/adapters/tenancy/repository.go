@@ -0,0 +1,96 @@
+package tenancy
+
+import (
+	"context"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/tenant"
+	"sync"
+	"time"
+)
+
+// Repository routes every call to the repository of the tenant of the context,
+// so a query can't see ads of another tenant.
+type Repository struct {
+	newRepo func() app.Repository
+	repos   map[tenant.ID]app.Repository
+	mx      *sync.Mutex
+}
+
+func (d *Repository) repo(ctx context.Context) app.Repository {
+	id := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	repo, ok := d.repos[id]
+	if !ok {
+		repo = d.newRepo()
+		d.repos[id] = repo
+	}
+	return repo
+}
+
+func (d *Repository) Find(ctx context.Context, adID int64) (ads.Ad, bool) {
+	return d.repo(ctx).Find(ctx, adID)
+}
+
+func (d *Repository) GetByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
+	return d.repo(ctx).GetByTitle(ctx, title)
+}
+
+func (d *Repository) Add(ctx context.Context, title string, text string, userID int64) (int64, error) {
+	return d.repo(ctx).Add(ctx, title, text, userID)
+}
+
+func (d *Repository) Delete(ctx context.Context, adID int64) error {
+	return d.repo(ctx).Delete(ctx, adID)
+}
+
+func (d *Repository) DeleteByAuthor(ctx context.Context, userID int64) error {
+	return d.repo(ctx).DeleteByAuthor(ctx, userID)
+}
+
+func (d *Repository) FindDeleted(ctx context.Context, adID int64) (ads.Ad, bool) {
+	return d.repo(ctx).FindDeleted(ctx, adID)
+}
+
+func (d *Repository) ListDeleted(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	return d.repo(ctx).ListDeleted(ctx, userID)
+}
+
+func (d *Repository) Restore(ctx context.Context, adID int64) error {
+	return d.repo(ctx).Restore(ctx, adID)
+}
+
+// Purge removes ads of the tenant of ctx only.
+func (d *Repository) Purge(ctx context.Context, before time.Time) ([]int64, error) {
+	return d.repo(ctx).Purge(ctx, before)
+}
+
+func (d *Repository) SetTitle(ctx context.Context, adID int64, title string) error {
+	return d.repo(ctx).SetTitle(ctx, adID, title)
+}
+
+func (d *Repository) SetText(ctx context.Context, adID int64, text string) error {
+	return d.repo(ctx).SetText(ctx, adID, text)
+}
+
+func (d *Repository) SetStatus(ctx context.Context, adID int64, status bool) error {
+	return d.repo(ctx).SetStatus(ctx, adID, status)
+}
+
+func (d *Repository) SetHidden(ctx context.Context, adID int64, hidden bool) error {
+	return d.repo(ctx).SetHidden(ctx, adID, hidden)
+}
+
+func (d *Repository) SetLang(ctx context.Context, adID int64, lang string, declared bool) error {
+	return d.repo(ctx).SetLang(ctx, adID, lang, declared)
+}
+
+func (d *Repository) GetAllByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error) {
+	return d.repo(ctx).GetAllByTemplate(ctx, adp)
+}
+
+func (d *Repository) Insert(ctx context.Context, ad ads.Ad) error {
+	return d.repo(ctx).Insert(ctx, ad)
+}
@@ -0,0 +1,30 @@
+package tenancy
+
+import (
+	"context"
+	"homework10/internal/tenant"
+	"sync"
+)
+
+// stores keeps a store of every tenant, made by newStore on first use.
+type stores[T any] struct {
+	newStore func() T
+	mp       map[tenant.ID]T
+	mx       *sync.Mutex
+}
+
+func newStores[T any](newStore func() T) *stores[T] {
+	return &stores[T]{newStore: newStore, mp: map[tenant.ID]T{}, mx: &sync.Mutex{}}
+}
+
+func (d *stores[T]) get(ctx context.Context) T {
+	id := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	s, ok := d.mp[id]
+	if !ok {
+		s = d.newStore()
+		d.mp[id] = s
+	}
+	return s
+}
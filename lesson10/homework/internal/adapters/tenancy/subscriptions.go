@@ -0,0 +1,76 @@
+package tenancy
+
+import (
+	"context"
+	"homework10/internal/app"
+	"homework10/internal/search"
+)
+
+type Favorites struct {
+	stores *stores[app.Favorites]
+}
+
+func (d *Favorites) Add(ctx context.Context, userID int64, adID int64) error {
+	return d.stores.get(ctx).Add(ctx, userID, adID)
+}
+
+func (d *Favorites) Remove(ctx context.Context, userID int64, adID int64) error {
+	return d.stores.get(ctx).Remove(ctx, userID, adID)
+}
+
+func (d *Favorites) List(ctx context.Context, userID int64) ([]int64, error) {
+	return d.stores.get(ctx).List(ctx, userID)
+}
+
+func (d *Favorites) DeleteByUser(ctx context.Context, userID int64) error {
+	return d.stores.get(ctx).DeleteByUser(ctx, userID)
+}
+
+type SavedSearches struct {
+	stores *stores[app.SavedSearches]
+}
+
+func (d *SavedSearches) Add(ctx context.Context, s search.SavedSearch) (search.SavedSearch, error) {
+	return d.stores.get(ctx).Add(ctx, s)
+}
+
+func (d *SavedSearches) Find(ctx context.Context, searchID int64) (search.SavedSearch, bool) {
+	return d.stores.get(ctx).Find(ctx, searchID)
+}
+
+func (d *SavedSearches) Delete(ctx context.Context, searchID int64) error {
+	return d.stores.get(ctx).Delete(ctx, searchID)
+}
+
+func (d *SavedSearches) ListByUser(ctx context.Context, userID int64) ([]search.SavedSearch, error) {
+	return d.stores.get(ctx).ListByUser(ctx, userID)
+}
+
+func (d *SavedSearches) DeleteByUser(ctx context.Context, userID int64) error {
+	return d.stores.get(ctx).DeleteByUser(ctx, userID)
+}
+
+// All returns saved searches of the tenant of ctx only.
+func (d *SavedSearches) All(ctx context.Context) ([]search.SavedSearch, error) {
+	return d.stores.get(ctx).All(ctx)
+}
+
+type ExternalIDs struct {
+	stores *stores[app.ExternalIDs]
+}
+
+func (d *ExternalIDs) Set(ctx context.Context, externalID string, adID int64) error {
+	return d.stores.get(ctx).Set(ctx, externalID, adID)
+}
+
+func (d *ExternalIDs) Find(ctx context.Context, externalID string) (int64, bool) {
+	return d.stores.get(ctx).Find(ctx, externalID)
+}
+
+func (d *ExternalIDs) FindByAd(ctx context.Context, adID int64) (string, bool) {
+	return d.stores.get(ctx).FindByAd(ctx, adID)
+}
+
+func (d *ExternalIDs) DeleteByAd(ctx context.Context, adID int64) error {
+	return d.stores.get(ctx).DeleteByAd(ctx, adID)
+}
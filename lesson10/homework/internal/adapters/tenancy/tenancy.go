@@ -0,0 +1,39 @@
+package tenancy
+
+import (
+	"homework10/internal/app"
+	"homework10/internal/tenant"
+	"sync"
+)
+
+// NewRepository keeps ads of every tenant in a separate repository made by newRepo on first use.
+func NewRepository(newRepo func() app.Repository) app.Repository {
+	return &Repository{newRepo: newRepo, repos: map[tenant.ID]app.Repository{}, mx: &sync.Mutex{}}
+}
+
+// NewUsers keeps users of every tenant in separate storage made by newUsers on first use.
+func NewUsers(newUsers func() app.Users) app.Users {
+	return &Users{newUsers: newUsers, users: map[tenant.ID]app.Users{}, mx: &sync.Mutex{}}
+}
+
+// NewFavorites keeps favorites of every tenant in separate storage made by newFavorites on first use,
+// NewSavedSearches, NewExternalIDs, NewMessages and NewBlocks do the same.
+func NewFavorites(newFavorites func() app.Favorites) app.Favorites {
+	return &Favorites{stores: newStores(newFavorites)}
+}
+
+func NewSavedSearches(newSearches func() app.SavedSearches) app.SavedSearches {
+	return &SavedSearches{stores: newStores(newSearches)}
+}
+
+func NewExternalIDs(newExternalIDs func() app.ExternalIDs) app.ExternalIDs {
+	return &ExternalIDs{stores: newStores(newExternalIDs)}
+}
+
+func NewMessages(newMessages func() app.Messages) app.Messages {
+	return &Messages{stores: newStores(newMessages)}
+}
+
+func NewBlocks(newBlocks func() app.Blocks) app.Blocks {
+	return &Blocks{stores: newStores(newBlocks)}
+}
@@ -0,0 +1,57 @@
+package tenancy
+
+import (
+	"context"
+	"homework10/internal/app"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"sync"
+)
+
+// Users routes every call to the users of the tenant of the context, user IDs and
+// emails are unique within a tenant only.
+type Users struct {
+	newUsers func() app.Users
+	users    map[tenant.ID]app.Users
+	mx       *sync.Mutex
+}
+
+func (d *Users) get(ctx context.Context) app.Users {
+	id := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	users, ok := d.users[id]
+	if !ok {
+		users = d.newUsers()
+		d.users[id] = users
+	}
+	return users
+}
+
+func (d *Users) Find(ctx context.Context, userID int64) (user.User, bool) {
+	return d.get(ctx).Find(ctx, userID)
+}
+
+func (d *Users) FindByEmail(ctx context.Context, email string) (user.User, bool) {
+	return d.get(ctx).FindByEmail(ctx, email)
+}
+
+func (d *Users) FindMany(ctx context.Context, userIDs []int64) (map[int64]user.User, error) {
+	return d.get(ctx).FindMany(ctx, userIDs)
+}
+
+func (d *Users) CreateByID(ctx context.Context, nickname, email string, userID int64) (user.User, error) {
+	return d.get(ctx).CreateByID(ctx, nickname, email, userID)
+}
+
+func (d *Users) DeleteByID(ctx context.Context, userID int64) (user.User, error) {
+	return d.get(ctx).DeleteByID(ctx, userID)
+}
+
+func (d *Users) ChangeInfo(ctx context.Context, userID int64, nickname, email string) error {
+	return d.get(ctx).ChangeInfo(ctx, userID, nickname, email)
+}
+
+func (d *Users) SetVerified(ctx context.Context, userID int64, verified bool) error {
+	return d.get(ctx).SetVerified(ctx, userID, verified)
+}
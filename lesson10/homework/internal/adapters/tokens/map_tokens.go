@@ -0,0 +1,45 @@
+package tokens
+
+import (
+	"context"
+	"homework10/internal/tenant"
+	"homework10/internal/verification"
+	"sync"
+)
+
+type MapTokens struct {
+	mx *sync.Mutex
+	mp map[string]verification.Token
+}
+
+func (d *MapTokens) Add(ctx context.Context, t verification.Token) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.mp[t.Value] = t
+	return nil
+}
+
+func (d *MapTokens) Take(ctx context.Context, value string) (verification.Token, bool) {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	t, ok := d.mp[value]
+	if !ok || t.Tenant != tenantID {
+		return verification.Token{}, false
+	}
+	delete(d.mp, value)
+	return t, true
+}
+
+// DeleteByUser scans all tokens, there are few of them as each user has at most one.
+func (d *MapTokens) DeleteByUser(ctx context.Context, userID int64) error {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for value, t := range d.mp {
+		if t.Tenant == tenantID && t.UserID == userID {
+			delete(d.mp, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package tokens
+
+import (
+	"homework10/internal/app"
+	"homework10/internal/verification"
+	"sync"
+)
+
+func New() app.VerificationTokens {
+	return &MapTokens{mx: &sync.Mutex{}, mp: map[string]verification.Token{}}
+}
@@ -0,0 +1,266 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Record layout on disk: 4 bytes payload length, 4 bytes CRC-32C of payload, payload.
+const (
+	headerSize    = 8
+	maxRecordSize = 1 << 24
+)
+
+var (
+	ErrCorruptSnapshot = errors.New("wal: corrupt snapshot")
+	// ErrCorruptLog is returned by Replay if a record in the middle of the log is damaged,
+	// the log is left as is then.
+	ErrCorruptLog = errors.New("wal: corrupt log")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Log is an append-only write-ahead log with a compacted snapshot next to it.
+// Recovery reads the snapshot and then the log tail, a torn or corrupted
+// last record of the log is cut off.
+type Log struct {
+	mx       sync.Mutex
+	path     string
+	file     *os.File
+	opts     Options
+	dirty    bool
+	stopSync chan struct{}
+	syncDone chan struct{}
+}
+
+func (d *Log) logPath() string {
+	return d.path + ".wal"
+}
+
+func (d *Log) snapshotPath() string {
+	return d.path + ".snapshot"
+}
+
+func (d *Log) Append(rec []byte) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if _, err := d.file.Write(encode(rec)); err != nil {
+		return fmt.Errorf("wal: append: %w", err)
+	}
+	if d.opts.Sync == SyncAlways {
+		return d.file.Sync()
+	}
+	d.dirty = true
+	return nil
+}
+
+// Replay calls apply for every record of the snapshot and then of the log tail.
+func (d *Log) Replay(apply func(rec []byte) error) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	if err := d.replaySnapshot(apply); err != nil {
+		return err
+	}
+
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(d.file)
+	var offset int64
+	for {
+		rec, n, err := readRecord(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// only the last record can be torn by a crash, intact records after
+			// a damaged one mean the log itself is corrupt
+			torn, tornErr := d.isTornTail(offset)
+			if tornErr != nil {
+				return tornErr
+			}
+			if !torn {
+				return fmt.Errorf("%w: %s at offset %d", ErrCorruptLog, err.Error(), offset)
+			}
+			if err := d.file.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+		if err := apply(rec); err != nil {
+			return err
+		}
+		offset += n
+	}
+	_, err := d.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// isTornTail reports whether the damaged record at offset is the last one of the log. A length
+// field damaged in the middle of the log also makes the record look cut off by the end of the file,
+// so the rest of the file is searched for an intact record.
+func (d *Log) isTornTail(offset int64) (bool, error) {
+	if _, err := d.file.Seek(offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	tail, err := io.ReadAll(d.file)
+	if err != nil {
+		return false, err
+	}
+	for i := 1; i+headerSize < len(tail); i++ {
+		size := binary.LittleEndian.Uint32(tail[i : i+4])
+		// appended records are never empty, zeroes left by a crash aren't taken for them
+		if size == 0 || int64(size) > int64(len(tail)-i-headerSize) {
+			continue
+		}
+		rec := tail[i+headerSize : i+headerSize+int(size)]
+		if crc32.Checksum(rec, crcTable) == binary.LittleEndian.Uint32(tail[i+4:i+8]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (d *Log) replaySnapshot(apply func(rec []byte) error) error {
+	f, err := os.Open(d.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		rec, _, err := readRecord(r)
+		if err != nil {
+			return ErrCorruptSnapshot
+		}
+		// an empty record terminates a complete snapshot
+		if len(rec) == 0 {
+			return nil
+		}
+		if err := apply(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// Snapshot atomically replaces the snapshot with records passed to emit and
+// empties the log. The caller must block its own mutations while it runs.
+func (d *Log) Snapshot(write func(emit func(rec []byte) error) error) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	tmpPath := d.snapshotPath() + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = write(func(rec []byte) error {
+		_, err := w.Write(encode(rec))
+		return err
+	})
+	if err == nil {
+		_, err = w.Write(encode(nil))
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("wal: snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, d.snapshotPath()); err != nil {
+		return fmt.Errorf("wal: snapshot: %w", err)
+	}
+
+	if err := d.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d.dirty = false
+	return d.file.Sync()
+}
+
+func (d *Log) Sync() error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if !d.dirty {
+		return nil
+	}
+	d.dirty = false
+	return d.file.Sync()
+}
+
+func (d *Log) Close() error {
+	if d.stopSync != nil {
+		close(d.stopSync)
+		<-d.syncDone
+	}
+	if err := d.Sync(); err != nil {
+		return err
+	}
+	return d.file.Close()
+}
+
+func (d *Log) syncLoop(interval time.Duration) {
+	defer close(d.syncDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = d.Sync()
+		case <-d.stopSync:
+			return
+		}
+	}
+}
+
+func encode(rec []byte) []byte {
+	buf := make([]byte, headerSize+len(rec))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(rec)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(rec, crcTable))
+	copy(buf[headerSize:], rec)
+	return buf
+}
+
+// readRecord returns the payload and the number of bytes consumed.
+func readRecord(r io.Reader) ([]byte, int64, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, 0, err
+	}
+	size := binary.LittleEndian.Uint32(header[0:4])
+	sum := binary.LittleEndian.Uint32(header[4:8])
+	if size > maxRecordSize {
+		return nil, 0, errors.New("wal: record too large")
+	}
+	rec := make([]byte, size)
+	if _, err := io.ReadFull(r, rec); err != nil {
+		return nil, 0, err
+	}
+	if crc32.Checksum(rec, crcTable) != sum {
+		return nil, 0, errors.New("wal: checksum mismatch")
+	}
+	return rec, int64(headerSize) + int64(size), nil
+}
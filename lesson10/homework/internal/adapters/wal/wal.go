@@ -0,0 +1,38 @@
+package wal
+
+import (
+	"os"
+	"time"
+)
+
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs the log after every appended record.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs the log in background every Options.SyncInterval.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+type Options struct {
+	Sync         SyncPolicy
+	SyncInterval time.Duration
+}
+
+// Open opens or creates path.wal and uses path.snapshot for snapshots.
+func Open(path string, opts Options) (*Log, error) {
+	d := &Log{path: path, opts: opts}
+	f, err := os.OpenFile(d.logPath(), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	d.file = f
+	if opts.Sync == SyncInterval && opts.SyncInterval > 0 {
+		d.stopSync = make(chan struct{})
+		d.syncDone = make(chan struct{})
+		go d.syncLoop(opts.SyncInterval)
+	}
+	return d, nil
+}
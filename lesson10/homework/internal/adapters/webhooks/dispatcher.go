@@ -0,0 +1,220 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"homework10/internal/app"
+	"homework10/internal/tenant"
+	"homework10/internal/webhook"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Dispatcher sends deliveries with a pool of workers. Failed attempts are retried with
+// exponential backoff, deliveries failing Options.MaxAttempts times in a row, or rejected
+// with a client error, are dead and wait in the dead-letter list until they are sent again.
+type Dispatcher struct {
+	webhooks   app.Webhooks
+	deliveries app.WebhookDeliveries
+	opts       Options
+	jobs       chan job
+	mx         *sync.Mutex
+	closed     bool
+	// retries are the deliveries waiting for their backoff to pass
+	retries map[*time.Timer]job
+	workers *sync.WaitGroup
+	done    chan struct{}
+}
+
+type job struct {
+	delivery webhook.Delivery
+	// tries counts attempts since the delivery was sent, Delivery.Attempts counts all of them
+	tries int
+}
+
+// maxResponseSize is the part of response bodies read, so that connections can be reused.
+const maxResponseSize = 64 << 10
+
+// Send queues the delivery, it is dead if the queue is full or the dispatcher is closed.
+func (d *Dispatcher) Send(dl webhook.Delivery) {
+	d.enqueue(job{delivery: dl})
+}
+
+func (d *Dispatcher) enqueue(j job) {
+	d.mx.Lock()
+	reason := ""
+	if d.closed {
+		reason = "dispatcher is closed"
+	} else {
+		select {
+		case d.jobs <- j:
+		default:
+			reason = "delivery queue is full"
+		}
+	}
+	d.mx.Unlock()
+	if reason != "" {
+		d.bury(j.delivery, reason)
+	}
+}
+
+// Close stops the workers after their current attempts, deliveries still waiting are dead.
+func (d *Dispatcher) Close() {
+	d.mx.Lock()
+	if d.closed {
+		d.mx.Unlock()
+		return
+	}
+	d.closed = true
+	var waiting []job
+	for t, j := range d.retries {
+		// timers which have fired see the dispatcher closed and bury their deliveries themselves
+		if t.Stop() {
+			waiting = append(waiting, j)
+		}
+	}
+	d.retries = nil
+	d.mx.Unlock()
+
+	close(d.done)
+	d.workers.Wait()
+	for len(d.jobs) > 0 {
+		waiting = append(waiting, <-d.jobs)
+	}
+	for _, j := range waiting {
+		d.bury(j.delivery, "dispatcher is closed")
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-d.done:
+			return
+		case j := <-d.jobs:
+			d.attempt(j)
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(j job) {
+	dl := j.delivery
+	s, isFound := d.webhooks.Find(tenant.NewContext(context.Background(), dl.Tenant), dl.SubscriptionID)
+	if !isFound {
+		d.bury(dl, "webhook is deleted")
+		return
+	}
+	j.tries++
+	dl.Attempts++
+	status, err := d.post(s, dl)
+	now := time.Now().UTC()
+	dl.ResponseStatus = status
+	dl.UpdateDate = now
+	dl.NextAttempt = time.Time{}
+	if err == nil {
+		dl.Status = webhook.StatusDelivered
+		dl.LastError = ""
+		d.update(dl)
+		return
+	}
+	dl.LastError = err.Error()
+	if j.tries >= d.opts.MaxAttempts || !retryable(status) {
+		dl.Status = webhook.StatusDead
+		d.update(dl)
+		return
+	}
+	backoff := d.backoff(j.tries)
+	dl.NextAttempt = now.Add(backoff)
+	d.update(dl)
+	j.delivery = dl
+	d.retry(j, backoff)
+}
+
+// post makes a signed request of the delivery, the status is 0 if there was no response.
+func (d *Dispatcher) post(s webhook.Subscription, dl webhook.Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.EventHeader, string(dl.Event))
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatInt(dl.ID, 10))
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(s.Secret, timestamp, dl.Payload))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable tells failures which may pass on their own, other client errors won't.
+func retryable(status int) bool {
+	return status == 0 || status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
+
+// backoff is the delay before the retry following the attempt, tries counts attempts made.
+func (d *Dispatcher) backoff(tries int) time.Duration {
+	res := d.opts.InitialBackoff
+	for i := 1; i < tries && res < d.opts.MaxBackoff; i++ {
+		res *= 2
+	}
+	if res > d.opts.MaxBackoff {
+		res = d.opts.MaxBackoff
+	}
+	return res
+}
+
+func (d *Dispatcher) retry(j job, after time.Duration) {
+	d.mx.Lock()
+	if d.closed {
+		d.mx.Unlock()
+		d.bury(j.delivery, "dispatcher is closed")
+		return
+	}
+	var t *time.Timer
+	// the timer can't fire before it is added, as adding holds the lock
+	t = time.AfterFunc(after, func() {
+		d.mx.Lock()
+		delete(d.retries, t)
+		closed := d.closed
+		d.mx.Unlock()
+		if closed {
+			d.bury(j.delivery, "dispatcher is closed")
+			return
+		}
+		d.enqueue(j)
+	})
+	d.retries[t] = j
+	d.mx.Unlock()
+}
+
+func (d *Dispatcher) bury(dl webhook.Delivery, reason string) {
+	dl.Status = webhook.StatusDead
+	dl.LastError = reason
+	dl.NextAttempt = time.Time{}
+	dl.UpdateDate = time.Now().UTC()
+	d.update(dl)
+}
+
+func (d *Dispatcher) update(dl webhook.Delivery) {
+	// workers run outside of requests, the delivery is updated in its own tenant
+	if err := d.deliveries.Update(tenant.NewContext(context.Background(), dl.Tenant), dl); err != nil {
+		log.Printf("can't update webhook delivery %d: %s", dl.ID, err.Error())
+	}
+}
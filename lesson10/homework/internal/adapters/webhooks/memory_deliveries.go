@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"context"
+	"homework10/internal/tenant"
+	"homework10/internal/webhook"
+	"sort"
+	"sync"
+)
+
+type MemoryDeliveries struct {
+	mx     *sync.RWMutex
+	mp     map[int64]webhook.Delivery
+	lastID int64
+}
+
+func (d *MemoryDeliveries) Add(ctx context.Context, dl webhook.Delivery) (webhook.Delivery, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.lastID++
+	dl.ID = d.lastID
+	d.mp[dl.ID] = dl
+	return dl, nil
+}
+
+func (d *MemoryDeliveries) Update(ctx context.Context, dl webhook.Delivery) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if old, ok := d.mp[dl.ID]; ok && old.Tenant == tenant.FromContext(ctx) {
+		d.mp[dl.ID] = dl
+	}
+	return nil
+}
+
+func (d *MemoryDeliveries) Find(ctx context.Context, deliveryID int64) (webhook.Delivery, bool) {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	dl, ok := d.mp[deliveryID]
+	if !ok || dl.Tenant != tenantID {
+		return webhook.Delivery{}, false
+	}
+	return dl, true
+}
+
+func (d *MemoryDeliveries) List(ctx context.Context, webhookID int64,
+	status webhook.Status) ([]webhook.Delivery, error) {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	res := []webhook.Delivery{}
+	for _, dl := range d.mp {
+		if dl.Tenant == tenantID && (webhookID == 0 || dl.SubscriptionID == webhookID) &&
+			(status == "" || dl.Status == status) {
+			res = append(res, dl)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
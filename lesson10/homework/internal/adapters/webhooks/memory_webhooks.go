@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"context"
+	"homework10/internal/tenant"
+	"homework10/internal/webhook"
+	"sort"
+	"sync"
+)
+
+type MemoryWebhooks struct {
+	mx     *sync.RWMutex
+	mp     map[int64]webhook.Subscription
+	lastID int64
+}
+
+func (d *MemoryWebhooks) Add(ctx context.Context, s webhook.Subscription) (webhook.Subscription, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.lastID++
+	s.ID = d.lastID
+	s.Events = append([]webhook.Event{}, s.Events...)
+	d.mp[s.ID] = s
+	return s, nil
+}
+
+func (d *MemoryWebhooks) Find(ctx context.Context, webhookID int64) (webhook.Subscription, bool) {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	s, ok := d.mp[webhookID]
+	if !ok || s.Tenant != tenantID {
+		return webhook.Subscription{}, false
+	}
+	return s, true
+}
+
+func (d *MemoryWebhooks) List(ctx context.Context) ([]webhook.Subscription, error) {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	res := []webhook.Subscription{}
+	for _, s := range d.mp {
+		if s.Tenant == tenantID {
+			res = append(res, s)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+func (d *MemoryWebhooks) Delete(ctx context.Context, webhookID int64) error {
+	tenantID := tenant.FromContext(ctx)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if s, ok := d.mp[webhookID]; ok && s.Tenant == tenantID {
+		delete(d.mp, webhookID)
+	}
+	return nil
+}
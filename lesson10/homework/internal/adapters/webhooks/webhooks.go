@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"homework10/internal/app"
+	"homework10/internal/webhook"
+	"net/http"
+	"sync"
+	"time"
+)
+
+func New() *MemoryWebhooks {
+	return &MemoryWebhooks{mx: &sync.RWMutex{}, mp: map[int64]webhook.Subscription{}}
+}
+
+func NewDeliveries() *MemoryDeliveries {
+	return &MemoryDeliveries{mx: &sync.RWMutex{}, mp: map[int64]webhook.Delivery{}}
+}
+
+const (
+	DefaultWorkers        = 4
+	DefaultMaxAttempts    = 6
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 10 * time.Minute
+	DefaultTimeout        = 10 * time.Second
+	DefaultBufferSize     = 1000
+)
+
+// Options of a Dispatcher, zero fields get default values.
+type Options struct {
+	// Workers is the number of requests made at once
+	Workers int
+	// MaxAttempts is the number of attempts after which a failing delivery is dead
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, it doubles with every next one up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Timeout limits every request
+	Timeout time.Duration
+	// BufferSize is the number of deliveries waiting for a worker, Send makes deliveries dead if it is full
+	BufferSize int
+	// Client makes requests, redirects aren't followed by the default one
+	Client *http.Client
+}
+
+// NewDispatcher starts workers sending deliveries to subscriptions in w and recording
+// their outcomes in l, Close stops them.
+func NewDispatcher(w app.Webhooks, l app.WebhookDeliveries, opts Options) *Dispatcher {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultInitialBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+	}
+	d := &Dispatcher{webhooks: w, deliveries: l, opts: opts, jobs: make(chan job, opts.BufferSize),
+		mx: &sync.Mutex{}, retries: map[*time.Timer]job{}, workers: &sync.WaitGroup{}, done: make(chan struct{})}
+	for i := 0; i < opts.Workers; i++ {
+		d.workers.Add(1)
+		go d.work()
+	}
+	return d
+}
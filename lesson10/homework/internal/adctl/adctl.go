@@ -0,0 +1,161 @@
+package adctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+const (
+	adminKeyMetadata = "x-admin-key"
+	tenantMetadata   = "x-tenant-id"
+)
+
+type Dialer func(ctx context.Context, addr string) (*grpc.ClientConn, error)
+
+type Option func(d *cli)
+
+// WithDialer replaces the default insecure dial of the gRPC address from the profile.
+func WithDialer(dial Dialer) Option {
+	return func(d *cli) {
+		d.dial = dial
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *cli) {
+		d.httpClient = client
+	}
+}
+
+// WithConfigPath sets the profiles file, see DefaultConfigPath.
+func WithConfigPath(path string) Option {
+	return func(d *cli) {
+		d.configPath = path
+	}
+}
+
+type cli struct {
+	out        io.Writer
+	dial       Dialer
+	httpClient *http.Client
+	configPath string
+
+	// flags of the root command, they override the selected profile
+	profileName string
+	flags       Profile
+
+	profile Profile
+	conn    *grpc.ClientConn
+}
+
+// New builds the adctl command tree writing results to out.
+func New(out io.Writer, opts ...Option) *cobra.Command {
+	d := &cli{
+		out: out,
+		dial: func(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		},
+		httpClient: http.DefaultClient,
+		configPath: DefaultConfigPath(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	root := &cobra.Command{
+		Use:           "adctl",
+		Short:         "Manage the ad service",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetOut(out)
+	pf := root.PersistentFlags()
+	pf.StringVar(&d.profileName, "profile", os.Getenv("ADCTL_PROFILE"), "profile from the config file, the current one if empty")
+	pf.StringVar(&d.flags.GRPCAddr, "grpc-addr", "", "address of the gRPC API")
+	pf.StringVar(&d.flags.HTTPAddr, "http-addr", "", "address of the HTTP API")
+	pf.StringVar(&d.flags.AdminKey, "admin-key", os.Getenv("ADCTL_ADMIN_KEY"), "admin key of the service")
+	pf.StringVarP(&d.flags.Output, "output", "o", "", "output format: table, json or yaml")
+	pf.StringVar(&d.flags.Tenant, "tenant", "", "tenant to manage, the one owning the address if empty")
+	_ = root.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return formats, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = root.RegisterFlagCompletionFunc("profile", d.completeProfiles)
+
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return d.loadProfile(cmd)
+	}
+	root.PersistentPostRun = func(*cobra.Command, []string) {
+		if d.conn != nil {
+			_ = d.conn.Close()
+		}
+	}
+
+	root.AddCommand(d.usersCmd(), d.adsCmd(), d.healthCmd(), d.exportCmd(), d.importCmd(), d.configCmd())
+	return root
+}
+
+// loadProfile merges the selected profile with defaults and flags set explicitly.
+func (d *cli) loadProfile(cmd *cobra.Command) error {
+	conf, err := LoadConfig(d.configPath)
+	if err != nil {
+		return err
+	}
+	name := d.profileName
+	if name == "" {
+		name = conf.Current
+	}
+	p := defaultProfile
+	if name != "" {
+		stored, ok := conf.Profiles[name]
+		if !ok {
+			return fmt.Errorf("no profile %q in %s", name, d.configPath)
+		}
+		p = p.merge(stored)
+	}
+	flags := cmd.Flags()
+	for _, key := range profileKeys {
+		if flags.Changed(key) {
+			*p.field(key) = *d.flags.field(key)
+		}
+	}
+	if p.AdminKey == "" {
+		p.AdminKey = d.flags.AdminKey
+	}
+	if !validFormat(p.Output) {
+		return fmt.Errorf("unknown output format %q", p.Output)
+	}
+	d.profile = p
+	return nil
+}
+
+// client connects to the gRPC API lazily, so commands working over HTTP don't need it.
+func (d *cli) client(ctx context.Context) (grpcPort.AdServiceClient, error) {
+	if d.conn == nil {
+		conn, err := d.dial(ctx, d.profile.GRPCAddr)
+		if err != nil {
+			return nil, fmt.Errorf("can't connect to %s: %w", d.profile.GRPCAddr, err)
+		}
+		d.conn = conn
+	}
+	return grpcPort.NewAdServiceClient(d.conn), nil
+}
+
+// outgoing attaches the admin key and the tenant of the profile to calls.
+func (d *cli) outgoing(ctx context.Context) context.Context {
+	if d.profile.AdminKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, adminKeyMetadata, d.profile.AdminKey)
+	}
+	if d.profile.Tenant != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, tenantMetadata, d.profile.Tenant)
+	}
+	return ctx
+}
@@ -0,0 +1,186 @@
+package adctl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/adpattern"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func (d *cli) adsCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "ads", Short: "Manage ads"}
+	cmd.AddCommand(d.createAdCmd(), d.listAdsCmd(), d.getAdCmd(),
+		d.adStatusCmd("publish", "Publish an ad", true), d.adStatusCmd("unpublish", "Unpublish an ad", false),
+		d.deleteAdCmd())
+	return cmd
+}
+
+func (d *cli) createAdCmd() *cobra.Command {
+	var userID int64
+	var title, text string
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an unpublished ad",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ad, err := client.CreateAd(d.outgoing(cmd.Context()),
+				&grpcPort.CreateAdRequest{Title: title, Text: text, UserId: userID})
+			if err != nil {
+				return err
+			}
+			return d.printAd(ad)
+		},
+	}
+	cmd.Flags().Int64Var(&userID, "user", 0, "ID of the author")
+	cmd.Flags().StringVar(&title, "title", "", "title of the ad")
+	cmd.Flags().StringVar(&text, "text", "", "text of the ad")
+	_ = cmd.MarkFlagRequired("user")
+	_ = cmd.MarkFlagRequired("title")
+	_ = cmd.MarkFlagRequired("text")
+	return cmd
+}
+
+func (d *cli) listAdsCmd() *cobra.Command {
+	var authorID int64
+	var all bool
+	var from, to, sort, filter string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List ads, only published ones unless --all is set",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &grpcPort.FilterRequest{AuthorId: authorID, PublishedConfig: grpcPort.PublishedConfig_PublishedOnly,
+				FilterExpr: filter}
+			if all {
+				req.PublishedConfig = grpcPort.PublishedConfig_AllAds
+			}
+			for _, bound := range []struct {
+				value string
+				dst   **timestamppb.Timestamp
+			}{{from, &req.LDate}, {to, &req.RDate}} {
+				if bound.value == "" {
+					continue
+				}
+				t, err := time.Parse(time.RFC3339, bound.value)
+				if err != nil {
+					return fmt.Errorf("bad time %q, expected RFC 3339", bound.value)
+				}
+				*bound.dst = timestamppb.New(t)
+			}
+			order, err := adpattern.ParseSort(sort)
+			if err != nil {
+				return err
+			}
+			for _, o := range order {
+				req.OrderBy = append(req.OrderBy, &grpcPort.OrderBy{Field: o.Field, Desc: o.Desc})
+			}
+
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			res, err := client.ListAds(d.outgoing(cmd.Context()), req)
+			if err != nil {
+				return err
+			}
+			return d.printAds(res.List)
+		},
+	}
+	cmd.Flags().Int64Var(&authorID, "author", 0, "list only ads of this author")
+	cmd.Flags().BoolVar(&all, "all", false, "list unpublished ads too")
+	cmd.Flags().StringVar(&from, "from", "", "list ads created since this time, RFC 3339")
+	cmd.Flags().StringVar(&to, "to", "", "list ads created before this time, RFC 3339")
+	cmd.Flags().StringVar(&sort, "sort", "", "sort order, e.g. -creation_date,title")
+	cmd.Flags().StringVar(&filter, "filter", "", `filter expression, e.g. 'author in (3, 5) and title ~ "bike"'`)
+	_ = cmd.RegisterFlagCompletionFunc("sort", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{adpattern.FieldCreationDate, "-" + adpattern.FieldCreationDate, adpattern.FieldUpdateDate,
+			"-" + adpattern.FieldUpdateDate, adpattern.FieldTitle, "-" + adpattern.FieldTitle}, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+func (d *cli) getAdCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get AD_ID",
+		Short: "Show an ad",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ad, err := client.GetAdByID(d.outgoing(cmd.Context()), &grpcPort.GetAdRequest{Id: id})
+			if err != nil {
+				return err
+			}
+			return d.printAd(ad)
+		},
+	}
+}
+
+// adStatusCmd publishes or unpublishes an ad on behalf of its author.
+func (d *cli) adStatusCmd(use string, short string, published bool) *cobra.Command {
+	var userID int64
+	cmd := &cobra.Command{
+		Use:   use + " AD_ID",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ad, err := client.ChangeAdStatus(d.outgoing(cmd.Context()),
+				&grpcPort.ChangeAdStatusRequest{AdId: id, UserId: userID, Published: published})
+			if err != nil {
+				return err
+			}
+			return d.printAd(ad)
+		},
+	}
+	cmd.Flags().Int64Var(&userID, "user", 0, "ID of the author")
+	_ = cmd.MarkFlagRequired("user")
+	return cmd
+}
+
+func (d *cli) deleteAdCmd() *cobra.Command {
+	var userID int64
+	cmd := &cobra.Command{
+		Use:   "delete AD_ID",
+		Short: "Move an ad to the trash of its author",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ad, err := client.DeleteAd(d.outgoing(cmd.Context()), &grpcPort.DeleteAdRequest{AdId: id, UserId: userID})
+			if err != nil {
+				return err
+			}
+			return d.printAd(ad)
+		},
+	}
+	cmd.Flags().Int64Var(&userID, "user", 0, "ID of the author")
+	_ = cmd.MarkFlagRequired("user")
+	return cmd
+}
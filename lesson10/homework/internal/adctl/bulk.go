@@ -0,0 +1,173 @@
+package adctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework10/internal/bulk"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// exportCmd streams ads from the HTTP API, the gRPC one has no streaming export.
+func (d *cli) exportCmd() *cobra.Command {
+	var format, file, sort string
+	var authorID int64
+	var publishedOnly bool
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write ads matching filters to a CSV or NDJSON file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			q.Set("format", format)
+			q.Set("published_only", strconv.FormatBool(publishedOnly))
+			if authorID != 0 {
+				q.Set("author_id", strconv.FormatInt(authorID, 10))
+			}
+			if sort != "" {
+				q.Set("sort", sort)
+			}
+
+			req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet,
+				d.profile.HTTPAddr+"/api/v1/ads/export?"+q.Encode(), nil)
+			if err != nil {
+				return err
+			}
+			d.setTenant(req)
+			resp, err := d.httpClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return responseError(resp)
+			}
+
+			w := d.out
+			if file != "" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			_, err = io.Copy(w, resp.Body)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", bulk.FormatCSV, "file format: csv or ndjson")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "file to write to, stdout if empty")
+	cmd.Flags().Int64Var(&authorID, "author", 0, "export only ads of this author")
+	cmd.Flags().BoolVar(&publishedOnly, "published-only", false, "export only published ads")
+	cmd.Flags().StringVar(&sort, "sort", "", "sort order, e.g. -creation_date,title")
+	_ = cmd.RegisterFlagCompletionFunc("format", completeBulkFormats)
+	return cmd
+}
+
+func completeBulkFormats(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return []string{bulk.FormatCSV, bulk.FormatNDJSON}, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (d *cli) importCmd() *cobra.Command {
+	var format string
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "import [FILE]",
+		Short: "Create or update ads from a CSV or NDJSON file, stdin if no file is given",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r io.Reader = cmd.InOrStdin()
+			name := ""
+			if len(args) > 0 && args[0] != "-" {
+				name = args[0]
+				f, err := os.Open(name)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			if format == "" {
+				format = bulk.FormatCSV
+				if strings.HasSuffix(name, ".ndjson") || strings.HasSuffix(name, ".jsonl") {
+					format = bulk.FormatNDJSON
+				}
+			}
+
+			q := url.Values{}
+			q.Set("format", format)
+			q.Set("dry_run", strconv.FormatBool(dryRun))
+			req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPost,
+				d.profile.HTTPAddr+"/api/v1/ads/import?"+q.Encode(), r)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Content-Type", bulk.ContentType(format))
+			req.Header.Set("X-Admin-Key", d.profile.AdminKey)
+			d.setTenant(req)
+			resp, err := d.httpClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return responseError(resp)
+			}
+
+			var report bulk.Report
+			if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+				return err
+			}
+			if err := d.printReport(report); err != nil {
+				return err
+			}
+			if len(report.Errors) > 0 {
+				return fmt.Errorf("%d lines failed", len(report.Errors))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "", "file format: csv or ndjson, guessed from the file extension if empty")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only validate the file")
+	_ = cmd.RegisterFlagCompletionFunc("format", completeBulkFormats)
+	return cmd
+}
+
+func (d *cli) printReport(report bulk.Report) error {
+	if d.profile.Output != formatTable {
+		return d.print(report, nil)
+	}
+	prefix := ""
+	if report.DryRun {
+		prefix = "dry run: "
+	}
+	fmt.Fprintf(d.out, "%screated %d, updated %d, unchanged %d, failed %d\n", prefix,
+		report.Created, report.Updated, report.Unchanged, len(report.Errors))
+	for _, e := range report.Errors {
+		fmt.Fprintf(d.out, "line %d: %s\n", e.Line, e.Error)
+	}
+	return nil
+}
+
+func (d *cli) setTenant(req *http.Request) {
+	if d.profile.Tenant != "" {
+		req.Header.Set("X-Tenant-ID", d.profile.Tenant)
+	}
+}
+
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return fmt.Errorf("%s: %s", resp.Status, body.Error)
+}
@@ -0,0 +1,107 @@
+package adctl
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile holds connection settings of one environment, empty fields fall back to defaults.
+type Profile struct {
+	GRPCAddr string `yaml:"grpc_addr,omitempty" json:"grpc_addr,omitempty"`
+	HTTPAddr string `yaml:"http_addr,omitempty" json:"http_addr,omitempty"`
+	AdminKey string `yaml:"admin_key,omitempty" json:"admin_key,omitempty"`
+	Output   string `yaml:"output,omitempty" json:"output,omitempty"`
+	Tenant   string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+}
+
+var defaultProfile = Profile{GRPCAddr: "localhost:8080", HTTPAddr: "http://localhost:18080", Output: formatTable}
+
+func (d Profile) merge(p Profile) Profile {
+	for _, flag := range profileKeys {
+		if v := *p.field(flag); v != "" {
+			*d.field(flag) = v
+		}
+	}
+	return d
+}
+
+var profileKeys = []string{"grpc-addr", "http-addr", "admin-key", "output", "tenant"}
+
+func (d *Profile) field(key string) *string {
+	switch key {
+	case "grpc-addr":
+		return &d.GRPCAddr
+	case "http-addr":
+		return &d.HTTPAddr
+	case "admin-key":
+		return &d.AdminKey
+	case "output":
+		return &d.Output
+	case "tenant":
+		return &d.Tenant
+	}
+	return nil
+}
+
+type Config struct {
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// DefaultConfigPath is $ADCTL_CONFIG or adctl/config.yaml in the user config directory.
+func DefaultConfigPath() string {
+	if path := os.Getenv("ADCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "adctl.yaml"
+	}
+	return filepath.Join(dir, "adctl", "config.yaml")
+}
+
+// LoadConfig returns an empty config if the file doesn't exist.
+func LoadConfig(path string) (Config, error) {
+	conf := Config{Profiles: map[string]Profile{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return conf, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return Config{}, fmt.Errorf("can't parse %s: %w", path, err)
+	}
+	if conf.Profiles == nil {
+		conf.Profiles = map[string]Profile{}
+	}
+	return conf, nil
+}
+
+func SaveConfig(path string, conf Config) error {
+	data, err := yaml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// profiles may keep admin keys
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (d Config) names() []string {
+	res := make([]string, 0, len(d.Profiles))
+	for name := range d.Profiles {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
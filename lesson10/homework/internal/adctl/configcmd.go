@@ -0,0 +1,124 @@
+package adctl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func (d *cli) configCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "config", Short: "Manage profiles of environments"}
+	// profiles must be editable even if the current one is broken
+	cmd.PersistentPreRunE = func(*cobra.Command, []string) error {
+		d.profile.Output = formatTable
+		return nil
+	}
+	cmd.AddCommand(d.listProfilesCmd(), d.setProfileCmd(), d.useProfileCmd(), d.deleteProfileCmd())
+	return cmd
+}
+
+func (d *cli) completeProfiles(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	conf, err := LoadConfig(d.configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return conf.names(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func (d *cli) listProfilesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List profiles, the current one is marked with *",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := LoadConfig(d.configPath)
+			if err != nil {
+				return err
+			}
+			for _, name := range conf.names() {
+				p := conf.Profiles[name]
+				mark := " "
+				if name == conf.Current {
+					mark = "*"
+				}
+				fmt.Fprintf(d.out, "%s %s\tgrpc=%s http=%s output=%s tenant=%s\n", mark, name, p.GRPCAddr, p.HTTPAddr,
+					p.Output, p.Tenant)
+			}
+			return nil
+		},
+	}
+}
+
+// setProfileCmd creates a profile or changes the fields given by flags.
+func (d *cli) setProfileCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "set NAME",
+		Short:             "Save --grpc-addr, --http-addr, --admin-key, --output and --tenant given with the command to a profile",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: d.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := LoadConfig(d.configPath)
+			if err != nil {
+				return err
+			}
+			p := conf.Profiles[args[0]]
+			for _, key := range profileKeys {
+				if cmd.Flags().Changed(key) {
+					*p.field(key) = *d.flags.field(key)
+				}
+			}
+			if p.Output != "" && !validFormat(p.Output) {
+				return fmt.Errorf("unknown output format %q, expected one of %s", p.Output, strings.Join(formats, ", "))
+			}
+			conf.Profiles[args[0]] = p
+			if conf.Current == "" {
+				conf.Current = args[0]
+			}
+			return SaveConfig(d.configPath, conf)
+		},
+	}
+}
+
+func (d *cli) useProfileCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "use NAME",
+		Short:             "Make a profile the current one",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: d.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := LoadConfig(d.configPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := conf.Profiles[args[0]]; !ok {
+				return fmt.Errorf("no profile %q", args[0])
+			}
+			conf.Current = args[0]
+			return SaveConfig(d.configPath, conf)
+		},
+	}
+}
+
+func (d *cli) deleteProfileCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete NAME",
+		Short:             "Delete a profile",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: d.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := LoadConfig(d.configPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := conf.Profiles[args[0]]; !ok {
+				return fmt.Errorf("no profile %q", args[0])
+			}
+			delete(conf.Profiles, args[0])
+			if conf.Current == args[0] {
+				conf.Current = ""
+			}
+			return SaveConfig(d.configPath, conf)
+		},
+	}
+}
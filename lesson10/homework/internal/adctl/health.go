@@ -0,0 +1,37 @@
+package adctl
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthCmd fails unless the service is serving, so it can be used in scripts.
+func (d *cli) healthCmd() *cobra.Command {
+	var service string
+	cmd := &cobra.Command{
+		Use:   "health",
+		Short: "Check that the service is up",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := d.client(cmd.Context()); err != nil {
+				return err
+			}
+			res, err := healthpb.NewHealthClient(d.conn).Check(cmd.Context(), &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				return err
+			}
+			v := healthView{Service: service, Status: res.Status.String()}
+			if err := d.print(v, []view{v}); err != nil {
+				return err
+			}
+			if res.Status != healthpb.HealthCheckResponse_SERVING {
+				return fmt.Errorf("service is %s", res.Status.String())
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&service, "service", "", "name of the gRPC service to check, the whole server if empty")
+	return cmd
+}
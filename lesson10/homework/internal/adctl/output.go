@@ -0,0 +1,144 @@
+package adctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+var formats = []string{formatTable, formatJSON, formatYAML}
+
+func validFormat(format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// view is what commands print, a list of views is printed as a single table.
+type view interface {
+	header() []string
+	row() []string
+}
+
+type userView struct {
+	ID       int64  `json:"user_id" yaml:"user_id"`
+	Nickname string `json:"nickname" yaml:"nickname"`
+	Email    string `json:"email" yaml:"email"`
+	Verified bool   `json:"verified" yaml:"verified"`
+}
+
+func newUserView(u *grpcPort.UniversalUser) userView {
+	return userView{ID: u.UserId, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}
+}
+
+func (d userView) header() []string {
+	return []string{"ID", "NICKNAME", "EMAIL", "VERIFIED"}
+}
+
+func (d userView) row() []string {
+	return []string{strconv.FormatInt(d.ID, 10), d.Nickname, d.Email, strconv.FormatBool(d.Verified)}
+}
+
+type adView struct {
+	ID           int64     `json:"id" yaml:"id"`
+	Title        string    `json:"title" yaml:"title"`
+	Text         string    `json:"text" yaml:"text"`
+	AuthorID     int64     `json:"author_id" yaml:"author_id"`
+	Published    bool      `json:"published" yaml:"published"`
+	CreationDate time.Time `json:"creation_date" yaml:"creation_date"`
+	UpdateDate   time.Time `json:"update_date" yaml:"update_date"`
+}
+
+func newAdView(ad *grpcPort.AdResponse) adView {
+	return adView{ID: ad.Id, Title: ad.Title, Text: ad.Text, AuthorID: ad.AuthorId, Published: ad.Published,
+		CreationDate: ad.CreationDate.AsTime().UTC(), UpdateDate: ad.UpdateDate.AsTime().UTC()}
+}
+
+func (d adView) header() []string {
+	return []string{"ID", "TITLE", "AUTHOR", "PUBLISHED", "CREATED"}
+}
+
+// row leaves the text out, it doesn't fit in a table.
+func (d adView) row() []string {
+	return []string{strconv.FormatInt(d.ID, 10), d.Title, strconv.FormatInt(d.AuthorID, 10),
+		strconv.FormatBool(d.Published), d.CreationDate.Format(time.RFC3339)}
+}
+
+type healthView struct {
+	Service string `json:"service" yaml:"service"`
+	Status  string `json:"status" yaml:"status"`
+}
+
+func (d healthView) header() []string {
+	return []string{"SERVICE", "STATUS"}
+}
+
+func (d healthView) row() []string {
+	service := d.Service
+	if service == "" {
+		service = "(server)"
+	}
+	return []string{service, d.Status}
+}
+
+// print writes a single view or a slice of views in the output format of the profile.
+func (d *cli) print(v any, list []view) error {
+	switch d.profile.Output {
+	case formatJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(d.out, string(data))
+		return err
+	case formatYAML:
+		return yaml.NewEncoder(d.out).Encode(v)
+	}
+
+	w := tabwriter.NewWriter(d.out, 0, 0, 2, ' ', 0)
+	if len(list) == 0 {
+		_, err := fmt.Fprintln(w, "no results")
+		return err
+	}
+	fmt.Fprintln(w, strings.Join(list[0].header(), "\t"))
+	for _, item := range list {
+		fmt.Fprintln(w, strings.Join(item.row(), "\t"))
+	}
+	return w.Flush()
+}
+
+func (d *cli) printUser(u *grpcPort.UniversalUser) error {
+	v := newUserView(u)
+	return d.print(v, []view{v})
+}
+
+func (d *cli) printAd(ad *grpcPort.AdResponse) error {
+	v := newAdView(ad)
+	return d.print(v, []view{v})
+}
+
+func (d *cli) printAds(list []*grpcPort.AdResponse) error {
+	res := make([]adView, 0, len(list))
+	views := make([]view, 0, len(list))
+	for _, ad := range list {
+		v := newAdView(ad)
+		res = append(res, v)
+		views = append(views, v)
+	}
+	return d.print(res, views)
+}
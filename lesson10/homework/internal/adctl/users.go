@@ -0,0 +1,140 @@
+package adctl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad id %q", s)
+	}
+	return id, nil
+}
+
+func (d *cli) usersCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "users", Short: "Manage users"}
+	cmd.AddCommand(d.createUserCmd(), d.getUserCmd(), d.updateUserCmd(), d.deleteUserCmd())
+	return cmd
+}
+
+func (d *cli) createUserCmd() *cobra.Command {
+	var id int64
+	var nickname, email string
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user with the given ID, needs the admin key if the service has one",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			u, err := client.CreateUser(d.outgoing(cmd.Context()),
+				&grpcPort.UniversalUser{UserId: id, Nickname: nickname, Email: email})
+			if err != nil {
+				return err
+			}
+			return d.printUser(u)
+		},
+	}
+	cmd.Flags().Int64Var(&id, "id", 0, "ID of the user")
+	cmd.Flags().StringVar(&nickname, "nickname", "", "nickname of the user")
+	cmd.Flags().StringVar(&email, "email", "", "email of the user")
+	_ = cmd.MarkFlagRequired("id")
+	_ = cmd.MarkFlagRequired("nickname")
+	_ = cmd.MarkFlagRequired("email")
+	return cmd
+}
+
+func (d *cli) getUserCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get USER_ID",
+		Short: "Show a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			u, err := client.GetUserByID(d.outgoing(cmd.Context()), &grpcPort.GetUserRequest{Id: id})
+			if err != nil {
+				return err
+			}
+			return d.printUser(u)
+		},
+	}
+}
+
+// updateUserCmd keeps the fields which aren't given.
+func (d *cli) updateUserCmd() *cobra.Command {
+	var nickname, email string
+	cmd := &cobra.Command{
+		Use:   "update USER_ID",
+		Short: "Change nickname or email of a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			if nickname == "" && email == "" {
+				return fmt.Errorf("nothing to update, set --nickname or --email")
+			}
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ctx := d.outgoing(cmd.Context())
+			u, err := client.GetUserByID(ctx, &grpcPort.GetUserRequest{Id: id})
+			if err != nil {
+				return err
+			}
+			if nickname != "" {
+				u.Nickname = nickname
+			}
+			if email != "" {
+				u.Email = email
+			}
+			u, err = client.ChangeUserInfo(ctx, &grpcPort.UniversalUser{UserId: id, Nickname: u.Nickname, Email: u.Email})
+			if err != nil {
+				return err
+			}
+			return d.printUser(u)
+		},
+	}
+	cmd.Flags().StringVar(&nickname, "nickname", "", "new nickname")
+	cmd.Flags().StringVar(&email, "email", "", "new email")
+	return cmd
+}
+
+func (d *cli) deleteUserCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete USER_ID",
+		Short: "Delete a user with all their ads",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := d.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			u, err := client.DeleteUserByID(d.outgoing(cmd.Context()), &grpcPort.DeleteUserRequest{Id: id})
+			if err != nil {
+				return err
+			}
+			return d.printUser(u)
+		},
+	}
+}
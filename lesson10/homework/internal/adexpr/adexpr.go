@@ -0,0 +1,217 @@
+package adexpr
+
+import (
+	"fmt"
+	"homework10/internal/ads"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is an attribute of ads filters can compare.
+type Field string
+
+const (
+	FieldID        Field = "id"
+	FieldAuthor    Field = "author"
+	FieldTitle     Field = "title"
+	FieldText      Field = "text"
+	FieldPublished Field = "published"
+	FieldCreated   Field = "created"
+	FieldUpdated   Field = "updated"
+)
+
+// Kind is the type of values of a field.
+type Kind int
+
+const (
+	KindInt Kind = iota
+	KindString
+	KindBool
+	KindTime
+)
+
+var fieldKinds = map[Field]Kind{
+	FieldID:        KindInt,
+	FieldAuthor:    KindInt,
+	FieldTitle:     KindString,
+	FieldText:      KindString,
+	FieldPublished: KindBool,
+	FieldCreated:   KindTime,
+	FieldUpdated:   KindTime,
+}
+
+func (f Field) Kind() Kind {
+	return fieldKinds[f]
+}
+
+type Op string
+
+const (
+	OpEq Op = "="
+	OpNe Op = "!="
+	OpLt Op = "<"
+	OpLe Op = "<="
+	OpGt Op = ">"
+	OpGe Op = ">="
+	// OpContains matches strings containing the value, case-insensitively
+	OpContains Op = "~"
+)
+
+// kindOps are the comparisons allowed for values of each kind.
+var kindOps = map[Kind][]Op{
+	KindInt:    {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+	KindString: {OpEq, OpNe, OpContains},
+	KindBool:   {OpEq, OpNe},
+	KindTime:   {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+}
+
+// Expr is a parsed filter, a tree of And, Or and Not over Cmp and In.
+// Values are int64, string, bool or time.Time depending on the kind of the field.
+type Expr interface {
+	Match(ad ads.Ad) bool
+	// String formats the expression so that Parse returns it back.
+	String() string
+}
+
+type And struct {
+	Left, Right Expr
+}
+
+type Or struct {
+	Left, Right Expr
+}
+
+type Not struct {
+	Expr Expr
+}
+
+// Cmp compares a field with a value.
+type Cmp struct {
+	Field Field
+	Op    Op
+	Value interface{}
+}
+
+// In matches ads whose field equals one of the values.
+type In struct {
+	Field  Field
+	Values []interface{}
+}
+
+func (e And) Match(ad ads.Ad) bool { return e.Left.Match(ad) && e.Right.Match(ad) }
+func (e Or) Match(ad ads.Ad) bool  { return e.Left.Match(ad) || e.Right.Match(ad) }
+func (e Not) Match(ad ads.Ad) bool { return !e.Expr.Match(ad) }
+
+func (e Cmp) Match(ad ads.Ad) bool {
+	v := value(ad, e.Field)
+	switch e.Op {
+	case OpEq:
+		return compare(v, e.Value) == 0
+	case OpNe:
+		return compare(v, e.Value) != 0
+	case OpLt:
+		return compare(v, e.Value) < 0
+	case OpLe:
+		return compare(v, e.Value) <= 0
+	case OpGt:
+		return compare(v, e.Value) > 0
+	case OpGe:
+		return compare(v, e.Value) >= 0
+	case OpContains:
+		return strings.Contains(strings.ToLower(v.(string)), strings.ToLower(e.Value.(string)))
+	}
+	return false
+}
+
+func (e In) Match(ad ads.Ad) bool {
+	v := value(ad, e.Field)
+	for _, want := range e.Values {
+		if compare(v, want) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (e And) String() string { return "(" + e.Left.String() + " and " + e.Right.String() + ")" }
+func (e Or) String() string  { return "(" + e.Left.String() + " or " + e.Right.String() + ")" }
+func (e Not) String() string { return "not " + e.Expr.String() }
+
+func (e Cmp) String() string {
+	return fmt.Sprintf("%s %s %s", e.Field, e.Op, formatValue(e.Value))
+}
+
+func (e In) String() string {
+	values := make([]string, 0, len(e.Values))
+	for _, v := range e.Values {
+		values = append(values, formatValue(v))
+	}
+	return fmt.Sprintf("%s in (%s)", e.Field, strings.Join(values, ", "))
+}
+
+func value(ad ads.Ad, f Field) interface{} {
+	switch f {
+	case FieldID:
+		return ad.ID
+	case FieldAuthor:
+		return ad.AuthorID
+	case FieldTitle:
+		return ad.Title
+	case FieldText:
+		return ad.Text
+	case FieldPublished:
+		return ad.Published
+	case FieldCreated:
+		return ad.CreationDate
+	case FieldUpdated:
+		return ad.UpdateDate
+	}
+	return nil
+}
+
+// compare expects values of the same kind, which the parser guarantees.
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	case bool:
+		if a != b.(bool) {
+			if a {
+				return 1
+			}
+			return -1
+		}
+	case time.Time:
+		b := b.(time.Time)
+		if a.Before(b) {
+			return -1
+		}
+		if a.After(b) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
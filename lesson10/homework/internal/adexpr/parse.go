@@ -0,0 +1,321 @@
+package adexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrBadExpr = fmt.Errorf("bad filter expression")
+
+const (
+	// MaxLen and MaxNodes bound the work and the size of the SQL of an expression.
+	MaxLen   = 1000
+	MaxNodes = 100
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(s string) ([]token, error) {
+	res := []token{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			res = append(res, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			res = append(res, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == ',':
+			res = append(res, token{kind: tokComma, text: ",", pos: i})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrBadExpr, i)
+			}
+			text, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad string at %d", ErrBadExpr, i)
+			}
+			res = append(res, token{kind: tokString, text: text, pos: i})
+			i = j + 1
+		case strings.ContainsRune("=!<>~", rune(c)):
+			op := string(c)
+			if i+1 < len(s) && s[i+1] == '=' && c != '=' && c != '~' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("%w: unexpected %q at %d", ErrBadExpr, c, i)
+			}
+			res = append(res, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		case isWordChar(rune(c)):
+			j := i
+			for j < len(s) && isWordChar(rune(s[j])) {
+				j++
+			}
+			res = append(res, token{kind: tokWord, text: s[i:j], pos: i})
+			i = j
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q at %d", ErrBadExpr, c, i)
+		}
+	}
+	return append(res, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+// isWordChar accepts characters of field names, keywords, numbers and dates such as 2024-01-01T10:00:00+03:00.
+func isWordChar(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) ||
+		r == '_' || r == '-' || r == ':' || r == '+' || r == '.'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	nodes  int
+}
+
+// Parse parses and validates an expression such as
+//
+//	author in (3, 5) and created >= 2024-01-01 and (title ~ "bike" or published = false)
+//
+// "and" binds tighter than "or", "not" negates the following comparison or parenthesized expression.
+// Times are dates or RFC 3339 timestamps in UTC unless they have an offset.
+func Parse(s string) (Expr, error) {
+	if len(s) > MaxLen {
+		return nil, fmt.Errorf("%w: longer than %d bytes", ErrBadExpr, MaxLen)
+	}
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.unexpected(t)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokEOF {
+		return fmt.Errorf("%w: unexpected end", ErrBadExpr)
+	}
+	return fmt.Errorf("%w: unexpected %q at %d", ErrBadExpr, t.text, t.pos)
+}
+
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokWord && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) node() error {
+	p.nodes++
+	if p.nodes > MaxNodes {
+		return fmt.Errorf("%w: more than %d nodes", ErrBadExpr, MaxNodes)
+	}
+	return nil
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.node(); err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.node(); err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (Expr, error) {
+	if err := p.node(); err != nil {
+		return nil, err
+	}
+	if p.keyword("not") {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: e}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, p.unexpected(t)
+		}
+		return e, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Expr, error) {
+	t := p.next()
+	if t.kind != tokWord {
+		return nil, p.unexpected(t)
+	}
+	field := Field(strings.ToLower(t.text))
+	kind, ok := fieldKinds[field]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown field %q at %d", ErrBadExpr, t.text, t.pos)
+	}
+
+	if p.keyword("in") {
+		if t := p.next(); t.kind != tokLParen {
+			return nil, p.unexpected(t)
+		}
+		values := []interface{}{}
+		for {
+			v, err := p.value(kind)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			if t := p.next(); t.kind == tokRParen {
+				break
+			} else if t.kind != tokComma {
+				return nil, p.unexpected(t)
+			}
+		}
+		return In{Field: field, Values: values}, nil
+	}
+
+	t = p.next()
+	if t.kind != tokOp {
+		return nil, p.unexpected(t)
+	}
+	op := Op(t.text)
+	if !allowed(kind, op) {
+		return nil, fmt.Errorf("%w: %s can't be compared with %s", ErrBadExpr, field, op)
+	}
+	v, err := p.value(kind)
+	if err != nil {
+		return nil, err
+	}
+	return Cmp{Field: field, Op: op, Value: v}, nil
+}
+
+func allowed(kind Kind, op Op) bool {
+	for _, o := range kindOps[kind] {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) value(kind Kind) (interface{}, error) {
+	t := p.next()
+	bad := fmt.Errorf("%w: bad value %q at %d", ErrBadExpr, t.text, t.pos)
+	if kind == KindString {
+		if t.kind != tokString {
+			return nil, bad
+		}
+		return t.text, nil
+	}
+	if t.kind != tokWord {
+		return nil, bad
+	}
+	switch kind {
+	case KindInt:
+		v, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, bad
+		}
+		return v, nil
+	case KindBool:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, bad
+	case KindTime:
+		if v, err := time.Parse("2006-01-02", t.text); err == nil {
+			return v.UTC(), nil
+		}
+		v, err := time.Parse(time.RFC3339Nano, t.text)
+		if err != nil {
+			return nil, bad
+		}
+		return v.UTC(), nil
+	}
+	return nil, bad
+}
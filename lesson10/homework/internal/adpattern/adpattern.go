@@ -1,12 +1,23 @@
 package adpattern
 
-import "time"
+import (
+	"homework10/internal/adexpr"
+	"time"
+)
 
 type AdPattern struct {
 	IsLTimeSet    bool
 	IsRTimeSet    bool
 	PublishedOnly bool
 	AuthorID      int64
-	LDate         time.Time
-	RDate         time.Time
+	// Lang matches ads in the language with the ISO 639 code, any language if empty
+	Lang  string
+	LDate time.Time
+	RDate time.Time
+	// Title matches ads whose title starts with it, any title if empty
+	Title string
+	// Sort orders the result, by ascending CreationDate if empty
+	Sort []OrderBy
+	// Expr further restricts matching ads if set
+	Expr adexpr.Expr
 }
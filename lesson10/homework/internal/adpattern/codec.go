@@ -0,0 +1,61 @@
+package adpattern
+
+import (
+	"encoding/json"
+	"homework10/internal/adexpr"
+	"time"
+)
+
+// encoded is the stored form of AdPattern, unset bounds are left out.
+type encoded struct {
+	PublishedOnly bool       `json:"published_only,omitempty"`
+	AuthorID      int64      `json:"author_id,omitempty"`
+	Lang          string     `json:"lang,omitempty"`
+	LDate         *time.Time `json:"l_date,omitempty"`
+	RDate         *time.Time `json:"r_date,omitempty"`
+	Title         string     `json:"title,omitempty"`
+	Sort          string     `json:"sort,omitempty"`
+	Expr          string     `json:"expr,omitempty"`
+}
+
+func Marshal(adp AdPattern) ([]byte, error) {
+	e := encoded{PublishedOnly: adp.PublishedOnly, AuthorID: adp.AuthorID, Lang: adp.Lang, Title: adp.Title,
+		Sort: FormatSort(adp.Sort)}
+	if adp.Expr != nil {
+		e.Expr = adp.Expr.String()
+	}
+	if adp.IsLTimeSet {
+		e.LDate = &adp.LDate
+	}
+	if adp.IsRTimeSet {
+		e.RDate = &adp.RDate
+	}
+	return json.Marshal(e)
+}
+
+func Unmarshal(data []byte) (AdPattern, error) {
+	var e encoded
+	if err := json.Unmarshal(data, &e); err != nil {
+		return AdPattern{}, err
+	}
+	order, err := ParseSort(e.Sort)
+	if err != nil {
+		return AdPattern{}, err
+	}
+	adp := AdPattern{PublishedOnly: e.PublishedOnly, AuthorID: e.AuthorID, Lang: e.Lang, Title: e.Title,
+		Sort: order}
+	if e.Expr != "" {
+		if adp.Expr, err = adexpr.Parse(e.Expr); err != nil {
+			return AdPattern{}, err
+		}
+	}
+	if e.LDate != nil {
+		adp.IsLTimeSet = true
+		adp.LDate = e.LDate.UTC()
+	}
+	if e.RDate != nil {
+		adp.IsRTimeSet = true
+		adp.RDate = e.RDate.UTC()
+	}
+	return adp, nil
+}
@@ -0,0 +1,122 @@
+package adpattern
+
+import (
+	"fmt"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"homework10/internal/ads"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FieldCreationDate = "creation_date"
+	FieldUpdateDate   = "update_date"
+	FieldTitle        = "title"
+)
+
+// OrderBy is a single sort key, keys of a sort spec are applied in order and
+// ties left by all of them are broken by ascending CreationDate and then ID.
+type OrderBy struct {
+	Field string
+	Desc  bool
+}
+
+var ErrBadSort = fmt.Errorf("bad sort spec")
+
+// ParseSort parses a comma-separated list of fields, a leading minus sorts by the field descending,
+// e.g. "-creation_date,title".
+func ParseSort(spec string) ([]OrderBy, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	res := []OrderBy{}
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		res = append(res, OrderBy{Field: strings.TrimPrefix(field, "-"), Desc: desc})
+	}
+	if err := ValidateSort(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func FormatSort(order []OrderBy) string {
+	fields := make([]string, 0, len(order))
+	for _, o := range order {
+		if o.Desc {
+			fields = append(fields, "-"+o.Field)
+		} else {
+			fields = append(fields, o.Field)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// ValidateSort fails on unknown and repeated fields.
+func ValidateSort(order []OrderBy) error {
+	seen := map[string]bool{}
+	for _, o := range order {
+		switch o.Field {
+		case FieldCreationDate, FieldUpdateDate, FieldTitle:
+		default:
+			return fmt.Errorf("%w: unknown field %q", ErrBadSort, o.Field)
+		}
+		if seen[o.Field] {
+			return fmt.Errorf("%w: field %q is repeated", ErrBadSort, o.Field)
+		}
+		seen[o.Field] = true
+	}
+	return nil
+}
+
+// Less returns the comparison of ads by order. Titles are compared with the root Unicode collation,
+// the same as "und-x-icu" in PostgreSQL. The result isn't safe for concurrent use.
+func Less(order []OrderBy) func(a, b ads.Ad) bool {
+	var c *collate.Collator
+	return func(a, b ads.Ad) bool {
+		for _, o := range order {
+			cmp := 0
+			switch o.Field {
+			case FieldCreationDate:
+				cmp = compareTime(a.CreationDate, b.CreationDate)
+			case FieldUpdateDate:
+				cmp = compareTime(a.UpdateDate, b.UpdateDate)
+			case FieldTitle:
+				if c == nil {
+					c = collate.New(language.Und)
+				}
+				cmp = c.CompareString(a.Title, b.Title)
+			}
+			if o.Desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		if cmp := compareTime(a.CreationDate, b.CreationDate); cmp != 0 {
+			return cmp < 0
+		}
+		return a.ID < b.ID
+	}
+}
+
+func SortAds(list []ads.Ad, order []OrderBy) {
+	less := Less(order)
+	sort.Slice(list, func(i, j int) bool {
+		return less(list[i], list[j])
+	})
+}
+
+func compareTime(a, b time.Time) int {
+	if a.Before(b) {
+		return -1
+	}
+	if a.After(b) {
+		return 1
+	}
+	return 0
+}
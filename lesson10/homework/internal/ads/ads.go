@@ -3,11 +3,23 @@ package ads
 import "time"
 
 type Ad struct {
-	ID           int64
-	Title        string `validate:"range:1,99"`
-	Text         string `validate:"range:1,499"`
-	AuthorID     int64
-	Published    bool
+	ID        int64
+	Title     string `validate:"range:1,99"`
+	Text      string `validate:"range:1,499"`
+	AuthorID  int64
+	Published bool
+	// Hidden is set by moderation, hidden ads aren't listed even if published
+	Hidden       bool
 	CreationDate time.Time
 	UpdateDate   time.Time
+	// Lang is the ISO 639 code of the language of the ad, detected from its text unless
+	// LangDeclared is set, empty if unknown
+	Lang         string
+	LangDeclared bool
+	// DeletionDate is set while the ad is in the trash of its author
+	DeletionDate time.Time
+}
+
+func (d Ad) IsDeleted() bool {
+	return !d.DeletionDate.IsZero()
 }
@@ -0,0 +1,84 @@
+package analytics
+
+import (
+	"context"
+	"homework10/internal/tenant"
+	"time"
+)
+
+// View is a single view of an ad, Viewer identifies who viewed it for deduplication.
+type View struct {
+	Tenant tenant.ID
+	AdID   int64
+	Viewer string
+	Time   time.Time
+}
+
+// ViewCount is the number of views of an ad during the hour starting at Hour.
+type ViewCount struct {
+	Tenant tenant.ID
+	AdID   int64
+	Hour   time.Time
+	Views  int64
+}
+
+// Publication is the first publication of an ad, later ones don't change it.
+type Publication struct {
+	Tenant          tenant.ID
+	AdID            int64
+	CreationDate    time.Time
+	PublicationDate time.Time
+}
+
+func (p Publication) TimeToPublish() time.Duration {
+	return p.PublicationDate.Sub(p.CreationDate)
+}
+
+// Bucket holds what happened to ads of an author from Start until the start of the next bucket.
+type Bucket struct {
+	Start      time.Time
+	Views      int64
+	AdsCreated int
+	// Published counts first publications, TimeToPublish is their average time since creation
+	Published     int
+	TimeToPublish time.Duration
+}
+
+// Report splits the time from From to To into buckets of the same length, the last one may be shorter.
+type Report struct {
+	UserID  int64
+	From    time.Time
+	To      time.Time
+	Step    time.Duration
+	Buckets []Bucket
+}
+
+// Index returns the index of the bucket t falls into, -1 if it is outside the report.
+func (r Report) Index(t time.Time) int {
+	if t.Before(r.From) || !t.Before(r.To) {
+		return -1
+	}
+	return int(t.Sub(r.From) / r.Step)
+}
+
+type ctxKey struct{}
+
+// ViewerKey is the key the viewer is stored under in string-keyed request values,
+// such as gin.Context.Set.
+const ViewerKey = "viewer"
+
+// NewContext marks reads of ads with ctx as views by the viewer.
+func NewContext(ctx context.Context, viewer string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, viewer)
+}
+
+// FromContext returns the viewer, empty if reads with ctx aren't views.
+func FromContext(ctx context.Context) string {
+	if viewer, ok := ctx.Value(ctxKey{}).(string); ok {
+		return viewer
+	}
+	if viewer, ok := ctx.Value(ViewerKey).(string); ok {
+		return viewer
+	}
+	return ""
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"homework10/internal/audit"
+	"homework10/internal/email"
+	"homework10/internal/session"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"time"
+)
+
+const (
+	minPasswordLength = 8
+	// bcrypt ignores everything after 72 bytes
+	maxPasswordLength = 72
+	sessionTTL        = 30 * 24 * time.Hour
+)
+
+// IDGenerator allocates IDs of registered users.
+type IDGenerator interface {
+	NextID() (int64, error)
+}
+
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	// Compare returns nil if the password matches the hash.
+	Compare(hash []byte, password string) error
+}
+
+// Credentials stores password hashes of users.
+type Credentials interface {
+	Set(ctx context.Context, userID int64, hash []byte) error
+	Get(ctx context.Context, userID int64) ([]byte, bool)
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+type Sessions interface {
+	Add(ctx context.Context, s session.Session) error
+	Find(ctx context.Context, token string) (session.Session, bool)
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+// AccountStores keep the data of a user besides Users, the stores the app doesn't use are nil.
+type AccountStores struct {
+	Credentials Credentials
+	Sessions    Sessions
+	Tokens      VerificationTokens
+	Favorites   Favorites
+	Searches    SavedSearches
+	Messages    Messages
+	Blocks      Blocks
+}
+
+// AccountsUnitOfWork is a UnitOfWork which also binds the account stores to the unit, so that
+// a user is created or deleted together with their data. Otherwise the stores of the app are
+// used in the unit of work and their changes aren't rolled back.
+type AccountsUnitOfWork interface {
+	DoAccounts(ctx context.Context, fn func(ctx context.Context, repo Repository, users Users,
+		stores AccountStores) error) error
+}
+
+// WithAccounts enables registration with server-generated IDs and login with a password.
+func WithAccounts(g IDGenerator, h PasswordHasher, c Credentials, s Sessions) Option {
+	return func(d *SimpleApp) {
+		d.ids = g
+		d.hasher = h
+		d.credentials = c
+		d.sessions = s
+	}
+}
+
+// WithAdminKey makes admin operations, like ImportAd, ListAudit, moderation and webhooks, available
+// to requests with the key, see ContextWithAdminKey. Without the key or with an empty one they are
+// disabled. CreateUserByID needs the key too once it is set.
+func WithAdminKey(key string) Option {
+	return func(d *SimpleApp) {
+		d.adminKey = &key
+	}
+}
+
+type adminKeyCtx struct{}
+
+// ContextWithAdminKey attaches the admin key presented by the client to ctx.
+func ContextWithAdminKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, adminKeyCtx{}, key)
+}
+
+// isAdmin fails closed: without an admin key nobody is an admin.
+func (d SimpleApp) isAdmin(ctx context.Context) bool {
+	if d.adminKey == nil || *d.adminKey == "" {
+		return false
+	}
+	key, _ := ctx.Value(adminKeyCtx{}).(string)
+	return key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(*d.adminKey)) == 1
+}
+
+func (d SimpleApp) accountsEnabled() bool {
+	return d.ids != nil && d.hasher != nil && d.credentials != nil && d.sessions != nil
+}
+
+// doAccounts runs fn in a unit of work with the account stores bound to it if the unit of work
+// supports that. Stores which are disabled in the app are nil either way.
+func (d SimpleApp) doAccounts(ctx context.Context, fn func(ctx context.Context, repo Repository, users Users,
+	stores AccountStores) error) error {
+	if uow, ok := d.uow.(AccountsUnitOfWork); ok {
+		return uow.DoAccounts(ctx, func(ctx context.Context, repo Repository, users Users, stores AccountStores) error {
+			return fn(ctx, repo, users, d.enabledStores(stores))
+		})
+	}
+	return d.uow.Do(ctx, func(ctx context.Context, repo Repository, users Users) error {
+		return fn(ctx, repo, users, AccountStores{Credentials: d.credentials, Sessions: d.sessions, Tokens: d.tokens,
+			Favorites: d.favorites, Searches: d.searches, Messages: d.messages, Blocks: d.blocks})
+	})
+}
+
+func (d SimpleApp) enabledStores(s AccountStores) AccountStores {
+	if d.credentials == nil {
+		s.Credentials = nil
+	}
+	if d.sessions == nil {
+		s.Sessions = nil
+	}
+	if d.tokens == nil {
+		s.Tokens = nil
+	}
+	if d.favorites == nil {
+		s.Favorites = nil
+	}
+	if d.searches == nil {
+		s.Searches = nil
+	}
+	if d.messages == nil {
+		s.Messages = nil
+	}
+	if d.blocks == nil {
+		s.Blocks = nil
+	}
+	return s
+}
+
+func (d SimpleApp) Register(ctx context.Context, nickname, address, password string) (user.User, error) {
+	if !d.accountsEnabled() {
+		return user.User{}, ErrApp
+	}
+	if nickname == "" || !email.IsValid(address) ||
+		len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return user.User{}, ErrWrongFormat
+	}
+	hash, err := d.hasher.Hash(password)
+	if err != nil {
+		return user.User{}, ErrApp
+	}
+	userID, err := d.ids.NextID()
+	if err != nil {
+		return user.User{}, ErrApp
+	}
+	var u user.User
+	err = d.doAccounts(ctx, func(ctx context.Context, _ Repository, users Users, stores AccountStores) error {
+		if _, isFound := users.Find(ctx, userID); isFound {
+			// an admin has taken the ID with CreateUserByID
+			return ErrApp
+		}
+		var err error
+		u, err = users.CreateByID(ctx, nickname, address, userID)
+		if err != nil {
+			return err
+		}
+		return stores.Credentials.Set(ctx, userID, hash)
+	})
+	if errors.Is(err, ErrEmailTaken) {
+		return user.User{}, ErrEmailTaken
+	}
+	if err != nil {
+		return user.User{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(userID), "register", audit.Target{Kind: audit.KindUser, ID: userID}, nil, u)
+	d.verifyEmail(ctx, u)
+	return u, nil
+}
+
+// Login checks the password and starts a new session, a wrong email or password gives ErrNoAccess.
+func (d SimpleApp) Login(ctx context.Context, address, password string) (session.Session, error) {
+	if !d.accountsEnabled() {
+		return session.Session{}, ErrApp
+	}
+	u, isFound := d.users.FindByEmail(ctx, address)
+	if !isFound {
+		return session.Session{}, ErrNoAccess
+	}
+	hash, isFound := d.credentials.Get(ctx, u.ID)
+	if !isFound || d.hasher.Compare(hash, password) != nil {
+		return session.Session{}, ErrNoAccess
+	}
+	token, err := newToken()
+	if err != nil {
+		return session.Session{}, ErrApp
+	}
+	s := session.Session{Token: token, Tenant: tenant.FromContext(ctx), UserID: u.ID, ExpirationDate: time.Now().UTC().Add(sessionTTL)}
+	if err := d.sessions.Add(ctx, s); err != nil {
+		return session.Session{}, ErrApp
+	}
+	// the session isn't recorded, as its token lets anyone act as the user
+	d.record(ctx, audit.UserActor(u.ID), "login", audit.Target{Kind: audit.KindUser, ID: u.ID}, nil, nil)
+	return s, nil
+}
+
+// Authenticate returns the user of an active session started in the tenant of ctx.
+func (d SimpleApp) Authenticate(ctx context.Context, token string) (user.User, error) {
+	if !d.accountsEnabled() {
+		return user.User{}, ErrApp
+	}
+	s, isFound := d.sessions.Find(ctx, token)
+	if !isFound || s.IsExpired(time.Now().UTC()) {
+		return user.User{}, ErrNoAccess
+	}
+	u, isFound := d.users.Find(ctx, s.UserID)
+	if !isFound {
+		return user.User{}, ErrNoAccess
+	}
+	return u, nil
+}
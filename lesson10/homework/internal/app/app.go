@@ -2,11 +2,25 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/danilabokhanov/strintvalidator"
+	"homework10/internal/adexpr"
 	"homework10/internal/adpattern"
 	"homework10/internal/ads"
+	"homework10/internal/analytics"
+	"homework10/internal/audit"
+	"homework10/internal/bulk"
+	"homework10/internal/email"
+	"homework10/internal/message"
+	"homework10/internal/report"
+	"homework10/internal/search"
+	"homework10/internal/session"
+	"homework10/internal/tenant"
 	"homework10/internal/user"
+	"homework10/internal/webhook"
+	"log"
+	"strings"
 	"time"
 )
 
@@ -21,9 +35,48 @@ type App interface {
 	GetAllAdsByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error)
 	GetNewFilter(ctx context.Context) (Filter, error)
 	FindUser(ctx context.Context, userID int64) (user.User, bool, error)
+	FindUsers(ctx context.Context, userIDs []int64) (map[int64]user.User, error)
 	CreateUserByID(ctx context.Context, nickname, email string, userID int64) (user.User, error)
 	DeleteUserByID(ctx context.Context, userID int64) (user.User, error)
 	ChangeUserInfo(ctx context.Context, userID int64, nickname, email string) (user.User, error)
+	AddFavorite(ctx context.Context, userID int64, adID int64) (ads.Ad, error)
+	RemoveFavorite(ctx context.Context, userID int64, adID int64) (ads.Ad, error)
+	ListFavorites(ctx context.Context, userID int64) ([]ads.Ad, error)
+	SaveSearch(ctx context.Context, userID int64, name string, adp adpattern.AdPattern) (search.SavedSearch, error)
+	ListSavedSearches(ctx context.Context, userID int64) ([]search.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, userID int64, searchID int64) (search.SavedSearch, error)
+	OpenThread(ctx context.Context, adID int64, buyerID int64) (message.Thread, error)
+	ListThreads(ctx context.Context, userID int64) ([]message.Thread, error)
+	SendMessage(ctx context.Context, threadID int64, senderID int64, text string) (message.Message, error)
+	GetMessages(ctx context.Context, threadID int64, userID int64, beforeID int64, limit int) ([]message.Message, error)
+	MarkRead(ctx context.Context, threadID int64, userID int64, upToID int64) (int, error)
+	SubscribeMessages(ctx context.Context, userID int64) (<-chan message.Event, error)
+	BlockUser(ctx context.Context, userID int64, blockedID int64) (user.User, error)
+	UnblockUser(ctx context.Context, userID int64, blockedID int64) (user.User, error)
+	RequestVerification(ctx context.Context, userID int64) (user.User, error)
+	VerifyEmail(ctx context.Context, userID int64, token string) (user.User, error)
+	Register(ctx context.Context, nickname, email, password string) (user.User, error)
+	Login(ctx context.Context, email, password string) (session.Session, error)
+	Authenticate(ctx context.Context, token string) (user.User, error)
+	ListTrash(ctx context.Context, userID int64) ([]ads.Ad, error)
+	RestoreAd(ctx context.Context, adID int64, userID int64) (ads.Ad, error)
+	PurgeTrash(ctx context.Context) (int, error)
+	ImportAd(ctx context.Context, rec bulk.Record, dryRun bool) (ads.Ad, bulk.Outcome, error)
+	ExportAds(ctx context.Context, adp adpattern.AdPattern, fn func(rec bulk.Record) error) error
+	ResolveTenant(ctx context.Context, requested string, host string) (tenant.ID, error)
+	ListAudit(ctx context.Context, target audit.Target) ([]audit.Entry, error)
+	GetUserStats(ctx context.Context, userID int64, from, to time.Time, step time.Duration) (analytics.Report, error)
+	SetAdLanguage(ctx context.Context, adID int64, userID int64, lang string) (ads.Ad, error)
+	ReportAd(ctx context.Context, adID int64, reporterID int64, reason report.Reason, comment string) (report.Report, error)
+	ListReports(ctx context.Context, status report.Status) ([]report.Report, error)
+	ResolveReport(ctx context.Context, reportID int64) (report.Report, error)
+	DismissReport(ctx context.Context, reportID int64) (report.Report, error)
+	CreateWebhook(ctx context.Context, url string, events []webhook.Event, adp adpattern.AdPattern,
+		secret string) (webhook.Subscription, error)
+	ListWebhooks(ctx context.Context) ([]webhook.Subscription, error)
+	DeleteWebhook(ctx context.Context, webhookID int64) (webhook.Subscription, error)
+	ListWebhookDeliveries(ctx context.Context, webhookID int64, status webhook.Status) ([]webhook.Delivery, error)
+	RedeliverWebhook(ctx context.Context, deliveryID int64) (webhook.Delivery, error)
 }
 
 type Repository interface {
@@ -31,59 +84,163 @@ type Repository interface {
 	Find(ctx context.Context, adID int64) (ads.Ad, bool)
 	GetByTitle(ctx context.Context, title string) ([]ads.Ad, error)
 	Add(ctx context.Context, title string, text string, userID int64) (int64, error)
+	// Delete and DeleteByAuthor move ads to the trash setting their DeletionDate,
+	// trashed ads are hidden from all queries except FindDeleted and ListDeleted.
 	Delete(ctx context.Context, adID int64) error
 	DeleteByAuthor(ctx context.Context, userID int64) error
+	FindDeleted(ctx context.Context, adID int64) (ads.Ad, bool)
+	ListDeleted(ctx context.Context, userID int64) ([]ads.Ad, error)
+	Restore(ctx context.Context, adID int64) error
+	// Purge permanently removes ads deleted before the given time and returns their IDs.
+	Purge(ctx context.Context, before time.Time) ([]int64, error)
 	SetTitle(ctx context.Context, adID int64, title string) error
 	SetText(ctx context.Context, adID int64, text string) error
 	SetStatus(ctx context.Context, adID int64, status bool) error
+	SetHidden(ctx context.Context, adID int64, hidden bool) error
+	SetLang(ctx context.Context, adID int64, lang string, declared bool) error
 	GetAllByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error)
+	Insert(ctx context.Context, ad ads.Ad) error
 }
 
 type Users interface {
 	// TODO: реализовать
 	Find(ctx context.Context, userID int64) (user.User, bool)
+	FindByEmail(ctx context.Context, email string) (user.User, bool)
+	// FindMany returns the users found among userIDs by their IDs.
+	FindMany(ctx context.Context, userIDs []int64) (map[int64]user.User, error)
+	// CreateByID and ChangeInfo fail with ErrEmailTaken if another user has the email,
+	// emails are compared case-insensitively. ChangeInfo resets Verified if the email changes.
 	CreateByID(ctx context.Context, nickname, email string, userID int64) (user.User, error)
 	DeleteByID(ctx context.Context, userID int64) (user.User, error)
 	ChangeInfo(ctx context.Context, userID int64, nickname, email string) error
+	SetVerified(ctx context.Context, userID int64, verified bool) error
 }
 
 type Filter interface {
 	BasicConfig(ctx context.Context) (Filter, error)
 	SetStatus(ctx context.Context, publishedOnly bool) (Filter, error)
 	SetAuthor(ctx context.Context, userID int64) (Filter, error)
+	SetLang(ctx context.Context, lang string) (Filter, error)
 	SetLTime(ctx context.Context, l time.Time) (Filter, error)
 	SetRTime(ctx context.Context, r time.Time) (Filter, error)
+	SetSort(ctx context.Context, order []adpattern.OrderBy) (Filter, error)
+	SetExpr(ctx context.Context, expr adexpr.Expr) (Filter, error)
 	GetPattern(ctx context.Context) (adpattern.AdPattern, error)
 }
 
+// UnitOfWork runs fn atomically: if fn returns an error, all changes made
+// through the given repo and users are rolled back.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, repo Repository, users Users) error) error
+}
+
 type SimpleApp struct {
-	repository Repository
-	users      Users
-	filter     Filter
+	repository  Repository
+	users       Users
+	filter      Filter
+	uow         UnitOfWork
+	favorites   Favorites
+	searches    SavedSearches
+	notifier    Notifier
+	messages    Messages
+	blocks      Blocks
+	broker      *broker
+	tokens      VerificationTokens
+	mailer      Mailer
+	ids         IDGenerator
+	hasher      PasswordHasher
+	credentials Credentials
+	sessions    Sessions
+	adminKey    *string
+	externalIDs ExternalIDs
+	tenants     *tenant.Registry
+
+	idempotencyKeys IdempotencyKeys
+	auditLog        AuditLog
+	stats           Stats
+	viewCounter     ViewCounter
+	reports         Reports
+	contentPolicy   ContentPolicy
+	webhooks        Webhooks
+	deliveries      WebhookDeliveries
+	webhookSender   WebhookSender
+
+	verificationTTL time.Duration
+	retention       time.Duration
+	idempotencyTTL  time.Duration
+	hideThreshold   int
+}
+
+type Option func(d *SimpleApp)
+
+// WithUnitOfWork replaces the default in-memory unit of work, e.g. with database transactions.
+func WithUnitOfWork(uow UnitOfWork) Option {
+	return func(d *SimpleApp) {
+		d.uow = uow
+	}
 }
 
-func NewApp(repo Repository, u Users, f Filter) App {
-	return SimpleApp{repository: repo, users: u, filter: f}
+func WithFavorites(f Favorites) Option {
+	return func(d *SimpleApp) {
+		d.favorites = f
+	}
+}
+
+// WithSavedSearches enables saved searches, n is notified when a newly published ad matches one of them.
+func WithSavedSearches(s SavedSearches, n Notifier) Option {
+	return func(d *SimpleApp) {
+		d.searches = s
+		d.notifier = n
+	}
+}
+
+func NewApp(repo Repository, u Users, f Filter, opts ...Option) App {
+	d := SimpleApp{repository: repo, users: u, filter: f, uow: NewJournalUnitOfWork(repo, u), broker: newBroker(),
+		retention: DefaultRetention, idempotencyTTL: DefaultIdempotencyTTL}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return d
 	// TODO: реализовать
 }
 
 var ErrWrongFormat = fmt.Errorf("wrong format")
 var ErrNoAccess = fmt.Errorf("permission denied")
 var ErrApp = fmt.Errorf("unknown application error")
+var ErrEmailTaken = fmt.Errorf("%w: email is already taken", ErrWrongFormat)
 
 func (d SimpleApp) CreateAd(ctx context.Context, title string, text string, userID int64) (ads.Ad, error) {
+	return idempotent(ctx, d, userID, "CreateAd", []interface{}{title, text}, func() (ads.Ad, error) {
+		return d.createAd(ctx, title, text, userID)
+	})
+}
+
+func (d SimpleApp) createAd(ctx context.Context, title string, text string, userID int64) (ads.Ad, error) {
 	if e := strintvalidator.Validate(ads.Ad{Title: title, Text: text}); e != nil {
 		return ads.Ad{}, ErrWrongFormat
 	}
+	if err := d.checkLimits(ctx, title, text); err != nil {
+		return ads.Ad{}, err
+	}
 	_, isFound := d.users.Find(ctx, userID)
 	if !isFound {
 		return ads.Ad{}, ErrWrongFormat
 	}
+	verdict, err := d.checkContent(ctx, nil, userID, title, text)
+	if err != nil {
+		return ads.Ad{}, err
+	}
 	adID, err := d.repository.Add(ctx, title, text, userID)
 	if err != nil {
 		return ads.Ad{}, ErrApp
 	}
+	// the ad is already added, so it's kept without a language rather than reported as not created
+	if err := detectLang(ctx, d.repository, ads.Ad{ID: adID}, title, text); err != nil {
+		log.Printf("can't detect language of ad %d: %s", adID, err.Error())
+	}
 	ad, _ := d.repository.Find(ctx, adID)
+	d.record(ctx, audit.UserActor(userID), "create_ad", audit.Target{Kind: audit.KindAd, ID: adID}, nil, ad)
+	d.flagContent(ctx, ad, verdict)
 	return ad, nil
 }
 
@@ -99,11 +256,19 @@ func (d SimpleApp) DeleteAd(ctx context.Context, adID, userID int64) (ads.Ad, er
 	if err != nil {
 		return ads.Ad{}, ErrApp
 	}
+	d.record(ctx, audit.UserActor(userID), "delete_ad", audit.Target{Kind: audit.KindAd, ID: adID}, ad, nil)
+	d.emitWebhooks(ctx, webhook.EventDeleted, ad)
 	return ad, nil
 }
 
 func (d SimpleApp) ChangeAdStatus(ctx context.Context, adID int64, userID int64, published bool) (ads.Ad, error) {
-	_, isFound := d.users.Find(ctx, userID)
+	return idempotent(ctx, d, userID, "ChangeAdStatus", []interface{}{adID, published}, func() (ads.Ad, error) {
+		return d.changeAdStatus(ctx, adID, userID, published)
+	})
+}
+
+func (d SimpleApp) changeAdStatus(ctx context.Context, adID int64, userID int64, published bool) (ads.Ad, error) {
+	u, isFound := d.users.Find(ctx, userID)
 	if !isFound {
 		return ads.Ad{}, ErrWrongFormat
 	}
@@ -111,21 +276,38 @@ func (d SimpleApp) ChangeAdStatus(ctx context.Context, adID int64, userID int64,
 	if !isFound {
 		return ads.Ad{}, ErrWrongFormat
 	}
-	if ad.AuthorID != userID {
+	if ad.AuthorID != userID || published && d.verificationEnabled() && !u.Verified {
 		return ads.Ad{}, ErrNoAccess
 	}
 	err := d.repository.SetStatus(ctx, adID, published)
 	if err != nil {
 		return ads.Ad{}, ErrApp
 	}
+	before := ad
+	wasPublished := ad.Published
 	ad.Published = published
+	d.record(ctx, audit.UserActor(userID), "change_ad_status", audit.Target{Kind: audit.KindAd, ID: adID}, before, ad)
+	if published && !wasPublished {
+		d.recordPublication(ctx, ad)
+		d.notifySearches(ctx, ad)
+		d.emitWebhooks(ctx, webhook.EventPublished, ad)
+	}
 	return ad, nil
 }
 
 func (d SimpleApp) UpdateAd(ctx context.Context, adID int64, userID int64, title string, text string) (ads.Ad, error) {
+	return idempotent(ctx, d, userID, "UpdateAd", []interface{}{adID, title, text}, func() (ads.Ad, error) {
+		return d.updateAd(ctx, adID, userID, title, text)
+	})
+}
+
+func (d SimpleApp) updateAd(ctx context.Context, adID int64, userID int64, title string, text string) (ads.Ad, error) {
 	if e := strintvalidator.Validate(ads.Ad{Title: title, Text: text}); e != nil {
 		return ads.Ad{}, ErrWrongFormat
 	}
+	if err := d.checkLimits(ctx, title, text); err != nil {
+		return ads.Ad{}, err
+	}
 	_, isFound := d.users.Find(ctx, userID)
 	if !isFound {
 		return ads.Ad{}, ErrWrongFormat
@@ -137,34 +319,62 @@ func (d SimpleApp) UpdateAd(ctx context.Context, adID int64, userID int64, title
 	if ad.AuthorID != userID {
 		return ads.Ad{}, ErrNoAccess
 	}
-	err := d.repository.SetText(ctx, adID, text)
+	verdict, err := d.checkContent(ctx, &ad, userID, title, text)
 	if err != nil {
-		return ads.Ad{}, ErrApp
+		return ads.Ad{}, err
 	}
-	err = d.repository.SetTitle(ctx, adID, title)
+	err = d.uow.Do(ctx, func(ctx context.Context, repo Repository, users Users) error {
+		if err := repo.SetText(ctx, adID, text); err != nil {
+			return err
+		}
+		if err := repo.SetTitle(ctx, adID, title); err != nil {
+			return err
+		}
+		return detectLang(ctx, repo, ad, title, text)
+	})
 	if err != nil {
 		return ads.Ad{}, ErrApp
 	}
-	ad.Title = title
-	ad.Text = text
+	before := ad
+	ad, _ = d.repository.Find(ctx, adID)
+	d.record(ctx, audit.UserActor(userID), "update_ad", audit.Target{Kind: audit.KindAd, ID: adID}, before, ad)
+	d.flagContent(ctx, ad, verdict)
 	return ad, nil
 }
 
-func (d SimpleApp) ChangeUserInfo(ctx context.Context, userID int64, nickname, email string) (user.User, error) {
+func (d SimpleApp) ChangeUserInfo(ctx context.Context, userID int64, nickname, address string) (user.User, error) {
+	if !email.IsValid(address) {
+		return user.User{}, ErrWrongFormat
+	}
 	u, isFound := d.users.Find(ctx, userID)
 	if !isFound {
 		return user.User{}, ErrWrongFormat
 	}
-	err := d.users.ChangeInfo(ctx, userID, nickname, email)
+	err := d.users.ChangeInfo(ctx, userID, nickname, address)
+	if errors.Is(err, ErrEmailTaken) {
+		return user.User{}, ErrEmailTaken
+	}
 	if err != nil {
 		return user.User{}, ErrApp
 	}
+	before := u
+	isChanged := email.Normalize(u.Email) != email.Normalize(address)
 	u.Nickname = nickname
-	u.Email = email
+	u.Email = address
+	if isChanged {
+		u.Verified = false
+	}
+	d.record(ctx, audit.UserActor(userID), "change_user_info", audit.Target{Kind: audit.KindUser, ID: userID}, before, u)
+	if isChanged {
+		d.verifyEmail(ctx, u)
+	}
 	return u, nil
 }
 
 func (d SimpleApp) GetAllAdsByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error) {
+	if adpattern.ValidateSort(adp.Sort) != nil {
+		return []ads.Ad{}, ErrWrongFormat
+	}
 	res, err := d.repository.GetAllByTemplate(ctx, adp)
 	if err != nil {
 		return []ads.Ad{}, ErrApp
@@ -181,18 +391,27 @@ func (d SimpleApp) GetNewFilter(ctx context.Context) (Filter, error) {
 }
 
 func CheckAd(ad ads.Ad, pattern adpattern.AdPattern) bool {
-	if pattern.PublishedOnly && !ad.Published {
+	if ad.IsDeleted() {
+		return false
+	}
+	if pattern.PublishedOnly && (!ad.Published || ad.Hidden) {
 		return false
 	}
 	if pattern.AuthorID != 0 && pattern.AuthorID != ad.AuthorID {
 		return false
 	}
+	if pattern.Lang != "" && pattern.Lang != ad.Lang {
+		return false
+	}
+	if !strings.HasPrefix(ad.Title, pattern.Title) {
+		return false
+	}
 
 	if pattern.IsRTimeSet && pattern.RDate.Before(ad.CreationDate) ||
 		pattern.IsLTimeSet && pattern.LDate.After(ad.CreationDate) {
 		return false
 	}
-	return true
+	return pattern.Expr == nil || pattern.Expr.Match(ad)
 }
 
 func (d SimpleApp) GetAdsByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
@@ -208,34 +427,73 @@ func (d SimpleApp) FindAd(ctx context.Context, adID int64) (ads.Ad, error) {
 	if !isFound {
 		return ads.Ad{}, ErrWrongFormat
 	}
+	d.recordView(ctx, ad)
 	return ad, nil
 }
 
-func (d SimpleApp) CreateUserByID(ctx context.Context, nickname, email string, userID int64) (user.User, error) {
+func (d SimpleApp) CreateUserByID(ctx context.Context, nickname, address string, userID int64) (user.User, error) {
+	// the check goes first, so stored responses are returned to admins only. Without
+	// WithAdminKey the app has no admins and users are created by anyone, as in the original API
+	if d.adminKey != nil && !d.isAdmin(ctx) {
+		return user.User{}, ErrNoAccess
+	}
+	return idempotent(ctx, d, userID, "CreateUserByID", []interface{}{nickname, address}, func() (user.User, error) {
+		return d.createUserByID(ctx, nickname, address, userID)
+	})
+}
+
+func (d SimpleApp) createUserByID(ctx context.Context, nickname, address string, userID int64) (user.User, error) {
+	if !email.IsValid(address) {
+		return user.User{}, ErrWrongFormat
+	}
 	_, isFound := d.users.Find(ctx, userID)
 	if isFound {
 		return user.User{}, ErrWrongFormat
 	}
-	u, err := d.users.CreateByID(ctx, nickname, email, userID)
+	u, err := d.users.CreateByID(ctx, nickname, address, userID)
+	if errors.Is(err, ErrEmailTaken) {
+		return user.User{}, ErrEmailTaken
+	}
 	if err != nil {
 		return user.User{}, ErrApp
 	}
+	d.record(ctx, d.accountActor(ctx, userID), "create_user", audit.Target{Kind: audit.KindUser, ID: userID}, nil, u)
+	d.verifyEmail(ctx, u)
 	return u, nil
 }
 
 func (d SimpleApp) DeleteUserByID(ctx context.Context, userID int64) (user.User, error) {
-	_, isFound := d.users.Find(ctx, userID)
+	before, isFound := d.users.Find(ctx, userID)
 	if !isFound {
 		return user.User{}, ErrWrongFormat
 	}
-	u, err := d.users.DeleteByID(ctx, userID)
-	if err != nil {
-		return user.User{}, ErrApp
+	var deleted []ads.Ad
+	if d.webhooksEnabled() {
+		// subscribers are told about ads of the user, which go to the trash with the user
+		list, err := d.repository.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: userID})
+		if err != nil {
+			return user.User{}, ErrApp
+		}
+		deleted = list
 	}
-	err = d.repository.DeleteByAuthor(ctx, userID)
+	var u user.User
+	err := d.doAccounts(ctx, func(ctx context.Context, repo Repository, users Users, stores AccountStores) error {
+		var err error
+		u, err = users.DeleteByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := repo.DeleteByAuthor(ctx, userID); err != nil {
+			return err
+		}
+		return d.forgetUser(ctx, userID, stores)
+	})
 	if err != nil {
+		log.Printf("can't delete user %d: %s", userID, err.Error())
 		return user.User{}, ErrApp
 	}
+	d.record(ctx, d.accountActor(ctx, userID), "delete_user", audit.Target{Kind: audit.KindUser, ID: userID}, before, nil)
+	d.emitWebhooks(ctx, webhook.EventDeleted, deleted...)
 	return u, nil
 }
 
@@ -243,3 +501,12 @@ func (d SimpleApp) FindUser(ctx context.Context, userID int64) (user.User, bool,
 	u, isFound := d.users.Find(ctx, userID)
 	return u, isFound, nil
 }
+
+// FindUsers looks users up in one go, missing ones are left out of the result.
+func (d SimpleApp) FindUsers(ctx context.Context, userIDs []int64) (map[int64]user.User, error) {
+	res, err := d.users.FindMany(ctx, userIDs)
+	if err != nil {
+		return nil, ErrApp
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"homework10/internal/audit"
+	"homework10/internal/tenant"
+	"log"
+	"time"
+)
+
+// AuditLog is append-only, entries are never changed or removed.
+type AuditLog interface {
+	// Append stores the entry and returns it with its ID set.
+	Append(ctx context.Context, e audit.Entry) (audit.Entry, error)
+	// List returns entries of the tenant about the target, oldest first. A zero target matches all entries.
+	List(ctx context.Context, target audit.Target) ([]audit.Entry, error)
+}
+
+// WithAuditLog records every change made through the app to l, admins read it with ListAudit.
+func WithAuditLog(l AuditLog) Option {
+	return func(d *SimpleApp) {
+		d.auditLog = l
+	}
+}
+
+func (d SimpleApp) ListAudit(ctx context.Context, target audit.Target) ([]audit.Entry, error) {
+	if d.auditLog == nil {
+		return []audit.Entry{}, ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return []audit.Entry{}, ErrNoAccess
+	}
+	res, err := d.auditLog.List(ctx, target)
+	if err != nil {
+		return []audit.Entry{}, ErrApp
+	}
+	return res, nil
+}
+
+// record appends an entry about a change which has already been made and only logs
+// a failure to append. Nil before or after is left empty.
+func (d SimpleApp) record(ctx context.Context, actor string, action string, target audit.Target,
+	before interface{}, after interface{}) {
+	if d.auditLog == nil {
+		return
+	}
+	r := audit.FromContext(ctx)
+	e := audit.Entry{Tenant: tenant.FromContext(ctx), Time: time.Now().UTC(), Actor: actor, Action: action,
+		Target: target, Before: snapshot(before), After: snapshot(after), RequestID: r.ID, Transport: r.Transport}
+	if _, err := d.auditLog.Append(ctx, e); err != nil {
+		log.Printf("can't append %s of %s to audit log: %s", action, target, err.Error())
+	}
+}
+
+// accountActor is the admin if the request carries the admin key, otherwise the user
+// changing their own account.
+func (d SimpleApp) accountActor(ctx context.Context, userID int64) string {
+	if d.isAdmin(ctx) {
+		return audit.Admin
+	}
+	return audit.UserActor(userID)
+}
+
+func snapshot(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danilabokhanov/strintvalidator"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/bulk"
+	"homework10/internal/webhook"
+	"strconv"
+)
+
+// ExternalIDs maps IDs of imported ads in the system they came from to their IDs here.
+type ExternalIDs interface {
+	Set(ctx context.Context, externalID string, adID int64) error
+	Find(ctx context.Context, externalID string) (int64, bool)
+	FindByAd(ctx context.Context, adID int64) (string, bool)
+	DeleteByAd(ctx context.Context, adID int64) error
+}
+
+// ImportUnitOfWork is a UnitOfWork which also binds external IDs to the unit and
+// serializes units looking up the same external ID. ImportAd uses it if the unit of
+// work of the app implements it, otherwise the unit of work has to be serialized itself.
+type ImportUnitOfWork interface {
+	DoImport(ctx context.Context, fn func(ctx context.Context, repo Repository, users Users,
+		externalIDs ExternalIDs) error) error
+}
+
+// WithExternalIDs enables ImportAd.
+func WithExternalIDs(e ExternalIDs) Option {
+	return func(d *SimpleApp) {
+		d.externalIDs = e
+	}
+}
+
+// ImportAd creates an ad from rec, or updates the ad imported earlier with the same external ID
+// to match it. The record is validated the same way as in CreateAd, with dryRun nothing is changed.
+func (d SimpleApp) ImportAd(ctx context.Context, rec bulk.Record, dryRun bool) (ads.Ad, bulk.Outcome, error) {
+	if d.externalIDs == nil {
+		return ads.Ad{}, "", ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return ads.Ad{}, "", ErrNoAccess
+	}
+	if rec.ExternalID == "" {
+		return ads.Ad{}, "", fmt.Errorf("%w: external_id is empty", ErrWrongFormat)
+	}
+	if e := strintvalidator.Validate(ads.Ad{Title: rec.Title, Text: rec.Text}); e != nil {
+		return ads.Ad{}, "", fmt.Errorf("%w: %s", ErrWrongFormat, e.Error())
+	}
+	if err := d.checkLimits(ctx, rec.Title, rec.Text); err != nil {
+		return ads.Ad{}, "", err
+	}
+	if _, isFound := d.users.Find(ctx, rec.AuthorID); !isFound {
+		return ads.Ad{}, "", fmt.Errorf("%w: author %d doesn't exist", ErrWrongFormat, rec.AuthorID)
+	}
+
+	var res, before ads.Ad
+	var outcome bulk.Outcome
+	err := d.doImport(ctx, func(ctx context.Context, repo Repository, externalIDs ExternalIDs) error {
+		// the lookup is inside the unit of work, so concurrent imports of the same ad don't both create it
+		adID, isFound := externalIDs.Find(ctx, rec.ExternalID)
+		if !isFound {
+			outcome = bulk.Created
+			res = ads.Ad{Title: rec.Title, Text: rec.Text, AuthorID: rec.AuthorID, Published: rec.Published}
+			if dryRun {
+				return nil
+			}
+			return d.importNew(ctx, repo, externalIDs, rec, &res)
+		}
+
+		ad, isFound := repo.Find(ctx, adID)
+		if !isFound {
+			return fmt.Errorf("%w: ad %d imported as %q is deleted", ErrWrongFormat, adID, rec.ExternalID)
+		}
+		if ad.AuthorID != rec.AuthorID {
+			return fmt.Errorf("%w: ad %d imported as %q has another author", ErrWrongFormat, adID, rec.ExternalID)
+		}
+		res, before = ad, ad
+		if ad.Title == rec.Title && ad.Text == rec.Text && ad.Published == rec.Published {
+			outcome = bulk.Unchanged
+			return nil
+		}
+		outcome = bulk.Updated
+		res.Title, res.Text, res.Published = rec.Title, rec.Text, rec.Published
+		if dryRun {
+			return nil
+		}
+		return d.importChanges(ctx, repo, ad, &res)
+	})
+	if errors.Is(err, ErrWrongFormat) {
+		return ads.Ad{}, "", err
+	}
+	if err != nil {
+		return ads.Ad{}, "", ErrApp
+	}
+	if !dryRun && res.Published && (outcome == bulk.Created || outcome == bulk.Updated && !before.Published) {
+		d.recordPublication(ctx, res)
+		d.emitWebhooks(ctx, webhook.EventPublished, res)
+	}
+	if !dryRun {
+		switch outcome {
+		case bulk.Created:
+			d.record(ctx, audit.Admin, "import_ad", audit.Target{Kind: audit.KindAd, ID: res.ID}, nil, res)
+		case bulk.Updated:
+			d.record(ctx, audit.Admin, "import_ad", audit.Target{Kind: audit.KindAd, ID: res.ID}, before, res)
+		}
+	}
+	return res, outcome, nil
+}
+
+// doImport runs fn in a unit of work with external IDs bound to it.
+func (d SimpleApp) doImport(ctx context.Context,
+	fn func(ctx context.Context, repo Repository, externalIDs ExternalIDs) error) error {
+	if uow, ok := d.uow.(ImportUnitOfWork); ok {
+		return uow.DoImport(ctx, func(ctx context.Context, repo Repository, _ Users, externalIDs ExternalIDs) error {
+			return fn(ctx, repo, externalIDs)
+		})
+	}
+	// JournalUnitOfWork serializes units of work, external IDs are only changed inside them
+	return d.uow.Do(ctx, func(ctx context.Context, repo Repository, _ Users) error {
+		return fn(ctx, repo, d.externalIDs)
+	})
+}
+
+func (d SimpleApp) importNew(ctx context.Context, repo Repository, externalIDs ExternalIDs, rec bulk.Record,
+	res *ads.Ad) error {
+	adID, err := repo.Add(ctx, rec.Title, rec.Text, rec.AuthorID)
+	if err != nil {
+		return err
+	}
+	if rec.Published {
+		if err := repo.SetStatus(ctx, adID, true); err != nil {
+			return err
+		}
+	}
+	if err := externalIDs.Set(ctx, rec.ExternalID, adID); err != nil {
+		return err
+	}
+	*res, _ = repo.Find(ctx, adID)
+	return nil
+}
+
+func (d SimpleApp) importChanges(ctx context.Context, repo Repository, old ads.Ad, res *ads.Ad) error {
+	if old.Title != res.Title {
+		if err := repo.SetTitle(ctx, old.ID, res.Title); err != nil {
+			return err
+		}
+	}
+	if old.Text != res.Text {
+		if err := repo.SetText(ctx, old.ID, res.Text); err != nil {
+			return err
+		}
+	}
+	if old.Published != res.Published {
+		if err := repo.SetStatus(ctx, old.ID, res.Published); err != nil {
+			return err
+		}
+	}
+	*res, _ = repo.Find(ctx, old.ID)
+	return nil
+}
+
+// ExportAds passes ads matching adp to fn one by one. The external ID of an ad is the one
+// it was imported with, or its own ID, so an export can be imported elsewhere repeatedly.
+func (d SimpleApp) ExportAds(ctx context.Context, adp adpattern.AdPattern, fn func(rec bulk.Record) error) error {
+	if adpattern.ValidateSort(adp.Sort) != nil {
+		return ErrWrongFormat
+	}
+	list, err := d.repository.GetAllByTemplate(ctx, adp)
+	if err != nil {
+		return ErrApp
+	}
+	for _, ad := range list {
+		rec := bulk.Record{ExternalID: strconv.FormatInt(ad.ID, 10), ID: ad.ID, Title: ad.Title, Text: ad.Text,
+			AuthorID: ad.AuthorID, Published: ad.Published, CreationDate: ad.CreationDate, UpdateDate: ad.UpdateDate}
+		if d.externalIDs != nil {
+			if externalID, isFound := d.externalIDs.FindByAd(ctx, ad.ID); isFound {
+				rec.ExternalID = externalID
+			}
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"homework10/internal/idempotency"
+	"homework10/internal/tenant"
+	"log"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long responses are kept for retries with the same idempotency key.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+var ErrConflict = fmt.Errorf("conflict")
+
+// IdempotencyKeys stores responses to requests by their idempotency keys.
+type IdempotencyKeys interface {
+	// Reserve saves rec unless an unexpired record with its key exists, which is returned then.
+	// The flag is set if rec is saved.
+	Reserve(ctx context.Context, rec idempotency.Record) (idempotency.Record, bool, error)
+	// Complete saves the response of the reserved request.
+	Complete(ctx context.Context, key idempotency.Key, response []byte) error
+	// Release drops the reserved record, so the request can be retried.
+	Release(ctx context.Context, key idempotency.Key) error
+}
+
+// WithIdempotencyKeys makes CreateAd, CreateUserByID, ChangeAdStatus and UpdateAd return
+// the stored result to retries with the idempotency key of the context for ttl.
+func WithIdempotencyKeys(k IdempotencyKeys, ttl time.Duration) Option {
+	return func(d *SimpleApp) {
+		d.idempotencyKeys = k
+		d.idempotencyTTL = ttl
+	}
+}
+
+// fingerprint hashes the operation with its arguments.
+func fingerprint(op string, args ...interface{}) (string, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(op+":"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// idempotent runs fn once per idempotency key of ctx and user, retries get the result of
+// the first run. Failed runs aren't stored, so the request can be fixed and retried.
+func idempotent[T any](ctx context.Context, d SimpleApp, userID int64, op string, args []interface{},
+	fn func() (T, error)) (T, error) {
+	var res T
+	value := idempotency.FromContext(ctx)
+	if d.idempotencyKeys == nil || value == "" {
+		return fn()
+	}
+	if len(value) > idempotency.MaxKeyLen {
+		return res, fmt.Errorf("%w: idempotency key is longer than %d bytes", ErrWrongFormat, idempotency.MaxKeyLen)
+	}
+	fp, err := fingerprint(op, args...)
+	if err != nil {
+		return res, ErrApp
+	}
+
+	key := idempotency.Key{Tenant: tenant.FromContext(ctx), UserID: userID, Value: value}
+	rec, isReserved, err := d.idempotencyKeys.Reserve(ctx, idempotency.Record{Key: key, Fingerprint: fp,
+		ExpirationDate: time.Now().UTC().Add(d.idempotencyTTL)})
+	if err != nil {
+		return res, ErrApp
+	}
+	if !isReserved {
+		if rec.Fingerprint != fp {
+			return res, fmt.Errorf("%w: idempotency key is already used for another request", ErrConflict)
+		}
+		if !rec.IsDone() {
+			return res, fmt.Errorf("%w: request with the idempotency key is in progress", ErrConflict)
+		}
+		if err := json.Unmarshal(rec.Response, &res); err != nil {
+			return res, ErrApp
+		}
+		return res, nil
+	}
+
+	res, err = fn()
+	if err != nil {
+		if err := d.idempotencyKeys.Release(ctx, key); err != nil {
+			log.Printf("can't release idempotency key %q: %s", key.Value, err.Error())
+		}
+		return res, err
+	}
+	// if the result can't be stored, retries get a conflict until the record expires
+	// rather than repeat the request
+	data, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("can't store response for idempotency key %q: %s", key.Value, err.Error())
+		return res, nil
+	}
+	if err := d.idempotencyKeys.Complete(ctx, key, data); err != nil {
+		log.Printf("can't store response for idempotency key %q: %s", key.Value, err.Error())
+	}
+	return res, nil
+}
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/user"
+	"log"
+	"sync"
+	"time"
+)
+
+// JournalUnitOfWork makes in-memory stores transactional: every mutation done
+// inside Do records how to undo it, and the journal is replayed backwards
+// if fn fails. Units of work are serialized with each other, but not with writes
+// made outside of them, so undoing restores only what the unit itself changed.
+type JournalUnitOfWork struct {
+	mx    *sync.Mutex
+	repo  Repository
+	users Users
+}
+
+func NewJournalUnitOfWork(repo Repository, u Users) *JournalUnitOfWork {
+	return &JournalUnitOfWork{mx: &sync.Mutex{}, repo: repo, users: u}
+}
+
+func (d *JournalUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repo Repository, users Users) error) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	j := &journal{}
+	err := fn(ctx, &journalRepo{repo: d.repo, j: j}, &journalUsers{users: d.users, j: j})
+	if err != nil {
+		j.rollback(ctx)
+		return err
+	}
+	return nil
+}
+
+type journal struct {
+	undo []func(ctx context.Context) error
+}
+
+func (d *journal) record(undo func(ctx context.Context) error) {
+	d.undo = append(d.undo, undo)
+}
+
+// rollback runs all undo steps even if some of them fail, failures are logged.
+func (d *journal) rollback(ctx context.Context) {
+	for i := len(d.undo) - 1; i >= 0; i-- {
+		if err := d.undo[i](ctx); err != nil {
+			log.Printf("can't roll back unit of work: %s", err.Error())
+		}
+	}
+}
+
+// journalRepo records how to undo every mutation before it is made,
+// so the mutation is undone even if it failed halfway.
+type journalRepo struct {
+	repo Repository
+	j    *journal
+}
+
+// restore records undo of a change of fields of the ad: revert sets them back in the ad
+// as it is then, and the ad is put back with Insert, keeping the UpdateDate it had, as
+// setters would stamp a new one. An ad that didn't exist is deleted instead.
+func (d *journalRepo) restore(ctx context.Context, adID int64, revert func(ad *ads.Ad, old ads.Ad)) {
+	old, isFound := d.repo.Find(ctx, adID)
+	d.j.record(func(ctx context.Context) error {
+		if !isFound {
+			return d.repo.Delete(ctx, adID)
+		}
+		ad, isFound := d.repo.Find(ctx, adID)
+		if !isFound {
+			ad = old
+		}
+		revert(&ad, old)
+		ad.UpdateDate = old.UpdateDate
+		return d.repo.Insert(ctx, ad)
+	})
+}
+
+func (d *journalRepo) Find(ctx context.Context, adID int64) (ads.Ad, bool) {
+	return d.repo.Find(ctx, adID)
+}
+
+func (d *journalRepo) GetByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
+	return d.repo.GetByTitle(ctx, title)
+}
+
+func (d *journalRepo) GetAllByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error) {
+	return d.repo.GetAllByTemplate(ctx, adp)
+}
+
+func (d *journalRepo) Add(ctx context.Context, title string, text string, userID int64) (int64, error) {
+	adID, err := d.repo.Add(ctx, title, text, userID)
+	if err != nil {
+		return adID, err
+	}
+	d.j.record(func(ctx context.Context) error {
+		return d.repo.Delete(ctx, adID)
+	})
+	return adID, nil
+}
+
+// Insert replaces the whole ad, so its undo puts the whole previous ad back.
+func (d *journalRepo) Insert(ctx context.Context, ad ads.Ad) error {
+	d.restore(ctx, ad.ID, func(ad *ads.Ad, old ads.Ad) {
+		*ad = old
+	})
+	return d.repo.Insert(ctx, ad)
+}
+
+func (d *journalRepo) Delete(ctx context.Context, adID int64) error {
+	if _, isFound := d.repo.Find(ctx, adID); isFound {
+		d.j.record(func(ctx context.Context) error {
+			return d.repo.Restore(ctx, adID)
+		})
+	}
+	return d.repo.Delete(ctx, adID)
+}
+
+func (d *journalRepo) DeleteByAuthor(ctx context.Context, userID int64) error {
+	list, err := d.repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: userID})
+	if err != nil {
+		return err
+	}
+	adIDs := []int64{}
+	for _, ad := range list {
+		if ad.AuthorID == userID {
+			adIDs = append(adIDs, ad.ID)
+		}
+	}
+	d.j.record(func(ctx context.Context) error {
+		for _, adID := range adIDs {
+			if err := d.repo.Restore(ctx, adID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return d.repo.DeleteByAuthor(ctx, userID)
+}
+
+func (d *journalRepo) FindDeleted(ctx context.Context, adID int64) (ads.Ad, bool) {
+	return d.repo.FindDeleted(ctx, adID)
+}
+
+func (d *journalRepo) ListDeleted(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	return d.repo.ListDeleted(ctx, userID)
+}
+
+// Restore is undone by deleting the ad again, so it stays in the trash longer.
+func (d *journalRepo) Restore(ctx context.Context, adID int64) error {
+	if _, isFound := d.repo.FindDeleted(ctx, adID); isFound {
+		d.j.record(func(ctx context.Context) error {
+			return d.repo.Delete(ctx, adID)
+		})
+	}
+	return d.repo.Restore(ctx, adID)
+}
+
+// Purge can't be rolled back, purged ads are gone for good.
+func (d *journalRepo) Purge(ctx context.Context, before time.Time) ([]int64, error) {
+	return d.repo.Purge(ctx, before)
+}
+
+func (d *journalRepo) SetTitle(ctx context.Context, adID int64, title string) error {
+	d.restore(ctx, adID, func(ad *ads.Ad, old ads.Ad) {
+		ad.Title = old.Title
+	})
+	return d.repo.SetTitle(ctx, adID, title)
+}
+
+func (d *journalRepo) SetText(ctx context.Context, adID int64, text string) error {
+	d.restore(ctx, adID, func(ad *ads.Ad, old ads.Ad) {
+		ad.Text = old.Text
+	})
+	return d.repo.SetText(ctx, adID, text)
+}
+
+func (d *journalRepo) SetStatus(ctx context.Context, adID int64, status bool) error {
+	d.restore(ctx, adID, func(ad *ads.Ad, old ads.Ad) {
+		ad.Published = old.Published
+	})
+	return d.repo.SetStatus(ctx, adID, status)
+}
+
+func (d *journalRepo) SetHidden(ctx context.Context, adID int64, hidden bool) error {
+	d.restore(ctx, adID, func(ad *ads.Ad, old ads.Ad) {
+		ad.Hidden = old.Hidden
+	})
+	return d.repo.SetHidden(ctx, adID, hidden)
+}
+
+func (d *journalRepo) SetLang(ctx context.Context, adID int64, lang string, declared bool) error {
+	d.restore(ctx, adID, func(ad *ads.Ad, old ads.Ad) {
+		ad.Lang, ad.LangDeclared = old.Lang, old.LangDeclared
+	})
+	return d.repo.SetLang(ctx, adID, lang, declared)
+}
+
+type journalUsers struct {
+	users Users
+	j     *journal
+}
+
+// restore records undo of a change of the user, setFields is called with the user
+// as it was. A user that didn't exist is deleted instead.
+func (d *journalUsers) restore(ctx context.Context, userID int64,
+	setFields func(ctx context.Context, old user.User) error) {
+	old, isFound := d.users.Find(ctx, userID)
+	d.j.record(func(ctx context.Context) error {
+		if !isFound {
+			_, err := d.users.DeleteByID(ctx, userID)
+			return err
+		}
+		return setFields(ctx, old)
+	})
+}
+
+// recreate puts the whole user back, for mutations replacing or removing all of it.
+func (d *journalUsers) recreate(ctx context.Context, old user.User) error {
+	if _, err := d.users.CreateByID(ctx, old.Nickname, old.Email, old.ID); err != nil {
+		return err
+	}
+	if old.Verified {
+		return d.users.SetVerified(ctx, old.ID, true)
+	}
+	return nil
+}
+
+func (d *journalUsers) Find(ctx context.Context, userID int64) (user.User, bool) {
+	return d.users.Find(ctx, userID)
+}
+
+func (d *journalUsers) FindByEmail(ctx context.Context, email string) (user.User, bool) {
+	return d.users.FindByEmail(ctx, email)
+}
+
+func (d *journalUsers) FindMany(ctx context.Context, userIDs []int64) (map[int64]user.User, error) {
+	return d.users.FindMany(ctx, userIDs)
+}
+
+func (d *journalUsers) CreateByID(ctx context.Context, nickname, email string, userID int64) (user.User, error) {
+	d.restore(ctx, userID, d.recreate)
+	return d.users.CreateByID(ctx, nickname, email, userID)
+}
+
+func (d *journalUsers) DeleteByID(ctx context.Context, userID int64) (user.User, error) {
+	d.restore(ctx, userID, d.recreate)
+	return d.users.DeleteByID(ctx, userID)
+}
+
+// ChangeInfo may reset the verification, so it is put back too.
+func (d *journalUsers) ChangeInfo(ctx context.Context, userID int64, nickname, email string) error {
+	d.restore(ctx, userID, func(ctx context.Context, old user.User) error {
+		if err := d.users.ChangeInfo(ctx, userID, old.Nickname, old.Email); err != nil {
+			return err
+		}
+		return d.users.SetVerified(ctx, userID, old.Verified)
+	})
+	return d.users.ChangeInfo(ctx, userID, nickname, email)
+}
+
+func (d *journalUsers) SetVerified(ctx context.Context, userID int64, verified bool) error {
+	d.restore(ctx, userID, func(ctx context.Context, old user.User) error {
+		return d.users.SetVerified(ctx, userID, old.Verified)
+	})
+	return d.users.SetVerified(ctx, userID, verified)
+}
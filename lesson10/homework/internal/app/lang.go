@@ -0,0 +1,48 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/langdetect"
+)
+
+// detectLang stores the language of the text of the ad unless its author declared one.
+func detectLang(ctx context.Context, repo Repository, ad ads.Ad, title string, text string) error {
+	if ad.LangDeclared {
+		return nil
+	}
+	lang := langdetect.Detect(title + "\n" + text)
+	if lang == ad.Lang {
+		return nil
+	}
+	return repo.SetLang(ctx, ad.ID, lang, false)
+}
+
+// SetAdLanguage declares the language of the ad, an empty lang makes it detected from the text again.
+func (d SimpleApp) SetAdLanguage(ctx context.Context, adID int64, userID int64, lang string) (ads.Ad, error) {
+	ad, isFound := d.repository.Find(ctx, adID)
+	if !isFound {
+		return ads.Ad{}, ErrWrongFormat
+	}
+	if ad.AuthorID != userID {
+		return ads.Ad{}, ErrNoAccess
+	}
+	declared := lang != ""
+	if declared {
+		code, err := langdetect.Parse(lang)
+		if err != nil {
+			return ads.Ad{}, fmt.Errorf("%w: %s", ErrWrongFormat, err.Error())
+		}
+		lang = code
+	} else {
+		lang = langdetect.Detect(ad.Title + "\n" + ad.Text)
+	}
+	if err := d.repository.SetLang(ctx, adID, lang, declared); err != nil {
+		return ads.Ad{}, ErrApp
+	}
+	next, _ := d.repository.Find(ctx, adID)
+	d.record(ctx, audit.UserActor(userID), "set_ad_lang", audit.Target{Kind: audit.KindAd, ID: adID}, ad, next)
+	return next, nil
+}
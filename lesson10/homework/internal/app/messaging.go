@@ -0,0 +1,300 @@
+package app
+
+import (
+	"context"
+	"github.com/danilabokhanov/strintvalidator"
+	"homework10/internal/audit"
+	"homework10/internal/message"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"sync"
+)
+
+const (
+	defaultMessagePage = 50
+	maxMessagePage     = 200
+	eventBufferSize    = 64
+)
+
+type Messages interface {
+	FindThread(ctx context.Context, threadID int64) (message.Thread, bool)
+	FindThreadByAd(ctx context.Context, adID int64, buyerID int64) (message.Thread, bool)
+	CreateThread(ctx context.Context, adID int64, buyerID int64, sellerID int64) (message.Thread, error)
+	ListThreads(ctx context.Context, userID int64) ([]message.Thread, error)
+	AddMessage(ctx context.Context, threadID int64, senderID int64, text string) (message.Message, error)
+	// ListMessages returns up to limit messages with ID less than beforeID (all if beforeID is 0)
+	// in ascending order, i.e. the latest page of the history before the cursor.
+	ListMessages(ctx context.Context, threadID int64, beforeID int64, limit int) ([]message.Message, error)
+	// MarkRead marks messages sent to readerID up to upToID as read and returns how many were marked.
+	MarkRead(ctx context.Context, threadID int64, readerID int64, upToID int64) (int, error)
+	DeleteByAd(ctx context.Context, adID int64) error
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+type Blocks interface {
+	Block(ctx context.Context, userID int64, blockedID int64) error
+	Unblock(ctx context.Context, userID int64, blockedID int64) error
+	// IsBlocked reports whether either of the users blocked the other one.
+	IsBlocked(ctx context.Context, first int64, second int64) (bool, error)
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+// subscriber is a user of a tenant, user IDs repeat across tenants.
+type subscriber struct {
+	tenant tenant.ID
+	userID int64
+}
+
+// broker fans thread events out to subscribers of this process.
+type broker struct {
+	mx     *sync.Mutex
+	subs   map[subscriber]map[int]chan message.Event
+	nextID int
+}
+
+func newBroker() *broker {
+	return &broker{mx: &sync.Mutex{}, subs: map[subscriber]map[int]chan message.Event{}}
+}
+
+func (d *broker) subscribe(tenantID tenant.ID, userID int64) (<-chan message.Event, func()) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	id := d.nextID
+	d.nextID++
+	ch := make(chan message.Event, eventBufferSize)
+	key := subscriber{tenant: tenantID, userID: userID}
+	if d.subs[key] == nil {
+		d.subs[key] = map[int]chan message.Event{}
+	}
+	d.subs[key][id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			d.mx.Lock()
+			defer d.mx.Unlock()
+			delete(d.subs[key], id)
+			if len(d.subs[key]) == 0 {
+				delete(d.subs, key)
+			}
+			close(ch)
+		})
+	}
+}
+
+// publish never blocks: a subscriber that doesn't keep up loses events
+// and has to fetch the history instead.
+func (d *broker) publish(tenantID tenant.ID, e message.Event, userIDs ...int64) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for _, userID := range userIDs {
+		for _, ch := range d.subs[subscriber{tenant: tenantID, userID: userID}] {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
+
+func WithMessages(m Messages, b Blocks) Option {
+	return func(d *SimpleApp) {
+		d.messages = m
+		d.blocks = b
+	}
+}
+
+func (d SimpleApp) blocked(ctx context.Context, first int64, second int64) (bool, error) {
+	if d.blocks == nil {
+		return false, nil
+	}
+	return d.blocks.IsBlocked(ctx, first, second)
+}
+
+// OpenThread returns the buyer's thread about the ad, creating it if needed.
+func (d SimpleApp) OpenThread(ctx context.Context, adID int64, buyerID int64) (message.Thread, error) {
+	if d.messages == nil {
+		return message.Thread{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, buyerID)
+	if !isFound {
+		return message.Thread{}, ErrWrongFormat
+	}
+	ad, isFound := d.repository.Find(ctx, adID)
+	if !isFound || ad.AuthorID == buyerID {
+		return message.Thread{}, ErrWrongFormat
+	}
+	isBlocked, err := d.blocked(ctx, buyerID, ad.AuthorID)
+	if err != nil {
+		return message.Thread{}, ErrApp
+	}
+	if isBlocked {
+		return message.Thread{}, ErrNoAccess
+	}
+	if t, isFound := d.messages.FindThreadByAd(ctx, adID, buyerID); isFound {
+		return t, nil
+	}
+	t, err := d.messages.CreateThread(ctx, adID, buyerID, ad.AuthorID)
+	if err != nil {
+		return message.Thread{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(buyerID), "open_thread", audit.Target{Kind: audit.KindThread, ID: t.ID}, nil, t)
+	return t, nil
+}
+
+// ListThreads returns threads of the user, threads with users blocked by either side are hidden.
+func (d SimpleApp) ListThreads(ctx context.Context, userID int64) ([]message.Thread, error) {
+	if d.messages == nil {
+		return []message.Thread{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return []message.Thread{}, ErrWrongFormat
+	}
+	list, err := d.messages.ListThreads(ctx, userID)
+	if err != nil {
+		return []message.Thread{}, ErrApp
+	}
+	res := []message.Thread{}
+	for _, t := range list {
+		// threads of ads in the trash are hidden until the ad is restored
+		if _, isFound := d.repository.Find(ctx, t.AdID); !isFound {
+			continue
+		}
+		isBlocked, err := d.blocked(ctx, userID, t.Peer(userID))
+		if err != nil {
+			return []message.Thread{}, ErrApp
+		}
+		if !isBlocked {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (d SimpleApp) memberThread(ctx context.Context, threadID int64, userID int64) (message.Thread, error) {
+	if d.messages == nil {
+		return message.Thread{}, ErrApp
+	}
+	t, isFound := d.messages.FindThread(ctx, threadID)
+	if !isFound {
+		return message.Thread{}, ErrWrongFormat
+	}
+	if _, isFound := d.repository.Find(ctx, t.AdID); !isFound {
+		return message.Thread{}, ErrWrongFormat
+	}
+	if !t.HasMember(userID) {
+		return message.Thread{}, ErrNoAccess
+	}
+	return t, nil
+}
+
+func (d SimpleApp) SendMessage(ctx context.Context, threadID int64, senderID int64, text string) (message.Message, error) {
+	if e := strintvalidator.Validate(message.Message{Text: text}); e != nil {
+		return message.Message{}, ErrWrongFormat
+	}
+	t, err := d.memberThread(ctx, threadID, senderID)
+	if err != nil {
+		return message.Message{}, err
+	}
+	isBlocked, err := d.blocked(ctx, t.BuyerID, t.SellerID)
+	if err != nil {
+		return message.Message{}, ErrApp
+	}
+	if isBlocked {
+		return message.Message{}, ErrNoAccess
+	}
+	m, err := d.messages.AddMessage(ctx, threadID, senderID, text)
+	if err != nil {
+		return message.Message{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(senderID), "send_message", audit.Target{Kind: audit.KindMessage, ID: m.ID}, nil, m)
+	d.broker.publish(tenant.FromContext(ctx), message.Event{Type: message.EventMessage, ThreadID: threadID, Message: m},
+		t.BuyerID, t.SellerID)
+	return m, nil
+}
+
+// GetMessages returns a page of the thread history, see Messages.ListMessages.
+func (d SimpleApp) GetMessages(ctx context.Context, threadID int64, userID int64, beforeID int64, limit int) ([]message.Message, error) {
+	if limit < 0 || limit > maxMessagePage {
+		return []message.Message{}, ErrWrongFormat
+	}
+	if limit == 0 {
+		limit = defaultMessagePage
+	}
+	if _, err := d.memberThread(ctx, threadID, userID); err != nil {
+		return []message.Message{}, err
+	}
+	res, err := d.messages.ListMessages(ctx, threadID, beforeID, limit)
+	if err != nil {
+		return []message.Message{}, ErrApp
+	}
+	return res, nil
+}
+
+func (d SimpleApp) MarkRead(ctx context.Context, threadID int64, userID int64, upToID int64) (int, error) {
+	t, err := d.memberThread(ctx, threadID, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := d.messages.MarkRead(ctx, threadID, userID, upToID)
+	if err != nil {
+		return 0, ErrApp
+	}
+	if n > 0 {
+		d.record(ctx, audit.UserActor(userID), "mark_read", audit.Target{Kind: audit.KindThread, ID: threadID}, nil, nil)
+		d.broker.publish(tenant.FromContext(ctx), message.Event{Type: message.EventRead, ThreadID: threadID, ReaderID: userID,
+			UpToID: upToID}, t.BuyerID, t.SellerID)
+	}
+	return n, nil
+}
+
+// SubscribeMessages delivers events of all threads of the user until ctx is done,
+// then the channel is closed.
+func (d SimpleApp) SubscribeMessages(ctx context.Context, userID int64) (<-chan message.Event, error) {
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return nil, ErrWrongFormat
+	}
+	ch, cancel := d.broker.subscribe(tenant.FromContext(ctx), userID)
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+	return ch, nil
+}
+
+func (d SimpleApp) BlockUser(ctx context.Context, userID int64, blockedID int64) (user.User, error) {
+	if d.blocks == nil {
+		return user.User{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return user.User{}, ErrWrongFormat
+	}
+	u, isFound := d.users.Find(ctx, blockedID)
+	if !isFound || userID == blockedID {
+		return user.User{}, ErrWrongFormat
+	}
+	if err := d.blocks.Block(ctx, userID, blockedID); err != nil {
+		return user.User{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(userID), "block_user", audit.Target{Kind: audit.KindUser, ID: blockedID}, nil, nil)
+	return u, nil
+}
+
+func (d SimpleApp) UnblockUser(ctx context.Context, userID int64, blockedID int64) (user.User, error) {
+	if d.blocks == nil {
+		return user.User{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return user.User{}, ErrWrongFormat
+	}
+	u, _ := d.users.Find(ctx, blockedID)
+	if err := d.blocks.Unblock(ctx, userID, blockedID); err != nil {
+		return user.User{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(userID), "unblock_user", audit.Target{Kind: audit.KindUser, ID: blockedID}, nil, nil)
+	return u, nil
+}
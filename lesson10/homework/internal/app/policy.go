@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/policy"
+	"homework10/internal/report"
+	"homework10/internal/tenant"
+	"log"
+	"time"
+)
+
+// ContentPolicy checks ads before CreateAd and UpdateAd store them.
+type ContentPolicy interface {
+	Check(ctx context.Context, c policy.Content) (policy.Verdict, error)
+}
+
+// WithContentPolicy rejects ads violating rules of p with severity policy.Reject. Ads violating
+// rules with severity policy.Flag are stored and reported to moderators, if reports are enabled.
+func WithContentPolicy(p ContentPolicy) Option {
+	return func(d *SimpleApp) {
+		d.contentPolicy = p
+	}
+}
+
+// checkContent returns the verdict on the ad, or ErrWrongFormat with the reasons if it is rejected.
+// current is the ad being updated, nil for new ones.
+func (d SimpleApp) checkContent(ctx context.Context, current *ads.Ad, userID int64, title string,
+	text string) (policy.Verdict, error) {
+	if d.contentPolicy == nil {
+		return policy.Verdict{}, nil
+	}
+	c := policy.Content{AuthorID: userID, Title: title, Text: text,
+		Siblings: func() ([]ads.Ad, error) {
+			list, err := d.repository.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: userID})
+			if err != nil {
+				return nil, err
+			}
+			res := make([]ads.Ad, 0, len(list))
+			for _, ad := range list {
+				if current == nil || ad.ID != current.ID {
+					res = append(res, ad)
+				}
+			}
+			return res, nil
+		}}
+	verdict, err := d.contentPolicy.Check(ctx, c)
+	if err != nil {
+		return policy.Verdict{}, ErrApp
+	}
+	if verdict.Rejected() {
+		return verdict, fmt.Errorf("%w: %s", ErrWrongFormat, verdict.Reasons(policy.Reject))
+	}
+	return verdict, nil
+}
+
+// flagContent puts a stored ad the verdict flags into the moderation queue, failures are logged.
+func (d SimpleApp) flagContent(ctx context.Context, ad ads.Ad, verdict policy.Verdict) {
+	if !verdict.Flagged() {
+		return
+	}
+	reasons := verdict.Reasons(policy.Flag)
+	d.record(ctx, audit.System, "flag_ad", audit.Target{Kind: audit.KindAd, ID: ad.ID}, nil, reasons)
+	if d.reports == nil {
+		return
+	}
+	_, isNew, err := d.reports.Add(ctx, report.Report{Tenant: tenant.FromContext(ctx), AdID: ad.ID,
+		ReporterID: report.SystemReporter, Reason: report.ReasonOther, Comment: truncate(reasons, report.MaxCommentLen),
+		Status: report.StatusOpen, CreationDate: time.Now().UTC()})
+	if err != nil {
+		log.Printf("can't flag ad %d: %s", ad.ID, err.Error())
+		return
+	}
+	if isNew {
+		if err := d.updateVisibility(ctx, ad); err != nil {
+			log.Printf("can't update visibility of ad %d: %s", ad.ID, err.Error())
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"context"
+	"github.com/danilabokhanov/strintvalidator"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/report"
+	"homework10/internal/tenant"
+	"time"
+)
+
+// Reports keeps reports of all tenants, Add stores the report in its Tenant.
+type Reports interface {
+	// Add stores the report with its ID set, unless the reporter has already reported the ad.
+	// Then the stored report is returned and isNew is false.
+	Add(ctx context.Context, r report.Report) (res report.Report, isNew bool, err error)
+	Find(ctx context.Context, reportID int64) (report.Report, bool)
+	// List returns reports with the status, oldest first. An empty status matches all reports.
+	List(ctx context.Context, status report.Status) ([]report.Report, error)
+	ListByAd(ctx context.Context, adID int64) ([]report.Report, error)
+	SetStatus(ctx context.Context, reportID int64, status report.Status, date time.Time) error
+}
+
+// WithReports lets users report ads and admins moderate them. An ad is hidden from
+// published listings as soon as it has hideThreshold open reports, 0 disables that.
+// ReportAd trusts the reporter ID like the rest of the API, so with a threshold anyone
+// able to reach the API can hide an ad by reporting it on behalf of several users.
+func WithReports(r Reports, hideThreshold int) Option {
+	return func(d *SimpleApp) {
+		d.reports = r
+		d.hideThreshold = hideThreshold
+	}
+}
+
+// ReportAd files a report about the ad, reporting the same ad again returns the first report.
+func (d SimpleApp) ReportAd(ctx context.Context, adID int64, reporterID int64, reason report.Reason,
+	comment string) (report.Report, error) {
+	if d.reports == nil {
+		return report.Report{}, ErrApp
+	}
+	if _, err := report.ParseReason(string(reason)); err != nil {
+		return report.Report{}, ErrWrongFormat
+	}
+	if e := strintvalidator.Validate(report.Report{Comment: comment}); e != nil {
+		return report.Report{}, ErrWrongFormat
+	}
+	if _, isFound := d.users.Find(ctx, reporterID); !isFound {
+		return report.Report{}, ErrWrongFormat
+	}
+	ad, isFound := d.repository.Find(ctx, adID)
+	if !isFound {
+		return report.Report{}, ErrWrongFormat
+	}
+	if ad.AuthorID == reporterID {
+		return report.Report{}, ErrNoAccess
+	}
+
+	res, isNew, err := d.reports.Add(ctx, report.Report{Tenant: tenant.FromContext(ctx), AdID: adID,
+		ReporterID: reporterID, Reason: reason, Comment: comment, Status: report.StatusOpen,
+		CreationDate: time.Now().UTC()})
+	if err != nil {
+		return report.Report{}, ErrApp
+	}
+	if !isNew {
+		return res, nil
+	}
+	d.record(ctx, audit.UserActor(reporterID), "report_ad", audit.Target{Kind: audit.KindReport, ID: res.ID}, nil, res)
+	if err := d.updateVisibility(ctx, ad); err != nil {
+		return report.Report{}, err
+	}
+	return res, nil
+}
+
+func (d SimpleApp) ListReports(ctx context.Context, status report.Status) ([]report.Report, error) {
+	if d.reports == nil {
+		return []report.Report{}, ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return []report.Report{}, ErrNoAccess
+	}
+	if status != "" {
+		if _, err := report.ParseStatus(string(status)); err != nil {
+			return []report.Report{}, ErrWrongFormat
+		}
+	}
+	res, err := d.reports.List(ctx, status)
+	if err != nil {
+		return []report.Report{}, ErrApp
+	}
+	return res, nil
+}
+
+// ResolveReport confirms the report and hides the ad, other open reports about the ad are resolved too.
+func (d SimpleApp) ResolveReport(ctx context.Context, reportID int64) (report.Report, error) {
+	return d.closeReport(ctx, reportID, report.StatusResolved, "resolve_report")
+}
+
+// DismissReport rejects the report, the ad is shown again unless it is still hidden for other reports.
+func (d SimpleApp) DismissReport(ctx context.Context, reportID int64) (report.Report, error) {
+	return d.closeReport(ctx, reportID, report.StatusDismissed, "dismiss_report")
+}
+
+func (d SimpleApp) closeReport(ctx context.Context, reportID int64, status report.Status,
+	action string) (report.Report, error) {
+	if d.reports == nil {
+		return report.Report{}, ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return report.Report{}, ErrNoAccess
+	}
+	old, isFound := d.reports.Find(ctx, reportID)
+	if !isFound {
+		return report.Report{}, ErrWrongFormat
+	}
+	if !old.IsOpen() {
+		return report.Report{}, ErrConflict
+	}
+
+	closing := []report.Report{old}
+	if status == report.StatusResolved {
+		list, err := d.reports.ListByAd(ctx, old.AdID)
+		if err != nil {
+			return report.Report{}, ErrApp
+		}
+		for _, r := range list {
+			if r.IsOpen() && r.ID != old.ID {
+				closing = append(closing, r)
+			}
+		}
+	}
+	now := time.Now().UTC()
+	var res report.Report
+	for i, r := range closing {
+		if err := d.reports.SetStatus(ctx, r.ID, status, now); err != nil {
+			return report.Report{}, ErrApp
+		}
+		next := r
+		next.Status = status
+		next.ResolutionDate = now
+		d.record(ctx, audit.Admin, action, audit.Target{Kind: audit.KindReport, ID: r.ID}, r, next)
+		if i == 0 {
+			res = next
+		}
+	}
+
+	if ad, isFound := d.repository.Find(ctx, old.AdID); isFound {
+		if err := d.updateVisibility(ctx, ad); err != nil {
+			return report.Report{}, err
+		}
+	}
+	return res, nil
+}
+
+// updateVisibility hides the ad if a report about it was resolved or it has too many open reports,
+// and shows it again otherwise.
+func (d SimpleApp) updateVisibility(ctx context.Context, ad ads.Ad) error {
+	list, err := d.reports.ListByAd(ctx, ad.ID)
+	if err != nil {
+		return ErrApp
+	}
+	open, resolved := 0, 0
+	for _, r := range list {
+		switch r.Status {
+		case report.StatusOpen:
+			open++
+		case report.StatusResolved:
+			resolved++
+		}
+	}
+	hidden := resolved > 0 || d.hideThreshold > 0 && open >= d.hideThreshold
+	if hidden == ad.Hidden {
+		return nil
+	}
+	if err := d.repository.SetHidden(ctx, ad.ID, hidden); err != nil {
+		return ErrApp
+	}
+	next, _ := d.repository.Find(ctx, ad.ID)
+	action := "show_ad"
+	if hidden {
+		action = "hide_ad"
+	}
+	d.record(ctx, audit.System, action, audit.Target{Kind: audit.KindAd, ID: ad.ID}, ad, next)
+	return nil
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/analytics"
+	"homework10/internal/tenant"
+	"log"
+	"time"
+)
+
+// MaxStatsBuckets limits the size of reports of GetUserStats.
+const MaxStatsBuckets = 1000
+
+// Stats keeps view counts aggregated by hour and first publications of ads.
+type Stats interface {
+	// AddViews adds the counts to the stored ones of the same ad and hour.
+	AddViews(ctx context.Context, counts []analytics.ViewCount) error
+	ViewCounts(ctx context.Context, adIDs []int64, from, to time.Time) ([]analytics.ViewCount, error)
+	// AddPublication does nothing if the ad has been published before.
+	AddPublication(ctx context.Context, p analytics.Publication) error
+	Publications(ctx context.Context, adIDs []int64) ([]analytics.Publication, error)
+}
+
+// ViewCounter aggregates views in the background and adds them to Stats, Record never blocks.
+type ViewCounter interface {
+	Record(v analytics.View)
+}
+
+// WithStats makes FindAd count views by viewers put in the context with analytics.NewContext,
+// and enables GetUserStats.
+func WithStats(s Stats, c ViewCounter) Option {
+	return func(d *SimpleApp) {
+		d.stats = s
+		d.viewCounter = c
+	}
+}
+
+// recordView counts the read of the ad as a view if ctx carries a viewer.
+func (d SimpleApp) recordView(ctx context.Context, ad ads.Ad) {
+	if d.viewCounter == nil {
+		return
+	}
+	viewer := analytics.FromContext(ctx)
+	if viewer == "" {
+		return
+	}
+	d.viewCounter.Record(analytics.View{Tenant: tenant.FromContext(ctx), AdID: ad.ID, Viewer: viewer,
+		Time: time.Now().UTC()})
+}
+
+// recordPublication keeps the time the ad was published first. Stats are best effort,
+// errors are only logged.
+func (d SimpleApp) recordPublication(ctx context.Context, ad ads.Ad) {
+	if d.stats == nil {
+		return
+	}
+	err := d.stats.AddPublication(ctx, analytics.Publication{Tenant: tenant.FromContext(ctx), AdID: ad.ID,
+		CreationDate: ad.CreationDate, PublicationDate: time.Now().UTC()})
+	if err != nil {
+		log.Printf("can't record publication of ad %d: %s", ad.ID, err.Error())
+	}
+}
+
+// GetUserStats reports views of ads of the user, created ads and their time to publish
+// from from until to in buckets of step. Ads in the trash are left out.
+func (d SimpleApp) GetUserStats(ctx context.Context, userID int64, from, to time.Time, step time.Duration) (analytics.Report, error) {
+	if d.stats == nil {
+		return analytics.Report{}, ErrApp
+	}
+	// views are stored by hour, so buckets must consist of whole hours
+	from = from.UTC().Truncate(time.Hour)
+	to = to.UTC()
+	if step < time.Hour || step%time.Hour != 0 || !from.Before(to) || (to.Sub(from)+step-1)/step > MaxStatsBuckets {
+		return analytics.Report{}, ErrWrongFormat
+	}
+	if _, isFound := d.users.Find(ctx, userID); !isFound {
+		return analytics.Report{}, ErrWrongFormat
+	}
+	list, err := d.repository.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: userID})
+	if err != nil {
+		return analytics.Report{}, ErrApp
+	}
+
+	res := analytics.Report{UserID: userID, From: from, To: to, Step: step}
+	for start := from; start.Before(to); start = start.Add(step) {
+		res.Buckets = append(res.Buckets, analytics.Bucket{Start: start})
+	}
+	adIDs := make([]int64, 0, len(list))
+	for _, ad := range list {
+		adIDs = append(adIDs, ad.ID)
+		if i := res.Index(ad.CreationDate); i >= 0 {
+			res.Buckets[i].AdsCreated++
+		}
+	}
+	if len(adIDs) == 0 {
+		return res, nil
+	}
+
+	counts, err := d.stats.ViewCounts(ctx, adIDs, from, to)
+	if err != nil {
+		return analytics.Report{}, ErrApp
+	}
+	for _, c := range counts {
+		if i := res.Index(c.Hour); i >= 0 {
+			res.Buckets[i].Views += c.Views
+		}
+	}
+	publications, err := d.stats.Publications(ctx, adIDs)
+	if err != nil {
+		return analytics.Report{}, ErrApp
+	}
+	total := make([]time.Duration, len(res.Buckets))
+	for _, p := range publications {
+		if i := res.Index(p.PublicationDate); i >= 0 {
+			res.Buckets[i].Published++
+			total[i] += p.TimeToPublish()
+		}
+	}
+	for i := range res.Buckets {
+		if n := res.Buckets[i].Published; n > 0 {
+			res.Buckets[i].TimeToPublish = total[i] / time.Duration(n)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/notification"
+	"homework10/internal/search"
+	"log"
+)
+
+type Favorites interface {
+	Add(ctx context.Context, userID int64, adID int64) error
+	Remove(ctx context.Context, userID int64, adID int64) error
+	List(ctx context.Context, userID int64) ([]int64, error)
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+// SavedSearches stores search patterns of users. Implementations keep patterns
+// serialized with adpattern.Marshal.
+type SavedSearches interface {
+	Add(ctx context.Context, s search.SavedSearch) (search.SavedSearch, error)
+	Find(ctx context.Context, searchID int64) (search.SavedSearch, bool)
+	Delete(ctx context.Context, searchID int64) error
+	ListByUser(ctx context.Context, userID int64) ([]search.SavedSearch, error)
+	DeleteByUser(ctx context.Context, userID int64) error
+	// All returns saved searches of the tenant of ctx.
+	All(ctx context.Context) ([]search.SavedSearch, error)
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, n notification.Notification) error
+}
+
+func (d SimpleApp) AddFavorite(ctx context.Context, userID int64, adID int64) (ads.Ad, error) {
+	if d.favorites == nil {
+		return ads.Ad{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return ads.Ad{}, ErrWrongFormat
+	}
+	ad, isFound := d.repository.Find(ctx, adID)
+	if !isFound {
+		return ads.Ad{}, ErrWrongFormat
+	}
+	if err := d.favorites.Add(ctx, userID, adID); err != nil {
+		return ads.Ad{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(userID), "add_favorite", audit.Target{Kind: audit.KindAd, ID: adID}, nil, nil)
+	return ad, nil
+}
+
+func (d SimpleApp) RemoveFavorite(ctx context.Context, userID int64, adID int64) (ads.Ad, error) {
+	if d.favorites == nil {
+		return ads.Ad{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return ads.Ad{}, ErrWrongFormat
+	}
+	ad, _ := d.repository.Find(ctx, adID)
+	if err := d.favorites.Remove(ctx, userID, adID); err != nil {
+		return ads.Ad{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(userID), "remove_favorite", audit.Target{Kind: audit.KindAd, ID: adID}, nil, nil)
+	return ad, nil
+}
+
+// ListFavorites returns favorite ads of the user, ads deleted since they were added are skipped.
+func (d SimpleApp) ListFavorites(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	if d.favorites == nil {
+		return []ads.Ad{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return []ads.Ad{}, ErrWrongFormat
+	}
+	adIDs, err := d.favorites.List(ctx, userID)
+	if err != nil {
+		return []ads.Ad{}, ErrApp
+	}
+	res := []ads.Ad{}
+	for _, adID := range adIDs {
+		if ad, isFound := d.repository.Find(ctx, adID); isFound {
+			res = append(res, ad)
+		}
+	}
+	return res, nil
+}
+
+func (d SimpleApp) SaveSearch(ctx context.Context, userID int64, name string, adp adpattern.AdPattern) (search.SavedSearch, error) {
+	if d.searches == nil {
+		return search.SavedSearch{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return search.SavedSearch{}, ErrWrongFormat
+	}
+	if adp.IsLTimeSet && adp.IsRTimeSet && adp.LDate.After(adp.RDate) {
+		return search.SavedSearch{}, ErrWrongFormat
+	}
+	s, err := d.searches.Add(ctx, search.SavedSearch{UserID: userID, Name: name, Pattern: adp})
+	if err != nil {
+		return search.SavedSearch{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(userID), "save_search", audit.Target{Kind: audit.KindSearch, ID: s.ID}, nil, s)
+	return s, nil
+}
+
+func (d SimpleApp) ListSavedSearches(ctx context.Context, userID int64) ([]search.SavedSearch, error) {
+	if d.searches == nil {
+		return []search.SavedSearch{}, ErrApp
+	}
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return []search.SavedSearch{}, ErrWrongFormat
+	}
+	res, err := d.searches.ListByUser(ctx, userID)
+	if err != nil {
+		return []search.SavedSearch{}, ErrApp
+	}
+	return res, nil
+}
+
+func (d SimpleApp) DeleteSavedSearch(ctx context.Context, userID int64, searchID int64) (search.SavedSearch, error) {
+	if d.searches == nil {
+		return search.SavedSearch{}, ErrApp
+	}
+	s, isFound := d.searches.Find(ctx, searchID)
+	if !isFound {
+		return search.SavedSearch{}, ErrWrongFormat
+	}
+	if s.UserID != userID {
+		return search.SavedSearch{}, ErrNoAccess
+	}
+	if err := d.searches.Delete(ctx, searchID); err != nil {
+		return search.SavedSearch{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(userID), "delete_search", audit.Target{Kind: audit.KindSearch, ID: searchID}, s, nil)
+	return s, nil
+}
+
+// notifySearches runs a newly published ad against all saved searches and notifies
+// their owners, except the author of the ad. The ad is already published, so failures are logged.
+func (d SimpleApp) notifySearches(ctx context.Context, ad ads.Ad) {
+	if d.searches == nil || d.notifier == nil {
+		return
+	}
+	list, err := d.searches.All(ctx)
+	if err != nil {
+		log.Printf("can't list saved searches for ad %d: %s", ad.ID, err.Error())
+		return
+	}
+	for _, s := range list {
+		if s.UserID == ad.AuthorID || !CheckAd(ad, s.Pattern) {
+			continue
+		}
+		err := d.notifier.Notify(ctx, notification.Notification{UserID: s.UserID, SearchID: s.ID,
+			AdID: ad.ID, Title: ad.Title, CreationDate: ad.CreationDate})
+		if err != nil {
+			log.Printf("can't notify user %d of ad %d: %s", s.UserID, ad.ID, err.Error())
+		}
+	}
+}
+
+// forgetUser drops favorites, saved searches, conversations, blocks, verification tokens,
+// credentials and sessions of a deleted user from stores, stopping at the first failure.
+func (d SimpleApp) forgetUser(ctx context.Context, userID int64, stores AccountStores) error {
+	if stores.Sessions != nil {
+		if err := stores.Sessions.DeleteByUser(ctx, userID); err != nil {
+			return fmt.Errorf("sessions: %w", err)
+		}
+	}
+	if stores.Credentials != nil {
+		if err := stores.Credentials.DeleteByUser(ctx, userID); err != nil {
+			return fmt.Errorf("credentials: %w", err)
+		}
+	}
+	if stores.Tokens != nil {
+		if err := stores.Tokens.DeleteByUser(ctx, userID); err != nil {
+			return fmt.Errorf("verification tokens: %w", err)
+		}
+	}
+	if stores.Messages != nil {
+		if err := stores.Messages.DeleteByUser(ctx, userID); err != nil {
+			return fmt.Errorf("conversations: %w", err)
+		}
+	}
+	if stores.Blocks != nil {
+		if err := stores.Blocks.DeleteByUser(ctx, userID); err != nil {
+			return fmt.Errorf("blocks: %w", err)
+		}
+	}
+	if stores.Favorites != nil {
+		if err := stores.Favorites.DeleteByUser(ctx, userID); err != nil {
+			return fmt.Errorf("favorites: %w", err)
+		}
+	}
+	if stores.Searches != nil {
+		if err := stores.Searches.DeleteByUser(ctx, userID); err != nil {
+			return fmt.Errorf("saved searches: %w", err)
+		}
+	}
+	return nil
+}
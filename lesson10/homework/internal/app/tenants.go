@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/tenant"
+)
+
+// WithTenants serves several tenants from one process, requests pick their tenant with ResolveTenant.
+// Every store scopes its operations to tenant.FromContext, records created by the app carry
+// the same tenant and background workers pass tenant.NewContext of the record they handle.
+func WithTenants(r *tenant.Registry) Option {
+	return func(d *SimpleApp) {
+		d.tenants = r
+	}
+}
+
+// ResolveTenant returns the tenant of a request which named the requested one, empty if none,
+// and was sent to host. Without WithTenants every request belongs to tenant.Default.
+func (d SimpleApp) ResolveTenant(ctx context.Context, requested string, host string) (tenant.ID, error) {
+	if d.tenants == nil {
+		return tenant.Default, nil
+	}
+	id, err := d.tenants.Resolve(requested, host)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrWrongFormat, err.Error())
+	}
+	return id, nil
+}
+
+// checkLimits applies validation limits of the tenant of ctx to an ad.
+func (d SimpleApp) checkLimits(ctx context.Context, title string, text string) error {
+	if d.tenants == nil {
+		return nil
+	}
+	c, _ := d.tenants.Config(tenant.FromContext(ctx))
+	if err := c.Check(title, text); err != nil {
+		return fmt.Errorf("%w: %s", ErrWrongFormat, err.Error())
+	}
+	return nil
+}
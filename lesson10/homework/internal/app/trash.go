@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"log"
+	"time"
+)
+
+// DefaultRetention is how long deleted ads stay in the trash before they are purged.
+const DefaultRetention = 30 * 24 * time.Hour
+
+func WithRetention(retention time.Duration) Option {
+	return func(d *SimpleApp) {
+		d.retention = retention
+	}
+}
+
+func (d SimpleApp) isExpired(ad ads.Ad, now time.Time) bool {
+	return !ad.DeletionDate.Add(d.retention).After(now)
+}
+
+// ListTrash returns deleted ads of the user which can still be restored, the most recently deleted go first.
+func (d SimpleApp) ListTrash(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return []ads.Ad{}, ErrWrongFormat
+	}
+	list, err := d.repository.ListDeleted(ctx, userID)
+	if err != nil {
+		return []ads.Ad{}, ErrApp
+	}
+	now := time.Now().UTC()
+	res := []ads.Ad{}
+	for _, ad := range list {
+		if !d.isExpired(ad, now) {
+			res = append(res, ad)
+		}
+	}
+	return res, nil
+}
+
+func (d SimpleApp) RestoreAd(ctx context.Context, adID int64, userID int64) (ads.Ad, error) {
+	_, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return ads.Ad{}, ErrWrongFormat
+	}
+	ad, isFound := d.repository.FindDeleted(ctx, adID)
+	if !isFound || d.isExpired(ad, time.Now().UTC()) {
+		return ads.Ad{}, ErrWrongFormat
+	}
+	if ad.AuthorID != userID {
+		return ads.Ad{}, ErrNoAccess
+	}
+	if err := d.repository.Restore(ctx, adID); err != nil {
+		return ads.Ad{}, ErrApp
+	}
+	before := ad
+	ad.DeletionDate = time.Time{}
+	d.record(ctx, audit.UserActor(userID), "restore_ad", audit.Target{Kind: audit.KindAd, ID: adID}, before, ad)
+	return ad, nil
+}
+
+// PurgeTrash permanently removes ads which stayed in the trash longer than the retention
+// together with their conversations and external IDs, and returns the number of removed ads.
+func (d SimpleApp) PurgeTrash(ctx context.Context) (int, error) {
+	purged, err := d.repository.Purge(ctx, time.Now().UTC().Add(-d.retention))
+	for _, adID := range purged {
+		if d.messages != nil {
+			if err := d.messages.DeleteByAd(ctx, adID); err != nil {
+				log.Printf("can't delete conversations of purged ad %d: %s", adID, err.Error())
+			}
+		}
+		if d.externalIDs != nil {
+			if err := d.externalIDs.DeleteByAd(ctx, adID); err != nil {
+				log.Printf("can't delete external ID of purged ad %d: %s", adID, err.Error())
+			}
+		}
+		d.record(ctx, audit.System, "purge_ad", audit.Target{Kind: audit.KindAd, ID: adID}, nil, nil)
+	}
+	if err != nil {
+		return len(purged), ErrApp
+	}
+	return len(purged), nil
+}
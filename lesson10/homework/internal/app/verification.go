@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"homework10/internal/audit"
+	"homework10/internal/email"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"homework10/internal/verification"
+	"log"
+	"time"
+)
+
+const (
+	defaultVerificationTTL = 24 * time.Hour
+	tokenSize              = 16
+)
+
+type VerificationTokens interface {
+	Add(ctx context.Context, t verification.Token) error
+	// Take returns the token and removes it, so every token can be used only once. Tokens of other tenants aren't found.
+	Take(ctx context.Context, value string) (verification.Token, bool)
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+type Mailer interface {
+	Send(ctx context.Context, m email.Message) error
+}
+
+// WithVerification makes users confirm their email: a token is mailed to every new user and
+// to users who change their email, unverified users can't publish ads. ttl <= 0 means a day.
+func WithVerification(t VerificationTokens, m Mailer, ttl time.Duration) Option {
+	return func(d *SimpleApp) {
+		if ttl <= 0 {
+			ttl = defaultVerificationTTL
+		}
+		d.tokens = t
+		d.mailer = m
+		d.verificationTTL = ttl
+	}
+}
+
+func (d SimpleApp) verificationEnabled() bool {
+	return d.tokens != nil && d.mailer != nil
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// sendVerification mails a new token to the user, previously issued tokens stop working.
+func (d SimpleApp) sendVerification(ctx context.Context, u user.User) error {
+	value, err := newToken()
+	if err != nil {
+		return err
+	}
+	t := verification.Token{Value: value, Tenant: tenant.FromContext(ctx), UserID: u.ID, Email: u.Email,
+		ExpirationDate: time.Now().UTC().Add(d.verificationTTL)}
+	if err := d.tokens.DeleteByUser(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := d.tokens.Add(ctx, t); err != nil {
+		return err
+	}
+	return d.mailer.Send(ctx, email.Message{To: u.Email, Subject: "Email verification",
+		Body: fmt.Sprintf("Your verification token: %s\nIt expires at %s.\n", value,
+			t.ExpirationDate.Format(time.RFC1123))})
+}
+
+// verifyEmail sends a token to a new or changed email if verification is enabled.
+// The user can request another token, so a failure is only logged.
+func (d SimpleApp) verifyEmail(ctx context.Context, u user.User) {
+	if !d.verificationEnabled() {
+		return
+	}
+	if err := d.sendVerification(ctx, u); err != nil {
+		log.Printf("can't send verification token to user %d: %s", u.ID, err.Error())
+	}
+}
+
+// RequestVerification mails a new verification token, it does nothing for verified users.
+func (d SimpleApp) RequestVerification(ctx context.Context, userID int64) (user.User, error) {
+	if !d.verificationEnabled() {
+		return user.User{}, ErrApp
+	}
+	u, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return user.User{}, ErrWrongFormat
+	}
+	if u.Verified {
+		return u, nil
+	}
+	if err := d.sendVerification(ctx, u); err != nil {
+		return user.User{}, ErrApp
+	}
+	d.record(ctx, audit.UserActor(userID), "request_verification", audit.Target{Kind: audit.KindUser, ID: userID}, nil, nil)
+	return u, nil
+}
+
+func (d SimpleApp) VerifyEmail(ctx context.Context, userID int64, token string) (user.User, error) {
+	if !d.verificationEnabled() {
+		return user.User{}, ErrApp
+	}
+	u, isFound := d.users.Find(ctx, userID)
+	if !isFound {
+		return user.User{}, ErrWrongFormat
+	}
+	t, isFound := d.tokens.Take(ctx, token)
+	if !isFound || t.UserID != userID || t.IsExpired(time.Now().UTC()) ||
+		email.Normalize(t.Email) != email.Normalize(u.Email) {
+		return user.User{}, ErrWrongFormat
+	}
+	if err := d.users.SetVerified(ctx, userID, true); err != nil {
+		return user.User{}, ErrApp
+	}
+	before := u
+	u.Verified = true
+	d.record(ctx, audit.UserActor(userID), "verify_email", audit.Target{Kind: audit.KindUser, ID: userID}, before, u)
+	return u, nil
+}
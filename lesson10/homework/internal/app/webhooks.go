@@ -0,0 +1,206 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/tenant"
+	"homework10/internal/webhook"
+	"log"
+	"time"
+)
+
+// Webhooks keeps subscriptions of all tenants, Add stores the subscription in its Tenant.
+type Webhooks interface {
+	Add(ctx context.Context, s webhook.Subscription) (webhook.Subscription, error)
+	Find(ctx context.Context, webhookID int64) (webhook.Subscription, bool)
+	// List returns subscriptions oldest first.
+	List(ctx context.Context) ([]webhook.Subscription, error)
+	Delete(ctx context.Context, webhookID int64) error
+}
+
+// WebhookDeliveries is the delivery log, scoped like Webhooks.
+type WebhookDeliveries interface {
+	Add(ctx context.Context, d webhook.Delivery) (webhook.Delivery, error)
+	// Update replaces the stored delivery with the same ID.
+	Update(ctx context.Context, d webhook.Delivery) error
+	Find(ctx context.Context, deliveryID int64) (webhook.Delivery, bool)
+	// List returns deliveries oldest first. A zero webhookID and an empty status match all deliveries.
+	List(ctx context.Context, webhookID int64, status webhook.Status) ([]webhook.Delivery, error)
+}
+
+// WebhookSender makes the requests of logged deliveries in the background and records
+// their outcomes in the log, Send never blocks.
+type WebhookSender interface {
+	Send(d webhook.Delivery)
+}
+
+// WithWebhooks lets admins subscribe partners to events of ads, deliveries are logged to l and sent by s.
+func WithWebhooks(w Webhooks, l WebhookDeliveries, s WebhookSender) Option {
+	return func(d *SimpleApp) {
+		d.webhooks = w
+		d.deliveries = l
+		d.webhookSender = s
+	}
+}
+
+func (d SimpleApp) webhooksEnabled() bool {
+	return d.webhooks != nil && d.deliveries != nil && d.webhookSender != nil
+}
+
+// CreateWebhook subscribes url to the events of ads matching adp, requests are signed with secret.
+func (d SimpleApp) CreateWebhook(ctx context.Context, url string, events []webhook.Event, adp adpattern.AdPattern,
+	secret string) (webhook.Subscription, error) {
+	if !d.webhooksEnabled() {
+		return webhook.Subscription{}, ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return webhook.Subscription{}, ErrNoAccess
+	}
+	if webhook.CheckURL(url) != nil || len(secret) < webhook.MinSecretLen || len(events) == 0 {
+		return webhook.Subscription{}, ErrWrongFormat
+	}
+	for _, e := range events {
+		if _, err := webhook.ParseEvent(string(e)); err != nil {
+			return webhook.Subscription{}, ErrWrongFormat
+		}
+	}
+	s, err := d.webhooks.Add(ctx, webhook.Subscription{Tenant: tenant.FromContext(ctx), URL: url, Events: events,
+		Pattern: adp, Secret: secret, CreationDate: time.Now().UTC()})
+	if err != nil {
+		return webhook.Subscription{}, ErrApp
+	}
+	d.record(ctx, audit.Admin, "create_webhook", audit.Target{Kind: audit.KindWebhook, ID: s.ID}, nil, redactSecret(s))
+	return s, nil
+}
+
+func (d SimpleApp) ListWebhooks(ctx context.Context) ([]webhook.Subscription, error) {
+	if !d.webhooksEnabled() {
+		return []webhook.Subscription{}, ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return []webhook.Subscription{}, ErrNoAccess
+	}
+	res, err := d.webhooks.List(ctx)
+	if err != nil {
+		return []webhook.Subscription{}, ErrApp
+	}
+	return res, nil
+}
+
+// DeleteWebhook stops events of the subscription, its deliveries stay in the log.
+func (d SimpleApp) DeleteWebhook(ctx context.Context, webhookID int64) (webhook.Subscription, error) {
+	if !d.webhooksEnabled() {
+		return webhook.Subscription{}, ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return webhook.Subscription{}, ErrNoAccess
+	}
+	s, isFound := d.webhooks.Find(ctx, webhookID)
+	if !isFound {
+		return webhook.Subscription{}, ErrWrongFormat
+	}
+	if err := d.webhooks.Delete(ctx, webhookID); err != nil {
+		return webhook.Subscription{}, ErrApp
+	}
+	d.record(ctx, audit.Admin, "delete_webhook", audit.Target{Kind: audit.KindWebhook, ID: webhookID}, redactSecret(s), nil)
+	return s, nil
+}
+
+// ListWebhookDeliveries returns the delivery log of the subscription, or of all subscriptions if
+// webhookID is 0. Dead deliveries make the dead-letter list.
+func (d SimpleApp) ListWebhookDeliveries(ctx context.Context, webhookID int64, status webhook.Status) ([]webhook.Delivery, error) {
+	if !d.webhooksEnabled() {
+		return []webhook.Delivery{}, ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return []webhook.Delivery{}, ErrNoAccess
+	}
+	if status != "" {
+		if _, err := webhook.ParseStatus(string(status)); err != nil {
+			return []webhook.Delivery{}, ErrWrongFormat
+		}
+	}
+	if webhookID != 0 {
+		if _, isFound := d.webhooks.Find(ctx, webhookID); !isFound {
+			return []webhook.Delivery{}, ErrWrongFormat
+		}
+	}
+	res, err := d.deliveries.List(ctx, webhookID, status)
+	if err != nil {
+		return []webhook.Delivery{}, ErrApp
+	}
+	return res, nil
+}
+
+// RedeliverWebhook takes a dead delivery off the dead-letter list and sends it again.
+func (d SimpleApp) RedeliverWebhook(ctx context.Context, deliveryID int64) (webhook.Delivery, error) {
+	if !d.webhooksEnabled() {
+		return webhook.Delivery{}, ErrApp
+	}
+	if !d.isAdmin(ctx) {
+		return webhook.Delivery{}, ErrNoAccess
+	}
+	dl, isFound := d.deliveries.Find(ctx, deliveryID)
+	if !isFound {
+		return webhook.Delivery{}, ErrWrongFormat
+	}
+	if dl.Status != webhook.StatusDead {
+		return webhook.Delivery{}, ErrConflict
+	}
+	if _, isFound := d.webhooks.Find(ctx, dl.SubscriptionID); !isFound {
+		return webhook.Delivery{}, ErrConflict
+	}
+	dl.Status = webhook.StatusPending
+	dl.UpdateDate = time.Now().UTC()
+	if err := d.deliveries.Update(ctx, dl); err != nil {
+		return webhook.Delivery{}, ErrApp
+	}
+	d.record(ctx, audit.Admin, "redeliver_webhook", audit.Target{Kind: audit.KindWebhook, ID: dl.SubscriptionID}, nil, nil)
+	d.webhookSender.Send(dl)
+	return dl, nil
+}
+
+// emitWebhooks logs deliveries of the event to subscriptions matching the ad and hands them
+// to the sender, logging failures. For deletions ad is its state before it was deleted.
+func (d SimpleApp) emitWebhooks(ctx context.Context, e webhook.Event, list ...ads.Ad) {
+	if !d.webhooksEnabled() || len(list) == 0 {
+		return
+	}
+	tenantID := tenant.FromContext(ctx)
+	subs, err := d.webhooks.List(ctx)
+	if err != nil {
+		log.Printf("can't list webhooks: %s", err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	for _, s := range subs {
+		if !s.Wants(e) {
+			continue
+		}
+		for _, ad := range list {
+			if !CheckAd(ad, s.Pattern) {
+				continue
+			}
+			body, err := webhook.NewPayload(e, tenantID, ad, now)
+			if err != nil {
+				log.Printf("can't make %s payload of ad %d: %s", e, ad.ID, err.Error())
+				continue
+			}
+			dl, err := d.deliveries.Add(ctx, webhook.Delivery{Tenant: tenantID, SubscriptionID: s.ID, Event: e,
+				AdID: ad.ID, Payload: body, Status: webhook.StatusPending, CreationDate: now, UpdateDate: now})
+			if err != nil {
+				log.Printf("can't log delivery to webhook %d: %s", s.ID, err.Error())
+				continue
+			}
+			d.webhookSender.Send(dl)
+		}
+	}
+}
+
+// redactSecret keeps secrets out of the audit log.
+func redactSecret(s webhook.Subscription) webhook.Subscription {
+	s.Secret = ""
+	return s
+}
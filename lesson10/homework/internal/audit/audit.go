@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"homework10/internal/tenant"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transport is the API a change came through, empty for changes the service makes on its own.
+type Transport string
+
+const (
+	HTTP Transport = "http"
+	GRPC Transport = "grpc"
+)
+
+// Actors other than users, users are named by UserActor.
+const (
+	Admin  = "admin"
+	System = "system"
+)
+
+func UserActor(userID int64) string {
+	return fmt.Sprint("user:", userID)
+}
+
+// Kinds of targets.
+const (
+	KindAd      = "ad"
+	KindUser    = "user"
+	KindSearch  = "search"
+	KindThread  = "thread"
+	KindMessage = "message"
+	KindReport  = "report"
+	KindWebhook = "webhook"
+)
+
+var ErrBadTarget = fmt.Errorf("bad audit target")
+
+// Target is the changed entity, written as kind:id, e.g. ad:123.
+type Target struct {
+	Kind string
+	ID   int64
+}
+
+func (t Target) String() string {
+	if t.Kind == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", t.Kind, t.ID)
+}
+
+func (t Target) IsZero() bool {
+	return t.Kind == ""
+}
+
+func ParseTarget(s string) (Target, error) {
+	kind, id, ok := strings.Cut(s, ":")
+	if !ok || kind == "" {
+		return Target{}, fmt.Errorf("%w: %q isn't of the form kind:id", ErrBadTarget, s)
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return Target{}, fmt.Errorf("%w: %q has a bad id", ErrBadTarget, s)
+	}
+	return Target{Kind: kind, ID: n}, nil
+}
+
+func (t Target) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Target) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*t = Target{}
+		return nil
+	}
+	res, err := ParseTarget(string(data))
+	if err != nil {
+		return err
+	}
+	*t = res
+	return nil
+}
+
+// Entry records a single change, Before and After are JSON snapshots of the target
+// and are empty if it didn't exist before or doesn't exist after the change.
+type Entry struct {
+	ID        int64           `json:"id"`
+	Tenant    tenant.ID       `json:"tenant"`
+	Time      time.Time       `json:"time"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Target    Target          `json:"target"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Transport Transport       `json:"transport,omitempty"`
+}
+
+// Request describes the request a change was made by.
+type Request struct {
+	ID        string
+	Transport Transport
+}
+
+// MaxRequestIDLen limits request IDs clients pick, longer ones are replaced with new ones.
+const MaxRequestIDLen = 128
+
+// RequestID returns the ID the client picked, or a new random one if it picked none or a too long one.
+func RequestID(picked string) string {
+	if picked != "" && len(picked) <= MaxRequestIDLen {
+		return picked
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+type ctxKey struct{}
+
+// ContextKey is the key the request is stored under in string-keyed request values,
+// such as gin.Context.Set.
+const ContextKey = "audit_request"
+
+func NewContext(ctx context.Context, r Request) context.Context {
+	return context.WithValue(ctx, ctxKey{}, r)
+}
+
+// FromContext returns an empty request if ctx carries none.
+func FromContext(ctx context.Context) Request {
+	if r, ok := ctx.Value(ctxKey{}).(Request); ok {
+		return r
+	}
+	if r, ok := ctx.Value(ContextKey).(Request); ok {
+		return r
+	}
+	return Request{}
+}
@@ -0,0 +1,72 @@
+package bulk
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+var ErrFormat = fmt.Errorf("unknown format")
+
+// ErrBadLine wraps errors of a single line, the rest of the file can still be read.
+var ErrBadLine = fmt.Errorf("bad line")
+
+// Record is an ad in an import or export file. On import only ExternalID, Title, Text,
+// AuthorID and Published are used, an ad imported twice with the same ExternalID is updated.
+type Record struct {
+	ExternalID   string    `json:"external_id"`
+	ID           int64     `json:"id,omitempty"`
+	Title        string    `json:"title"`
+	Text         string    `json:"text"`
+	AuthorID     int64     `json:"author_id"`
+	Published    bool      `json:"published"`
+	CreationDate time.Time `json:"creation_date,omitempty"`
+	UpdateDate   time.Time `json:"update_date,omitempty"`
+}
+
+type Outcome string
+
+const (
+	Created   Outcome = "created"
+	Updated   Outcome = "updated"
+	Unchanged Outcome = "unchanged"
+)
+
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type Report struct {
+	DryRun    bool        `json:"dry_run"`
+	Created   int         `json:"created"`
+	Updated   int         `json:"updated"`
+	Unchanged int         `json:"unchanged"`
+	Errors    []LineError `json:"errors"`
+}
+
+func (d *Report) Add(o Outcome) {
+	switch o {
+	case Created:
+		d.Created++
+	case Updated:
+		d.Updated++
+	case Unchanged:
+		d.Unchanged++
+	}
+}
+
+func (d *Report) Fail(line int, err error) {
+	d.Errors = append(d.Errors, LineError{Line: line, Error: err.Error()})
+}
+
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
@@ -0,0 +1,116 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Reader reads records one by one. Next returns io.EOF after the last record,
+// errors wrapping ErrBadLine concern only the returned line.
+type Reader interface {
+	Next() (Record, int, error)
+}
+
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON:
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64*1024), maxLineSize)
+		return &ndjsonReader{s: s}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrFormat, format)
+	}
+}
+
+const maxLineSize = 1 << 20
+
+var requiredColumns = []string{"external_id", "title", "text", "author_id"}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+// newCSVReader reads the header, columns are matched by name and unknown ones are ignored.
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("can't read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("no %q column in header", name)
+		}
+	}
+	return &csvReader{r: cr, columns: columns}, nil
+}
+
+func (d *csvReader) Next() (Record, int, error) {
+	row, err := d.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Record{}, parseErr.StartLine, fmt.Errorf("%w: %s", ErrBadLine, parseErr.Err.Error())
+	}
+	if err != nil {
+		return Record{}, 0, err
+	}
+	line, _ := d.r.FieldPos(0)
+
+	field := func(name string) string {
+		if i, ok := d.columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	rec := Record{ExternalID: field("external_id"), Title: field("title"), Text: field("text")}
+	rec.AuthorID, err = strconv.ParseInt(field("author_id"), 10, 64)
+	if err != nil {
+		return Record{}, line, fmt.Errorf("%w: bad author_id: %s", ErrBadLine, err.Error())
+	}
+	if published := field("published"); published != "" {
+		rec.Published, err = strconv.ParseBool(published)
+		if err != nil {
+			return Record{}, line, fmt.Errorf("%w: bad published: %s", ErrBadLine, err.Error())
+		}
+	}
+	return rec, line, nil
+}
+
+type ndjsonReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+// Next skips blank lines.
+func (d *ndjsonReader) Next() (Record, int, error) {
+	for d.s.Scan() {
+		d.line++
+		data := d.s.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return Record{}, d.line, fmt.Errorf("%w: %s", ErrBadLine, err.Error())
+		}
+		return rec, d.line, nil
+	}
+	if err := d.s.Err(); err != nil {
+		return Record{}, d.line + 1, err
+	}
+	return Record{}, d.line, io.EOF
+}
@@ -0,0 +1,61 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Writer writes records one by one, Flush pushes buffered records to the underlying writer.
+type Writer interface {
+	Write(rec Record) error
+	Flush() error
+}
+
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{e: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrFormat, format)
+	}
+}
+
+var csvHeader = []string{"external_id", "id", "title", "text", "author_id", "published", "creation_date", "update_date"}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (d *csvWriter) Write(rec Record) error {
+	return d.w.Write([]string{rec.ExternalID, strconv.FormatInt(rec.ID, 10), rec.Title, rec.Text,
+		strconv.FormatInt(rec.AuthorID, 10), strconv.FormatBool(rec.Published),
+		rec.CreationDate.Format(time.RFC3339Nano), rec.UpdateDate.Format(time.RFC3339Nano)})
+}
+
+func (d *csvWriter) Flush() error {
+	d.w.Flush()
+	return d.w.Error()
+}
+
+// ndjsonWriter doesn't buffer, every record goes straight to the underlying writer.
+type ndjsonWriter struct {
+	e *json.Encoder
+}
+
+func (d *ndjsonWriter) Write(rec Record) error {
+	return d.e.Encode(rec)
+}
+
+func (d *ndjsonWriter) Flush() error {
+	return nil
+}
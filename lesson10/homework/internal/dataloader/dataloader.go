@@ -0,0 +1,63 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc looks up many keys at once, keys missing from the result aren't found.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader collects keys requested by Load until the value of one of them is needed, then
+// looks them up with one call of its BatchFunc. Results are cached for the lifetime of
+// the loader, which is meant to be a single request.
+type Loader[K comparable, V any] struct {
+	ctx     context.Context
+	batch   BatchFunc[K, V]
+	mu      sync.Mutex
+	queued  []K
+	pending map[K]bool
+	results map[K]result[V]
+}
+
+type result[V any] struct {
+	value V
+	found bool
+	err   error
+}
+
+func New[K comparable, V any](ctx context.Context, batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{ctx: ctx, batch: batch, pending: map[K]bool{}, results: map[K]result[V]{}}
+}
+
+// Load queues the key and returns a function returning its value, the first call of such
+// a function looks up all keys queued so far.
+func (l *Loader[K, V]) Load(key K) func() (V, bool, error) {
+	l.mu.Lock()
+	if _, done := l.results[key]; !done && !l.pending[key] {
+		l.pending[key] = true
+		l.queued = append(l.queued, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, done := l.results[key]; !done {
+			l.dispatch()
+		}
+		r := l.results[key]
+		return r.value, r.found, r.err
+	}
+}
+
+func (l *Loader[K, V]) dispatch() {
+	keys := l.queued
+	l.queued = nil
+	l.pending = map[K]bool{}
+	values, err := l.batch(l.ctx, keys)
+	for _, key := range keys {
+		v, ok := values[key]
+		l.results[key] = result[V]{value: v, found: ok, err: err}
+	}
+}
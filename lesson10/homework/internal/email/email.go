@@ -0,0 +1,42 @@
+package email
+
+import (
+	"net/mail"
+	"strings"
+)
+
+const maxLength = 254
+
+// Message is a letter sent to a user.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// IsValid accepts a bare RFC 5322 address, i.e. without a display name or angle brackets,
+// whose domain consists of at least two non-empty labels.
+func IsValid(address string) bool {
+	if len(address) > maxLength {
+		return false
+	}
+	addr, err := mail.ParseAddress(address)
+	if err != nil || addr.Name != "" || addr.Address != address {
+		return false
+	}
+	labels := strings.Split(address[strings.LastIndexByte(address, '@')+1:], ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize returns the form in which addresses are compared, they are case-insensitive as a whole.
+func Normalize(address string) string {
+	return strings.ToLower(address)
+}
@@ -0,0 +1,119 @@
+package graphql
+
+// document is a parsed query with its operations and fragments.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []*variableDef
+	selections []selection
+	loc        Location
+}
+
+type variableDef struct {
+	name string
+	typ  typeRef
+	// def is nil if the variable has no default value
+	def *value
+	loc Location
+}
+
+// typeRef is a type as written in a query, such as [ID!]!.
+type typeRef struct {
+	name    string
+	list    *typeRef
+	nonNull bool
+}
+
+func (t typeRef) String() string {
+	res := t.name
+	if t.list != nil {
+		res = "[" + t.list.String() + "]"
+	}
+	if t.nonNull {
+		res += "!"
+	}
+	return res
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+	loc           Location
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment.
+type selection interface {
+	location() Location
+}
+
+type field struct {
+	alias      string
+	name       string
+	args       []*argument
+	selections []selection
+	loc        Location
+}
+
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value *value
+	loc   Location
+}
+
+type fragmentSpread struct {
+	name string
+	loc  Location
+}
+
+type inlineFragment struct {
+	// typeCondition is empty if the fragment applies to any type
+	typeCondition string
+	selections    []selection
+	loc           Location
+}
+
+func (f *field) location() Location          { return f.loc }
+func (f *fragmentSpread) location() Location { return f.loc }
+func (f *inlineFragment) location() Location { return f.loc }
+
+type valueKind int
+
+const (
+	valueNull valueKind = iota
+	valueInt
+	valueFloat
+	valueString
+	valueBool
+	valueEnum
+	valueList
+	valueObject
+	valueVariable
+)
+
+// value is a literal or a variable of a query, raw holds the text of scalars and the name of
+// variables and enums.
+type value struct {
+	kind   valueKind
+	raw    string
+	list   []*value
+	fields []*objectField
+	loc    Location
+}
+
+type objectField struct {
+	name  string
+	value *value
+}
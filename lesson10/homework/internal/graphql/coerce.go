@@ -0,0 +1,254 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// types collects named types reachable from the schema, variables refer to input types by name.
+func (s *Schema) types() map[string]Type {
+	res := map[string]Type{}
+	for _, t := range []Type{Int, Float, String, Boolean, ID} {
+		res[t.String()] = t
+	}
+	var walk func(t Type)
+	walk = func(t Type) {
+		t = unwrap(t)
+		if _, ok := res[t.String()]; ok {
+			return
+		}
+		res[t.String()] = t
+		switch v := t.(type) {
+		case *Object:
+			for _, f := range v.Fields {
+				walk(f.Type)
+				for _, a := range f.Args {
+					walk(a.Type)
+				}
+			}
+		case *InputObject:
+			for _, a := range v.Fields {
+				walk(a.Type)
+			}
+		}
+	}
+	walk(s.Query)
+	return res
+}
+
+// resolveTypeRef finds the input type a variable is declared with.
+func resolveTypeRef(types map[string]Type, ref typeRef) (Type, error) {
+	var res Type
+	if ref.list != nil {
+		of, err := resolveTypeRef(types, *ref.list)
+		if err != nil {
+			return nil, err
+		}
+		res = &List{Of: of}
+	} else {
+		t, ok := types[ref.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q", ref.name)
+		}
+		switch t.(type) {
+		case *Scalar, *InputObject:
+		default:
+			return nil, fmt.Errorf("type %q can't be used as input", ref.name)
+		}
+		res = t
+	}
+	if ref.nonNull {
+		res = &NonNull{Of: res}
+	}
+	return res, nil
+}
+
+// coerceVariables checks the values given to variables of the operation and adds defaults.
+func coerceVariables(types map[string]Type, op *operation, input map[string]interface{}) (map[string]interface{}, []*Error) {
+	res := map[string]interface{}{}
+	var errs []*Error
+	for _, def := range op.variables {
+		t, err := resolveTypeRef(types, def.typ)
+		if err != nil {
+			errs = append(errs, requestError(def.loc, "variable $%s: %s", def.name, err.Error()))
+			continue
+		}
+		v, given := input[def.name]
+		if !given && def.def != nil {
+			v, err = literal(def.def, nil)
+			if err != nil {
+				errs = append(errs, requestError(def.loc, "variable $%s: %s", def.name, err.Error()))
+				continue
+			}
+			given = true
+		}
+		if !given {
+			if _, ok := t.(*NonNull); ok {
+				errs = append(errs, requestError(def.loc, "variable $%s of type %s isn't given", def.name, t))
+			}
+			continue
+		}
+		v, err = coerceInput(t, v)
+		if err != nil {
+			errs = append(errs, requestError(def.loc, "variable $%s: %s", def.name, err.Error()))
+			continue
+		}
+		res[def.name] = v
+	}
+	return res, errs
+}
+
+// coerceInput converts a value decoded from JSON, or made of a literal, to a value of type t.
+func coerceInput(t Type, v interface{}) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected a value of type %s, got null", t)
+		}
+		return coerceInput(nn.Of, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch typ := t.(type) {
+	case *Scalar:
+		return typ.Parse(v)
+	case *List:
+		items, ok := v.([]interface{})
+		if !ok {
+			// a single value is coerced to a list of one item
+			items = []interface{}{v}
+		}
+		res := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			c, err := coerceInput(typ.Of, item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			res = append(res, c)
+		}
+		return res, nil
+	case *InputObject:
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object of type %s", t)
+		}
+		return coerceFields(typ.Name, typ.Fields, fields)
+	}
+	return nil, fmt.Errorf("type %s can't be used as input", t)
+}
+
+// coerceFields checks fields of an input object or arguments of a field against their
+// definitions, unknown names and missing required values are errors.
+func coerceFields(owner string, defs map[string]*Arg, given map[string]interface{}) (map[string]interface{}, error) {
+	names := make([]string, 0, len(given))
+	for name := range given {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := defs[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q of %s", name, owner)
+		}
+	}
+	res := map[string]interface{}{}
+	names = names[:0]
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := defs[name]
+		v, ok := given[name]
+		if !ok {
+			if def.Default != nil {
+				res[name] = def.Default
+				continue
+			}
+			if _, isNonNull := def.Type.(*NonNull); isNonNull {
+				return nil, fmt.Errorf("%q of %s is required", name, owner)
+			}
+			continue
+		}
+		c, err := coerceInput(def.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("%q of %s: %w", name, owner, err)
+		}
+		res[name] = c
+	}
+	return res, nil
+}
+
+// literal converts a value written in the query to a Go value, variables are taken from vars.
+func literal(v *value, vars map[string]interface{}) (interface{}, error) {
+	switch v.kind {
+	case valueNull:
+		return nil, nil
+	case valueInt:
+		n, err := strconv.ParseInt(v.raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad integer %s", v.raw)
+		}
+		return n, nil
+	case valueFloat:
+		f, err := strconv.ParseFloat(v.raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad float %s", v.raw)
+		}
+		return f, nil
+	case valueString:
+		return v.raw, nil
+	case valueBool:
+		return v.raw == "true", nil
+	case valueEnum:
+		return nil, fmt.Errorf("enum values aren't supported, got %s", v.raw)
+	case valueVariable:
+		return vars[v.raw], nil
+	case valueList:
+		res := make([]interface{}, 0, len(v.list))
+		for _, item := range v.list {
+			c, err := literal(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, c)
+		}
+		return res, nil
+	case valueObject:
+		res := map[string]interface{}{}
+		for _, f := range v.fields {
+			if _, ok := res[f.name]; ok {
+				return nil, fmt.Errorf("field %q is given twice", f.name)
+			}
+			c, err := literal(f.value, vars)
+			if err != nil {
+				return nil, err
+			}
+			res[f.name] = c
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("unknown value")
+}
+
+// coerceArgs converts arguments of a field, variables must be coerced already.
+func coerceArgs(owner string, defs map[string]*Arg, args []*argument, vars map[string]interface{}) (map[string]interface{}, error) {
+	given := map[string]interface{}{}
+	for _, arg := range args {
+		if _, ok := given[arg.name]; ok {
+			return nil, fmt.Errorf("argument %q is given twice", arg.name)
+		}
+		// arguments set to variables which aren't given count as not given
+		if arg.value.kind == valueVariable {
+			if _, ok := vars[arg.value.raw]; !ok {
+				continue
+			}
+		}
+		v, err := literal(arg.value, vars)
+		if err != nil {
+			return nil, err
+		}
+		given[arg.name] = v
+	}
+	return coerceFields(owner, defs, given)
+}
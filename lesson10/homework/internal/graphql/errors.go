@@ -0,0 +1,28 @@
+package graphql
+
+import "fmt"
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an entry of the errors of a response. Path is set for errors of fields, made of
+// response keys and list indices.
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func syntaxError(loc Location, format string, args ...interface{}) *Error {
+	return &Error{Message: "syntax error: " + fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+func requestError(loc Location, format string, args ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
@@ -0,0 +1,296 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+)
+
+// Request is the body of a GraphQL request sent over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response has no data if the request is invalid, fields failing to resolve are null
+// and have their errors listed.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Execute runs a query operation of the request. Only queries are supported, and null
+// values of non-null fields aren't propagated to their parents.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	op, gqlErr := pickOperation(doc, req.OperationName)
+	if gqlErr != nil {
+		return &Response{Errors: []*Error{gqlErr}}
+	}
+	if op.kind != "query" {
+		return &Response{Errors: []*Error{requestError(op.loc, "%s operations aren't supported", op.kind)}}
+	}
+
+	vars, errs := coerceVariables(s.types(), op, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	v := &validator{doc: doc, vars: vars, defined: map[string]bool{}, args: map[*field]map[string]interface{}{}}
+	for _, def := range op.variables {
+		v.defined[def.name] = true
+	}
+	complexity, depth := v.selections(s.Query, op.selections, map[string]bool{})
+	if len(v.errs) > 0 {
+		return &Response{Errors: v.errs}
+	}
+	if s.MaxDepth > 0 && depth > s.MaxDepth {
+		return &Response{Errors: []*Error{requestError(op.loc, "query depth %d exceeds the limit of %d", depth, s.MaxDepth)}}
+	}
+	if s.MaxComplexity > 0 && complexity > s.MaxComplexity {
+		return &Response{Errors: []*Error{requestError(op.loc, "query complexity %d exceeds the limit of %d",
+			complexity, s.MaxComplexity)}}
+	}
+
+	e := &executor{ctx: ctx, doc: doc, args: v.args}
+	data := e.object(s.Query, nil, op.selections, nil)
+	// thunks run level by level, so those of one level share batches of their loaders
+	for len(e.pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			e.errs = append(e.errs, &Error{Message: err.Error()})
+			break
+		}
+		batch := e.pending
+		e.pending = nil
+		for _, run := range batch {
+			run()
+		}
+	}
+	return &Response{Data: data, Errors: e.errs}
+}
+
+func asError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{Message: err.Error()}
+}
+
+func pickOperation(doc *document, name string) (*operation, *Error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, requestError(doc.operations[1].loc, "operationName is required for queries with many operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("unknown operation %q", name)}
+}
+
+type executor struct {
+	ctx     context.Context
+	doc     *document
+	args    map[*field]map[string]interface{}
+	pending []func()
+	errs    []*Error
+}
+
+func (e *executor) fail(err error, loc Location, path []interface{}) {
+	e.errs = append(e.errs, &Error{Message: err.Error(), Locations: []Location{loc}, Path: path})
+}
+
+// fieldGroup is the fields of a selection set sharing a response key, they are merged.
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+func (e *executor) collect(t *Object, sels []selection, groups []*fieldGroup, index map[string]*fieldGroup) []*fieldGroup {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *field:
+			key := s.responseKey()
+			if g, ok := index[key]; ok {
+				g.fields = append(g.fields, s)
+				continue
+			}
+			g := &fieldGroup{key: key, fields: []*field{s}}
+			index[key] = g
+			groups = append(groups, g)
+		case *fragmentSpread:
+			groups = e.collect(t, e.doc.fragments[s.name].selections, groups, index)
+		case *inlineFragment:
+			groups = e.collect(t, s.selections, groups, index)
+		}
+	}
+	return groups
+}
+
+func (e *executor) object(t *Object, source interface{}, sels []selection, path []interface{}) *orderedMap {
+	res := &orderedMap{values: map[string]interface{}{}}
+	for _, g := range e.collect(t, sels, nil, map[string]*fieldGroup{}) {
+		key := g.key
+		f := g.fields[0]
+		res.keys = append(res.keys, key)
+		res.values[key] = nil
+		if f.name == "__typename" {
+			res.values[key] = t.Name
+			continue
+		}
+		def := t.Fields[f.name]
+		fieldPath := appendPath(path, key)
+		v, err := e.resolve(def, f.name, source, e.args[f])
+		if err != nil {
+			e.fail(err, f.loc, fieldPath)
+			continue
+		}
+		var subs []selection
+		for _, gf := range g.fields {
+			subs = append(subs, gf.selections...)
+		}
+		e.complete(def.Type, v, subs, fieldPath, f.loc, func(v interface{}) {
+			res.values[key] = v
+		})
+	}
+	return res
+}
+
+func (e *executor) resolve(def *Field, name string, source interface{}, args map[string]interface{}) (_ interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("graphql: panic resolving %s: %v", name, r)
+			err = fmt.Errorf("internal error")
+		}
+	}()
+	if def.Resolve == nil {
+		if m, ok := source.(map[string]interface{}); ok {
+			return m[name], nil
+		}
+		return nil, nil
+	}
+	return def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+}
+
+// complete converts a resolved value to the JSON one of type t and passes it to set,
+// thunks are run after the current level.
+func (e *executor) complete(t Type, v interface{}, sels []selection, path []interface{}, loc Location,
+	set func(interface{})) {
+	if th, ok := v.(Thunk); ok {
+		e.pending = append(e.pending, func() {
+			v, err := e.runThunk(th)
+			if err != nil {
+				e.fail(err, loc, path)
+				return
+			}
+			e.complete(t, v, sels, path, loc, set)
+		})
+		return
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if isNil(v) {
+			e.fail(fmt.Errorf("non-null field is null"), loc, path)
+			return
+		}
+		e.complete(nn.Of, v, sels, path, loc, set)
+		return
+	}
+	if isNil(v) {
+		set(nil)
+		return
+	}
+	switch typ := t.(type) {
+	case *Scalar:
+		res, err := typ.Serialize(v)
+		if err != nil {
+			e.fail(err, loc, path)
+			return
+		}
+		set(res)
+	case *Object:
+		set(e.object(typ, v, sels, path))
+	case *List:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.fail(fmt.Errorf("expected a list, got %T", v), loc, path)
+			return
+		}
+		items := make([]interface{}, rv.Len())
+		set(items)
+		for i := range items {
+			i := i
+			e.complete(typ.Of, rv.Index(i).Interface(), sels, appendPath(path, i), loc, func(v interface{}) {
+				items[i] = v
+			})
+		}
+	default:
+		e.fail(fmt.Errorf("type %s can't be output", t), loc, path)
+	}
+}
+
+func (e *executor) runThunk(th Thunk) (_ interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("graphql: panic in thunk: %v", r)
+			err = fmt.Errorf("internal error")
+		}
+	}()
+	return th()
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// appendPath copies the path, as paths of sibling fields share their prefix.
+func appendPath(path []interface{}, elem interface{}) []interface{} {
+	res := make([]interface{}, len(path), len(path)+1)
+	copy(res, path)
+	return append(res, elem)
+}
+
+// orderedMap is an object of a response, keeping fields in the order they were selected in.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,227 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	loc   Location
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of query"
+	}
+	if t.kind == tokenString {
+		return strconv.Quote(t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+// lexer splits a query into tokens, commas are insignificant as in the spec.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func (l *lexer) loc() Location {
+	return Location{Line: l.line, Column: l.col}
+}
+
+func (l *lexer) advance(n int) {
+	for _, r := range l.src[l.pos : l.pos+n] {
+		if r == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+	}
+	l.pos += n
+}
+
+func tokenize(src string) ([]token, error) {
+	l := &lexer{src: src, line: 1, col: 1}
+	var res []token
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+		if t.kind == tokenEOF {
+			return res, nil
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := l.loc()
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, loc: loc}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.advance(3)
+		return token{kind: tokenPunct, value: "...", loc: loc}, nil
+	case strings.IndexByte("!$():=@[]{}|&", c) >= 0:
+		l.advance(1)
+		return token{kind: tokenPunct, value: string(c), loc: loc}, nil
+	case c == '_' || isLetter(c):
+		start := l.pos
+		end := start
+		for end < len(l.src) && (l.src[end] == '_' || isLetter(l.src[end]) || isDigit(l.src[end])) {
+			end++
+		}
+		l.advance(end - start)
+		return token{kind: tokenName, value: l.src[start:end], loc: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+	case c == '"':
+		return l.string(loc)
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, syntaxError(loc, "unexpected character %q", r)
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				end = len(l.src) - l.pos
+			}
+			l.advance(end)
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) number(loc Location) (token, error) {
+	start := l.pos
+	end := start
+	if l.src[end] == '-' {
+		end++
+	}
+	digits := func() int {
+		n := 0
+		for end < len(l.src) && isDigit(l.src[end]) {
+			end++
+			n++
+		}
+		return n
+	}
+	if digits() == 0 {
+		return token{}, syntaxError(loc, "bad number")
+	}
+	kind := tokenInt
+	if end < len(l.src) && l.src[end] == '.' {
+		end++
+		kind = tokenFloat
+		if digits() == 0 {
+			return token{}, syntaxError(loc, "bad number")
+		}
+	}
+	if end < len(l.src) && (l.src[end] == 'e' || l.src[end] == 'E') {
+		end++
+		kind = tokenFloat
+		if end < len(l.src) && (l.src[end] == '+' || l.src[end] == '-') {
+			end++
+		}
+		if digits() == 0 {
+			return token{}, syntaxError(loc, "bad number")
+		}
+	}
+	if end < len(l.src) && (l.src[end] == '_' || isLetter(l.src[end])) {
+		return token{}, syntaxError(loc, "bad number")
+	}
+	l.advance(end - start)
+	return token{kind: kind, value: l.src[start:end], loc: loc}, nil
+}
+
+// string reads a quoted string, block strings aren't supported.
+func (l *lexer) string(loc Location) (token, error) {
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		return token{}, syntaxError(loc, "block strings aren't supported")
+	}
+	var sb strings.Builder
+	i := l.pos + 1
+	for i < len(l.src) {
+		c := l.src[i]
+		switch {
+		case c == '"':
+			l.advance(i + 1 - l.pos)
+			return token{kind: tokenString, value: sb.String(), loc: loc}, nil
+		case c == '\n' || c == '\r':
+			return token{}, syntaxError(loc, "unterminated string")
+		case c == '\\':
+			if i+1 >= len(l.src) {
+				return token{}, syntaxError(loc, "unterminated string")
+			}
+			i++
+			switch e := l.src[i]; e {
+			case '"', '\\', '/':
+				sb.WriteByte(e)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if i+5 > len(l.src) {
+					return token{}, syntaxError(loc, "bad escape sequence")
+				}
+				code, err := strconv.ParseUint(l.src[i+1:i+5], 16, 32)
+				if err != nil {
+					return token{}, syntaxError(loc, "bad escape sequence")
+				}
+				sb.WriteRune(rune(code))
+				i += 4
+			default:
+				return token{}, syntaxError(loc, "bad escape sequence")
+			}
+			i++
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return token{}, syntaxError(loc, "unterminated string")
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,403 @@
+package graphql
+
+import "fmt"
+
+const (
+	// MaxLen and MaxNesting bound the work of parsing a query before limits of the schema are checked,
+	// MaxNesting also keeps the recursive descent from exhausting the stack.
+	MaxLen     = 64 << 10
+	MaxNesting = 32
+)
+
+// parser builds a document from tokens by recursive descent over the executable part of
+// the GraphQL grammar. Directives aren't supported.
+type parser struct {
+	tokens []token
+	pos    int
+	// depth is the number of selection sets, lists, input objects and list types being parsed
+	depth int
+}
+
+func parse(query string) (*document, error) {
+	if len(query) > MaxLen {
+		return nil, &Error{Message: fmt.Sprintf("query is longer than %d bytes", MaxLen)}
+	}
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: map[string]*fragment{}}
+	for p.peek().kind != tokenEOF {
+		t := p.peek()
+		switch {
+		case t.kind == tokenPunct && t.value == "{":
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sels, loc: t.loc})
+		case t.kind == tokenName && (t.value == "query" || t.value == "mutation" || t.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case t.kind == tokenName && t.value == "fragment":
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, syntaxError(f.loc, "fragment %q is defined twice", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, syntaxError(t.loc, "unexpected %s", t)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, syntaxError(p.peek().loc, "no operations in query")
+	}
+	return doc, nil
+}
+
+// enter is called before parsing a nested part of the query, leave after it.
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > MaxNesting {
+		return syntaxError(p.peek().loc, "nesting is deeper than %d", MaxNesting)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isPunct(value string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.value == value
+}
+
+func (p *parser) expectPunct(value string) (token, error) {
+	t := p.take()
+	if t.kind != tokenPunct || t.value != value {
+		return t, syntaxError(t.loc, "expected %q, got %s", value, t)
+	}
+	return t, nil
+}
+
+func (p *parser) name() (token, error) {
+	t := p.take()
+	if t.kind != tokenName {
+		return t, syntaxError(t.loc, "expected a name, got %s", t)
+	}
+	return t, nil
+}
+
+func (p *parser) noDirectives() error {
+	if p.isPunct("@") {
+		return syntaxError(p.peek().loc, "directives aren't supported")
+	}
+	return nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	t := p.take()
+	op := &operation{kind: t.value, loc: t.loc}
+	if p.peek().kind == tokenName {
+		op.name = p.take().value
+	}
+	if p.isPunct("(") {
+		p.take()
+		for !p.isPunct(")") {
+			v, err := p.variableDef()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, v)
+		}
+		p.take()
+	}
+	if err := p.noDirectives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+func (p *parser) variableDef() (*variableDef, error) {
+	t, err := p.expectPunct("$")
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+	v := &variableDef{name: name.value, typ: typ, loc: t.loc}
+	if p.isPunct("=") {
+		p.take()
+		def, err := p.value(true)
+		if err != nil {
+			return nil, err
+		}
+		v.def = def
+	}
+	return v, p.noDirectives()
+}
+
+func (p *parser) typeRef() (typeRef, error) {
+	if err := p.enter(); err != nil {
+		return typeRef{}, err
+	}
+	defer p.leave()
+	var res typeRef
+	if p.isPunct("[") {
+		p.take()
+		of, err := p.typeRef()
+		if err != nil {
+			return typeRef{}, err
+		}
+		if _, err := p.expectPunct("]"); err != nil {
+			return typeRef{}, err
+		}
+		res.list = &of
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return typeRef{}, err
+		}
+		res.name = name.value
+	}
+	if p.isPunct("!") {
+		p.take()
+		res.nonNull = true
+	}
+	return res, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	t := p.take()
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name.value == "on" {
+		return nil, syntaxError(name.loc, "fragment can't be named \"on\"")
+	}
+	on, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if on.value != "on" {
+		return nil, syntaxError(on.loc, "expected \"on\", got %s", on)
+	}
+	typ, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.noDirectives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name.value, typeCondition: typ.value, selections: sels, loc: t.loc}, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	if _, err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var res []selection
+	for !p.isPunct("}") {
+		if p.peek().kind == tokenEOF {
+			return nil, syntaxError(p.peek().loc, "expected \"}\", got %s", p.peek())
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, sel)
+	}
+	p.take()
+	if len(res) == 0 {
+		return nil, syntaxError(p.peek().loc, "empty selection set")
+	}
+	return res, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	if !p.isPunct("...") {
+		return p.field()
+	}
+	t := p.take()
+	if p.peek().kind == tokenName && p.peek().value != "on" {
+		name := p.take()
+		return &fragmentSpread{name: name.value, loc: t.loc}, p.noDirectives()
+	}
+	f := &inlineFragment{loc: t.loc}
+	if p.peek().kind == tokenName {
+		p.take()
+		typ, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		f.typeCondition = typ.value
+	}
+	if err := p.noDirectives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	f.selections = sels
+	return f, nil
+}
+
+func (p *parser) field() (*field, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f := &field{name: name.value, loc: name.loc}
+	if p.isPunct(":") {
+		p.take()
+		real, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		f.alias, f.name = f.name, real.value
+	}
+	if p.isPunct("(") {
+		p.take()
+		for !p.isPunct(")") {
+			arg, err := p.argument(false)
+			if err != nil {
+				return nil, err
+			}
+			f.args = append(f.args, arg)
+		}
+		p.take()
+	}
+	if err := p.noDirectives(); err != nil {
+		return nil, err
+	}
+	if p.isPunct("{") {
+		sels, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		f.selections = sels
+	}
+	return f, nil
+}
+
+func (p *parser) argument(isConst bool) (*argument, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	v, err := p.value(isConst)
+	if err != nil {
+		return nil, err
+	}
+	return &argument{name: name.value, value: v, loc: name.loc}, nil
+}
+
+// value parses a literal, variables are allowed unless isConst.
+func (p *parser) value(isConst bool) (*value, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	t := p.take()
+	switch t.kind {
+	case tokenInt:
+		return &value{kind: valueInt, raw: t.value, loc: t.loc}, nil
+	case tokenFloat:
+		return &value{kind: valueFloat, raw: t.value, loc: t.loc}, nil
+	case tokenString:
+		return &value{kind: valueString, raw: t.value, loc: t.loc}, nil
+	case tokenName:
+		switch t.value {
+		case "true", "false":
+			return &value{kind: valueBool, raw: t.value, loc: t.loc}, nil
+		case "null":
+			return &value{kind: valueNull, loc: t.loc}, nil
+		}
+		return &value{kind: valueEnum, raw: t.value, loc: t.loc}, nil
+	case tokenPunct:
+		switch t.value {
+		case "$":
+			if isConst {
+				return nil, syntaxError(t.loc, "variables aren't allowed here")
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return &value{kind: valueVariable, raw: name.value, loc: t.loc}, nil
+		case "[":
+			v := &value{kind: valueList, loc: t.loc}
+			for !p.isPunct("]") {
+				if p.peek().kind == tokenEOF {
+					return nil, syntaxError(p.peek().loc, "expected \"]\", got %s", p.peek())
+				}
+				item, err := p.value(isConst)
+				if err != nil {
+					return nil, err
+				}
+				v.list = append(v.list, item)
+			}
+			p.take()
+			return v, nil
+		case "{":
+			v := &value{kind: valueObject, loc: t.loc}
+			for !p.isPunct("}") {
+				arg, err := p.argument(isConst)
+				if err != nil {
+					return nil, err
+				}
+				v.fields = append(v.fields, &objectField{name: arg.name, value: arg.value})
+			}
+			p.take()
+			return v, nil
+		}
+	}
+	return nil, syntaxError(t.loc, "expected a value, got %s", t)
+}
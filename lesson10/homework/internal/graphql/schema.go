@@ -0,0 +1,232 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Type is a *Scalar, *Object, *InputObject, *List or *NonNull.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Serialize converts resolved values to JSON ones, Parse converts
+// values of arguments and variables, decoded from JSON or literals, to Go ones.
+type Scalar struct {
+	Name      string
+	Serialize func(v interface{}) (interface{}, error)
+	Parse     func(v interface{}) (interface{}, error)
+}
+
+func (t *Scalar) String() string { return t.Name }
+
+// Object is an output type with fields. Fields may be added after it is created, so that
+// objects can refer to each other.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+func (t *Object) String() string { return t.Name }
+
+// InputObject is the type of arguments made of named fields, they are parsed into map[string]interface{}.
+type InputObject struct {
+	Name   string
+	Fields map[string]*Arg
+}
+
+func (t *InputObject) String() string { return t.Name }
+
+type List struct {
+	Of Type
+}
+
+func (t *List) String() string { return "[" + t.Of.String() + "]" }
+
+type NonNull struct {
+	Of Type
+}
+
+func (t *NonNull) String() string { return t.Of.String() + "!" }
+
+// ResolveFunc returns the value of a field, or a Thunk to delay loading it until all fields
+// on the same level are resolved, so that loaders can batch their lookups.
+type ResolveFunc func(p ResolveParams) (interface{}, error)
+
+// Thunk is called once, after all fields resolved before it.
+type Thunk func() (interface{}, error)
+
+type ResolveParams struct {
+	Context context.Context
+	// Source is the value of the object the field belongs to, nil for root fields
+	Source interface{}
+	Args   map[string]interface{}
+}
+
+type Field struct {
+	Type Type
+	Args map[string]*Arg
+	// Resolve is nil for fields taken by name from a map[string]interface{} source
+	Resolve ResolveFunc
+	// Cost is added to the complexity of queries selecting the field, 1 if zero
+	Cost int
+	// Multiplier is the number of items a list field returns at most for the arguments, the
+	// complexity of the subselection is multiplied by it. Nil means 1.
+	Multiplier func(args map[string]interface{}) int
+}
+
+type Arg struct {
+	Type Type
+	// Default is used if the argument isn't given, nil means no default
+	Default interface{}
+}
+
+// Schema holds the root type and limits checked before a query is executed, a zero
+// limit means no limit.
+type Schema struct {
+	Query *Object
+	// MaxDepth limits the nesting of fields
+	MaxDepth int
+	// MaxComplexity limits the sum of costs of fields, see Field.Multiplier
+	MaxComplexity int
+}
+
+func unwrap(t Type) Type {
+	for {
+		switch v := t.(type) {
+		case *NonNull:
+			t = v.Of
+		case *List:
+			t = v.Of
+		default:
+			return t
+		}
+	}
+}
+
+// Int is a signed 32-bit integer as in the spec, it is parsed into int.
+var Int = &Scalar{
+	Name: "Int",
+	Serialize: func(v interface{}) (interface{}, error) {
+		n, err := toInt(v)
+		if err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("Int can't represent %v", v)
+		}
+		return n, nil
+	},
+	Parse: func(v interface{}) (interface{}, error) {
+		n, err := toInt(v)
+		if err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("Int can't represent %v", v)
+		}
+		return int(n), nil
+	},
+}
+
+var Float = &Scalar{
+	Name: "Float",
+	Serialize: func(v interface{}) (interface{}, error) {
+		return toFloat(v)
+	},
+	Parse: func(v interface{}) (interface{}, error) {
+		return toFloat(v)
+	},
+}
+
+var String = &Scalar{
+	Name: "String",
+	Serialize: func(v interface{}) (interface{}, error) {
+		switch s := v.(type) {
+		case string:
+			return s, nil
+		case fmt.Stringer:
+			return s.String(), nil
+		}
+		return nil, fmt.Errorf("String can't represent %v", v)
+	},
+	Parse: func(v interface{}) (interface{}, error) {
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("String can't represent %v", v)
+	},
+}
+
+var Boolean = &Scalar{
+	Name: "Boolean",
+	Serialize: func(v interface{}) (interface{}, error) {
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("Boolean can't represent %v", v)
+	},
+	Parse: func(v interface{}) (interface{}, error) {
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("Boolean can't represent %v", v)
+	},
+}
+
+// ID is serialized as a string, so that 64-bit IDs survive JavaScript clients, and accepts
+// strings and integers. It is parsed into int64.
+var ID = &Scalar{
+	Name: "ID",
+	Serialize: func(v interface{}) (interface{}, error) {
+		n, err := toInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("ID can't represent %v", v)
+		}
+		return strconv.FormatInt(n, 10), nil
+	},
+	Parse: func(v interface{}) (interface{}, error) {
+		if s, ok := v.(string); ok {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("ID can't represent %q", s)
+			}
+			return n, nil
+		}
+		n, err := toInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("ID can't represent %v", v)
+		}
+		return n, nil
+	},
+}
+
+func toInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case float64:
+		if n != math.Trunc(n) || math.Abs(n) > 1<<53 {
+			return 0, fmt.Errorf("not an integer")
+		}
+		return int64(n), nil
+	case json.Number:
+		return n.Int64()
+	}
+	return 0, fmt.Errorf("not an integer")
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	}
+	return 0, fmt.Errorf("Float can't represent %v", v)
+}
@@ -0,0 +1,130 @@
+package graphql
+
+// validator checks selections of an operation against the schema, coerces arguments of
+// fields and measures the depth and complexity of the operation.
+type validator struct {
+	doc  *document
+	vars map[string]interface{}
+	// defined are the variables the operation declares
+	defined map[string]bool
+	// args are the coerced arguments of every field, so they are coerced once
+	args map[*field]map[string]interface{}
+	// visits counts fields checked, fragments spread many times would take long to check otherwise
+	visits int
+	errs   []*Error
+}
+
+const maxVisits = 10000
+
+func (v *validator) fail(err *Error) {
+	v.errs = append(v.errs, err)
+}
+
+// selections returns the complexity and depth of the selections on an object of type t.
+func (v *validator) selections(t *Object, sels []selection, visiting map[string]bool) (int, int) {
+	complexity, depth := 0, 0
+	for _, sel := range sels {
+		var c, d int
+		switch s := sel.(type) {
+		case *field:
+			c, d = v.field(t, s, visiting)
+		case *fragmentSpread:
+			f, ok := v.doc.fragments[s.name]
+			if !ok {
+				v.fail(requestError(s.loc, "unknown fragment %q", s.name))
+				continue
+			}
+			if visiting[s.name] {
+				v.fail(requestError(s.loc, "fragment %q spreads itself", s.name))
+				continue
+			}
+			if f.typeCondition != t.Name {
+				v.fail(requestError(s.loc, "fragment %q on %s can't be spread on %s", s.name, f.typeCondition, t.Name))
+				continue
+			}
+			visiting[s.name] = true
+			c, d = v.selections(t, f.selections, visiting)
+			delete(visiting, s.name)
+		case *inlineFragment:
+			if s.typeCondition != "" && s.typeCondition != t.Name {
+				v.fail(requestError(s.loc, "fragment on %s can't be spread on %s", s.typeCondition, t.Name))
+				continue
+			}
+			c, d = v.selections(t, s.selections, visiting)
+		}
+		complexity += c
+		if d > depth {
+			depth = d
+		}
+	}
+	return complexity, depth
+}
+
+func (v *validator) field(t *Object, f *field, visiting map[string]bool) (int, int) {
+	v.visits++
+	if v.visits == maxVisits {
+		v.fail(requestError(f.loc, "query is too large"))
+	}
+	if v.visits >= maxVisits {
+		return 0, 0
+	}
+	if f.name == "__typename" {
+		if len(f.args) > 0 || f.selections != nil {
+			v.fail(requestError(f.loc, "__typename has no arguments and fields"))
+		}
+		return 0, 0
+	}
+	def, ok := t.Fields[f.name]
+	if !ok {
+		v.fail(requestError(f.loc, "type %s has no field %q", t.Name, f.name))
+		return 0, 0
+	}
+	for _, arg := range f.args {
+		v.checkVariables(arg.value)
+	}
+	args, err := coerceArgs(t.Name+"."+f.name, def.Args, f.args, v.vars)
+	if err != nil {
+		v.fail(requestError(f.loc, "%s", err.Error()))
+		return 0, 0
+	}
+	v.args[f] = args
+
+	cost := def.Cost
+	if cost == 0 {
+		cost = 1
+	}
+	obj, isObject := unwrap(def.Type).(*Object)
+	if !isObject {
+		if f.selections != nil {
+			v.fail(requestError(f.loc, "field %q of type %s has no fields", f.name, def.Type))
+		}
+		return cost, 1
+	}
+	if f.selections == nil {
+		v.fail(requestError(f.loc, "field %q of type %s needs a selection of fields", f.name, def.Type))
+		return cost, 1
+	}
+	c, d := v.selections(obj, f.selections, visiting)
+	multiplier := 1
+	if def.Multiplier != nil {
+		multiplier = def.Multiplier(args)
+	}
+	return cost + c*multiplier, d + 1
+}
+
+func (v *validator) checkVariables(val *value) {
+	switch val.kind {
+	case valueVariable:
+		if !v.defined[val.raw] {
+			v.fail(requestError(val.loc, "variable $%s isn't defined", val.raw))
+		}
+	case valueList:
+		for _, item := range val.list {
+			v.checkVariables(item)
+		}
+	case valueObject:
+		for _, f := range val.fields {
+			v.checkVariables(f.value)
+		}
+	}
+}
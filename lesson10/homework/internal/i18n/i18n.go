@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Supported lists languages of message catalogs, messages are written in the first one.
+var Supported = []language.Tag{language.English, language.Russian}
+
+var matcher = language.NewMatcher(Supported)
+
+// Match picks the supported language closest to an Accept-Language value, English if none is close.
+func Match(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return language.English
+	}
+	_, i, conf := matcher.Match(tags...)
+	if conf == language.No {
+		return language.English
+	}
+	return Supported[i]
+}
+
+type ctxKey struct{}
+
+// Key is the key the language is stored under in string-keyed request values, such as gin.Context.Set.
+const Key = "lang"
+
+func NewContext(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, ctxKey{}, tag)
+}
+
+// FromContext returns English if ctx carries no language.
+func FromContext(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(ctxKey{}).(language.Tag); ok {
+		return tag
+	}
+	if tag, ok := ctx.Value(Key).(language.Tag); ok {
+		return tag
+	}
+	return language.English
+}
+
+// sep separates an error message from the one it wraps, as in fmt.Errorf("%w: ...").
+const sep = ": "
+
+// Translate translates every part of an error message wrapping others, parts missing
+// from the catalog of the language are left as they are.
+func Translate(tag language.Tag, msg string) string {
+	c, ok := catalogs[tag]
+	if !ok {
+		return msg
+	}
+	parts := strings.Split(msg, sep)
+	for i, part := range parts {
+		parts[i] = c.translate(part)
+	}
+	return strings.Join(parts, sep)
+}
+
+// entry translates messages made by a format with %d, %q or %s verbs, the values
+// are put into the translation in the same order.
+type entry struct {
+	re          *regexp.Regexp
+	translation string
+}
+
+var verbs = map[string]string{"%d": `(-?\d+)`, "%q": `(".*")`, "%s": `(.+)`}
+
+var verbRe = regexp.MustCompile(`%[dqs]`)
+
+type catalog struct {
+	exact    map[string]string
+	patterns []entry
+}
+
+func newCatalog(messages map[string]string) catalog {
+	res := catalog{exact: map[string]string{}}
+	for format, translation := range messages {
+		if !verbRe.MatchString(format) {
+			res.exact[format] = translation
+			continue
+		}
+		expr := ""
+		last := 0
+		for _, loc := range verbRe.FindAllStringIndex(format, -1) {
+			expr += regexp.QuoteMeta(format[last:loc[0]]) + verbs[format[loc[0]:loc[1]]]
+			last = loc[1]
+		}
+		expr += regexp.QuoteMeta(format[last:])
+		res.patterns = append(res.patterns, entry{re: regexp.MustCompile("^" + expr + "$"), translation: translation})
+	}
+	return res
+}
+
+func (d catalog) translate(msg string) string {
+	if res, ok := d.exact[msg]; ok {
+		return res
+	}
+	for _, e := range d.patterns {
+		m := e.re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		values := m[1:]
+		return verbRe.ReplaceAllStringFunc(e.translation, func(string) string {
+			v := values[0]
+			values = values[1:]
+			return v
+		})
+	}
+	return msg
+}
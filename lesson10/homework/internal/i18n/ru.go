@@ -0,0 +1,38 @@
+package i18n
+
+import "golang.org/x/text/language"
+
+var catalogs = map[language.Tag]catalog{
+	language.Russian: newCatalog(map[string]string{
+		"wrong format":              "неверный формат",
+		"permission denied":         "доступ запрещён",
+		"unknown application error": "внутренняя ошибка приложения",
+		"email is already taken":    "адрес электронной почты уже занят",
+		"conflict":                  "конфликт",
+		"unknown tenant":            "неизвестная площадка",
+		"bad filter expression":     "неверное выражение фильтра",
+		"bad sort spec":             "неверный порядок сортировки",
+		"bad report reason":         "неверная причина жалобы",
+		"bad report status":         "неверный статус жалобы",
+		"bad audit target":          "неверный объект журнала",
+		"bad language":              "неверный код языка",
+		"unknown format":            "неизвестный формат",
+		"bad line":                  "неверная строка",
+		"bad csrf token":            "неверный CSRF-токен",
+		"bad webhook event":         "неверное событие вебхука",
+		"bad webhook url":           "неверный адрес вебхука",
+		"bad delivery status":       "неверный статус доставки",
+
+		"title is longer than %d characters":          "заголовок длиннее %d символов",
+		"text is longer than %d characters":           "текст длиннее %d символов",
+		"contains banned word %q":                     "содержит запрещённое слово %q",
+		"contains link %q":                            "содержит ссылку %q",
+		"contains phone number %q":                    "содержит номер телефона %q",
+		"duplicates ad %d":                            "повторяет объявление %d",
+		"unknown bucket %q":                           "неизвестный интервал %q",
+		"query depth %d exceeds the limit of %d":      "глубина запроса %d превышает предел %d",
+		"query complexity %d exceeds the limit of %d": "сложность запроса %d превышает предел %d",
+		"limit must be between 0 and %d":              "лимит должен быть от 0 до %d",
+		"query is longer than %d bytes":               "запрос длиннее %d байт",
+	}),
+}
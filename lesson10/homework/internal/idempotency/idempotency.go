@@ -0,0 +1,56 @@
+package idempotency
+
+import (
+	"context"
+	"homework10/internal/tenant"
+	"time"
+)
+
+// MaxKeyLen limits keys clients pick, so they can't make stored records arbitrarily large.
+const MaxKeyLen = 255
+
+// Key identifies a request, keys clients pick are scoped to the tenant and the user.
+type Key struct {
+	Tenant tenant.ID
+	UserID int64
+	Value  string
+}
+
+// Record keeps the response to the first request with a key, Response is nil
+// while the request is in progress.
+type Record struct {
+	Key Key
+	// Fingerprint is a hash of the operation and its arguments, retries must have the same one
+	Fingerprint    string
+	Response       []byte
+	ExpirationDate time.Time
+}
+
+func (r Record) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpirationDate)
+}
+
+func (r Record) IsDone() bool {
+	return r.Response != nil
+}
+
+type ctxKey struct{}
+
+// ContextKey is the key the idempotency key is stored under in string-keyed request values,
+// such as gin.Context.Set.
+const ContextKey = "idempotency_key"
+
+func NewContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, key)
+}
+
+// FromContext returns the key the client sent with the request, empty if none.
+func FromContext(ctx context.Context) string {
+	if key, ok := ctx.Value(ctxKey{}).(string); ok {
+		return key
+	}
+	if key, ok := ctx.Value(ContextKey).(string); ok {
+		return key
+	}
+	return ""
+}
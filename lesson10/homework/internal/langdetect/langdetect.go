@@ -0,0 +1,143 @@
+package langdetect
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+)
+
+var ErrBadLang = fmt.Errorf("bad language")
+
+// Parse normalizes a language tag such as "ru-RU" or "RU" to its ISO 639 code, e.g. "ru".
+func Parse(s string) (string, error) {
+	tag, err := language.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrBadLang, s)
+	}
+	base, conf := tag.Base()
+	if conf != language.Exact || base.String() == "und" {
+		return "", fmt.Errorf("%w: %q", ErrBadLang, s)
+	}
+	return base.String(), nil
+}
+
+// minLetters is the number of letters a text needs for its language to be detected.
+const minLetters = 10
+
+// dominance is the share of letters of the script the text is written in.
+const dominance = 0.6
+
+// scripts are checked in order, so kana wins over Han which Japanese texts use too.
+var scripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Cyrillic, ""},
+	{unicode.Latin, ""},
+	{unicode.Greek, "el"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Hangul, "ko"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Armenian, "hy"},
+	{unicode.Georgian, "ka"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// Detect guesses the ISO 639 code of the language of the text from its script and, for
+// Cyrillic and Latin ones, from letters and words specific to languages. It returns an
+// empty string if the text is too short or the guess is unsure.
+func Detect(text string) string {
+	counts := make([]int, len(scripts))
+	total := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		for i, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	if total < minLetters {
+		return ""
+	}
+	lower := strings.ToLower(text)
+	// Japanese mixes kana with Han, any kana tells it from Chinese
+	if counts[3]+counts[4] > 0 && float64(counts[3]+counts[4]+counts[5]) >= dominance*float64(total) {
+		return "ja"
+	}
+	for i, s := range scripts {
+		if float64(counts[i]) < dominance*float64(total) {
+			continue
+		}
+		switch s.table {
+		case unicode.Cyrillic:
+			return cyrillic(lower)
+		case unicode.Latin:
+			return latin(lower)
+		}
+		return s.lang
+	}
+	return ""
+}
+
+// cyrillic tells languages by letters the others don't have, Russian is the most common otherwise.
+func cyrillic(text string) string {
+	switch {
+	case strings.ContainsAny(text, "әғқңөұһ"):
+		return "kk"
+	case strings.ContainsRune(text, 'ў'):
+		return "be"
+	case strings.ContainsAny(text, "їєґ"), strings.ContainsRune(text, 'і') && !strings.ContainsAny(text, "ыэё"):
+		return "uk"
+	}
+	return "ru"
+}
+
+// stopWords are frequent short words of languages written in Latin script.
+var stopWords = map[string][]string{
+	"en": {"the", "and", "is", "of", "to", "in", "for", "with", "it", "this", "that", "are", "not", "you", "my"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "ein", "eine", "für", "zu", "auf", "ich", "sie", "den"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "un", "pour", "avec", "pas", "dans", "je", "vous", "du"},
+	"es": {"el", "los", "las", "y", "es", "una", "para", "con", "por", "que", "del", "muy", "se", "está", "en"},
+	"it": {"il", "lo", "gli", "e", "è", "una", "per", "con", "che", "non", "della", "sono", "di", "molto", "del"},
+}
+
+// latin picks the language whose stop words the text uses most, nothing on a tie.
+func latin(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	scores := map[string]int{}
+	for _, w := range words {
+		for lang, list := range stopWords {
+			for _, s := range list {
+				if w == s {
+					scores[lang]++
+				}
+			}
+		}
+	}
+	best, bestScore, tie := "", 0, false
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = lang, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
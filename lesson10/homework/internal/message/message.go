@@ -0,0 +1,56 @@
+package message
+
+import "time"
+
+// Thread is a conversation about an ad between a buyer and the ad's author.
+type Thread struct {
+	ID           int64
+	AdID         int64
+	BuyerID      int64
+	SellerID     int64
+	CreationDate time.Time
+	UpdateDate   time.Time
+}
+
+func (t Thread) HasMember(userID int64) bool {
+	return t.BuyerID == userID || t.SellerID == userID
+}
+
+// Peer returns the other member of the thread.
+func (t Thread) Peer(userID int64) int64 {
+	if t.BuyerID == userID {
+		return t.SellerID
+	}
+	return t.BuyerID
+}
+
+type Message struct {
+	ID           int64
+	ThreadID     int64
+	SenderID     int64
+	Text         string `validate:"range:1,999"`
+	CreationDate time.Time
+	// ReadDate is zero until the recipient reads the message.
+	ReadDate time.Time
+}
+
+func (m Message) IsRead() bool {
+	return !m.ReadDate.IsZero()
+}
+
+type EventType string
+
+const (
+	EventMessage EventType = "message"
+	EventRead    EventType = "read"
+)
+
+// Event is delivered in realtime to both members of a thread.
+// For EventRead, messages of the thread up to UpToID were read by ReaderID.
+type Event struct {
+	Type     EventType
+	ThreadID int64
+	Message  Message
+	ReaderID int64
+	UpToID   int64
+}
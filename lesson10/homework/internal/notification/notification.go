@@ -0,0 +1,12 @@
+package notification
+
+import "time"
+
+// Notification tells a user that a newly published ad matches one of their saved searches.
+type Notification struct {
+	UserID       int64
+	SearchID     int64
+	AdID         int64
+	Title        string
+	CreationDate time.Time
+}
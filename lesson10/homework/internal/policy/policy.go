@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"fmt"
+	"homework10/internal/ads"
+	"strings"
+)
+
+// Severity tells what happens to an ad violating a rule.
+type Severity string
+
+const (
+	// Reject refuses to store the ad
+	Reject Severity = "reject"
+	// Flag stores the ad and puts it into the moderation queue
+	Flag Severity = "flag"
+)
+
+var ErrBadSeverity = fmt.Errorf("bad severity")
+
+func ParseSeverity(s string) (Severity, error) {
+	switch sv := Severity(s); sv {
+	case Reject, Flag:
+		return sv, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrBadSeverity, s)
+}
+
+// Content is an ad about to be stored.
+type Content struct {
+	AuthorID int64
+	Title    string
+	Text     string
+	// Siblings returns the other ads of the author, rules which don't need them never call it
+	Siblings func() ([]ads.Ad, error)
+}
+
+// Violation describes why a rule doesn't accept the ad.
+type Violation struct {
+	Rule     string
+	Severity Severity
+	Reason   string
+}
+
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Reason
+}
+
+// Verdict lists violations of all rules, an ad without violations is accepted.
+type Verdict struct {
+	Violations []Violation
+}
+
+func (v Verdict) Rejected() bool {
+	return v.has(Reject)
+}
+
+func (v Verdict) Flagged() bool {
+	return v.has(Flag)
+}
+
+func (v Verdict) has(s Severity) bool {
+	for _, violation := range v.Violations {
+		if violation.Severity == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Reasons joins reasons of violations of the given severity.
+func (v Verdict) Reasons(s Severity) string {
+	res := []string{}
+	for _, violation := range v.Violations {
+		if violation.Severity == s {
+			res = append(res, violation.String())
+		}
+	}
+	return strings.Join(res, "; ")
+}
@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/app"
+	"strings"
+)
+
+const (
+	adminKeyMetadata      = "x-admin-key"
+	authorizationMetadata = "authorization"
+	bearerPrefix          = "Bearer "
+)
+
+// adminKey returns the admin key from the incoming metadata, if any.
+func adminKey(ctx context.Context) string {
+	return incoming(ctx, adminKeyMetadata)
+}
+
+// incoming returns the first value of the incoming metadata key, empty if there is none.
+func incoming(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// authorize checks that the session passed as "authorization: Bearer <token>"
+// in the incoming metadata belongs to the user with the given ID.
+func (d AdService) authorize(ctx context.Context, userID int64) error {
+	header := incoming(ctx, authorizationMetadata)
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return status.Error(codes.PermissionDenied, app.ErrNoAccess.Error())
+	}
+	u, err := d.a.Authenticate(ctx, strings.TrimPrefix(header, bearerPrefix))
+	if err != nil {
+		return errorStatus(err)
+	}
+	if u.ID != userID {
+		return status.Error(codes.PermissionDenied, app.ErrNoAccess.Error())
+	}
+	return nil
+}
+
+func (d AdService) Register(ctx context.Context, req *RegisterRequest) (*UniversalUser, error) {
+	u, err := d.a.Register(ctx, req.Nickname, req.Email, req.Password)
+	if err != nil {
+		return &UniversalUser{}, errorStatus(err)
+	}
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
+}
+
+func (d AdService) Login(ctx context.Context, req *LoginRequest) (*SessionResponse, error) {
+	s, err := d.a.Login(ctx, req.Email, req.Password)
+	if err != nil {
+		return &SessionResponse{}, errorStatus(err)
+	}
+	return &SessionResponse{Token: s.Token, UserId: s.UserID, ExpirationDate: timestamppb.New(s.ExpirationDate)}, nil
+}
@@ -0,0 +1,20 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"homework10/internal/audit"
+)
+
+const requestIDMetadata = "x-request-id"
+
+// RequestInterceptor puts the x-request-id metadata into the context of the call for the audit log
+// and sends it back in the header, a new one is picked if the client didn't send one.
+func RequestInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	id := audit.RequestID(incoming(ctx, requestIDMetadata))
+	// the header can't be set only if the call is already gone
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+	return handler(audit.NewContext(ctx, audit.Request{ID: id, Transport: audit.GRPC}), req)
+}
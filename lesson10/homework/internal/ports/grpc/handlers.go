@@ -6,22 +6,25 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/adexpr"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/analytics"
 	"homework10/internal/app"
+	"homework10/internal/langdetect"
 )
 
 func (d AdService) CreateAd(ctx context.Context, req *CreateAdRequest) (*AdResponse, error) {
 	ad, err := d.a.CreateAd(ctx, req.Title, req.Text, req.UserId)
 	if err != nil {
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return &AdResponse{}, status.Error(codes.Internal, err.Error())
+		return &AdResponse{}, errorStatus(err)
 	}
 	return &AdResponse{Id: ad.ID,
 		Title:        ad.Title,
 		Text:         ad.Text,
 		AuthorId:     ad.AuthorID,
 		Published:    ad.Published,
+		Lang:         ad.Lang,
 		CreationDate: timestamppb.New(ad.CreationDate),
 		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
 }
@@ -29,19 +32,14 @@ func (d AdService) CreateAd(ctx context.Context, req *CreateAdRequest) (*AdRespo
 func (d AdService) ChangeAdStatus(ctx context.Context, req *ChangeAdStatusRequest) (*AdResponse, error) {
 	ad, err := d.a.ChangeAdStatus(ctx, req.AdId, req.UserId, req.Published)
 	if err != nil {
-		if errors.Is(err, app.ErrNoAccess) {
-			return &AdResponse{}, status.Error(codes.PermissionDenied, err.Error())
-		}
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return &AdResponse{}, status.Error(codes.Internal, err.Error())
+		return &AdResponse{}, errorStatus(err)
 	}
 	return &AdResponse{Id: ad.ID,
 		Title:        ad.Title,
 		Text:         ad.Text,
 		AuthorId:     ad.AuthorID,
 		Published:    ad.Published,
+		Lang:         ad.Lang,
 		CreationDate: timestamppb.New(ad.CreationDate),
 		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
 }
@@ -49,23 +47,33 @@ func (d AdService) ChangeAdStatus(ctx context.Context, req *ChangeAdStatusReques
 func (d AdService) UpdateAd(ctx context.Context, req *UpdateAdRequest) (*AdResponse, error) {
 	ad, err := d.a.UpdateAd(ctx, req.AdId, req.UserId, req.Title, req.Text)
 	if err != nil {
-		if errors.Is(err, app.ErrNoAccess) {
-			return &AdResponse{}, status.Error(codes.PermissionDenied, err.Error())
-		}
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return &AdResponse{}, status.Error(codes.Internal, err.Error())
+		return &AdResponse{}, errorStatus(err)
 	}
 	return &AdResponse{Id: ad.ID,
 		Title:        ad.Title,
 		Text:         ad.Text,
 		AuthorId:     ad.AuthorID,
 		Published:    ad.Published,
+		Lang:         ad.Lang,
 		CreationDate: timestamppb.New(ad.CreationDate),
 		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
 }
 
+func (d AdService) SetAdLanguage(ctx context.Context, req *SetAdLanguageRequest) (*AdResponse, error) {
+	ad, err := d.a.SetAdLanguage(ctx, req.AdId, req.UserId, req.Lang)
+	if err != nil {
+		return &AdResponse{}, errorStatus(err)
+	}
+	return &AdResponse{Id: ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorId:     ad.AuthorID,
+		Published:    ad.Published,
+		Lang:         ad.Lang,
+		CreationDate: timestamppb.New(ad.CreationDate),
+		UpdateDate:   timestamppb.New(ad.UpdateDate)}, nil
+}
+
 func (d AdService) DeleteAd(ctx context.Context, req *DeleteAdRequest) (*AdResponse, error) {
 	ad, err := d.a.DeleteAd(ctx, req.AdId, req.UserId)
 	if err != nil {
@@ -82,18 +90,53 @@ func (d AdService) DeleteAd(ctx context.Context, req *DeleteAdRequest) (*AdRespo
 		Text:         ad.Text,
 		AuthorId:     ad.AuthorID,
 		Published:    ad.Published,
+		Lang:         ad.Lang,
 		CreationDate: timestamppb.New(ad.CreationDate),
 		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
 }
 
 func (d AdService) ListAds(ctx context.Context, req *FilterRequest) (*ListAdResponse, error) {
-	f, err := d.a.GetNewFilter(ctx)
+	adp, err := d.pattern(ctx, req)
+	if err != nil {
+		return &ListAdResponse{}, patternStatus(err)
+	}
+	ads, err := d.a.GetAllAdsByTemplate(ctx, adp)
 	if err != nil {
 		return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
 	}
+	res := ListAdResponse{}
+	for _, ad := range ads {
+		res.List = append(res.List, &AdResponse{Id: ad.ID,
+			Title:        ad.Title,
+			Text:         ad.Text,
+			AuthorId:     ad.AuthorID,
+			Published:    ad.Published,
+			Lang:         ad.Lang,
+			CreationDate: timestamppb.New(ad.CreationDate),
+			UpdateDate:   timestamppb.New(ad.CreationDate)})
+	}
+	return &res, nil
+}
+
+// patternStatus maps errors of pattern, invalid sort specs, expressions and languages are the
+// client's fault.
+func patternStatus(err error) error {
+	if errors.Is(err, adpattern.ErrBadSort) || errors.Is(err, adexpr.ErrBadExpr) ||
+		errors.Is(err, langdetect.ErrBadLang) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// pattern builds a search pattern from the request with a fresh filter.
+func (d AdService) pattern(ctx context.Context, req *FilterRequest) (adpattern.AdPattern, error) {
+	f, err := d.a.GetNewFilter(ctx)
+	if err != nil {
+		return adpattern.AdPattern{}, err
+	}
 	f, err = f.SetAuthor(ctx, req.AuthorId)
 	if err != nil {
-		return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
+		return adpattern.AdPattern{}, err
 	}
 	if req.PublishedConfig != PublishedConfig_NotGiven {
 		var publishedOnly bool
@@ -102,46 +145,62 @@ func (d AdService) ListAds(ctx context.Context, req *FilterRequest) (*ListAdResp
 		}
 		f, err = f.SetStatus(ctx, publishedOnly)
 		if err != nil {
-			return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if req.Lang != "" {
+		lang, err := langdetect.Parse(req.Lang)
+		if err != nil {
+			return adpattern.AdPattern{}, err
+		}
+		f, err = f.SetLang(ctx, lang)
+		if err != nil {
+			return adpattern.AdPattern{}, err
 		}
 	}
 	lDate := req.LDate.AsTime().UTC()
 	if lDate.Unix() != 0 {
 		f, err = f.SetLTime(ctx, lDate)
 		if err != nil {
-			return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
+			return adpattern.AdPattern{}, err
 		}
 	}
 	rDate := req.RDate.AsTime().UTC()
 	if rDate.Unix() != 0 {
 		f, err = f.SetRTime(ctx, rDate)
 		if err != nil {
-			return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
+			return adpattern.AdPattern{}, err
 		}
 	}
-	adp, err := f.GetPattern(ctx)
-	if err != nil {
-		return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
+	if len(req.OrderBy) > 0 {
+		f, err = f.SetSort(ctx, order(req.OrderBy))
+		if err != nil {
+			return adpattern.AdPattern{}, err
+		}
 	}
-	ads, err := d.a.GetAllAdsByTemplate(ctx, adp)
-	if err != nil {
-		return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
+	if req.FilterExpr != "" {
+		expr, err := adexpr.Parse(req.FilterExpr)
+		if err != nil {
+			return adpattern.AdPattern{}, err
+		}
+		f, err = f.SetExpr(ctx, expr)
+		if err != nil {
+			return adpattern.AdPattern{}, err
+		}
 	}
-	res := ListAdResponse{}
-	for _, ad := range ads {
-		res.List = append(res.List, &AdResponse{Id: ad.ID,
-			Title:        ad.Title,
-			Text:         ad.Text,
-			AuthorId:     ad.AuthorID,
-			Published:    ad.Published,
-			CreationDate: timestamppb.New(ad.CreationDate),
-			UpdateDate:   timestamppb.New(ad.CreationDate)})
+	return f.GetPattern(ctx)
+}
+
+func order(list []*OrderBy) []adpattern.OrderBy {
+	res := make([]adpattern.OrderBy, 0, len(list))
+	for _, o := range list {
+		res = append(res, adpattern.OrderBy{Field: o.Field, Desc: o.Desc})
 	}
-	return &res, nil
+	return res
 }
 
 func (d AdService) GetAdByID(ctx context.Context, req *GetAdRequest) (*AdResponse, error) {
-	ad, err := d.a.FindAd(ctx, req.Id)
+	ad, err := d.a.FindAd(analytics.NewContext(ctx, viewer(ctx)), req.Id)
 	if err != nil {
 		if errors.Is(err, app.ErrWrongFormat) {
 			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
@@ -153,19 +212,17 @@ func (d AdService) GetAdByID(ctx context.Context, req *GetAdRequest) (*AdRespons
 		Text:         ad.Text,
 		AuthorId:     ad.AuthorID,
 		Published:    ad.Published,
+		Lang:         ad.Lang,
 		CreationDate: timestamppb.New(ad.CreationDate),
 		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
 }
 
 func (d AdService) CreateUser(ctx context.Context, req *UniversalUser) (*UniversalUser, error) {
-	u, err := d.a.CreateUserByID(ctx, req.Nickname, req.Email, req.UserId)
+	u, err := d.a.CreateUserByID(app.ContextWithAdminKey(ctx, adminKey(ctx)), req.Nickname, req.Email, req.UserId)
 	if err != nil {
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &UniversalUser{}, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return &UniversalUser{}, status.Error(codes.Internal, err.Error())
+		return &UniversalUser{}, errorStatus(err)
 	}
-	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email}, nil
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
 }
 
 func (d AdService) DeleteUserByID(ctx context.Context, req *DeleteUserRequest) (*UniversalUser, error) {
@@ -176,7 +233,7 @@ func (d AdService) DeleteUserByID(ctx context.Context, req *DeleteUserRequest) (
 		}
 		return &UniversalUser{}, status.Error(codes.Internal, err.Error())
 	}
-	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email}, nil
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
 }
 
 func (d AdService) ChangeUserInfo(ctx context.Context, req *UniversalUser) (*UniversalUser, error) {
@@ -187,21 +244,28 @@ func (d AdService) ChangeUserInfo(ctx context.Context, req *UniversalUser) (*Uni
 		}
 		return &UniversalUser{}, status.Error(codes.Internal, err.Error())
 	}
-	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email}, nil
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
 }
 
 func (d AdService) GetAdsByTitle(ctx context.Context, req *AdsByTitleRequest) (*ListAdResponse, error) {
-	ads, err := d.a.GetAdsByTitle(ctx, req.Title)
+	var list []ads.Ad
+	var err error
+	if len(req.OrderBy) > 0 {
+		list, err = d.a.GetAllAdsByTemplate(ctx, adpattern.AdPattern{Title: req.Title, Sort: order(req.OrderBy)})
+	} else {
+		list, err = d.a.GetAdsByTitle(ctx, req.Title)
+	}
 	if err != nil {
-		return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
+		return &ListAdResponse{}, errorStatus(err)
 	}
 	res := ListAdResponse{}
-	for _, ad := range ads {
+	for _, ad := range list {
 		res.List = append(res.List, &AdResponse{Id: ad.ID,
 			Title:        ad.Title,
 			Text:         ad.Text,
 			AuthorId:     ad.AuthorID,
 			Published:    ad.Published,
+			Lang:         ad.Lang,
 			CreationDate: timestamppb.New(ad.CreationDate),
 			UpdateDate:   timestamppb.New(ad.CreationDate)})
 	}
@@ -216,5 +280,5 @@ func (d AdService) GetUserByID(ctx context.Context, req *GetUserRequest) (*Unive
 	if !isFound {
 		return &UniversalUser{}, status.Error(codes.NotFound, "")
 	}
-	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email}, nil
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
 }
@@ -0,0 +1,18 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"homework10/internal/idempotency"
+)
+
+const idempotencyMetadata = "idempotency-key"
+
+// IdempotencyInterceptor puts the idempotency-key metadata into the context of the call.
+func IdempotencyInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	if key := incoming(ctx, idempotencyMetadata); key != "" {
+		ctx = idempotency.NewContext(ctx, key)
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,24 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"homework10/internal/i18n"
+)
+
+const localeMetadata = "accept-language"
+
+// LocaleInterceptor translates messages of errors returned by the call to the language
+// picked by the accept-language metadata.
+func LocaleInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	tag := i18n.Match(incoming(ctx, localeMetadata))
+	res, err := handler(i18n.NewContext(ctx, tag), req)
+	if err == nil {
+		return res, nil
+	}
+	p := status.Convert(err).Proto()
+	p.Message = i18n.Translate(tag, p.Message)
+	return res, status.ErrorProto(p)
+}
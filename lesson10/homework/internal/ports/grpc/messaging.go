@@ -0,0 +1,131 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/app"
+	"homework10/internal/message"
+)
+
+// errorStatus maps application errors to gRPC status errors.
+func errorStatus(err error) error {
+	if errors.Is(err, app.ErrNoAccess) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	if errors.Is(err, app.ErrWrongFormat) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, app.ErrConflict) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func threadResponse(t message.Thread) *ThreadResponse {
+	return &ThreadResponse{Id: t.ID,
+		AdId:         t.AdID,
+		BuyerId:      t.BuyerID,
+		SellerId:     t.SellerID,
+		CreationDate: timestamppb.New(t.CreationDate),
+		UpdateDate:   timestamppb.New(t.UpdateDate)}
+}
+
+func messageResponse(m message.Message) *MessageResponse {
+	res := &MessageResponse{Id: m.ID,
+		ThreadId:     m.ThreadID,
+		SenderId:     m.SenderID,
+		Text:         m.Text,
+		CreationDate: timestamppb.New(m.CreationDate)}
+	if m.IsRead() {
+		res.ReadDate = timestamppb.New(m.ReadDate)
+	}
+	return res
+}
+
+func (d AdService) OpenThread(ctx context.Context, req *OpenThreadRequest) (*ThreadResponse, error) {
+	if err := d.authorize(ctx, req.UserId); err != nil {
+		return &ThreadResponse{}, err
+	}
+	t, err := d.a.OpenThread(ctx, req.AdId, req.UserId)
+	if err != nil {
+		return &ThreadResponse{}, errorStatus(err)
+	}
+	return threadResponse(t), nil
+}
+
+func (d AdService) ListThreads(ctx context.Context, req *GetUserRequest) (*ListThreadResponse, error) {
+	if err := d.authorize(ctx, req.Id); err != nil {
+		return &ListThreadResponse{}, err
+	}
+	list, err := d.a.ListThreads(ctx, req.Id)
+	if err != nil {
+		return &ListThreadResponse{}, errorStatus(err)
+	}
+	res := ListThreadResponse{}
+	for _, t := range list {
+		res.List = append(res.List, threadResponse(t))
+	}
+	return &res, nil
+}
+
+func (d AdService) SendMessage(ctx context.Context, req *SendMessageRequest) (*MessageResponse, error) {
+	if err := d.authorize(ctx, req.UserId); err != nil {
+		return &MessageResponse{}, err
+	}
+	m, err := d.a.SendMessage(ctx, req.ThreadId, req.UserId, req.Text)
+	if err != nil {
+		return &MessageResponse{}, errorStatus(err)
+	}
+	return messageResponse(m), nil
+}
+
+func (d AdService) GetMessages(ctx context.Context, req *GetMessagesRequest) (*ListMessageResponse, error) {
+	if err := d.authorize(ctx, req.UserId); err != nil {
+		return &ListMessageResponse{}, err
+	}
+	list, err := d.a.GetMessages(ctx, req.ThreadId, req.UserId, req.BeforeId, int(req.Limit))
+	if err != nil {
+		return &ListMessageResponse{}, errorStatus(err)
+	}
+	res := ListMessageResponse{}
+	for _, m := range list {
+		res.List = append(res.List, messageResponse(m))
+	}
+	return &res, nil
+}
+
+func (d AdService) MarkRead(ctx context.Context, req *MarkReadRequest) (*MarkReadResponse, error) {
+	if err := d.authorize(ctx, req.UserId); err != nil {
+		return &MarkReadResponse{}, err
+	}
+	n, err := d.a.MarkRead(ctx, req.ThreadId, req.UserId, req.UpToId)
+	if err != nil {
+		return &MarkReadResponse{}, errorStatus(err)
+	}
+	return &MarkReadResponse{ThreadId: req.ThreadId, UpToId: req.UpToId, Marked: int32(n)}, nil
+}
+
+func (d AdService) BlockUser(ctx context.Context, req *BlockUserRequest) (*UniversalUser, error) {
+	if err := d.authorize(ctx, req.UserId); err != nil {
+		return &UniversalUser{}, err
+	}
+	u, err := d.a.BlockUser(ctx, req.UserId, req.BlockedId)
+	if err != nil {
+		return &UniversalUser{}, errorStatus(err)
+	}
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
+}
+
+func (d AdService) UnblockUser(ctx context.Context, req *BlockUserRequest) (*UniversalUser, error) {
+	if err := d.authorize(ctx, req.UserId); err != nil {
+		return &UniversalUser{}, err
+	}
+	u, err := d.a.UnblockUser(ctx, req.UserId, req.BlockedId)
+	if err != nil {
+		return &UniversalUser{}, errorStatus(err)
+	}
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
+}
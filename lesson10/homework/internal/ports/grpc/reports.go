@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/report"
+)
+
+var reportReasons = map[ReportReason]report.Reason{
+	ReportReason_Spam:       report.ReasonSpam,
+	ReportReason_Fraud:      report.ReasonFraud,
+	ReportReason_Offensive:  report.ReasonOffensive,
+	ReportReason_Prohibited: report.ReasonProhibited,
+	ReportReason_Other:      report.ReasonOther,
+}
+
+func reportResponse(r report.Report) *ReportResponse {
+	res := &ReportResponse{Id: r.ID,
+		AdId:         r.AdID,
+		ReporterId:   r.ReporterID,
+		Comment:      r.Comment,
+		Status:       string(r.Status),
+		CreationDate: timestamppb.New(r.CreationDate)}
+	for k, v := range reportReasons {
+		if v == r.Reason {
+			res.Reason = k
+		}
+	}
+	if !r.IsOpen() {
+		res.ResolutionDate = timestamppb.New(r.ResolutionDate)
+	}
+	return res
+}
+
+func (d AdService) ReportAd(ctx context.Context, req *ReportAdRequest) (*ReportResponse, error) {
+	reason, ok := reportReasons[req.Reason]
+	if !ok {
+		return &ReportResponse{}, status.Error(codes.InvalidArgument, report.ErrBadReason.Error())
+	}
+	r, err := d.a.ReportAd(ctx, req.AdId, req.UserId, reason, req.Comment)
+	if err != nil {
+		return &ReportResponse{}, errorStatus(err)
+	}
+	return reportResponse(r), nil
+}
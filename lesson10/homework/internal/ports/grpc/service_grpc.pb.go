@@ -33,6 +33,27 @@ type AdServiceClient interface {
 	ChangeUserInfo(ctx context.Context, in *UniversalUser, opts ...grpc.CallOption) (*UniversalUser, error)
 	GetAdsByTitle(ctx context.Context, in *AdsByTitleRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
 	GetUserByID(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UniversalUser, error)
+	AddFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	RemoveFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	ListFavorites(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	SaveSearch(ctx context.Context, in *SaveSearchRequest, opts ...grpc.CallOption) (*SavedSearchResponse, error)
+	ListSavedSearches(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*ListSavedSearchResponse, error)
+	DeleteSavedSearch(ctx context.Context, in *DeleteSavedSearchRequest, opts ...grpc.CallOption) (*SavedSearchResponse, error)
+	OpenThread(ctx context.Context, in *OpenThreadRequest, opts ...grpc.CallOption) (*ThreadResponse, error)
+	ListThreads(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*ListThreadResponse, error)
+	SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error)
+	GetMessages(ctx context.Context, in *GetMessagesRequest, opts ...grpc.CallOption) (*ListMessageResponse, error)
+	MarkRead(ctx context.Context, in *MarkReadRequest, opts ...grpc.CallOption) (*MarkReadResponse, error)
+	BlockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*UniversalUser, error)
+	UnblockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*UniversalUser, error)
+	RequestVerification(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UniversalUser, error)
+	VerifyEmail(ctx context.Context, in *VerifyEmailRequest, opts ...grpc.CallOption) (*UniversalUser, error)
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*UniversalUser, error)
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*SessionResponse, error)
+	ListTrash(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	RestoreAd(ctx context.Context, in *RestoreAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	ReportAd(ctx context.Context, in *ReportAdRequest, opts ...grpc.CallOption) (*ReportResponse, error)
+	SetAdLanguage(ctx context.Context, in *SetAdLanguageRequest, opts ...grpc.CallOption) (*AdResponse, error)
 }
 
 type adServiceClient struct {
@@ -142,6 +163,195 @@ func (c *adServiceClient) GetUserByID(ctx context.Context, in *GetUserRequest, o
 	return out, nil
 }
 
+func (c *adServiceClient) AddFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/AddFavorite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RemoveFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/RemoveFavorite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListFavorites(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
+	out := new(ListAdResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/ListFavorites", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SaveSearch(ctx context.Context, in *SaveSearchRequest, opts ...grpc.CallOption) (*SavedSearchResponse, error) {
+	out := new(SavedSearchResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/SaveSearch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListSavedSearches(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*ListSavedSearchResponse, error) {
+	out := new(ListSavedSearchResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/ListSavedSearches", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) DeleteSavedSearch(ctx context.Context, in *DeleteSavedSearchRequest, opts ...grpc.CallOption) (*SavedSearchResponse, error) {
+	out := new(SavedSearchResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/DeleteSavedSearch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) OpenThread(ctx context.Context, in *OpenThreadRequest, opts ...grpc.CallOption) (*ThreadResponse, error) {
+	out := new(ThreadResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/OpenThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListThreads(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*ListThreadResponse, error) {
+	out := new(ListThreadResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/ListThreads", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error) {
+	out := new(MessageResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/SendMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) GetMessages(ctx context.Context, in *GetMessagesRequest, opts ...grpc.CallOption) (*ListMessageResponse, error) {
+	out := new(ListMessageResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/GetMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) MarkRead(ctx context.Context, in *MarkReadRequest, opts ...grpc.CallOption) (*MarkReadResponse, error) {
+	out := new(MarkReadResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/MarkRead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) BlockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*UniversalUser, error) {
+	out := new(UniversalUser)
+	err := c.cc.Invoke(ctx, "/ad.AdService/BlockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) UnblockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*UniversalUser, error) {
+	out := new(UniversalUser)
+	err := c.cc.Invoke(ctx, "/ad.AdService/UnblockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RequestVerification(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UniversalUser, error) {
+	out := new(UniversalUser)
+	err := c.cc.Invoke(ctx, "/ad.AdService/RequestVerification", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) VerifyEmail(ctx context.Context, in *VerifyEmailRequest, opts ...grpc.CallOption) (*UniversalUser, error) {
+	out := new(UniversalUser)
+	err := c.cc.Invoke(ctx, "/ad.AdService/VerifyEmail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*UniversalUser, error) {
+	out := new(UniversalUser)
+	err := c.cc.Invoke(ctx, "/ad.AdService/Register", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*SessionResponse, error) {
+	out := new(SessionResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/Login", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListTrash(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
+	out := new(ListAdResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/ListTrash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RestoreAd(ctx context.Context, in *RestoreAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/RestoreAd", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ReportAd(ctx context.Context, in *ReportAdRequest, opts ...grpc.CallOption) (*ReportResponse, error) {
+	out := new(ReportResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/ReportAd", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SetAdLanguage(ctx context.Context, in *SetAdLanguageRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, "/ad.AdService/SetAdLanguage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AdServiceServer is the server API for AdService service.
 // All implementations should embed UnimplementedAdServiceServer
 // for forward compatibility
@@ -157,6 +367,27 @@ type AdServiceServer interface {
 	ChangeUserInfo(context.Context, *UniversalUser) (*UniversalUser, error)
 	GetAdsByTitle(context.Context, *AdsByTitleRequest) (*ListAdResponse, error)
 	GetUserByID(context.Context, *GetUserRequest) (*UniversalUser, error)
+	AddFavorite(context.Context, *FavoriteRequest) (*AdResponse, error)
+	RemoveFavorite(context.Context, *FavoriteRequest) (*AdResponse, error)
+	ListFavorites(context.Context, *GetUserRequest) (*ListAdResponse, error)
+	SaveSearch(context.Context, *SaveSearchRequest) (*SavedSearchResponse, error)
+	ListSavedSearches(context.Context, *GetUserRequest) (*ListSavedSearchResponse, error)
+	DeleteSavedSearch(context.Context, *DeleteSavedSearchRequest) (*SavedSearchResponse, error)
+	OpenThread(context.Context, *OpenThreadRequest) (*ThreadResponse, error)
+	ListThreads(context.Context, *GetUserRequest) (*ListThreadResponse, error)
+	SendMessage(context.Context, *SendMessageRequest) (*MessageResponse, error)
+	GetMessages(context.Context, *GetMessagesRequest) (*ListMessageResponse, error)
+	MarkRead(context.Context, *MarkReadRequest) (*MarkReadResponse, error)
+	BlockUser(context.Context, *BlockUserRequest) (*UniversalUser, error)
+	UnblockUser(context.Context, *BlockUserRequest) (*UniversalUser, error)
+	RequestVerification(context.Context, *GetUserRequest) (*UniversalUser, error)
+	VerifyEmail(context.Context, *VerifyEmailRequest) (*UniversalUser, error)
+	Register(context.Context, *RegisterRequest) (*UniversalUser, error)
+	Login(context.Context, *LoginRequest) (*SessionResponse, error)
+	ListTrash(context.Context, *GetUserRequest) (*ListAdResponse, error)
+	RestoreAd(context.Context, *RestoreAdRequest) (*AdResponse, error)
+	ReportAd(context.Context, *ReportAdRequest) (*ReportResponse, error)
+	SetAdLanguage(context.Context, *SetAdLanguageRequest) (*AdResponse, error)
 }
 
 // UnimplementedAdServiceServer should be embedded to have forward compatible implementations.
@@ -196,6 +427,69 @@ func (UnimplementedAdServiceServer) GetAdsByTitle(context.Context, *AdsByTitleRe
 func (UnimplementedAdServiceServer) GetUserByID(context.Context, *GetUserRequest) (*UniversalUser, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserByID not implemented")
 }
+func (UnimplementedAdServiceServer) AddFavorite(context.Context, *FavoriteRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddFavorite not implemented")
+}
+func (UnimplementedAdServiceServer) RemoveFavorite(context.Context, *FavoriteRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFavorite not implemented")
+}
+func (UnimplementedAdServiceServer) ListFavorites(context.Context, *GetUserRequest) (*ListAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFavorites not implemented")
+}
+func (UnimplementedAdServiceServer) SaveSearch(context.Context, *SaveSearchRequest) (*SavedSearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveSearch not implemented")
+}
+func (UnimplementedAdServiceServer) ListSavedSearches(context.Context, *GetUserRequest) (*ListSavedSearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSavedSearches not implemented")
+}
+func (UnimplementedAdServiceServer) DeleteSavedSearch(context.Context, *DeleteSavedSearchRequest) (*SavedSearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSavedSearch not implemented")
+}
+func (UnimplementedAdServiceServer) OpenThread(context.Context, *OpenThreadRequest) (*ThreadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenThread not implemented")
+}
+func (UnimplementedAdServiceServer) ListThreads(context.Context, *GetUserRequest) (*ListThreadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListThreads not implemented")
+}
+func (UnimplementedAdServiceServer) SendMessage(context.Context, *SendMessageRequest) (*MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
+}
+func (UnimplementedAdServiceServer) GetMessages(context.Context, *GetMessagesRequest) (*ListMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMessages not implemented")
+}
+func (UnimplementedAdServiceServer) MarkRead(context.Context, *MarkReadRequest) (*MarkReadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkRead not implemented")
+}
+func (UnimplementedAdServiceServer) BlockUser(context.Context, *BlockUserRequest) (*UniversalUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockUser not implemented")
+}
+func (UnimplementedAdServiceServer) UnblockUser(context.Context, *BlockUserRequest) (*UniversalUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockUser not implemented")
+}
+func (UnimplementedAdServiceServer) RequestVerification(context.Context, *GetUserRequest) (*UniversalUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestVerification not implemented")
+}
+func (UnimplementedAdServiceServer) VerifyEmail(context.Context, *VerifyEmailRequest) (*UniversalUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
+}
+func (UnimplementedAdServiceServer) Register(context.Context, *RegisterRequest) (*UniversalUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedAdServiceServer) Login(context.Context, *LoginRequest) (*SessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedAdServiceServer) ListTrash(context.Context, *GetUserRequest) (*ListAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrash not implemented")
+}
+func (UnimplementedAdServiceServer) RestoreAd(context.Context, *RestoreAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreAd not implemented")
+}
+func (UnimplementedAdServiceServer) ReportAd(context.Context, *ReportAdRequest) (*ReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportAd not implemented")
+}
+func (UnimplementedAdServiceServer) SetAdLanguage(context.Context, *SetAdLanguageRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAdLanguage not implemented")
+}
 
 // UnsafeAdServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to AdServiceServer will
@@ -406,6 +700,384 @@ func _AdService_GetUserByID_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AdService_AddFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).AddFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/AddFavorite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).AddFavorite(ctx, req.(*FavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RemoveFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RemoveFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/RemoveFavorite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RemoveFavorite(ctx, req.(*FavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListFavorites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListFavorites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/ListFavorites",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListFavorites(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SaveSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveSearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SaveSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/SaveSearch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SaveSearch(ctx, req.(*SaveSearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListSavedSearches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListSavedSearches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/ListSavedSearches",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListSavedSearches(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_DeleteSavedSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSavedSearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).DeleteSavedSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/DeleteSavedSearch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).DeleteSavedSearch(ctx, req.(*DeleteSavedSearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_OpenThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).OpenThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/OpenThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).OpenThread(ctx, req.(*OpenThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListThreads_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListThreads(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/ListThreads",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListThreads(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SendMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/SendMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SendMessage(ctx, req.(*SendMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_GetMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).GetMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/GetMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).GetMessages(ctx, req.(*GetMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_MarkRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).MarkRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/MarkRead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).MarkRead(ctx, req.(*MarkReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_BlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).BlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/BlockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).BlockUser(ctx, req.(*BlockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_UnblockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).UnblockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/UnblockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).UnblockUser(ctx, req.(*BlockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RequestVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RequestVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/RequestVerification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RequestVerification(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyEmailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).VerifyEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/VerifyEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).VerifyEmail(ctx, req.(*VerifyEmailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/Login",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/ListTrash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListTrash(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RestoreAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RestoreAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/RestoreAd",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RestoreAd(ctx, req.(*RestoreAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ReportAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ReportAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/ReportAd",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ReportAd(ctx, req.(*ReportAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SetAdLanguage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAdLanguageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SetAdLanguage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ad.AdService/SetAdLanguage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SetAdLanguage(ctx, req.(*SetAdLanguageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AdService_ServiceDesc is the grpc.ServiceDesc for AdService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -457,6 +1129,90 @@ var AdService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetUserByID",
 			Handler:    _AdService_GetUserByID_Handler,
 		},
+		{
+			MethodName: "AddFavorite",
+			Handler:    _AdService_AddFavorite_Handler,
+		},
+		{
+			MethodName: "RemoveFavorite",
+			Handler:    _AdService_RemoveFavorite_Handler,
+		},
+		{
+			MethodName: "ListFavorites",
+			Handler:    _AdService_ListFavorites_Handler,
+		},
+		{
+			MethodName: "SaveSearch",
+			Handler:    _AdService_SaveSearch_Handler,
+		},
+		{
+			MethodName: "ListSavedSearches",
+			Handler:    _AdService_ListSavedSearches_Handler,
+		},
+		{
+			MethodName: "DeleteSavedSearch",
+			Handler:    _AdService_DeleteSavedSearch_Handler,
+		},
+		{
+			MethodName: "OpenThread",
+			Handler:    _AdService_OpenThread_Handler,
+		},
+		{
+			MethodName: "ListThreads",
+			Handler:    _AdService_ListThreads_Handler,
+		},
+		{
+			MethodName: "SendMessage",
+			Handler:    _AdService_SendMessage_Handler,
+		},
+		{
+			MethodName: "GetMessages",
+			Handler:    _AdService_GetMessages_Handler,
+		},
+		{
+			MethodName: "MarkRead",
+			Handler:    _AdService_MarkRead_Handler,
+		},
+		{
+			MethodName: "BlockUser",
+			Handler:    _AdService_BlockUser_Handler,
+		},
+		{
+			MethodName: "UnblockUser",
+			Handler:    _AdService_UnblockUser_Handler,
+		},
+		{
+			MethodName: "RequestVerification",
+			Handler:    _AdService_RequestVerification_Handler,
+		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _AdService_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "Register",
+			Handler:    _AdService_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _AdService_Login_Handler,
+		},
+		{
+			MethodName: "ListTrash",
+			Handler:    _AdService_ListTrash_Handler,
+		},
+		{
+			MethodName: "RestoreAd",
+			Handler:    _AdService_RestoreAd_Handler,
+		},
+		{
+			MethodName: "ReportAd",
+			Handler:    _AdService_ReportAd_Handler,
+		},
+		{
+			MethodName: "SetAdLanguage",
+			Handler:    _AdService_SetAdLanguage_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "service.proto",
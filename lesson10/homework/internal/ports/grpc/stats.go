@@ -0,0 +1,20 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc/peer"
+	"net"
+)
+
+// viewer identifies the caller by its address for deduplication of views.
+func viewer(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/search"
+)
+
+func favoriteResponse(ad ads.Ad, err error) (*AdResponse, error) {
+	if err != nil {
+		if errors.Is(err, app.ErrWrongFormat) {
+			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return &AdResponse{}, status.Error(codes.Internal, err.Error())
+	}
+	return &AdResponse{Id: ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorId:     ad.AuthorID,
+		Published:    ad.Published,
+		Lang:         ad.Lang,
+		CreationDate: timestamppb.New(ad.CreationDate),
+		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
+}
+
+func (d AdService) AddFavorite(ctx context.Context, req *FavoriteRequest) (*AdResponse, error) {
+	return favoriteResponse(d.a.AddFavorite(ctx, req.UserId, req.AdId))
+}
+
+func (d AdService) RemoveFavorite(ctx context.Context, req *FavoriteRequest) (*AdResponse, error) {
+	return favoriteResponse(d.a.RemoveFavorite(ctx, req.UserId, req.AdId))
+}
+
+func (d AdService) ListFavorites(ctx context.Context, req *GetUserRequest) (*ListAdResponse, error) {
+	ads, err := d.a.ListFavorites(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongFormat) {
+			return &ListAdResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return &ListAdResponse{}, status.Error(codes.Internal, err.Error())
+	}
+	res := ListAdResponse{}
+	for _, ad := range ads {
+		res.List = append(res.List, &AdResponse{Id: ad.ID,
+			Title:        ad.Title,
+			Text:         ad.Text,
+			AuthorId:     ad.AuthorID,
+			Published:    ad.Published,
+			Lang:         ad.Lang,
+			CreationDate: timestamppb.New(ad.CreationDate),
+			UpdateDate:   timestamppb.New(ad.CreationDate)})
+	}
+	return &res, nil
+}
+
+func savedSearchResponse(s search.SavedSearch) *SavedSearchResponse {
+	filter := &FilterRequest{AuthorId: s.Pattern.AuthorID, Lang: s.Pattern.Lang, PublishedConfig: PublishedConfig_AllAds}
+	if s.Pattern.PublishedOnly {
+		filter.PublishedConfig = PublishedConfig_PublishedOnly
+	}
+	if s.Pattern.IsLTimeSet {
+		filter.LDate = timestamppb.New(s.Pattern.LDate)
+	}
+	if s.Pattern.IsRTimeSet {
+		filter.RDate = timestamppb.New(s.Pattern.RDate)
+	}
+	if s.Pattern.Expr != nil {
+		filter.FilterExpr = s.Pattern.Expr.String()
+	}
+	return &SavedSearchResponse{Id: s.ID, UserId: s.UserID, Name: s.Name, Filter: filter}
+}
+
+func (d AdService) SaveSearch(ctx context.Context, req *SaveSearchRequest) (*SavedSearchResponse, error) {
+	filter := req.Filter
+	if filter == nil {
+		filter = &FilterRequest{}
+	}
+	adp, err := d.pattern(ctx, filter)
+	if err != nil {
+		return &SavedSearchResponse{}, patternStatus(err)
+	}
+	s, err := d.a.SaveSearch(ctx, req.UserId, req.Name, adp)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongFormat) {
+			return &SavedSearchResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return &SavedSearchResponse{}, status.Error(codes.Internal, err.Error())
+	}
+	return savedSearchResponse(s), nil
+}
+
+func (d AdService) ListSavedSearches(ctx context.Context, req *GetUserRequest) (*ListSavedSearchResponse, error) {
+	list, err := d.a.ListSavedSearches(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongFormat) {
+			return &ListSavedSearchResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return &ListSavedSearchResponse{}, status.Error(codes.Internal, err.Error())
+	}
+	res := ListSavedSearchResponse{}
+	for _, s := range list {
+		res.List = append(res.List, savedSearchResponse(s))
+	}
+	return &res, nil
+}
+
+func (d AdService) DeleteSavedSearch(ctx context.Context, req *DeleteSavedSearchRequest) (*SavedSearchResponse, error) {
+	s, err := d.a.DeleteSavedSearch(ctx, req.UserId, req.SearchId)
+	if err != nil {
+		if errors.Is(err, app.ErrNoAccess) {
+			return &SavedSearchResponse{}, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrWrongFormat) {
+			return &SavedSearchResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return &SavedSearchResponse{}, status.Error(codes.Internal, err.Error())
+	}
+	return savedSearchResponse(s), nil
+}
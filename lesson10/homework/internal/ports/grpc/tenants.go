@@ -0,0 +1,25 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework10/internal/app"
+	"homework10/internal/tenant"
+)
+
+const tenantMetadata = "x-tenant-id"
+
+// TenantInterceptor puts the tenant named by the x-tenant-id metadata, or owning the
+// authority the call was sent to, into the context of the call.
+func TenantInterceptor(a app.App) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		id, err := a.ResolveTenant(ctx, incoming(ctx, tenantMetadata), incoming(ctx, ":authority"))
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return handler(tenant.NewContext(ctx, id), req)
+	}
+}
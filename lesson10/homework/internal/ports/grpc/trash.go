@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/ads"
+)
+
+func trashedAdResponse(ad ads.Ad) *AdResponse {
+	res := &AdResponse{Id: ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorId:     ad.AuthorID,
+		Published:    ad.Published,
+		Lang:         ad.Lang,
+		CreationDate: timestamppb.New(ad.CreationDate),
+		UpdateDate:   timestamppb.New(ad.UpdateDate)}
+	if ad.IsDeleted() {
+		res.DeletionDate = timestamppb.New(ad.DeletionDate)
+	}
+	return res
+}
+
+func (d AdService) ListTrash(ctx context.Context, req *GetUserRequest) (*ListAdResponse, error) {
+	list, err := d.a.ListTrash(ctx, req.Id)
+	if err != nil {
+		return &ListAdResponse{}, errorStatus(err)
+	}
+	res := ListAdResponse{}
+	for _, ad := range list {
+		res.List = append(res.List, trashedAdResponse(ad))
+	}
+	return &res, nil
+}
+
+func (d AdService) RestoreAd(ctx context.Context, req *RestoreAdRequest) (*AdResponse, error) {
+	ad, err := d.a.RestoreAd(ctx, req.AdId, req.UserId)
+	if err != nil {
+		return &AdResponse{}, errorStatus(err)
+	}
+	return trashedAdResponse(ad), nil
+}
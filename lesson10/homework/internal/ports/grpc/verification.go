@@ -0,0 +1,21 @@
+package grpc
+
+import (
+	"context"
+)
+
+func (d AdService) RequestVerification(ctx context.Context, req *GetUserRequest) (*UniversalUser, error) {
+	u, err := d.a.RequestVerification(ctx, req.Id)
+	if err != nil {
+		return &UniversalUser{}, errorStatus(err)
+	}
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
+}
+
+func (d AdService) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*UniversalUser, error) {
+	u, err := d.a.VerifyEmail(ctx, req.UserId, req.Token)
+	if err != nil {
+		return &UniversalUser{}, errorStatus(err)
+	}
+	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email, Verified: u.Verified}, nil
+}
@@ -0,0 +1,90 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"net/http"
+	"strings"
+)
+
+const (
+	adminKeyHeader = "X-Admin-Key"
+	bearerPrefix   = "Bearer "
+	tokenParam     = "token"
+)
+
+func register(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody registerRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		u, err := a.Register(c, reqBody.Nickname, reqBody.Email, reqBody.Password)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
+
+func login(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody loginRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		s, err := a.Login(c, reqBody.Email, reqBody.Password)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, SessionSuccessResponse(&s))
+	}
+}
+
+// getAccount returns the user of the session passed as "Authorization: Bearer <token>".
+func getAccount(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			c.JSON(http.StatusForbidden, ErrorResponse(c, app.ErrNoAccess))
+			return
+		}
+
+		u, err := a.Authenticate(c, strings.TrimPrefix(header, bearerPrefix))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
+
+// sessionToken returns the token passed as "Authorization: Bearer <token>", or in the token
+// query parameter, as browsers can't set headers of WebSocket requests.
+func sessionToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, bearerPrefix) {
+		return strings.TrimPrefix(header, bearerPrefix)
+	}
+	return c.Query(tokenParam)
+}
+
+// authorize checks that the session of the request belongs to the user with the given ID,
+// otherwise it writes the error response and returns false.
+func authorize(c *gin.Context, a app.App, userID int64) bool {
+	u, err := a.Authenticate(c, sessionToken(c))
+	if err != nil {
+		c.JSON(errorStatus(err), ErrorResponse(c, err))
+		return false
+	}
+	if u.ID != userID {
+		c.JSON(http.StatusForbidden, ErrorResponse(c, app.ErrNoAccess))
+		return false
+	}
+	return true
+}
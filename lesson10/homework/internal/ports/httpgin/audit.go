@@ -0,0 +1,41 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"homework10/internal/audit"
+	"net/http"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestMiddleware passes the request ID to the app for the audit log and echoes it,
+// a new one is picked if the client didn't send one.
+func requestMiddleware(c *gin.Context) {
+	id := audit.RequestID(c.GetHeader(requestIDHeader))
+	c.Header(requestIDHeader, id)
+	c.Set(audit.ContextKey, audit.Request{ID: id, Transport: audit.HTTP})
+	c.Next()
+}
+
+// listAudit returns entries about the target given as kind:id, e.g. ad:123, or all entries without one.
+func listAudit(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var target audit.Target
+		if s := c.Query("target"); s != "" {
+			var err error
+			target, err = audit.ParseTarget(s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+		}
+
+		entries, err := a.ListAudit(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)), target)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, AuditSuccessResponseList(entries))
+	}
+}
@@ -0,0 +1,120 @@
+package httpgin
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"homework10/internal/bulk"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// exportFlushEvery is how many records are written between flushes of a streamed export.
+const exportFlushEvery = 100
+
+func bulkFormat(c *gin.Context) string {
+	return c.DefaultQuery("format", bulk.FormatCSV)
+}
+
+// exportAds streams ads matching the same query parameters as listAds in the format
+// given by the format parameter, csv or ndjson.
+func exportAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := bulkFormat(c)
+		pattern, status, err := patternFromQuery(c, a)
+		if err != nil {
+			c.JSON(status, ErrorResponse(c, err))
+			return
+		}
+		w, err := bulk.NewWriter(c.Writer, format)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		started := false
+		n := 0
+		err = a.ExportAds(c, pattern, func(rec bulk.Record) error {
+			if !started {
+				c.Header("Content-Type", bulk.ContentType(format))
+				c.Status(http.StatusOK)
+				started = true
+			}
+			if err := w.Write(rec); err != nil {
+				return err
+			}
+			n++
+			if n%exportFlushEvery == 0 {
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				c.Writer.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			if !started {
+				c.JSON(errorStatus(err), ErrorResponse(c, err))
+			}
+			// the status is already sent, the client sees a truncated body
+			_ = c.Error(err)
+			return
+		}
+		if !started {
+			c.Header("Content-Type", bulk.ContentType(format))
+			c.Status(http.StatusOK)
+		}
+		_ = w.Flush()
+	}
+}
+
+// importAds creates or updates ads from the request body line by line. Bad lines are
+// reported and skipped, with dry_run=true the body is only validated.
+func importAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dryRun := false
+		if strDryRun := c.Query("dry_run"); strDryRun != "" {
+			var err error
+			dryRun, err = strconv.ParseBool(strDryRun)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+		}
+		r, err := bulk.NewReader(c.Request.Body, bulkFormat(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		ctx := app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader))
+		report := bulk.Report{DryRun: dryRun, Errors: []bulk.LineError{}}
+		for {
+			rec, line, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if errors.Is(err, bulk.ErrBadLine) {
+				report.Fail(line, err)
+				continue
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+
+			_, outcome, err := a.ImportAd(ctx, rec, dryRun)
+			if errors.Is(err, app.ErrWrongFormat) {
+				report.Fail(line, err)
+				continue
+			}
+			if err != nil {
+				c.JSON(errorStatus(err), ErrorResponse(c, err))
+				return
+			}
+			report.Add(outcome)
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
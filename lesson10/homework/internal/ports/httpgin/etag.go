@@ -0,0 +1,26 @@
+package httpgin
+
+import (
+	"fmt"
+	"hash/fnv"
+	"homework10/internal/ads"
+	"strings"
+)
+
+func AdETag(ad *ads.Ad) string {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%d|%d|%t|%d|%d|%s|%s", ad.ID, ad.AuthorID, ad.Published,
+		ad.CreationDate.UnixNano(), ad.UpdateDate.UnixNano(), ad.Title, ad.Text)
+	return fmt.Sprintf("\"%x\"", h.Sum64())
+}
+
+// etagMatches reports whether If-None-Match header lists the given entity tag.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
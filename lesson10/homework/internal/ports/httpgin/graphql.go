@@ -0,0 +1,417 @@
+package httpgin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/adexpr"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/analytics"
+	"homework10/internal/app"
+	"homework10/internal/dataloader"
+	"homework10/internal/graphql"
+	"homework10/internal/i18n"
+	"homework10/internal/langdetect"
+	"homework10/internal/user"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	graphqlMaxDepth      = 6
+	graphqlMaxComplexity = 5000
+	// graphqlMaxLimit caps the limit argument of lists of ads
+	graphqlMaxLimit     = 100
+	graphqlDefaultLimit = 20
+	// graphqlMaxBodySize leaves room for variables next to a query of graphql.MaxLen bytes
+	graphqlMaxBodySize = 1 << 20
+)
+
+// graphqlHandler serves queries sent as JSON in POST bodies, or in the query,
+// operationName and variables parameters of GET requests.
+func graphqlHandler(a app.App) gin.HandlerFunc {
+	schema := graphqlSchema(a)
+	return func(c *gin.Context) {
+		var req graphql.Request
+		if c.Request.Method == http.MethodGet {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if s := c.Query("variables"); s != "" {
+				if err := decodeJSON(strings.NewReader(s), &req.Variables); err != nil {
+					c.JSON(http.StatusBadRequest, graphqlErrorResponse(c, err))
+					return
+				}
+			}
+		} else if err := decodeJSON(http.MaxBytesReader(c.Writer, c.Request.Body, graphqlMaxBodySize), &req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, graphqlErrorResponse(c, err))
+				return
+			}
+			c.JSON(http.StatusBadRequest, graphqlErrorResponse(c, err))
+			return
+		}
+
+		c.Set(analytics.ViewerKey, c.ClientIP())
+		ctx := context.WithValue(c, graphqlLoadersKey{}, newGraphqlLoaders(c, a))
+		resp := schema.Execute(ctx, req)
+		lang := i18n.FromContext(c)
+		for _, e := range resp.Errors {
+			e.Message = i18n.Translate(lang, e.Message)
+		}
+		// requests failing validation have no data
+		if resp.Data == nil {
+			c.JSON(http.StatusBadRequest, resp)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// decodeJSON keeps numbers as json.Number, so that 64-bit IDs given to variables stay exact.
+func decodeJSON(r io.Reader, v interface{}) error {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+func graphqlErrorResponse(ctx context.Context, err error) *graphql.Response {
+	msg := i18n.Translate(i18n.FromContext(ctx), err.Error())
+	return &graphql.Response{Errors: []*graphql.Error{{Message: msg}}}
+}
+
+type graphqlLoadersKey struct{}
+
+// graphqlLoaders batch lookups made while resolving a single query.
+type graphqlLoaders struct {
+	users *dataloader.Loader[int64, user.User]
+	// adsByAuthor has a loader for every filter User.ads is selected with
+	adsByAuthor map[string]*dataloader.Loader[int64, []ads.Ad]
+}
+
+func newGraphqlLoaders(ctx context.Context, a app.App) *graphqlLoaders {
+	return &graphqlLoaders{
+		users:       dataloader.New(ctx, a.FindUsers),
+		adsByAuthor: map[string]*dataloader.Loader[int64, []ads.Ad]{},
+	}
+}
+
+func loadersFrom(ctx context.Context) *graphqlLoaders {
+	return ctx.Value(graphqlLoadersKey{}).(*graphqlLoaders)
+}
+
+// adsOfAuthors returns the loader of ads of authors matching the filter, the ads of all
+// authors are listed at once.
+func (l *graphqlLoaders) adsOfAuthors(ctx context.Context, a app.App, filter map[string]interface{}) (
+	*dataloader.Loader[int64, []ads.Ad], error) {
+	key, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	if loader, ok := l.adsByAuthor[string(key)]; ok {
+		return loader, nil
+	}
+	adp, err := graphqlPattern(ctx, a, filter)
+	if err != nil {
+		return nil, err
+	}
+	loader := dataloader.New(ctx, func(ctx context.Context, authorIDs []int64) (map[int64][]ads.Ad, error) {
+		values := make([]interface{}, 0, len(authorIDs))
+		for _, id := range authorIDs {
+			values = append(values, id)
+		}
+		p := adp
+		var expr adexpr.Expr = adexpr.In{Field: adexpr.FieldAuthor, Values: values}
+		if p.Expr != nil {
+			expr = adexpr.And{Left: expr, Right: p.Expr}
+		}
+		p.Expr = expr
+		list, err := a.GetAllAdsByTemplate(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		res := map[int64][]ads.Ad{}
+		for _, ad := range list {
+			res[ad.AuthorID] = append(res[ad.AuthorID], ad)
+		}
+		return res, nil
+	})
+	l.adsByAuthor[string(key)] = loader
+	return loader, nil
+}
+
+// graphqlPattern builds the pattern of ads from an AdFilter argument, the filter is nil if not given.
+func graphqlPattern(ctx context.Context, a app.App, filter map[string]interface{}) (adpattern.AdPattern, error) {
+	f, err := a.GetNewFilter(ctx)
+	if err != nil {
+		return adpattern.AdPattern{}, err
+	}
+	if v, ok := filter["authorId"].(int64); ok {
+		if f, err = f.SetAuthor(ctx, v); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if v, ok := filter["publishedOnly"].(bool); ok {
+		if f, err = f.SetStatus(ctx, v); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if v, ok := filter["lang"].(string); ok {
+		lang, err := langdetect.Parse(v)
+		if err != nil {
+			return adpattern.AdPattern{}, wrongFormat(err)
+		}
+		if f, err = f.SetLang(ctx, lang); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if v, ok := filter["from"].(string); ok {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return adpattern.AdPattern{}, wrongFormat(err)
+		}
+		if f, err = f.SetLTime(ctx, t); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if v, ok := filter["to"].(string); ok {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return adpattern.AdPattern{}, wrongFormat(err)
+		}
+		if f, err = f.SetRTime(ctx, t); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if v, ok := filter["sort"].(string); ok {
+		order, err := adpattern.ParseSort(v)
+		if err != nil {
+			return adpattern.AdPattern{}, wrongFormat(err)
+		}
+		if f, err = f.SetSort(ctx, order); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if v, ok := filter["expr"].(string); ok {
+		expr, err := adexpr.Parse(v)
+		if err != nil {
+			return adpattern.AdPattern{}, wrongFormat(err)
+		}
+		if f, err = f.SetExpr(ctx, expr); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	adp, err := f.GetPattern(ctx)
+	if err != nil {
+		return adpattern.AdPattern{}, err
+	}
+	if v, ok := filter["title"].(string); ok {
+		adp.Title = v
+	}
+	return adp, nil
+}
+
+func graphqlLimit(args map[string]interface{}) (int, error) {
+	// an explicit null means the default
+	limit, ok := args["limit"].(int)
+	if !ok {
+		limit = graphqlDefaultLimit
+	}
+	if limit < 0 || limit > graphqlMaxLimit {
+		return 0, fmt.Errorf("%w: limit must be between 0 and %d", app.ErrWrongFormat, graphqlMaxLimit)
+	}
+	return limit, nil
+}
+
+// limitMultiplier counts lists of ads as long as their limit for the complexity.
+func limitMultiplier(args map[string]interface{}) int {
+	limit, err := graphqlLimit(args)
+	if err != nil {
+		return graphqlMaxLimit
+	}
+	return limit
+}
+
+func filterArg(args map[string]interface{}) map[string]interface{} {
+	filter, _ := args["filter"].(map[string]interface{})
+	return filter
+}
+
+func graphqlSchema(a app.App) *graphql.Schema {
+	adFilter := &graphql.InputObject{
+		Name: "AdFilter",
+		Fields: map[string]*graphql.Arg{
+			"authorId":      {Type: graphql.ID},
+			"publishedOnly": {Type: graphql.Boolean},
+			"lang":          {Type: graphql.String},
+			"from":          {Type: graphql.String},
+			"to":            {Type: graphql.String},
+			"title":         {Type: graphql.String},
+			"sort":          {Type: graphql.String},
+			"expr":          {Type: graphql.String},
+		},
+	}
+	listArgs := map[string]*graphql.Arg{
+		"filter": {Type: adFilter},
+		"limit":  {Type: graphql.Int, Default: graphqlDefaultLimit},
+	}
+	userType := &graphql.Object{Name: "User"}
+	adType := &graphql.Object{Name: "Ad"}
+
+	adType.Fields = map[string]*graphql.Field{
+		"id":       {Type: &graphql.NonNull{Of: graphql.ID}, Resolve: adField(func(ad ads.Ad) interface{} { return ad.ID })},
+		"title":    {Type: &graphql.NonNull{Of: graphql.String}, Resolve: adField(func(ad ads.Ad) interface{} { return ad.Title })},
+		"text":     {Type: &graphql.NonNull{Of: graphql.String}, Resolve: adField(func(ad ads.Ad) interface{} { return ad.Text })},
+		"authorId": {Type: &graphql.NonNull{Of: graphql.ID}, Resolve: adField(func(ad ads.Ad) interface{} { return ad.AuthorID })},
+		"published": {Type: &graphql.NonNull{Of: graphql.Boolean},
+			Resolve: adField(func(ad ads.Ad) interface{} { return ad.Published })},
+		"lang": {Type: graphql.String, Resolve: adField(func(ad ads.Ad) interface{} {
+			if ad.Lang == "" {
+				return nil
+			}
+			return ad.Lang
+		})},
+		"creationDate": {Type: &graphql.NonNull{Of: graphql.String},
+			Resolve: adField(func(ad ads.Ad) interface{} { return ad.CreationDate.UTC().Format(time.RFC3339Nano) })},
+		"updateDate": {Type: &graphql.NonNull{Of: graphql.String},
+			Resolve: adField(func(ad ads.Ad) interface{} { return ad.UpdateDate.UTC().Format(time.RFC3339Nano) })},
+		"author": {Type: userType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadUser(p.Context, p.Source.(ads.Ad).AuthorID), nil
+		}},
+	}
+
+	userType.Fields = map[string]*graphql.Field{
+		"id": {Type: &graphql.NonNull{Of: graphql.ID}, Resolve: userField(func(u user.User) interface{} { return u.ID })},
+		"nickname": {Type: &graphql.NonNull{Of: graphql.String},
+			Resolve: userField(func(u user.User) interface{} { return u.Nickname })},
+		"verified": {Type: &graphql.NonNull{Of: graphql.Boolean},
+			Resolve: userField(func(u user.User) interface{} { return u.Verified })},
+		"ads": {
+			Type:       &graphql.NonNull{Of: &graphql.List{Of: &graphql.NonNull{Of: adType}}},
+			Args:       listArgs,
+			Multiplier: limitMultiplier,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				limit, err := graphqlLimit(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				loader, err := loadersFrom(p.Context).adsOfAuthors(p.Context, a, filterArg(p.Args))
+				if err != nil {
+					return nil, err
+				}
+				load := loader.Load(p.Source.(user.User).ID)
+				return graphql.Thunk(func() (interface{}, error) {
+					list, _, err := load()
+					if err != nil {
+						return nil, err
+					}
+					if len(list) > limit {
+						list = list[:limit]
+					}
+					return list, nil
+				}), nil
+			},
+		},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: map[string]*graphql.Field{
+			"ad": {
+				Type: adType,
+				Args: map[string]*graphql.Arg{"id": {Type: &graphql.NonNull{Of: graphql.ID}}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ad, err := a.FindAd(p.Context, p.Args["id"].(int64))
+					// unknown ads are null
+					if errors.Is(err, app.ErrWrongFormat) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return ad, nil
+				},
+			},
+			"ads": {
+				Type:       &graphql.NonNull{Of: &graphql.List{Of: &graphql.NonNull{Of: adType}}},
+				Args:       listArgs,
+				Multiplier: limitMultiplier,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit, err := graphqlLimit(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					adp, err := graphqlPattern(p.Context, a, filterArg(p.Args))
+					if err != nil {
+						return nil, err
+					}
+					list, err := a.GetAllAdsByTemplate(p.Context, adp)
+					if err != nil {
+						return nil, err
+					}
+					if len(list) > limit {
+						list = list[:limit]
+					}
+					return list, nil
+				},
+			},
+			"user": {
+				Type: userType,
+				Args: map[string]*graphql.Arg{"id": {Type: &graphql.NonNull{Of: graphql.ID}}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p.Context, p.Args["id"].(int64)), nil
+				},
+			},
+			"users": {
+				Type: &graphql.NonNull{Of: &graphql.List{Of: userType}},
+				Args: map[string]*graphql.Arg{
+					"ids": {Type: &graphql.NonNull{Of: &graphql.List{Of: &graphql.NonNull{Of: graphql.ID}}}},
+				},
+				Multiplier: func(args map[string]interface{}) int {
+					return len(args["ids"].([]interface{}))
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ids := p.Args["ids"].([]interface{})
+					if len(ids) > graphqlMaxLimit {
+						return nil, fmt.Errorf("%w: at most %d users can be looked up", app.ErrWrongFormat, graphqlMaxLimit)
+					}
+					res := make([]interface{}, 0, len(ids))
+					for _, id := range ids {
+						res = append(res, loadUser(p.Context, id.(int64)))
+					}
+					return res, nil
+				},
+			},
+		},
+	}
+	return &graphql.Schema{Query: query, MaxDepth: graphqlMaxDepth, MaxComplexity: graphqlMaxComplexity}
+}
+
+// loadUser queues the user for the batched lookup, unknown users are null.
+func loadUser(ctx context.Context, userID int64) graphql.Thunk {
+	load := loadersFrom(ctx).users.Load(userID)
+	return func() (interface{}, error) {
+		u, ok, err := load()
+		if err != nil || !ok {
+			return nil, err
+		}
+		return u, nil
+	}
+}
+
+func adField(get func(ad ads.Ad) interface{}) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(ads.Ad)), nil
+	}
+}
+
+func userField(get func(u user.User) interface{}) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(user.User)), nil
+	}
+}
@@ -3,7 +3,12 @@ package httpgin
 import (
 	"errors"
 	"github.com/gin-gonic/gin"
+	"homework10/internal/adexpr"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/analytics"
 	"homework10/internal/app"
+	"homework10/internal/langdetect"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,18 +20,14 @@ func createAd(a app.App) gin.HandlerFunc {
 		var reqBody createAdRequest
 		err := c.ShouldBindJSON(&reqBody)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		ad, e := a.CreateAd(c, reqBody.Title, reqBody.Text, reqBody.UserID)
 
 		if e != nil {
-			if errors.Is(e, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, ErrorResponse(e))
-				return
-			}
-			c.JSON(http.StatusInternalServerError, ErrorResponse(e))
+			c.JSON(errorStatus(e), ErrorResponse(c, e))
 			return
 		}
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
@@ -37,33 +38,25 @@ func changeAdStatus(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody changeAdStatusRequest
 		if err := c.ShouldBindJSON(&reqBody); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		strAdID := c.Param("ad_id")
 		adID, err := strconv.Atoi(strAdID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 		_, err = a.FindAd(c, int64(adID))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		ad, e := a.ChangeAdStatus(c, int64(adID), reqBody.UserID, reqBody.Published)
 		if e != nil {
-			if errors.Is(e, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, ErrorResponse(e))
-				return
-			}
-			if errors.Is(e, app.ErrNoAccess) {
-				c.JSON(http.StatusForbidden, ErrorResponse(e))
-				return
-			}
-			c.JSON(http.StatusInternalServerError, ErrorResponse(e))
+			c.JSON(errorStatus(e), ErrorResponse(c, e))
 			return
 		}
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
@@ -75,131 +68,190 @@ func updateAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody updateAdRequest
 		if err := c.ShouldBindJSON(&reqBody); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 		}
 
 		strAdID := c.Param("ad_id")
 		adID, err := strconv.Atoi(strAdID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 		_, err = a.FindAd(c, int64(adID))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		ad, e := a.UpdateAd(c, int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
 		if e != nil {
-			if errors.Is(e, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, ErrorResponse(e))
-				return
-			}
-			if errors.Is(e, app.ErrNoAccess) {
-				c.JSON(http.StatusForbidden, ErrorResponse(e))
-				return
-			}
-			c.JSON(http.StatusInternalServerError, ErrorResponse(e))
+			c.JSON(errorStatus(e), ErrorResponse(c, e))
 			return
 		}
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
 	}
 }
 
-func listAds(a app.App) gin.HandlerFunc {
+func setAdLanguage(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		f, err := a.GetNewFilter(c)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+		var reqBody setAdLanguageRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
-		filter, err := f.BasicConfig(c)
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
-		var authorID int
-		var publishedOnly bool
-		var secondsL, secondsR int64
-
-		strAuthorID := c.Query("author_id")
-		authorID, err = strconv.Atoi(strAuthorID)
-		if strAuthorID != "" && err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+		ad, e := a.SetAdLanguage(c, int64(adID), reqBody.UserID, reqBody.Lang)
+		if e != nil {
+			c.JSON(errorStatus(e), ErrorResponse(c, e))
 			return
 		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
 
-		strPublishedOnly := c.Query("published_only")
-		publishedOnly, err = strconv.ParseBool(strPublishedOnly)
-		if strPublishedOnly != "" && err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+func listAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pattern, status, err := patternFromQuery(c, a)
+		if err != nil {
+			c.JSON(status, ErrorResponse(c, err))
 			return
 		}
 
-		strLTime := c.Query("l_time")
-		secondsL, err = strconv.ParseInt(strLTime, 10, 64)
-		if strLTime != "" && err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+		ads, err := a.GetAllAdsByTemplate(c, pattern)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
 			return
 		}
 
-		strRTime := c.Query("r_time")
-		secondsR, err = strconv.ParseInt(strRTime, 10, 64)
-		if strRTime != "" && err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
-			return
+		c.JSON(http.StatusOK, AdSuccessResponseList(&ads))
+	}
+}
+
+// patternFromQuery builds the pattern of ads listed by the author_id, published_only,
+// lang, l_time, r_time, sort and q query parameters, the status is the one to respond with on error.
+func patternFromQuery(c *gin.Context, a app.App) (adpattern.AdPattern, int, error) {
+	f, err := a.GetNewFilter(c)
+	if err != nil {
+		return adpattern.AdPattern{}, http.StatusInternalServerError, err
+	}
+	filter, err := f.BasicConfig(c)
+	if err != nil {
+		return adpattern.AdPattern{}, http.StatusInternalServerError, err
+	}
+
+	var authorID int
+	var publishedOnly bool
+	var secondsL, secondsR int64
+
+	strAuthorID := c.Query("author_id")
+	authorID, err = strconv.Atoi(strAuthorID)
+	if strAuthorID != "" && err != nil {
+		return adpattern.AdPattern{}, http.StatusBadRequest, err
+	}
+
+	strPublishedOnly := c.Query("published_only")
+	publishedOnly, err = strconv.ParseBool(strPublishedOnly)
+	if strPublishedOnly != "" && err != nil {
+		return adpattern.AdPattern{}, http.StatusBadRequest, err
+	}
+
+	lang := c.Query("lang")
+	if lang != "" {
+		lang, err = langdetect.Parse(lang)
+		if err != nil {
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
 		}
+	}
 
-		if strAuthorID != "" {
-			filter, err = filter.SetAuthor(c, int64(authorID))
-			if err != nil {
-				c.JSON(http.StatusBadRequest, ErrorResponse(err))
-				return
-			}
+	strLTime := c.Query("l_time")
+	secondsL, err = strconv.ParseInt(strLTime, 10, 64)
+	if strLTime != "" && err != nil {
+		return adpattern.AdPattern{}, http.StatusBadRequest, err
+	}
+
+	strRTime := c.Query("r_time")
+	secondsR, err = strconv.ParseInt(strRTime, 10, 64)
+	if strRTime != "" && err != nil {
+		return adpattern.AdPattern{}, http.StatusBadRequest, err
+	}
+
+	strSort := c.Query("sort")
+	order, err := adpattern.ParseSort(strSort)
+	if err != nil {
+		return adpattern.AdPattern{}, http.StatusBadRequest, err
+	}
+
+	strExpr := c.Query("q")
+	var expr adexpr.Expr
+	if strExpr != "" {
+		expr, err = adexpr.Parse(strExpr)
+		if err != nil {
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
 		}
+	}
 
-		if strPublishedOnly != "" {
-			filter, err = filter.SetStatus(c, publishedOnly)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, ErrorResponse(err))
-				return
-			}
+	if strAuthorID != "" {
+		filter, err = filter.SetAuthor(c, int64(authorID))
+		if err != nil {
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
 		}
+	}
 
-		if strLTime != "" {
-			lTime := time.UnixMicro(secondsL).UTC()
-			filter, err = filter.SetLTime(c, lTime)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, ErrorResponse(err))
-				return
-			}
+	if strPublishedOnly != "" {
+		filter, err = filter.SetStatus(c, publishedOnly)
+		if err != nil {
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
 		}
+	}
 
-		if strRTime != "" {
-			rTime := time.UnixMicro(secondsR).UTC()
-			filter, err = filter.SetRTime(c, rTime)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, ErrorResponse(err))
-				return
-			}
+	if lang != "" {
+		filter, err = filter.SetLang(c, lang)
+		if err != nil {
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
 		}
+	}
 
-		pattern, err := filter.GetPattern(c)
+	if strLTime != "" {
+		lTime := time.UnixMicro(secondsL).UTC()
+		filter, err = filter.SetLTime(c, lTime)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
-			return
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
 		}
+	}
 
-		ads, err := a.GetAllAdsByTemplate(c, pattern)
+	if strRTime != "" {
+		rTime := time.UnixMicro(secondsR).UTC()
+		filter, err = filter.SetRTime(c, rTime)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
-			return
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
 		}
+	}
 
-		c.JSON(http.StatusOK, AdSuccessResponseList(&ads))
+	if strSort != "" {
+		filter, err = filter.SetSort(c, order)
+		if err != nil {
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
+		}
+	}
+
+	if strExpr != "" {
+		filter, err = filter.SetExpr(c, expr)
+		if err != nil {
+			return adpattern.AdPattern{}, http.StatusBadRequest, err
+		}
+	}
+
+	pattern, err := filter.GetPattern(c)
+	if err != nil {
+		return adpattern.AdPattern{}, http.StatusInternalServerError, err
 	}
+	return pattern, http.StatusOK, nil
 }
 
 func getAdByID(a app.App) gin.HandlerFunc {
@@ -207,17 +259,26 @@ func getAdByID(a app.App) gin.HandlerFunc {
 		strAdID := c.Param("ad_id")
 		adID, err := strconv.Atoi(strAdID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
+		// the viewer is kept in the gin context, like the tenant
+		c.Set(analytics.ViewerKey, c.ClientIP())
 		ad, err := a.FindAd(c, int64(adID))
 		if err != nil {
 			if errors.Is(err, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 				return
 			}
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+
+		etag := AdETag(&ad)
+		c.Header("ETag", etag)
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.Status(http.StatusNotModified)
 			return
 		}
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
@@ -229,28 +290,28 @@ func deleteAd(a app.App) gin.HandlerFunc {
 		strAdID := c.Param("ad_id")
 		adID, err := strconv.Atoi(strAdID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		var reqBody deleteAdRequest
 		err = c.ShouldBindJSON(&reqBody)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		ad, e := a.DeleteAd(c, int64(adID), reqBody.UserID)
 		if e != nil {
 			if errors.Is(e, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, ErrorResponse(e))
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, e))
 				return
 			}
 			if errors.Is(e, app.ErrNoAccess) {
-				c.JSON(http.StatusForbidden, ErrorResponse(e))
+				c.JSON(http.StatusForbidden, ErrorResponse(c, e))
 				return
 			}
-			c.JSON(http.StatusInternalServerError, ErrorResponse(e))
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, e))
 			return
 		}
 
@@ -264,18 +325,15 @@ func createUser(a app.App) gin.HandlerFunc {
 		var reqBody universalUser
 		err := c.ShouldBindJSON(&reqBody)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
-		u, e := a.CreateUserByID(c, reqBody.Nickname, reqBody.Email, reqBody.ID)
+		ctx := app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader))
+		u, e := a.CreateUserByID(ctx, reqBody.Nickname, reqBody.Email, reqBody.ID)
 
 		if e != nil {
-			if errors.Is(e, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, ErrorResponse(e))
-				return
-			}
-			c.JSON(http.StatusInternalServerError, ErrorResponse(e))
+			c.JSON(errorStatus(e), ErrorResponse(c, e))
 			return
 		}
 		c.JSON(http.StatusOK, UserSuccessResponse(&u))
@@ -287,17 +345,17 @@ func deleteUserByID(a app.App) gin.HandlerFunc {
 		strUserID := c.Param("user_id")
 		userID, err := strconv.Atoi(strUserID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		u, err := a.DeleteUserByID(c, int64(userID))
 		if err != nil {
 			if errors.Is(err, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 				return
 			}
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
 			return
 		}
 
@@ -309,19 +367,19 @@ func changeUserInfo(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody changeUserStatusRequest
 		if err := c.ShouldBindJSON(&reqBody); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		strUserID := c.Param("user_id")
 		userID, err := strconv.Atoi(strUserID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 		_, isFound, err := a.FindUser(c, int64(userID))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
 			return
 		}
 		if !isFound {
@@ -332,14 +390,14 @@ func changeUserInfo(a app.App) gin.HandlerFunc {
 		u, e := a.ChangeUserInfo(c, int64(userID), reqBody.Nickname, reqBody.Email)
 		if e != nil {
 			if errors.Is(e, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, ErrorResponse(e))
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, e))
 				return
 			}
 			if errors.Is(e, app.ErrNoAccess) {
-				c.JSON(http.StatusForbidden, ErrorResponse(e))
+				c.JSON(http.StatusForbidden, ErrorResponse(c, e))
 				return
 			}
-			c.JSON(http.StatusInternalServerError, ErrorResponse(e))
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, e))
 			return
 		}
 		c.JSON(http.StatusOK, UserSuccessResponse(&u))
@@ -350,12 +408,22 @@ func getAdsByTitle(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		title := c.Query("title")
-		ads, err := a.GetAdsByTitle(c, title)
+		order, err := adpattern.ParseSort(c.Query("sort"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
-		c.JSON(http.StatusOK, AdSuccessResponseList(&ads))
+		var list []ads.Ad
+		if len(order) > 0 {
+			list, err = a.GetAllAdsByTemplate(c, adpattern.AdPattern{Title: title, Sort: order})
+		} else {
+			list, err = a.GetAdsByTitle(c, title)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponseList(&list))
 	}
 }
 
@@ -364,13 +432,13 @@ func getUserByID(a app.App) gin.HandlerFunc {
 		strUserID := c.Param("user_id")
 		userID, err := strconv.Atoi(strUserID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
 			return
 		}
 
 		u, isFound, err := a.FindUser(c, int64(userID))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
 			return
 		}
 		if !isFound {
@@ -381,3 +449,189 @@ func getUserByID(a app.App) gin.HandlerFunc {
 		c.JSON(http.StatusOK, UserSuccessResponse(&u))
 	}
 }
+
+// favoriteParams parses user_id and ad_id path parameters.
+func favoriteParams(c *gin.Context) (int64, int64, error) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	adID, err := strconv.Atoi(c.Param("ad_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(userID), int64(adID), nil
+}
+
+func addFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, adID, err := favoriteParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		ad, err := a.AddFavorite(c, userID, adID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongFormat) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+func removeFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, adID, err := favoriteParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		ad, err := a.RemoveFavorite(c, userID, adID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongFormat) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+func listFavorites(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		ads, err := a.ListFavorites(c, int64(userID))
+		if err != nil {
+			if errors.Is(err, app.ErrWrongFormat) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponseList(&ads))
+	}
+}
+
+func saveSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody saveSearchRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		f, err := a.GetNewFilter(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		filter, err := f.SetAuthor(c, reqBody.AuthorID)
+		if err == nil {
+			filter, err = filter.SetStatus(c, reqBody.PublishedOnly)
+		}
+		if err == nil && reqBody.LTime != nil {
+			filter, err = filter.SetLTime(c, time.UnixMicro(*reqBody.LTime).UTC())
+		}
+		if err == nil && reqBody.RTime != nil {
+			filter, err = filter.SetRTime(c, time.UnixMicro(*reqBody.RTime).UTC())
+		}
+		if err == nil && reqBody.Q != "" {
+			var expr adexpr.Expr
+			expr, err = adexpr.Parse(reqBody.Q)
+			if err == nil {
+				filter, err = filter.SetExpr(c, expr)
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		pattern, err := filter.GetPattern(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+
+		s, err := a.SaveSearch(c, int64(userID), reqBody.Name, pattern)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongFormat) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, SavedSearchSuccessResponse(&s))
+	}
+}
+
+func listSavedSearches(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		list, err := a.ListSavedSearches(c, int64(userID))
+		if err != nil {
+			if errors.Is(err, app.ErrWrongFormat) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, SavedSearchSuccessResponseList(&list))
+	}
+}
+
+func deleteSavedSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		searchID, err := strconv.Atoi(c.Param("search_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		s, err := a.DeleteSavedSearch(c, int64(userID), int64(searchID))
+		if err != nil {
+			if errors.Is(err, app.ErrWrongFormat) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			if errors.Is(err, app.ErrNoAccess) {
+				c.JSON(http.StatusForbidden, ErrorResponse(c, err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, SavedSearchSuccessResponse(&s))
+	}
+}
@@ -0,0 +1,17 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/idempotency"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+// idempotencyMiddleware passes the Idempotency-Key header to the app, which returns
+// the stored response to retries of requests it makes idempotent.
+func idempotencyMiddleware(c *gin.Context) {
+	if key := c.GetHeader(idempotencyHeader); key != "" {
+		c.Set(idempotency.ContextKey, key)
+	}
+	c.Next()
+}
@@ -0,0 +1,219 @@
+package httpgin
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"net/http"
+	"strconv"
+)
+
+// errorStatus maps application errors to HTTP status codes.
+func errorStatus(err error) int {
+	if errors.Is(err, app.ErrWrongFormat) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, app.ErrNoAccess) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, app.ErrConflict) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func openThread(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody openThreadRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		if !authorize(c, a, reqBody.UserID) {
+			return
+		}
+
+		t, err := a.OpenThread(c, int64(adID), reqBody.UserID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, ThreadSuccessResponse(&t))
+	}
+}
+
+func listThreads(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		if !authorize(c, a, int64(userID)) {
+			return
+		}
+
+		list, err := a.ListThreads(c, int64(userID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, ThreadSuccessResponseList(&list))
+	}
+}
+
+func sendMessage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody sendMessageRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		threadID, err := strconv.Atoi(c.Param("thread_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		if !authorize(c, a, reqBody.UserID) {
+			return
+		}
+
+		m, err := a.SendMessage(c, int64(threadID), reqBody.UserID, reqBody.Text)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, MessageSuccessResponse(&m))
+	}
+}
+
+// getMessages returns the history page before the before_id cursor,
+// the smallest ID of a page is the cursor for the previous one.
+func getMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		threadID, err := strconv.Atoi(c.Param("thread_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		userID, err := strconv.Atoi(c.Query("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		var beforeID, limit int
+		if str := c.Query("before_id"); str != "" {
+			beforeID, err = strconv.Atoi(str)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+		}
+		if str := c.Query("limit"); str != "" {
+			limit, err = strconv.Atoi(str)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+		}
+
+		if !authorize(c, a, int64(userID)) {
+			return
+		}
+
+		list, err := a.GetMessages(c, int64(threadID), int64(userID), int64(beforeID), limit)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, MessageSuccessResponseList(&list))
+	}
+}
+
+func markRead(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody markReadRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		threadID, err := strconv.Atoi(c.Param("thread_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		if !authorize(c, a, reqBody.UserID) {
+			return
+		}
+
+		n, err := a.MarkRead(c, int64(threadID), reqBody.UserID, reqBody.UpToID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, MarkReadSuccessResponse(int64(threadID), reqBody.UpToID, n))
+	}
+}
+
+func blockParams(c *gin.Context) (int64, int64, error) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	blockedID, err := strconv.Atoi(c.Param("blocked_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(userID), int64(blockedID), nil
+}
+
+func blockUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, blockedID, err := blockParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		if !authorize(c, a, userID) {
+			return
+		}
+
+		u, err := a.BlockUser(c, userID, blockedID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
+
+func unblockUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, blockedID, err := blockParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		if !authorize(c, a, userID) {
+			return
+		}
+
+		u, err := a.UnblockUser(c, userID, blockedID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
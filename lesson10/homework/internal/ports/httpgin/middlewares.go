@@ -3,6 +3,7 @@ package httpgin
 import (
 	"bytes"
 	"github.com/gin-gonic/gin"
+	"homework10/internal/i18n"
 	"log"
 	"time"
 )
@@ -36,3 +37,11 @@ func CustomLogger(c *gin.Context) {
 		log.Println("body:", b.body.String())
 	}
 }
+
+// localeMiddleware picks the language of error messages from the Accept-Language header.
+func localeMiddleware(c *gin.Context) {
+	tag := i18n.Match(c.GetHeader("Accept-Language"))
+	c.Set(i18n.Key, tag)
+	c.Header("Content-Language", tag.String())
+	c.Next()
+}
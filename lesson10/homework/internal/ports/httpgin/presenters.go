@@ -1,9 +1,19 @@
 package httpgin
 
 import (
+	"context"
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"homework10/internal/ads"
+	"homework10/internal/analytics"
+	"homework10/internal/audit"
+	"homework10/internal/i18n"
+	"homework10/internal/message"
+	"homework10/internal/report"
+	"homework10/internal/search"
+	"homework10/internal/session"
 	"homework10/internal/user"
+	"homework10/internal/webhook"
 	"time"
 )
 
@@ -17,20 +27,29 @@ type deleteAdRequest struct {
 	UserID int64 `json:"user_id" binding:"required"`
 }
 
+type restoreAdRequest struct {
+	UserID int64 `json:"user_id" binding:"required"`
+}
+
 type universalUser struct {
 	Nickname string `json:"nickname" binding:"required"`
 	Email    string `json:"email" binding:"required"`
 	ID       int64  `json:"user_id" binding:"required"`
+	Verified bool   `json:"verified"`
 }
 
 type adResponse struct {
-	ID           int64     `json:"id"`
-	Title        string    `json:"title"`
-	Text         string    `json:"text"`
-	AuthorID     int64     `json:"author_id"`
-	Published    bool      `json:"published"`
-	CreationDate time.Time `json:"creation_date"`
-	UpdateDate   time.Time `json:"update_date"`
+	ID           int64      `json:"id"`
+	Title        string     `json:"title"`
+	Text         string     `json:"text"`
+	AuthorID     int64      `json:"author_id"`
+	Published    bool       `json:"published"`
+	Hidden       bool       `json:"hidden,omitempty"`
+	Lang         string     `json:"lang,omitempty"`
+	LangDeclared bool       `json:"lang_declared,omitempty"`
+	CreationDate time.Time  `json:"creation_date"`
+	UpdateDate   time.Time  `json:"update_date"`
+	DeletionDate *time.Time `json:"deletion_date,omitempty"`
 }
 
 type changeAdStatusRequest struct {
@@ -49,17 +68,114 @@ type updateAdRequest struct {
 	UserID int64  `json:"user_id" binding:"required"`
 }
 
+type setAdLanguageRequest struct {
+	Lang   string `json:"lang"`
+	UserID int64  `json:"user_id" binding:"required"`
+}
+
+type saveSearchRequest struct {
+	Name          string `json:"name" binding:"required"`
+	AuthorID      int64  `json:"author_id"`
+	PublishedOnly bool   `json:"published_only"`
+	LTime         *int64 `json:"l_time"`
+	RTime         *int64 `json:"r_time"`
+	// Q is a filter expression, the same as the q query parameter of ad listings
+	Q string `json:"q"`
+}
+
+type savedSearchResponse struct {
+	ID            int64  `json:"id"`
+	UserID        int64  `json:"user_id"`
+	Name          string `json:"name"`
+	AuthorID      int64  `json:"author_id"`
+	PublishedOnly bool   `json:"published_only"`
+	LTime         *int64 `json:"l_time"`
+	RTime         *int64 `json:"r_time"`
+	Q             string `json:"q,omitempty"`
+}
+
+type registerRequest struct {
+	Nickname string `json:"nickname" binding:"required"`
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type sessionResponse struct {
+	Token          string    `json:"token"`
+	UserID         int64     `json:"user_id"`
+	ExpirationDate time.Time `json:"expiration_date"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type openThreadRequest struct {
+	UserID int64 `json:"user_id" binding:"required"`
+}
+
+type sendMessageRequest struct {
+	UserID int64  `json:"user_id" binding:"required"`
+	Text   string `json:"text" binding:"required"`
+}
+
+type markReadRequest struct {
+	UserID int64 `json:"user_id" binding:"required"`
+	UpToID int64 `json:"up_to_id" binding:"required"`
+}
+
+type threadResponse struct {
+	ID           int64     `json:"id"`
+	AdID         int64     `json:"ad_id"`
+	BuyerID      int64     `json:"buyer_id"`
+	SellerID     int64     `json:"seller_id"`
+	CreationDate time.Time `json:"creation_date"`
+	UpdateDate   time.Time `json:"update_date"`
+}
+
+type messageResponse struct {
+	ID           int64      `json:"id"`
+	ThreadID     int64      `json:"thread_id"`
+	SenderID     int64      `json:"sender_id"`
+	Text         string     `json:"text"`
+	CreationDate time.Time  `json:"creation_date"`
+	ReadDate     *time.Time `json:"read_date"`
+}
+
+type markReadResponse struct {
+	ThreadID int64 `json:"thread_id"`
+	UpToID   int64 `json:"up_to_id"`
+	Marked   int   `json:"marked"`
+}
+
+func newAdResponse(ad *ads.Ad) adResponse {
+	res := adResponse{
+		ID:           ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorID:     ad.AuthorID,
+		Published:    ad.Published,
+		Hidden:       ad.Hidden,
+		Lang:         ad.Lang,
+		LangDeclared: ad.LangDeclared,
+		CreationDate: ad.CreationDate,
+		UpdateDate:   ad.UpdateDate,
+	}
+	if ad.IsDeleted() {
+		deletionDate := ad.DeletionDate
+		res.DeletionDate = &deletionDate
+	}
+	return res
+}
+
 func AdSuccessResponse(ad *ads.Ad) *gin.H {
 	return &gin.H{
-		"data": adResponse{
-			ID:           ad.ID,
-			Title:        ad.Title,
-			Text:         ad.Text,
-			AuthorID:     ad.AuthorID,
-			Published:    ad.Published,
-			CreationDate: ad.CreationDate,
-			UpdateDate:   ad.UpdateDate,
-		},
+		"data":  newAdResponse(ad),
 		"error": nil,
 	}
 }
@@ -70,6 +186,7 @@ func UserSuccessResponse(u *user.User) *gin.H {
 			ID:       u.ID,
 			Nickname: u.Nickname,
 			Email:    u.Email,
+			Verified: u.Verified,
 		},
 		"error": nil,
 	}
@@ -77,16 +194,142 @@ func UserSuccessResponse(u *user.User) *gin.H {
 
 func AdSuccessResponseList(ads *[]ads.Ad) *gin.H {
 	res := []adResponse{}
-	for _, ad := range *ads {
-		res = append(res, adResponse{
-			ID:           ad.ID,
-			Title:        ad.Title,
-			Text:         ad.Text,
-			AuthorID:     ad.AuthorID,
-			Published:    ad.Published,
-			CreationDate: ad.CreationDate,
-			UpdateDate:   ad.UpdateDate,
-		})
+	for i := range *ads {
+		res = append(res, newAdResponse(&(*ads)[i]))
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+func newSavedSearchResponse(s *search.SavedSearch) savedSearchResponse {
+	res := savedSearchResponse{
+		ID:            s.ID,
+		UserID:        s.UserID,
+		Name:          s.Name,
+		AuthorID:      s.Pattern.AuthorID,
+		PublishedOnly: s.Pattern.PublishedOnly,
+	}
+	if s.Pattern.IsLTimeSet {
+		lTime := s.Pattern.LDate.UnixMicro()
+		res.LTime = &lTime
+	}
+	if s.Pattern.IsRTimeSet {
+		rTime := s.Pattern.RDate.UnixMicro()
+		res.RTime = &rTime
+	}
+	if s.Pattern.Expr != nil {
+		res.Q = s.Pattern.Expr.String()
+	}
+	return res
+}
+
+func SavedSearchSuccessResponse(s *search.SavedSearch) *gin.H {
+	return &gin.H{
+		"data":  newSavedSearchResponse(s),
+		"error": nil,
+	}
+}
+
+func SavedSearchSuccessResponseList(list *[]search.SavedSearch) *gin.H {
+	res := []savedSearchResponse{}
+	for i := range *list {
+		res = append(res, newSavedSearchResponse(&(*list)[i]))
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+func newThreadResponse(t *message.Thread) threadResponse {
+	return threadResponse{
+		ID:           t.ID,
+		AdID:         t.AdID,
+		BuyerID:      t.BuyerID,
+		SellerID:     t.SellerID,
+		CreationDate: t.CreationDate,
+		UpdateDate:   t.UpdateDate,
+	}
+}
+
+func newMessageResponse(m *message.Message) messageResponse {
+	res := messageResponse{
+		ID:           m.ID,
+		ThreadID:     m.ThreadID,
+		SenderID:     m.SenderID,
+		Text:         m.Text,
+		CreationDate: m.CreationDate,
+	}
+	if m.IsRead() {
+		readDate := m.ReadDate
+		res.ReadDate = &readDate
+	}
+	return res
+}
+
+func ThreadSuccessResponse(t *message.Thread) *gin.H {
+	return &gin.H{
+		"data":  newThreadResponse(t),
+		"error": nil,
+	}
+}
+
+func ThreadSuccessResponseList(list *[]message.Thread) *gin.H {
+	res := []threadResponse{}
+	for i := range *list {
+		res = append(res, newThreadResponse(&(*list)[i]))
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+func MessageSuccessResponse(m *message.Message) *gin.H {
+	return &gin.H{
+		"data":  newMessageResponse(m),
+		"error": nil,
+	}
+}
+
+func MessageSuccessResponseList(list *[]message.Message) *gin.H {
+	res := []messageResponse{}
+	for i := range *list {
+		res = append(res, newMessageResponse(&(*list)[i]))
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+func MarkReadSuccessResponse(threadID int64, upToID int64, marked int) *gin.H {
+	return &gin.H{
+		"data":  markReadResponse{ThreadID: threadID, UpToID: upToID, Marked: marked},
+		"error": nil,
+	}
+}
+
+type auditEntryResponse struct {
+	ID        int64           `json:"id"`
+	Time      time.Time       `json:"time"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Transport string          `json:"transport,omitempty"`
+}
+
+func AuditSuccessResponseList(entries []audit.Entry) *gin.H {
+	res := []auditEntryResponse{}
+	for _, e := range entries {
+		res = append(res, auditEntryResponse{ID: e.ID, Time: e.Time, Actor: e.Actor, Action: e.Action,
+			Target: e.Target.String(), Before: e.Before, After: e.After, RequestID: e.RequestID,
+			Transport: string(e.Transport)})
 	}
 	return &gin.H{
 		"data":  res,
@@ -94,9 +337,189 @@ func AdSuccessResponseList(ads *[]ads.Ad) *gin.H {
 	}
 }
 
-func ErrorResponse(err error) *gin.H {
+type statsBucketResponse struct {
+	Start      time.Time `json:"start"`
+	Views      int64     `json:"views"`
+	AdsCreated int       `json:"ads_created"`
+	Published  int       `json:"published"`
+	// TimeToPublish is the average of published ads in seconds
+	TimeToPublish float64 `json:"avg_time_to_publish"`
+}
+
+type statsResponse struct {
+	UserID  int64                 `json:"user_id"`
+	From    time.Time             `json:"from"`
+	To      time.Time             `json:"to"`
+	Buckets []statsBucketResponse `json:"buckets"`
+}
+
+func StatsSuccessResponse(r *analytics.Report) *gin.H {
+	res := statsResponse{UserID: r.UserID, From: r.From, To: r.To, Buckets: []statsBucketResponse{}}
+	for _, b := range r.Buckets {
+		res.Buckets = append(res.Buckets, statsBucketResponse{Start: b.Start, Views: b.Views, AdsCreated: b.AdsCreated,
+			Published: b.Published, TimeToPublish: b.TimeToPublish.Seconds()})
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+type reportAdRequest struct {
+	UserID  int64  `json:"user_id" binding:"required"`
+	Reason  string `json:"reason" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+type reportResponse struct {
+	ID             int64      `json:"id"`
+	AdID           int64      `json:"ad_id"`
+	ReporterID     int64      `json:"reporter_id"`
+	Reason         string     `json:"reason"`
+	Comment        string     `json:"comment,omitempty"`
+	Status         string     `json:"status"`
+	CreationDate   time.Time  `json:"creation_date"`
+	ResolutionDate *time.Time `json:"resolution_date,omitempty"`
+}
+
+func newReportResponse(r *report.Report) reportResponse {
+	res := reportResponse{ID: r.ID, AdID: r.AdID, ReporterID: r.ReporterID, Reason: string(r.Reason),
+		Comment: r.Comment, Status: string(r.Status), CreationDate: r.CreationDate}
+	if !r.IsOpen() {
+		resolutionDate := r.ResolutionDate
+		res.ResolutionDate = &resolutionDate
+	}
+	return res
+}
+
+func ReportSuccessResponse(r *report.Report) *gin.H {
+	return &gin.H{
+		"data":  newReportResponse(r),
+		"error": nil,
+	}
+}
+
+func ReportSuccessResponseList(list []report.Report) *gin.H {
+	res := []reportResponse{}
+	for i := range list {
+		res = append(res, newReportResponse(&list[i]))
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+type createWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+	// AuthorID, Lang and Q select ads events are sent about, like the query parameters of ad listings
+	AuthorID int64  `json:"author_id"`
+	Lang     string `json:"lang"`
+	Q        string `json:"q"`
+}
+
+// webhookResponse leaves the secret out.
+type webhookResponse struct {
+	ID           int64     `json:"id"`
+	URL          string    `json:"url"`
+	Events       []string  `json:"events"`
+	AuthorID     int64     `json:"author_id,omitempty"`
+	Lang         string    `json:"lang,omitempty"`
+	Q            string    `json:"q,omitempty"`
+	CreationDate time.Time `json:"creation_date"`
+}
+
+func newWebhookResponse(s *webhook.Subscription) webhookResponse {
+	res := webhookResponse{ID: s.ID, URL: s.URL, Events: []string{}, AuthorID: s.Pattern.AuthorID,
+		Lang: s.Pattern.Lang, CreationDate: s.CreationDate}
+	for _, e := range s.Events {
+		res.Events = append(res.Events, string(e))
+	}
+	if s.Pattern.Expr != nil {
+		res.Q = s.Pattern.Expr.String()
+	}
+	return res
+}
+
+func WebhookSuccessResponse(s *webhook.Subscription) *gin.H {
+	return &gin.H{
+		"data":  newWebhookResponse(s),
+		"error": nil,
+	}
+}
+
+func WebhookSuccessResponseList(list []webhook.Subscription) *gin.H {
+	res := []webhookResponse{}
+	for i := range list {
+		res = append(res, newWebhookResponse(&list[i]))
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+type deliveryResponse struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Event          string          `json:"event"`
+	AdID           int64           `json:"ad_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	CreationDate   time.Time       `json:"creation_date"`
+	UpdateDate     time.Time       `json:"update_date"`
+	NextAttempt    *time.Time      `json:"next_attempt,omitempty"`
+}
+
+func newDeliveryResponse(dl *webhook.Delivery) deliveryResponse {
+	res := deliveryResponse{ID: dl.ID, WebhookID: dl.SubscriptionID, Event: string(dl.Event), AdID: dl.AdID,
+		Payload: dl.Payload, Status: string(dl.Status), Attempts: dl.Attempts, LastError: dl.LastError,
+		ResponseStatus: dl.ResponseStatus, CreationDate: dl.CreationDate, UpdateDate: dl.UpdateDate}
+	if !dl.NextAttempt.IsZero() {
+		next := dl.NextAttempt
+		res.NextAttempt = &next
+	}
+	return res
+}
+
+func DeliverySuccessResponse(dl *webhook.Delivery) *gin.H {
+	return &gin.H{
+		"data":  newDeliveryResponse(dl),
+		"error": nil,
+	}
+}
+
+func DeliverySuccessResponseList(list []webhook.Delivery) *gin.H {
+	res := []deliveryResponse{}
+	for i := range list {
+		res = append(res, newDeliveryResponse(&list[i]))
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+// ErrorResponse translates the error to the language the client asked for.
+func ErrorResponse(ctx context.Context, err error) *gin.H {
 	return &gin.H{
 		"data":  nil,
-		"error": err.Error(),
+		"error": i18n.Translate(i18n.FromContext(ctx), err.Error()),
+	}
+}
+
+func SessionSuccessResponse(s *session.Session) *gin.H {
+	return &gin.H{
+		"data": sessionResponse{
+			Token:          s.Token,
+			UserID:         s.UserID,
+			ExpirationDate: s.ExpirationDate,
+		},
+		"error": nil,
 	}
 }
@@ -0,0 +1,87 @@
+package httpgin
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"homework10/internal/report"
+	"net/http"
+	"strconv"
+)
+
+func reportAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody reportAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		reason, err := report.ParseReason(reqBody.Reason)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		r, err := a.ReportAd(c, int64(adID), reqBody.UserID, reason, reqBody.Comment)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, ReportSuccessResponse(&r))
+	}
+}
+
+// listReports is the moderation queue, it returns open reports unless the status query parameter
+// asks for others, status=all returns all of them.
+func listReports(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := report.StatusOpen
+		if s := c.Query("status"); s == "all" {
+			status = ""
+		} else if s != "" {
+			var err error
+			status, err = report.ParseStatus(s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+		}
+
+		list, err := a.ListReports(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)), status)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, ReportSuccessResponseList(list))
+	}
+}
+
+func resolveReport(a app.App) gin.HandlerFunc {
+	return moderateReport(a.ResolveReport)
+}
+
+func dismissReport(a app.App) gin.HandlerFunc {
+	return moderateReport(a.DismissReport)
+}
+
+func moderateReport(action func(ctx context.Context, reportID int64) (report.Report, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reportID, err := strconv.Atoi(c.Param("report_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		r, err := action(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)), int64(reportID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, ReportSuccessResponse(&r))
+	}
+}
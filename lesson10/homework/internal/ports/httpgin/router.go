@@ -8,13 +8,52 @@ import (
 func AppRouter(r *gin.RouterGroup, a app.App) {
 	r.POST("/ads", createAd(a))
 	r.PUT("/ads/:ad_id/status", changeAdStatus(a))
+	r.PUT("/ads/:ad_id/lang", setAdLanguage(a))
 	r.PUT("/ads/:ad_id", updateAd(a))
 	r.DELETE("/ads/:ad_id", deleteAd(a))
 	r.GET("/ads", listAds(a))
 	r.GET("/ads/by_title", getAdsByTitle(a))
+	r.GET("/ads/export", exportAds(a))
+	r.POST("/ads/import", importAds(a))
 	r.GET("/ads/:ad_id", getAdByID(a))
+	r.POST("/ads/:ad_id/restore", restoreAd(a))
+	r.POST("/ads/:ad_id/reports", reportAd(a))
+	r.GET("/users/:user_id/trash", listTrash(a))
+	r.GET("/users/:user_id/stats", getUserStats(a))
 	r.POST("/users", createUser(a))
 	r.PUT("/users/:user_id", changeUserInfo(a))
 	r.GET("/users/:user_id", getUserByID(a))
 	r.DELETE("/users/:user_id", deleteUserByID(a))
+	r.POST("/accounts", register(a))
+	r.GET("/accounts/me", getAccount(a))
+	r.POST("/sessions", login(a))
+	r.POST("/users/:user_id/verification", requestVerification(a))
+	r.PUT("/users/:user_id/verification", verifyEmail(a))
+	r.PUT("/users/:user_id/favorites/:ad_id", addFavorite(a))
+	r.DELETE("/users/:user_id/favorites/:ad_id", removeFavorite(a))
+	r.GET("/users/:user_id/favorites", listFavorites(a))
+	r.POST("/users/:user_id/searches", saveSearch(a))
+	r.GET("/users/:user_id/searches", listSavedSearches(a))
+	r.DELETE("/users/:user_id/searches/:search_id", deleteSavedSearch(a))
+	r.POST("/ads/:ad_id/threads", openThread(a))
+	r.GET("/users/:user_id/threads", listThreads(a))
+	r.POST("/threads/:thread_id/messages", sendMessage(a))
+	r.GET("/threads/:thread_id/messages", getMessages(a))
+	r.PUT("/threads/:thread_id/read", markRead(a))
+	r.PUT("/users/:user_id/blocks/:blocked_id", blockUser(a))
+	r.DELETE("/users/:user_id/blocks/:blocked_id", unblockUser(a))
+	r.GET("/users/:user_id/ws", messagesSocket(a))
+	r.GET("/audit", listAudit(a))
+	r.GET("/reports", listReports(a))
+	r.POST("/reports/:report_id/resolve", resolveReport(a))
+	r.POST("/reports/:report_id/dismiss", dismissReport(a))
+	r.POST("/webhooks", createWebhook(a))
+	r.GET("/webhooks", listWebhooks(a))
+	r.GET("/webhooks/dead_letters", listDeadLetters(a))
+	r.DELETE("/webhooks/:webhook_id", deleteWebhook(a))
+	r.GET("/webhooks/:webhook_id/deliveries", listDeliveries(a))
+	r.POST("/webhooks/deliveries/:delivery_id/redeliver", redeliverWebhook(a))
+	gql := graphqlHandler(a)
+	r.POST("/graphql", gql)
+	r.GET("/graphql", gql)
 }
@@ -13,8 +13,13 @@ func NewHTTPServer(port string, a app.App) *http.Server {
 	handler := gin.New()
 	handler.Use(gin.Recovery())
 	handler.Use(CustomLogger)
+	handler.Use(localeMiddleware)
+	handler.Use(tenantMiddleware(a))
+	handler.Use(idempotencyMiddleware)
+	handler.Use(requestMiddleware)
 	v1 := handler.Group("/api/v1")
 	AppRouter(v1, a)
+	WebRouter(handler.Group("/web"), a)
 	s := &http.Server{Addr: port, Handler: handler}
 
 	return s
@@ -0,0 +1,139 @@
+package httpgin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"homework10/internal/app"
+	"homework10/internal/message"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// socketRequest is a frame sent by a client: "send" posts Text to the thread,
+// "read" marks messages of the thread up to UpToID as read.
+type socketRequest struct {
+	Type     string `json:"type"`
+	ThreadID int64  `json:"thread_id"`
+	Text     string `json:"text"`
+	UpToID   int64  `json:"up_to_id"`
+}
+
+type socketEvent struct {
+	Type     string           `json:"type"`
+	ThreadID int64            `json:"thread_id,omitempty"`
+	Message  *messageResponse `json:"message,omitempty"`
+	ReaderID int64            `json:"reader_id,omitempty"`
+	UpToID   int64            `json:"up_to_id,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+func newSocketEvent(e *message.Event) socketEvent {
+	res := socketEvent{Type: string(e.Type), ThreadID: e.ThreadID}
+	switch e.Type {
+	case message.EventMessage:
+		m := newMessageResponse(&e.Message)
+		res.Message = &m
+	case message.EventRead:
+		res.ReaderID = e.ReaderID
+		res.UpToID = e.UpToID
+	}
+	return res
+}
+
+type socket struct {
+	mx   sync.Mutex
+	conn net.Conn
+}
+
+func (d *socket) write(e socketEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return wsutil.WriteServerMessage(d.conn, ws.OpText, data)
+}
+
+// messagesSocket streams events of all threads of the user over a WebSocket
+// and accepts messages and read receipts from the client. Only the user
+// themselves can connect, with the token of their session.
+func messagesSocket(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		if !authorize(c, a, int64(userID)) {
+			return
+		}
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		events, err := a.SubscribeMessages(ctx, int64(userID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+
+		conn, _, _, err := ws.UpgradeHTTP(c.Request, c.Writer)
+		if err != nil {
+			log.Printf("can't upgrade connection: %s\n", err.Error())
+			return
+		}
+		s := &socket{conn: conn}
+		defer conn.Close()
+
+		go func() {
+			for e := range events {
+				if err := s.write(newSocketEvent(&e)); err != nil {
+					log.Printf("can't write message: %s\n", err.Error())
+					cancel()
+					_ = conn.Close()
+					return
+				}
+			}
+		}()
+
+		for {
+			data, _, err := wsutil.ReadClientData(conn)
+			if err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					log.Printf("can't read message from connection: %s\n", err.Error())
+				}
+				return
+			}
+			if err := handleSocketRequest(ctx, a, int64(userID), data); err != nil {
+				if err := s.write(socketEvent{Type: "error", Error: err.Error()}); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func handleSocketRequest(ctx context.Context, a app.App, userID int64, data []byte) error {
+	var req socketRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	switch req.Type {
+	case "send":
+		_, err := a.SendMessage(ctx, req.ThreadID, userID, req.Text)
+		return err
+	case "read":
+		_, err := a.MarkRead(ctx, req.ThreadID, userID, req.UpToID)
+		return err
+	default:
+		return app.ErrWrongFormat
+	}
+}
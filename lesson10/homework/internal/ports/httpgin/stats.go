@@ -0,0 +1,63 @@
+package httpgin
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultStatsPeriod = 30 * 24 * time.Hour
+
+var statsSteps = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+// getUserStats reports on ads of the user from the from until the to query parameters, given in
+// microseconds like l_time and r_time, in buckets of an hour, a day or a week. It defaults to
+// the last 30 days by day.
+func getUserStats(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		// the current hour is the last one, so default buckets are whole
+		to := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+		if s := c.Query("to"); s != "" {
+			micros, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			to = time.UnixMicro(micros).UTC()
+		}
+		from := to.Add(-defaultStatsPeriod)
+		if s := c.Query("from"); s != "" {
+			micros, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			from = time.UnixMicro(micros).UTC()
+		}
+		bucket := c.DefaultQuery("bucket", "day")
+		step, ok := statsSteps[bucket]
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, fmt.Errorf("unknown bucket %q", bucket)))
+			return
+		}
+
+		report, err := a.GetUserStats(c, int64(userID), from, to, step)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, StatsSuccessResponse(&report))
+	}
+}
@@ -0,0 +1,24 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"homework10/internal/tenant"
+	"net/http"
+)
+
+const tenantHeader = "X-Tenant-ID"
+
+// tenantMiddleware resolves the tenant of a request from the X-Tenant-ID header or the host.
+// It is kept in the gin context, as handlers pass the gin context to the app.
+func tenantMiddleware(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := a.ResolveTenant(c, c.GetHeader(tenantHeader), c.Request.Host)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		c.Set(tenant.Key, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"net/http"
+	"strconv"
+)
+
+// listTrash returns deleted ads of the user which can still be restored.
+func listTrash(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		ads, err := a.ListTrash(c, int64(userID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponseList(&ads))
+	}
+}
+
+func restoreAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody restoreAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		ad, err := a.RestoreAd(c, int64(adID), reqBody.UserID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
@@ -0,0 +1,48 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"net/http"
+	"strconv"
+)
+
+// requestVerification mails a new verification token to the user.
+func requestVerification(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		u, err := a.RequestVerification(c, int64(userID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
+
+func verifyEmail(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody verifyEmailRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		u, err := a.VerifyEmail(c, int64(userID), reqBody.Token)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
@@ -0,0 +1,346 @@
+package httpgin
+
+import (
+	"embed"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/adexpr"
+	"homework10/internal/adpattern"
+	"homework10/internal/analytics"
+	"homework10/internal/app"
+	"homework10/internal/i18n"
+	"homework10/internal/langdetect"
+	"homework10/internal/user"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//go:embed web/templates/*.html web/static/*
+var webAssets embed.FS
+
+// pages are parsed once, each together with the layout it fills in.
+var pages = func() map[string]*template.Template {
+	res := map[string]*template.Template{}
+	for _, name := range []string{"ads", "ad", "login", "dashboard", "edit", "error"} {
+		res[name] = template.Must(template.ParseFS(webAssets, "web/templates/layout.html",
+			"web/templates/"+name+".html"))
+	}
+	return res
+}()
+
+const (
+	sessionCookie = "session"
+	webBaseKey    = "web_base"
+	webUserKey    = "web_user"
+)
+
+// WebRouter serves the HTML UI: published ads for everyone, and a dashboard where a logged-in
+// author creates, edits and publishes their ads. Forms are protected by csrfMiddleware.
+func WebRouter(r *gin.RouterGroup, a app.App) {
+	static, err := fs.Sub(webAssets, "web/static")
+	if err != nil {
+		log.Fatal(err)
+	}
+	base := r.BasePath()
+	if base == "/" {
+		base = ""
+	}
+	r.Use(func(c *gin.Context) {
+		c.Set(webBaseKey, base)
+		c.Next()
+	})
+	r.StaticFS("/static", http.FS(static))
+
+	r.Use(csrfMiddleware, webUserMiddleware(a))
+	r.GET("/", webListAds(a))
+	r.GET("/ads/:ad_id", webGetAd(a))
+	r.GET("/login", webLoginForm)
+	r.POST("/login", webLogin(a))
+	r.POST("/logout", webLogout)
+
+	dashboard := r.Group("/dashboard", webAuthRequired)
+	dashboard.GET("", webDashboard(a))
+	dashboard.POST("/ads", webCreateAd(a))
+	dashboard.GET("/ads/:ad_id/edit", webEditForm(a))
+	dashboard.POST("/ads/:ad_id", webUpdateAd(a))
+	dashboard.POST("/ads/:ad_id/status", webChangeAdStatus(a))
+}
+
+// render executes the page with the data every page needs added to data.
+func render(c *gin.Context, status int, name string, data gin.H) {
+	data["Base"] = c.GetString(webBaseKey)
+	data["Lang"] = i18n.FromContext(c).String()
+	data["CSRF"] = c.GetString(csrfKey)
+	data["User"] = webUser(c)
+	if _, ok := data["Error"]; !ok {
+		data["Error"] = ""
+	}
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := pages[name].ExecuteTemplate(c.Writer, "layout", data); err != nil {
+		log.Printf("can't render %s: %s", name, err.Error())
+	}
+}
+
+// renderError shows the error translated to the language of the client.
+func renderError(c *gin.Context, status int, err error) {
+	render(c, status, "error", gin.H{"Error": i18n.Translate(i18n.FromContext(c), err.Error())})
+}
+
+func redirect(c *gin.Context, path string) {
+	c.Redirect(http.StatusSeeOther, c.GetString(webBaseKey)+path)
+}
+
+// webUserMiddleware puts the user of the session cookie, if it is active, into the gin context.
+func webUserMiddleware(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, err := c.Cookie(sessionCookie); err == nil && token != "" {
+			if u, err := a.Authenticate(c, token); err == nil {
+				c.Set(webUserKey, u)
+			}
+		}
+		c.Next()
+	}
+}
+
+func webUser(c *gin.Context) *user.User {
+	if u, ok := c.Value(webUserKey).(user.User); ok {
+		return &u
+	}
+	return nil
+}
+
+func webAuthRequired(c *gin.Context) {
+	if webUser(c) == nil {
+		redirect(c, "/login")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func webAdID(c *gin.Context) (int64, bool) {
+	adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+	if err != nil {
+		renderError(c, http.StatusBadRequest, app.ErrWrongFormat)
+		return 0, false
+	}
+	return adID, true
+}
+
+func webListAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := map[string]string{}
+		for _, key := range []string{"q", "lang", "sort", "author_id"} {
+			query[key] = c.Query(key)
+		}
+		adp, err := webPattern(c, a, query)
+		if err != nil {
+			renderError(c, errorStatus(err), err)
+			return
+		}
+		list, err := a.GetAllAdsByTemplate(c, adp)
+		if err != nil {
+			renderError(c, errorStatus(err), err)
+			return
+		}
+		render(c, http.StatusOK, "ads", gin.H{"Ads": list, "Query": query})
+	}
+}
+
+// webPattern builds the pattern of published ads from the search form, bad input is ErrWrongFormat.
+func webPattern(c *gin.Context, a app.App, query map[string]string) (adpattern.AdPattern, error) {
+	f, err := a.GetNewFilter(c)
+	if err != nil {
+		return adpattern.AdPattern{}, err
+	}
+	f, err = f.BasicConfig(c)
+	if err != nil {
+		return adpattern.AdPattern{}, err
+	}
+	if s := query["author_id"]; s != "" {
+		authorID, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return adpattern.AdPattern{}, app.ErrWrongFormat
+		}
+		if f, err = f.SetAuthor(c, authorID); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if s := query["lang"]; s != "" {
+		lang, err := langdetect.Parse(s)
+		if err != nil {
+			return adpattern.AdPattern{}, wrongFormat(err)
+		}
+		if f, err = f.SetLang(c, lang); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if s := query["sort"]; s != "" {
+		order, err := adpattern.ParseSort(s)
+		if err != nil {
+			return adpattern.AdPattern{}, wrongFormat(err)
+		}
+		if f, err = f.SetSort(c, order); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	if s := query["q"]; s != "" {
+		expr, err := adexpr.Parse(s)
+		if err != nil {
+			return adpattern.AdPattern{}, wrongFormat(err)
+		}
+		if f, err = f.SetExpr(c, expr); err != nil {
+			return adpattern.AdPattern{}, err
+		}
+	}
+	return f.GetPattern(c)
+}
+
+func wrongFormat(err error) error {
+	return fmt.Errorf("%w: %s", app.ErrWrongFormat, err.Error())
+}
+
+func webGetAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := webAdID(c)
+		if !ok {
+			return
+		}
+		c.Set(analytics.ViewerKey, c.ClientIP())
+		ad, err := a.FindAd(c, adID)
+		if err != nil {
+			renderError(c, http.StatusNotFound, err)
+			return
+		}
+		// drafts and ads hidden by moderators are seen only by their authors
+		if u := webUser(c); (!ad.Published || ad.Hidden) && (u == nil || u.ID != ad.AuthorID) {
+			renderError(c, http.StatusNotFound, app.ErrWrongFormat)
+			return
+		}
+		render(c, http.StatusOK, "ad", gin.H{"Ad": ad})
+	}
+}
+
+func webLoginForm(c *gin.Context) {
+	render(c, http.StatusOK, "login", gin.H{"Email": ""})
+}
+
+func webLogin(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email := c.PostForm("email")
+		s, err := a.Login(c, email, c.PostForm("password"))
+		if err != nil {
+			render(c, errorStatus(err), "login", gin.H{"Email": email,
+				"Error": i18n.Translate(i18n.FromContext(c), err.Error())})
+			return
+		}
+		maxAge := int(time.Until(s.ExpirationDate).Seconds())
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(sessionCookie, s.Token, maxAge, cookiePath(c), "", c.Request.TLS != nil, true)
+		redirect(c, "/dashboard")
+	}
+}
+
+func webLogout(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, "", -1, cookiePath(c), "", c.Request.TLS != nil, true)
+	redirect(c, "/")
+}
+
+func cookiePath(c *gin.Context) string {
+	if base := c.GetString(webBaseKey); base != "" {
+		return base
+	}
+	return "/"
+}
+
+func webDashboard(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		renderDashboard(c, a, http.StatusOK, gin.H{"Title": "", "Text": ""})
+	}
+}
+
+// renderDashboard lists all ads of the user, drafts included, next to the form of a new ad.
+func renderDashboard(c *gin.Context, a app.App, status int, data gin.H) {
+	list, err := a.GetAllAdsByTemplate(c, adpattern.AdPattern{AuthorID: webUser(c).ID})
+	if err != nil {
+		renderError(c, errorStatus(err), err)
+		return
+	}
+	data["Ads"] = list
+	render(c, status, "dashboard", data)
+}
+
+func webCreateAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		title, text := c.PostForm("title"), c.PostForm("text")
+		_, err := a.CreateAd(c, title, text, webUser(c).ID)
+		if err != nil {
+			renderDashboard(c, a, errorStatus(err), gin.H{"Title": title, "Text": text,
+				"Error": i18n.Translate(i18n.FromContext(c), err.Error())})
+			return
+		}
+		redirect(c, "/dashboard")
+	}
+}
+
+func webEditForm(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := webAdID(c)
+		if !ok {
+			return
+		}
+		ad, err := a.FindAd(c, adID)
+		if err != nil {
+			renderError(c, errorStatus(err), err)
+			return
+		}
+		if ad.AuthorID != webUser(c).ID {
+			renderError(c, http.StatusForbidden, app.ErrNoAccess)
+			return
+		}
+		render(c, http.StatusOK, "edit", gin.H{"Ad": ad})
+	}
+}
+
+func webUpdateAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := webAdID(c)
+		if !ok {
+			return
+		}
+		title, text := c.PostForm("title"), c.PostForm("text")
+		ad, err := a.UpdateAd(c, adID, webUser(c).ID, title, text)
+		if err != nil {
+			ad.ID, ad.Title, ad.Text = adID, title, text
+			render(c, errorStatus(err), "edit", gin.H{"Ad": ad,
+				"Error": i18n.Translate(i18n.FromContext(c), err.Error())})
+			return
+		}
+		redirect(c, "/dashboard")
+	}
+}
+
+func webChangeAdStatus(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := webAdID(c)
+		if !ok {
+			return
+		}
+		published, err := strconv.ParseBool(c.PostForm("published"))
+		if err != nil {
+			renderError(c, http.StatusBadRequest, app.ErrWrongFormat)
+			return
+		}
+		if _, err := a.ChangeAdStatus(c, adID, webUser(c).ID, published); err != nil {
+			renderError(c, errorStatus(err), err)
+			return
+		}
+		redirect(c, "/dashboard")
+	}
+}
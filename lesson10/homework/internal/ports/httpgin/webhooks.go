@@ -0,0 +1,153 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/adexpr"
+	"homework10/internal/app"
+	"homework10/internal/langdetect"
+	"homework10/internal/webhook"
+	"net/http"
+	"strconv"
+)
+
+func createWebhook(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createWebhookRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		events := make([]webhook.Event, 0, len(reqBody.Events))
+		for _, s := range reqBody.Events {
+			e, err := webhook.ParseEvent(s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+			events = append(events, e)
+		}
+
+		f, err := a.GetNewFilter(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+		filter, err := f.SetAuthor(c, reqBody.AuthorID)
+		if err == nil && reqBody.Lang != "" {
+			var lang string
+			lang, err = langdetect.Parse(reqBody.Lang)
+			if err == nil {
+				filter, err = filter.SetLang(c, lang)
+			}
+		}
+		if err == nil && reqBody.Q != "" {
+			var expr adexpr.Expr
+			expr, err = adexpr.Parse(reqBody.Q)
+			if err == nil {
+				filter, err = filter.SetExpr(c, expr)
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		pattern, err := filter.GetPattern(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(c, err))
+			return
+		}
+
+		s, err := a.CreateWebhook(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)), reqBody.URL, events,
+			pattern, reqBody.Secret)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, WebhookSuccessResponse(&s))
+	}
+}
+
+func listWebhooks(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := a.ListWebhooks(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, WebhookSuccessResponseList(list))
+	}
+}
+
+func deleteWebhook(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhookID, err := strconv.Atoi(c.Param("webhook_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		s, err := a.DeleteWebhook(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)), int64(webhookID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, WebhookSuccessResponse(&s))
+	}
+}
+
+// listDeliveries is the delivery log of a webhook, the status query parameter filters it.
+func listDeliveries(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhookID, err := strconv.Atoi(c.Param("webhook_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+		var status webhook.Status
+		if s := c.Query("status"); s != "" {
+			status, err = webhook.ParseStatus(s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+				return
+			}
+		}
+
+		list, err := a.ListWebhookDeliveries(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)),
+			int64(webhookID), status)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, DeliverySuccessResponseList(list))
+	}
+}
+
+// listDeadLetters returns dead deliveries of all webhooks.
+func listDeadLetters(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := a.ListWebhookDeliveries(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)), 0,
+			webhook.StatusDead)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, DeliverySuccessResponseList(list))
+	}
+}
+
+func redeliverWebhook(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deliveryID, err := strconv.Atoi(c.Param("delivery_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(c, err))
+			return
+		}
+
+		dl, err := a.RedeliverWebhook(app.ContextWithAdminKey(c, c.GetHeader(adminKeyHeader)), int64(deliveryID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(c, err))
+			return
+		}
+		c.JSON(http.StatusOK, DeliverySuccessResponse(&dl))
+	}
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"fmt"
+	"homework10/internal/tenant"
+	"time"
+)
+
+type Reason string
+
+const (
+	ReasonSpam       Reason = "spam"
+	ReasonFraud      Reason = "fraud"
+	ReasonOffensive  Reason = "offensive"
+	ReasonProhibited Reason = "prohibited"
+	ReasonOther      Reason = "other"
+)
+
+var ErrBadReason = fmt.Errorf("bad report reason")
+
+func ParseReason(s string) (Reason, error) {
+	switch r := Reason(s); r {
+	case ReasonSpam, ReasonFraud, ReasonOffensive, ReasonProhibited, ReasonOther:
+		return r, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrBadReason, s)
+}
+
+// Status of a report, open reports wait in the moderation queue.
+type Status string
+
+const (
+	StatusOpen      Status = "open"
+	StatusResolved  Status = "resolved"
+	StatusDismissed Status = "dismissed"
+)
+
+var ErrBadStatus = fmt.Errorf("bad report status")
+
+func ParseStatus(s string) (Status, error) {
+	switch st := Status(s); st {
+	case StatusOpen, StatusResolved, StatusDismissed:
+		return st, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrBadStatus, s)
+}
+
+// SystemReporter is the reporter of reports filed by the service itself, e.g. for ads
+// flagged by the content policy.
+const SystemReporter int64 = 0
+
+// MaxCommentLen matches the validation of Comment.
+const MaxCommentLen = 499
+
+// Report is a complaint of a user about an ad, a user reports an ad at most once.
+type Report struct {
+	ID           int64
+	Tenant       tenant.ID
+	AdID         int64
+	ReporterID   int64
+	Reason       Reason
+	Comment      string `validate:"range:0,499"`
+	Status       Status
+	CreationDate time.Time
+	// ResolutionDate is zero while the report is open
+	ResolutionDate time.Time
+}
+
+func (r Report) IsOpen() bool {
+	return r.Status == StatusOpen
+}
@@ -0,0 +1,10 @@
+package search
+
+import "homework10/internal/adpattern"
+
+type SavedSearch struct {
+	ID      int64
+	UserID  int64
+	Name    string
+	Pattern adpattern.AdPattern
+}
@@ -0,0 +1,18 @@
+package session
+
+import (
+	"homework10/internal/tenant"
+	"time"
+)
+
+// Session lets the user act in the tenant it is started in only.
+type Session struct {
+	Token          string
+	Tenant         tenant.ID
+	UserID         int64
+	ExpirationDate time.Time
+}
+
+func (s Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpirationDate)
+}
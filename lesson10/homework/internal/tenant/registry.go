@@ -0,0 +1,89 @@
+package tenant
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Registry lists tenants of a deployment, Default is always there.
+type Registry struct {
+	configs map[ID]Config
+	hosts   map[string]ID
+}
+
+func NewRegistry(configs map[ID]Config) (*Registry, error) {
+	d := &Registry{configs: map[ID]Config{Default: {}}, hosts: map[string]ID{}}
+	for id, c := range configs {
+		if id == "" {
+			return nil, fmt.Errorf("empty tenant id")
+		}
+		d.configs[id] = c
+		for _, host := range c.Hosts {
+			host = strings.ToLower(host)
+			if other, ok := d.hosts[host]; ok && other != id {
+				return nil, fmt.Errorf("host %q belongs to tenants %q and %q", host, other, id)
+			}
+			d.hosts[host] = id
+		}
+	}
+	return d, nil
+}
+
+// Load reads a YAML file of the form
+//
+//	tenants:
+//	  acme:
+//	    hosts: [ads.acme.com]
+//	    max_title_len: 50
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tenants map[ID]Config `yaml:"tenants"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("can't parse %s: %w", path, err)
+	}
+	return NewRegistry(file.Tenants)
+}
+
+func (d *Registry) IDs() []ID {
+	res := make([]ID, 0, len(d.configs))
+	for id := range d.configs {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	return res
+}
+
+func (d *Registry) Config(id ID) (Config, bool) {
+	c, ok := d.configs[id]
+	return c, ok
+}
+
+// Resolve picks the tenant named by the client, or the one owning the host the request
+// was sent to, or Default.
+func (d *Registry) Resolve(requested string, host string) (ID, error) {
+	if requested != "" {
+		if _, ok := d.configs[ID(requested)]; !ok {
+			return "", fmt.Errorf("%w %q", ErrUnknown, requested)
+		}
+		return ID(requested), nil
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if id, ok := d.hosts[strings.ToLower(host)]; ok {
+		return id, nil
+	}
+	return Default, nil
+}
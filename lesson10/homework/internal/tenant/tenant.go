@@ -0,0 +1,56 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ID names a marketplace served by the service, all ads and users belong to one.
+type ID string
+
+// Default is the tenant of requests which don't name one, and the only tenant of a
+// single-tenant deployment.
+const Default ID = "default"
+
+var ErrUnknown = fmt.Errorf("unknown tenant")
+
+type ctxKey struct{}
+
+// Key is the key the tenant is stored under in string-keyed request values, such as
+// gin.Context.Set, whose Value method doesn't see values of the request context.
+const Key = "tenant_id"
+
+func NewContext(ctx context.Context, id ID) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns Default if ctx carries no tenant.
+func FromContext(ctx context.Context) ID {
+	if id, ok := ctx.Value(ctxKey{}).(ID); ok {
+		return id
+	}
+	if id, ok := ctx.Value(Key).(ID); ok {
+		return id
+	}
+	return Default
+}
+
+// Config holds settings of a tenant. Limits are applied on top of the ones of ads.Ad,
+// zero means no extra limit.
+type Config struct {
+	// Hosts requests to which belong to the tenant if they don't name one
+	Hosts       []string `yaml:"hosts"`
+	MaxTitleLen int      `yaml:"max_title_len"`
+	MaxTextLen  int      `yaml:"max_text_len"`
+}
+
+func (d Config) Check(title string, text string) error {
+	if d.MaxTitleLen > 0 && utf8.RuneCountInString(title) > d.MaxTitleLen {
+		return fmt.Errorf("title is longer than %d characters", d.MaxTitleLen)
+	}
+	if d.MaxTextLen > 0 && utf8.RuneCountInString(text) > d.MaxTextLen {
+		return fmt.Errorf("text is longer than %d characters", d.MaxTextLen)
+	}
+	return nil
+}
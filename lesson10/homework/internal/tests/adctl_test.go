@@ -0,0 +1,263 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"gopkg.in/yaml.v3"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/extids"
+	"homework10/internal/adapters/tenancy"
+	"homework10/internal/adctl"
+	"homework10/internal/app"
+	"homework10/internal/ports/httpgin"
+	"io"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+type adctlEnv struct {
+	health     *health.Server
+	configPath string
+	dial       adctl.Dialer
+	httpURL    string
+}
+
+func newAdctlEnv(t *testing.T, opts ...app.Option) *adctlEnv {
+	a := app.NewApp(tenancy.NewRepository(adrepo.New), tenancy.NewUsers(customer.New), adfilter.New(),
+		append(opts, app.WithExternalIDs(extids.New()))...)
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.UnaryInterceptor, grpcPort.RecoveryInterceptor,
+		grpcPort.TenantInterceptor(a)))
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(a))
+	h := health.NewServer()
+	healthpb.RegisterHealthServer(srv, h)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	httpServer := httptest.NewServer(httpgin.NewHTTPServer(":18080", a).Handler)
+	t.Cleanup(func() {
+		httpServer.Close()
+		srv.Stop()
+	})
+
+	return &adctlEnv{
+		health:     h,
+		configPath: filepath.Join(t.TempDir(), "config.yaml"),
+		dial: func(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, addr, grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return lis.Dial()
+			}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		},
+		httpURL: httpServer.URL,
+	}
+}
+
+func (d *adctlEnv) run(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := adctl.New(&out, adctl.WithDialer(d.dial), adctl.WithConfigPath(d.configPath))
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs(append([]string{"--http-addr", d.httpURL}, args...))
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestAdctl_Users(t *testing.T) {
+	env := newAdctlEnv(t, app.WithAdminKey("secret"))
+
+	_, err := env.run("users", "create", "--id", "3", "--nickname", "Tom", "--email", "tom@mail.ru")
+	assert.Error(t, err)
+	out, err := env.run("users", "create", "--id", "3", "--nickname", "Tom", "--email", "tom@mail.ru",
+		"--admin-key", "secret", "-o", "json")
+	assert.NoError(t, err)
+	var u struct {
+		ID       int64  `json:"user_id"`
+		Nickname string `json:"nickname"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(out), &u))
+	assert.Equal(t, int64(3), u.ID)
+	assert.Equal(t, "Tom", u.Nickname)
+
+	out, err = env.run("users", "update", "3", "--nickname", "Tommy")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "Tommy")
+	assert.Contains(t, out, "tom@mail.ru")
+
+	out, err = env.run("users", "get", "3", "-o", "yaml")
+	assert.NoError(t, err)
+	var y map[string]any
+	assert.NoError(t, yaml.Unmarshal([]byte(out), &y))
+	assert.Equal(t, "Tommy", y["nickname"])
+
+	_, err = env.run("users", "update", "3")
+	assert.Error(t, err)
+	_, err = env.run("users", "get", "abc")
+	assert.Error(t, err)
+	_, err = env.run("users", "delete", "3")
+	assert.NoError(t, err)
+	_, err = env.run("users", "get", "3")
+	assert.Error(t, err)
+}
+
+func TestAdctl_Ads(t *testing.T) {
+	env := newAdctlEnv(t)
+	_, _ = env.run("users", "create", "--id", "1", "--nickname", "Tom", "--email", "tom@mail.ru")
+	_, _ = env.run("users", "create", "--id", "2", "--nickname", "Cat", "--email", "cat@mail.ru")
+	for _, args := range [][]string{{"1", "aba"}, {"1", "foo"}, {"2", "alpha"}} {
+		_, err := env.run("ads", "create", "--user", args[0], "--title", args[1], "--text", "text")
+		assert.NoError(t, err)
+	}
+
+	out, err := env.run("ads", "list")
+	assert.NoError(t, err)
+	assert.Equal(t, "no results\n", out)
+
+	_, err = env.run("ads", "publish", "0", "--user", "1")
+	assert.NoError(t, err)
+	_, err = env.run("ads", "publish", "0", "--user", "2")
+	assert.Error(t, err)
+	out, err = env.run("ads", "list")
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "ID"))
+	assert.Contains(t, lines[1], "aba")
+
+	out, err = env.run("ads", "list", "--all", "--author", "1", "--sort", "-title", "-o", "json")
+	assert.NoError(t, err)
+	var list []struct {
+		Title string `json:"title"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(out), &list))
+	assert.Len(t, list, 2)
+	assert.Equal(t, "foo", list[0].Title)
+	_, err = env.run("ads", "list", "--sort", "nope")
+	assert.Error(t, err)
+	_, err = env.run("ads", "list", "--from", "yesterday")
+	assert.Error(t, err)
+
+	_, err = env.run("ads", "unpublish", "0", "--user", "1")
+	assert.NoError(t, err)
+	out, _ = env.run("ads", "get", "0", "-o", "yaml")
+	assert.Contains(t, out, "published: false")
+	_, err = env.run("ads", "delete", "0", "--user", "1")
+	assert.NoError(t, err)
+	_, err = env.run("ads", "get", "0")
+	assert.Error(t, err)
+}
+
+func TestAdctl_Health(t *testing.T) {
+	env := newAdctlEnv(t)
+	out, err := env.run("health")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "SERVING")
+
+	env.health.Shutdown()
+	out, err = env.run("health", "-o", "json")
+	assert.Error(t, err)
+	assert.Contains(t, out, "NOT_SERVING")
+}
+
+func TestAdctl_Profiles(t *testing.T) {
+	env := newAdctlEnv(t, app.WithAdminKey("secret"))
+	_, err := env.run("config", "set", "prod", "--admin-key", "wrong")
+	assert.NoError(t, err)
+	_, err = env.run("config", "set", "dev", "--admin-key", "secret", "--output", "json")
+	assert.NoError(t, err)
+	_, err = env.run("config", "set", "bad", "--output", "xml")
+	assert.Error(t, err)
+
+	out, err := env.run("config", "list")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "  dev\t")
+	assert.Contains(t, out, "* prod\t")
+
+	_, err = env.run("users", "create", "--id", "1", "--nickname", "Tom", "--email", "tom@mail.ru")
+	assert.Error(t, err)
+	out, err = env.run("--profile", "dev", "users", "create", "--id", "1", "--nickname", "Tom", "--email", "tom@mail.ru")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "{"))
+
+	_, err = env.run("config", "use", "dev")
+	assert.NoError(t, err)
+	out, err = env.run("users", "get", "1", "-o", "table")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "ID"))
+
+	_, err = env.run("--profile", "staging", "health")
+	assert.Error(t, err)
+	_, err = env.run("config", "use", "staging")
+	assert.Error(t, err)
+	_, err = env.run("config", "delete", "dev")
+	assert.NoError(t, err)
+	conf, err := adctl.LoadConfig(env.configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "", conf.Current)
+	assert.Len(t, conf.Profiles, 1)
+	info, err := os.Stat(env.configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestAdctl_Completion(t *testing.T) {
+	env := newAdctlEnv(t)
+	_, _ = env.run("config", "set", "dev")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"bash", []string{"completion", "bash"}, []string{"bash completion", "adctl"}},
+		{"zsh", []string{"completion", "zsh"}, []string{"compdef"}},
+		{"commands", []string{"__complete", ""}, []string{"ads", "users", "health", "config"}},
+		{"output", []string{"__complete", "--output", ""}, []string{"table", "json", "yaml"}},
+		{"profile", []string{"__complete", "--profile", ""}, []string{"dev"}},
+		{"sort", []string{"__complete", "ads", "list", "--sort", ""}, []string{"-creation_date"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := env.run(tc.args...)
+			assert.NoError(t, err)
+			for _, s := range tc.want {
+				assert.Contains(t, out, s)
+			}
+		})
+	}
+}
+
+func TestAdctl_Bulk(t *testing.T) {
+	env := newAdctlEnv(t, app.WithAdminKey("secret"))
+	_, _ = env.run("users", "create", "--id", "1", "--nickname", "Tom", "--email", "tom@mail.ru", "--admin-key", "secret")
+	file := filepath.Join(t.TempDir(), "ads.ndjson")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"external_id":"x","title":"aba","text":"caba","author_id":1}`+"\n"+
+		`{"external_id":"y","title":"","text":"caba","author_id":1}`+"\n"), 0o644))
+
+	_, err := env.run("import", file)
+	assert.Error(t, err)
+	out, err := env.run("import", file, "--dry-run", "--admin-key", "secret")
+	assert.Error(t, err)
+	assert.Contains(t, out, "dry run: created 1, updated 0, unchanged 0, failed 1")
+	assert.Contains(t, out, "line 2:")
+	_, _ = env.run("import", file, "--admin-key", "secret")
+
+	out, err = env.run("export", "--format", "ndjson")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"external_id":"x"`)
+	_, err = env.run("export", "--format", "xml")
+	assert.Error(t, err)
+}
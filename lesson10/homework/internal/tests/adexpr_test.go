@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework10/internal/adapters/adcache"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/searches"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/adexpr"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdExpr_Parse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`author = 3`, `author = 3`},
+		{`author in (3,5) and created >= 2024-01-01 and (title ~ "bike" or published = false)`,
+			`((author in (3, 5) and created >= 2024-01-01T00:00:00Z) and (title ~ "bike" or published = false))`},
+		{`a_or_b`, ""},
+		{`title = "a" or text = "b" and id != 2`, `(title = "a" or (text = "b" and id != 2))`},
+		{`NOT (published = TRUE) AND updated < 2024-01-01T10:00:00+03:00`,
+			`(not published = true and updated < 2024-01-01T07:00:00Z)`},
+		{`not not id <= -1`, `not not id <= -1`},
+		{`title = "say \"hi\"\n"`, `title = "say \"hi\"\n"`},
+		{`title ~ "вело"`, `title ~ "вело"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			e, err := adexpr.Parse(tc.expr)
+			if tc.want == "" {
+				assert.ErrorIs(t, err, adexpr.ErrBadExpr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, e.String())
+			again, err := adexpr.Parse(e.String())
+			assert.NoError(t, err)
+			assert.Equal(t, e, again)
+		})
+	}
+}
+
+func TestAdExpr_ParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`price = 3`,
+		`author ~ 3`,
+		`title < "a"`,
+		`published > false`,
+		`author = "3"`,
+		`title = bike`,
+		`published = 1`,
+		`created >= yesterday`,
+		`title = "bike`,
+		`author = 3 and`,
+		`(author = 3`,
+		`author = 3)`,
+		`author in ()`,
+		`author in (3 5)`,
+		`author == 3`,
+		`author ! 3`,
+		`author = 3 author = 4`,
+		`author = 3 # comment`,
+		strings.Repeat("(", 1001),
+		strings.TrimSuffix(strings.Repeat("id = 1 or ", 60), " or "),
+	} {
+		_, err := adexpr.Parse(expr)
+		assert.ErrorIs(t, err, adexpr.ErrBadExpr, expr)
+	}
+}
+
+func TestAdExpr_Match(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ad := ads.Ad{ID: 7, AuthorID: 3, Title: "Red Bike", Text: "almost new", Published: true,
+		CreationDate: date, UpdateDate: date.Add(time.Hour)}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`author = 3`, true},
+		{`author != 3`, false},
+		{`author in (1, 3)`, true},
+		{`author in (1, 2)`, false},
+		{`id > 6 and id < 8`, true},
+		{`id >= 8 or id <= 6`, false},
+		{`title ~ "BIKE"`, true},
+		{`title ~ "car"`, false},
+		{`title = "Red Bike"`, true},
+		{`title in ("red bike")`, false},
+		{`text ~ "new" and published = true`, true},
+		{`not published = true`, false},
+		{`created >= 2024-01-01`, true},
+		{`created > 2024-01-01`, false},
+		{`created = 2024-01-01T03:00:00+03:00`, true},
+		{`updated > created`, false},
+		{`author in (3, 5) and created >= 2024-01-01 and (title ~ "car" or published = false)`, false},
+		{`author in (3, 5) and created >= 2024-01-01 and (title ~ "bike" or published = false)`, true},
+	}
+	for _, tc := range tests {
+		e, err := adexpr.Parse(tc.expr)
+		if tc.expr == `updated > created` {
+			// only values can be compared with fields
+			assert.ErrorIs(t, err, adexpr.ErrBadExpr)
+			continue
+		}
+		assert.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.want, e.Match(ad), tc.expr)
+	}
+}
+
+func TestAdExpr_Repositories(t *testing.T) {
+	tests := []struct {
+		name string
+		repo func() app.Repository
+	}{
+		{"map", adrepo.New},
+		{"sharded", func() app.Repository { return adrepo.NewSharded(3) }},
+		{"cached", func() app.Repository { return adcache.New(adrepo.New(), 10, time.Minute) }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := tc.repo()
+			titles := []string{"red bike", "blue bike", "car", "bike pump", "boat"}
+			for i, title := range titles {
+				adID, _ := repo.Add(ctx, title, "text", int64(i%3+1))
+				_ = repo.SetStatus(ctx, adID, i%2 == 0)
+			}
+			expr, err := adexpr.Parse(`author in (1, 2) and (title ~ "bike" or published = true)`)
+			assert.NoError(t, err)
+
+			list, err := repo.GetAllByTemplate(ctx, adpattern.AdPattern{Expr: expr})
+			assert.NoError(t, err)
+			got := []string{}
+			for _, ad := range list {
+				got = append(got, ad.Title)
+			}
+			assert.Equal(t, []string{"red bike", "blue bike", "bike pump", "boat"}, got)
+
+			list, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{Expr: expr, AuthorID: 2, Title: "b"})
+			assert.Len(t, list, 2)
+			list, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{Expr: expr, PublishedOnly: true,
+				Sort: []adpattern.OrderBy{{Field: adpattern.FieldTitle}}})
+			assert.Len(t, list, 2)
+			assert.Equal(t, "boat", list[0].Title)
+		})
+	}
+}
+
+func TestAdExpr_SQL(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	repo := sqlstore.NewAdRepo(db)
+	expr, err := adexpr.Parse(`author in (3,5) and created >= 2024-01-01 and not (title ~ "bike" or published != false)`)
+	assert.NoError(t, err)
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`WHERE tenant_id = $1 AND deleted_at IS NULL AND author_id = $2 AND `+
+		`(((author_id IN ($3, $4)) AND (creation_date >= $5)) AND `+
+		`NOT ((strpos(lower(title), lower($6)) > 0) OR (published <> $7))) ORDER BY creation_date, id`)).
+		WithArgs("default", 3, 3, 5, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "bike", false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "text", "author_id", "published",
+			"hidden", "lang", "lang_declared", "creation_date", "update_date"}))
+	_, err = repo.GetAllByTemplate(context.Background(), adpattern.AdPattern{AuthorID: 3, Expr: expr})
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestAdExpr_Codec(t *testing.T) {
+	expr, _ := adexpr.Parse(`author in (3, 5) and title ~ "bike"`)
+	adp := adpattern.AdPattern{PublishedOnly: true, Expr: expr}
+	data, err := adpattern.Marshal(adp)
+	assert.NoError(t, err)
+	decoded, err := adpattern.Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, adp, decoded)
+
+	_, err = adpattern.Unmarshal([]byte(`{"expr":"author ="}`))
+	assert.ErrorIs(t, err, adexpr.ErrBadExpr)
+}
+
+func TestAdExpr_HTTP(t *testing.T) {
+	client := getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New()))
+	_, _ = client.createUser(3, "tom", "tom@mail.ru")
+	_, _ = client.createUser(4, "cat", "cat@mail.ru")
+	bike, _ := client.createAd(3, "red bike", "text")
+	_, _ = client.changeAdStatus(3, bike.Data.ID, true)
+	_, _ = client.createAd(3, "car", "text")
+	_, _ = client.createAd(4, "blue bike", "text")
+
+	list, err := client.listAdsFiltered(`author in (3, 5) and (title ~ "bike" or published = false)`)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 2)
+	list, _ = client.listAdsFiltered(`title ~ "bike"`)
+	assert.Len(t, list.Data, 2)
+	// the expression doesn't outlive the request
+	list, _ = client.listAdsSorted("")
+	assert.Len(t, list.Data, 3)
+
+	_, err = client.listAdsFiltered(`price < 3`)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestAdExpr_GRPC(t *testing.T) {
+	client, ctx := getGRPCClient(t, app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithSavedSearches(searches.New(), nil)))
+	u, _ := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "Tom", Email: "tom@mail.ru", UserId: 3})
+	_, _ = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "red bike", Text: "text", UserId: u.UserId})
+	_, _ = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "car", Text: "text", UserId: u.UserId})
+
+	list, err := client.ListAds(ctx, &grpcPort.FilterRequest{PublishedConfig: grpcPort.PublishedConfig_AllAds,
+		FilterExpr: `title ~ "bike"`})
+	assert.NoError(t, err)
+	assert.Len(t, list.List, 1)
+	_, err = client.ListAds(ctx, &grpcPort.FilterRequest{FilterExpr: `title ~`})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	s, err := client.SaveSearch(ctx, &grpcPort.SaveSearchRequest{UserId: u.UserId, Name: "bikes",
+		Filter: &grpcPort.FilterRequest{FilterExpr: `title ~ "bike"`}})
+	assert.NoError(t, err)
+	assert.Equal(t, `title ~ "bike"`, s.Filter.FilterExpr)
+}
+
+func TestAdExpr_Adctl(t *testing.T) {
+	env := newAdctlEnv(t)
+	_, _ = env.run("users", "create", "--id", "1", "--nickname", "Tom", "--email", "tom@mail.ru")
+	_, _ = env.run("ads", "create", "--user", "1", "--title", "red bike", "--text", "text")
+	_, _ = env.run("ads", "create", "--user", "1", "--title", "car", "--text", "text")
+
+	out, err := env.run("ads", "list", "--all", "--filter", `title ~ "bike"`)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "red bike")
+	assert.NotContains(t, out, "car")
+	_, err = env.run("ads", "list", "--filter", `title ~`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/auditlog"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/idemkeys"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/audit"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/tenant"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const auditAdminKey = "secret"
+
+func newAuditedApp(opts ...app.Option) app.App {
+	return app.NewApp(adrepo.New(), customer.New(), adfilter.New(), append([]app.Option{
+		app.WithAuditLog(auditlog.New()), app.WithAdminKey(auditAdminKey)}, opts...)...)
+}
+
+func TestAudit_AdLifecycle(t *testing.T) {
+	client := getTestClient(newAuditedApp())
+	_, err := client.createUserAsAdmin(1, "tom", "tom@mail.ru", auditAdminKey)
+	assert.NoError(t, err)
+
+	ad, err := client.withRequestID("req-1").createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = client.updateAd(1, ad.Data.ID, "new title", "new text")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+	_, err = client.deleteAd(1, ad.Data.ID)
+	assert.NoError(t, err)
+	// failed changes aren't recorded
+	_, err = client.updateAd(2, ad.Data.ID, "foo", "bar")
+	assert.Error(t, err)
+
+	resp, err := client.listAudit(fmt.Sprint("ad:", ad.Data.ID), auditAdminKey)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 4)
+	var actions []string
+	for _, e := range resp.Data {
+		actions = append(actions, e.Action)
+		assert.Equal(t, "user:1", e.Actor)
+		assert.Equal(t, "http", e.Transport)
+		assert.NotEmpty(t, e.RequestID)
+	}
+	assert.Equal(t, []string{"create_ad", "update_ad", "change_ad_status", "delete_ad"}, actions)
+	assert.Equal(t, "req-1", resp.Data[0].RequestID)
+	assert.Empty(t, resp.Data[0].Before)
+
+	var before, after ads.Ad
+	assert.NoError(t, json.Unmarshal(resp.Data[1].Before, &before))
+	assert.NoError(t, json.Unmarshal(resp.Data[1].After, &after))
+	assert.Equal(t, "aba", before.Title)
+	assert.Equal(t, "new title", after.Title)
+	assert.Empty(t, resp.Data[3].After)
+
+	users, err := client.listAudit("user:1", auditAdminKey)
+	assert.NoError(t, err)
+	assert.Len(t, users.Data, 1)
+	assert.Equal(t, "admin", users.Data[0].Actor)
+	assert.Equal(t, "create_user", users.Data[0].Action)
+
+	all, err := client.listAudit("", auditAdminKey)
+	assert.NoError(t, err)
+	assert.Len(t, all.Data, 5)
+}
+
+func TestAudit_UserActors(t *testing.T) {
+	adminCtx := app.ContextWithAdminKey(context.Background(), auditAdminKey)
+	tests := []struct {
+		name  string
+		opts  []app.Option
+		ctx   context.Context
+		actor string
+	}{
+		{name: "admin", opts: []app.Option{app.WithAdminKey(auditAdminKey)}, ctx: adminCtx, actor: audit.Admin},
+		{name: "no admins", ctx: context.Background(), actor: "user:1"},
+		{name: "admin key without admins", ctx: adminCtx, actor: "user:1"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := auditlog.New()
+			a := app.NewApp(adrepo.New(), customer.New(), adfilter.New(), append(tc.opts, app.WithAuditLog(l))...)
+			_, err := a.CreateUserByID(tc.ctx, "tom", "tom@mail.ru", 1)
+			assert.NoError(t, err)
+			_, err = a.DeleteUserByID(tc.ctx, 1)
+			assert.NoError(t, err)
+
+			entries, err := l.List(tc.ctx, audit.Target{Kind: audit.KindUser, ID: 1})
+			assert.NoError(t, err)
+			assert.Len(t, entries, 2)
+			for _, e := range entries {
+				assert.Equal(t, tc.actor, e.Actor, e.Action)
+			}
+		})
+	}
+}
+
+func TestAudit_Access(t *testing.T) {
+	client := getTestClient(newAuditedApp())
+
+	_, err := client.listAudit("ad:1", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.listAudit("ad:1", "wrong")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	for _, target := range []string{"ad", "ad:", ":1", "ad:x"} {
+		_, err = client.listAudit(target, auditAdminKey)
+		assert.ErrorIs(t, err, ErrBadRequest, target)
+	}
+
+	_, err = getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New())).listAudit("ad:1", "")
+	assert.ErrorIs(t, err, InternalServerErr)
+}
+
+func TestAudit_IdempotentRetryIsRecordedOnce(t *testing.T) {
+	client := getTestClient(newAuditedApp(app.WithIdempotencyKeys(idemkeys.New(), time.Hour)))
+	_, _ = client.createUserAsAdmin(1, "tom", "tom@mail.ru", auditAdminKey)
+
+	retry := client.withIdempotencyKey("first")
+	ad, err := retry.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = retry.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+
+	resp, err := client.listAudit(fmt.Sprint("ad:", ad.Data.ID), auditAdminKey)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+}
+
+func TestAudit_GRPC(t *testing.T) {
+	a := newAuditedApp()
+	client, ctx := getGRPCClient(t, a)
+	adminCtx := app.ContextWithAdminKey(context.Background(), auditAdminKey)
+	_, err := a.CreateUserByID(adminCtx, "tom", "tom@mail.ru", 1)
+	assert.NoError(t, err)
+
+	var header metadata.MD
+	ad, err := client.CreateAd(metadata.AppendToOutgoingContext(ctx, "x-request-id", "grpc-1"),
+		&grpcPort.CreateAdRequest{Title: "aba", Text: "caba", UserId: 1}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"grpc-1"}, header.Get("x-request-id"))
+
+	entries, err := a.ListAudit(adminCtx, audit.Target{Kind: audit.KindAd, ID: ad.Id})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, audit.GRPC, entries[0].Transport)
+	assert.Equal(t, "grpc-1", entries[0].RequestID)
+
+	// calls made by the service itself have no transport
+	entries, err = a.ListAudit(adminCtx, audit.Target{Kind: audit.KindUser, ID: 1})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Empty(t, entries[0].Transport)
+}
+
+func TestAudit_Tenants(t *testing.T) {
+	ctx := context.Background()
+	l := auditlog.New()
+	for _, id := range []tenant.ID{"acme", "initech", "acme"} {
+		_, err := l.Append(ctx, audit.Entry{Tenant: id, Target: audit.Target{Kind: audit.KindAd, ID: 1}})
+		assert.NoError(t, err)
+	}
+
+	entries, err := l.List(tenant.NewContext(ctx, "acme"), audit.Target{Kind: audit.KindAd, ID: 1})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, []int64{1, 3}, []int64{entries[0].ID, entries[1].ID})
+	entries, err = l.List(tenant.NewContext(ctx, "acme"), audit.Target{Kind: audit.KindAd, ID: 2})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestAudit_FileLog(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	l, err := auditlog.NewFile(path)
+	assert.NoError(t, err)
+	first, err := l.Append(ctx, audit.Entry{Tenant: tenant.Default, Time: now, Actor: "user:1", Action: "create_ad",
+		Target: audit.Target{Kind: audit.KindAd, ID: 7}, After: json.RawMessage(`{"Title":"aba"}`),
+		RequestID: "req", Transport: audit.HTTP})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), first.ID)
+
+	// a torn line is cut off on recovery
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"id":2,"act`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	l, err = auditlog.NewFile(path)
+	assert.NoError(t, err)
+	second, err := l.Append(ctx, audit.Entry{Tenant: tenant.Default, Time: now, Actor: "user:1", Action: "delete_ad",
+		Target: audit.Target{Kind: audit.KindAd, ID: 7}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), second.ID)
+
+	entries, err := l.List(ctx, audit.Target{Kind: audit.KindAd, ID: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Entry{first, second}, entries)
+}
+
+func TestAudit_SQLLog(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	l := sqlstore.NewAuditLog(db)
+	now := time.Now().UTC()
+
+	e := audit.Entry{Tenant: "acme", Time: now, Actor: "user:1", Action: "update_ad",
+		Target: audit.Target{Kind: audit.KindAd, ID: 7}, Before: json.RawMessage(`{"Title":"aba"}`),
+		After: json.RawMessage(`{"Title":"caba"}`), RequestID: "req", Transport: audit.GRPC}
+	sqlMock.ExpectQuery("INSERT INTO audit_log").
+		WithArgs("acme", now, "user:1", "update_ad", "ad", 7, `{"Title":"aba"}`, `{"Title":"caba"}`, "req", "grpc").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	res, err := l.Append(ctx, e)
+	assert.NoError(t, err)
+	e.ID = 3
+	assert.Equal(t, e, res)
+
+	sqlMock.ExpectQuery("SELECT (.+) FROM audit_log WHERE tenant_id (.+) AND target_kind (.+) ORDER BY id").
+		WithArgs("acme", "ad", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "created_at", "actor", "action", "target_kind",
+			"target_id", "before_value", "after_value", "request_id", "transport"}).
+			AddRow(3, "acme", now, "user:1", "update_ad", "ad", 7, []byte(`{"Title":"aba"}`),
+				[]byte(`{"Title":"caba"}`), "req", "grpc").
+			AddRow(4, "acme", now, "user:1", "delete_ad", "ad", 7, nil, nil, "", ""))
+	entries, err := l.List(ctx, audit.Target{Kind: audit.KindAd, ID: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Entry{e, {ID: 4, Tenant: "acme", Time: now, Actor: "user:1", Action: "delete_ad",
+		Target: audit.Target{Kind: audit.KindAd, ID: 7}}}, entries)
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -5,7 +5,13 @@ import (
 	"fmt"
 	"homework10/internal/adapters/adrepo"
 	"homework10/internal/adapters/customer"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -30,3 +36,146 @@ func BenchmarkBasicCustomer(b *testing.B) {
 			"example"+strconv.Itoa(i)+"@mail.ru", int64(i))
 	}
 }
+
+var (
+	bigRepoOnce sync.Once
+	bigRepo     app.Repository
+	bigRepoAds  []ads.Ad
+)
+
+// getBigRepo fills the repository with Ads ads of Users authors once for all benchmarks,
+// every second ad is published.
+func getBigRepo() (app.Repository, []ads.Ad) {
+	bigRepoOnce.Do(func() {
+		ctx := context.Background()
+		bigRepo = adrepo.New()
+		for i := 0; i < Ads; i++ {
+			adID, _ := bigRepo.Add(ctx, fmt.Sprint("ad", i), "test ad", int64(i%Users+1))
+			if i%2 == 0 {
+				_ = bigRepo.SetStatus(ctx, adID, true)
+			}
+		}
+		bigRepoAds, _ = bigRepo.GetAllByTemplate(ctx, adpattern.AdPattern{})
+	})
+	return bigRepo, bigRepoAds
+}
+
+// fullScan is how MapRepo answered queries before it got indexes.
+func fullScan(all []ads.Ad, check func(ad ads.Ad) bool) []ads.Ad {
+	res := []ads.Ad{}
+	for _, ad := range all {
+		if check(ad) {
+			res = append(res, ad)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].CreationDate.Before(res[j].CreationDate)
+	})
+	return res
+}
+
+func benchmarkPatterns(all []ads.Ad) map[string]adpattern.AdPattern {
+	middle := all[len(all)/2].CreationDate
+	return map[string]adpattern.AdPattern{
+		"Author":          {AuthorID: 42},
+		"AuthorPublished": {AuthorID: 43, PublishedOnly: true},
+		"TimeRange": {IsLTimeSet: true, IsRTimeSet: true, LDate: middle,
+			RDate: all[len(all)/2+100].CreationDate},
+	}
+}
+
+func BenchmarkGetAllByTemplate_Indexed(b *testing.B) {
+	ctx := context.Background()
+	repo, all := getBigRepo()
+	for name, adp := range benchmarkPatterns(all) {
+		adp := adp
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = repo.GetAllByTemplate(ctx, adp)
+			}
+		})
+	}
+}
+
+func BenchmarkGetAllByTemplate_FullScan(b *testing.B) {
+	_, all := getBigRepo()
+	for name, adp := range benchmarkPatterns(all) {
+		adp := adp
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = fullScan(all, func(ad ads.Ad) bool {
+					return app.CheckAd(ad, adp)
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkGetByTitle_Indexed(b *testing.B) {
+	ctx := context.Background()
+	repo, _ := getBigRepo()
+	for i := 0; i < b.N; i++ {
+		_, _ = repo.GetByTitle(ctx, "ad12345")
+	}
+}
+
+func BenchmarkGetByTitle_FullScan(b *testing.B) {
+	_, all := getBigRepo()
+	for i := 0; i < b.N; i++ {
+		_ = fullScan(all, func(ad ads.Ad) bool {
+			return strings.HasPrefix(ad.Title, "ad12345")
+		})
+	}
+}
+
+func BenchmarkDeleteByAuthor_Indexed(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		repo := adrepo.New()
+		for j := 0; j < Ads/100; j++ {
+			_, _ = repo.Add(ctx, "ad", "test ad", int64(j%Users+1))
+		}
+		b.StartTimer()
+		_ = repo.DeleteByAuthor(ctx, 42)
+	}
+}
+
+// BenchmarkDeleteByAuthor_FullScan deletes the way MapRepo did before it got indexes.
+func BenchmarkDeleteByAuthor_FullScan(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		mp := map[int64]ads.Ad{}
+		for j := 0; j < Ads/100; j++ {
+			mp[int64(j)] = ads.Ad{ID: int64(j), Title: "ad", Text: "test ad", AuthorID: int64(j%Users + 1)}
+		}
+		b.StartTimer()
+		keysToDelete := []int64{}
+		for key, val := range mp {
+			if val.AuthorID == 42 {
+				keysToDelete = append(keysToDelete, key)
+			}
+		}
+		for _, key := range keysToDelete {
+			delete(mp, key)
+		}
+	}
+}
+
+func benchmarkParallelWrites(b *testing.B, repo app.Repository) {
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			adID, _ := repo.Add(ctx, "ad", "test ad", 1)
+			_ = repo.SetStatus(ctx, adID, true)
+		}
+	})
+}
+
+func BenchmarkParallelWrites_MapRepo(b *testing.B) {
+	benchmarkParallelWrites(b, adrepo.New())
+}
+
+func BenchmarkParallelWrites_ShardedRepo(b *testing.B) {
+	benchmarkParallelWrites(b, adrepo.NewSharded(16))
+}
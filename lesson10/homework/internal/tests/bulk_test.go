@@ -0,0 +1,339 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/extids"
+	"homework10/internal/adapters/wal"
+	"homework10/internal/adpattern"
+	"homework10/internal/app"
+	"homework10/internal/bulk"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, r bulk.Reader) ([]bulk.Record, []bulk.LineError) {
+	var recs []bulk.Record
+	var errs []bulk.LineError
+	for {
+		rec, line, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return recs, errs
+		}
+		if err != nil {
+			if !assert.ErrorIs(t, err, bulk.ErrBadLine) {
+				return recs, errs
+			}
+			errs = append(errs, bulk.LineError{Line: line, Error: err.Error()})
+			continue
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func TestBulk_RoundTrip(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	recs := []bulk.Record{
+		{ExternalID: "a-1", ID: 1, Title: "aba", Text: "caba, \"quoted\"", AuthorID: 1, Published: true,
+			CreationDate: now, UpdateDate: now},
+		{ExternalID: "a-2", ID: 2, Title: "foo", Text: "multi\nline", AuthorID: 2, CreationDate: now, UpdateDate: now},
+	}
+	for _, format := range []string{bulk.FormatCSV, bulk.FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := bulk.NewWriter(&buf, format)
+			assert.NoError(t, err)
+			for _, rec := range recs {
+				assert.NoError(t, w.Write(rec))
+			}
+			assert.NoError(t, w.Flush())
+
+			r, err := bulk.NewReader(&buf, format)
+			assert.NoError(t, err)
+			got, errs := readAll(t, r)
+			assert.Empty(t, errs)
+			assert.Len(t, got, 2)
+			for i := range got {
+				assert.Equal(t, recs[i].ExternalID, got[i].ExternalID)
+				assert.Equal(t, recs[i].Title, got[i].Title)
+				assert.Equal(t, recs[i].Text, got[i].Text)
+				assert.Equal(t, recs[i].AuthorID, got[i].AuthorID)
+				assert.Equal(t, recs[i].Published, got[i].Published)
+			}
+		})
+	}
+}
+
+func TestBulk_LineErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		body   string
+		lines  []int
+		valid  int
+	}{
+		{"csv", bulk.FormatCSV,
+			"external_id,title,text,author_id\na,aba,caba,1\nb,foo,bar,x\nc,\"foo\nbar\",baz,2\nd,e,f,1,yes\n", []int{3}, 3},
+		{"csv published", bulk.FormatCSV,
+			"external_id,title,text,author_id,published\na,aba,caba,1,maybe\nb,foo,bar,1,true\n", []int{2}, 1},
+		{"ndjson", bulk.FormatNDJSON,
+			"{\"external_id\":\"a\",\"title\":\"aba\",\"text\":\"caba\",\"author_id\":1}\n\n{oops\n" +
+				"{\"external_id\":\"b\",\"title\":\"foo\",\"text\":\"bar\",\"author_id\":\"1\"}\n", []int{3, 4}, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := bulk.NewReader(strings.NewReader(tc.body), tc.format)
+			assert.NoError(t, err)
+			recs, errs := readAll(t, r)
+			assert.Len(t, recs, tc.valid)
+			lines := []int{}
+			for _, e := range errs {
+				lines = append(lines, e.Line)
+			}
+			assert.Equal(t, tc.lines, lines)
+		})
+	}
+}
+
+func TestBulk_BadHeaderAndFormat(t *testing.T) {
+	_, err := bulk.NewReader(strings.NewReader("external_id,title,text\n"), bulk.FormatCSV)
+	assert.Error(t, err)
+	_, err = bulk.NewReader(strings.NewReader(""), "xml")
+	assert.ErrorIs(t, err, bulk.ErrFormat)
+	_, err = bulk.NewWriter(io.Discard, "xml")
+	assert.ErrorIs(t, err, bulk.ErrFormat)
+}
+
+func TestExternalIDs(t *testing.T) {
+	ctx := context.Background()
+	ids := extids.New()
+	assert.NoError(t, ids.Set(ctx, "a", 1))
+	assert.NoError(t, ids.Set(ctx, "b", 2))
+	adID, isFound := ids.Find(ctx, "a")
+	assert.True(t, isFound)
+	assert.Equal(t, int64(1), adID)
+	externalID, isFound := ids.FindByAd(ctx, 2)
+	assert.True(t, isFound)
+	assert.Equal(t, "b", externalID)
+
+	assert.NoError(t, ids.DeleteByAd(ctx, 1))
+	_, isFound = ids.Find(ctx, "a")
+	assert.False(t, isFound)
+	_, isFound = ids.FindByAd(ctx, 1)
+	assert.False(t, isFound)
+}
+
+const bulkAdminKey = "bulk admin key"
+
+func newBulkApp(opts ...app.Option) app.App {
+	opts = append([]app.Option{app.WithAdminKey(bulkAdminKey)}, append(opts, app.WithExternalIDs(extids.New()))...)
+	return app.NewApp(adrepo.New(), customer.New(), adfilter.New(), opts...)
+}
+
+func TestImportAds(t *testing.T) {
+	client := getTestClient(newBulkApp())
+	_, _ = client.createUserAsAdmin(1, "author", "author@mail.ru", bulkAdminKey)
+	_, _ = client.createUserAsAdmin(2, "other", "other@mail.ru", bulkAdminKey)
+	body := "external_id,title,text,author_id,published\n" +
+		"x-1,aba,caba,1,true\n" +
+		"x-2,foo,bar,2,false\n" +
+		"x-3,,empty title,1,false\n" +
+		"x-4,alpha,beta,3,false\n" +
+		",no,id,1,false\n"
+
+	report, err := client.importAds(bulk.FormatCSV, body, true, bulkAdminKey)
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 2, report.Created)
+	assert.Len(t, report.Errors, 3)
+	assert.Equal(t, []int{4, 5, 6}, []int{report.Errors[0].Line, report.Errors[1].Line, report.Errors[2].Line})
+	list, _ := client.listAdsSorted("")
+	assert.Empty(t, list.Data)
+
+	report, err = client.importAds(bulk.FormatCSV, body, false, bulkAdminKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Len(t, report.Errors, 3)
+	list, _ = client.listAdsSorted("")
+	assert.Len(t, list.Data, 2)
+
+	report, err = client.importAds(bulk.FormatCSV, body, false, bulkAdminKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, 2, report.Unchanged)
+	list, _ = client.listAdsSorted("")
+	assert.Len(t, list.Data, 2)
+
+	report, err = client.importAds(bulk.FormatNDJSON,
+		`{"external_id":"x-1","title":"abacaba","text":"caba","author_id":1,"published":false}`+"\n"+
+			`{"external_id":"x-2","title":"foo","text":"bar","author_id":1}`+"\n", false, bulkAdminKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Updated)
+	assert.Len(t, report.Errors, 1)
+	assert.Equal(t, 2, report.Errors[0].Line)
+	byTitle, _ := client.getAdsByTitle("abacaba")
+	assert.Len(t, byTitle.Data, 1)
+	assert.False(t, byTitle.Data[0].Published)
+
+	_, err = client.importAds("xml", body, false, bulkAdminKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.importAds(bulk.FormatCSV, "title,text\n", false, bulkAdminKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestImportAds_AdminKey(t *testing.T) {
+	client := getTestClient(newBulkApp(app.WithAdminKey("secret")))
+	body := "external_id,title,text,author_id\nx-1,aba,caba,1\n"
+
+	_, err := client.importAds(bulk.FormatCSV, body, false, "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.importAds(bulk.FormatCSV, body, false, "wrong")
+	assert.ErrorIs(t, err, ErrForbidden)
+	report, err := client.importAds(bulk.FormatCSV, body, true, "secret")
+	assert.NoError(t, err)
+	assert.Len(t, report.Errors, 1)
+}
+
+func TestImportAds_Disabled(t *testing.T) {
+	client := getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New()))
+	_, err := client.importAds(bulk.FormatCSV, "external_id,title,text,author_id\nx-1,aba,caba,1\n", false, "")
+	assert.ErrorIs(t, err, InternalServerErr)
+}
+
+func TestExportAds(t *testing.T) {
+	client := getTestClient(newBulkApp())
+	_, _ = client.createUserAsAdmin(1, "author", "author@mail.ru", bulkAdminKey)
+	_, _ = client.createUserAsAdmin(2, "other", "other@mail.ru", bulkAdminKey)
+	_, _ = client.importAds(bulk.FormatNDJSON, `{"external_id":"ext","title":"imported","text":"ad","author_id":2}`,
+		false, bulkAdminKey)
+	first, _ := client.createAd(1, "aba", "caba")
+	_, _ = client.createAd(1, "foo", "bar")
+	_, _ = client.changeAdStatus(1, first.Data.ID, true)
+
+	body, contentType, err := client.exportAds(url.Values{"author_id": {"1"}, "published_only": {"false"}, "sort": {"title"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv", contentType)
+	r, _ := bulk.NewReader(strings.NewReader(body), bulk.FormatCSV)
+	recs, errs := readAll(t, r)
+	assert.Empty(t, errs)
+	assert.Len(t, recs, 2)
+	assert.Equal(t, "aba", recs[0].Title)
+	assert.True(t, recs[0].Published)
+	assert.Equal(t, "foo", recs[1].Title)
+
+	body, contentType, err = client.exportAds(url.Values{"format": {bulk.FormatNDJSON}, "author_id": {"2"}, "published_only": {"false"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "application/x-ndjson", contentType)
+	var rec bulk.Record
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(body)), &rec))
+	assert.Equal(t, "ext", rec.ExternalID)
+	assert.Equal(t, "imported", rec.Title)
+
+	body, _, err = client.exportAds(url.Values{"author_id": {"3"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "external_id,id,title,text,author_id,published,creation_date,update_date\n", body)
+
+	_, _, err = client.exportAds(url.Values{"format": {"xml"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, _, err = client.exportAds(url.Values{"sort": {"title,title"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestExportAds_ReimportIsIdempotent(t *testing.T) {
+	ctx := app.ContextWithAdminKey(context.Background(), bulkAdminKey)
+	a := newBulkApp()
+	_, _ = a.CreateUserByID(ctx, "author", "author@mail.ru", 1)
+	for i := 0; i < 3; i++ {
+		_, _ = a.CreateAd(ctx, "aba", "caba", 1)
+	}
+
+	var recs []bulk.Record
+	assert.NoError(t, a.ExportAds(ctx, adpattern.AdPattern{}, func(rec bulk.Record) error {
+		recs = append(recs, rec)
+		return nil
+	}))
+	assert.Len(t, recs, 3)
+	for i := 0; i < 2; i++ {
+		for _, rec := range recs {
+			_, outcome, err := a.ImportAd(ctx, rec, false)
+			assert.NoError(t, err)
+			if i == 0 {
+				assert.Equal(t, bulk.Created, outcome)
+			} else {
+				assert.Equal(t, bulk.Unchanged, outcome)
+			}
+		}
+	}
+	all, _ := a.GetAllAdsByTemplate(ctx, adpattern.AdPattern{})
+	assert.Len(t, all, 6)
+	_, outcome, err := a.ImportAd(ctx, bulk.Record{ExternalID: recs[0].ExternalID, Title: "new", Text: "caba",
+		AuthorID: 1}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.Updated, outcome)
+	list, _ := a.GetAdsByTitle(ctx, "new")
+	assert.Empty(t, list)
+}
+
+// openPersistentImports returns an app keeping ads, users and external IDs in write-ahead logs in dir.
+func openPersistentImports(t *testing.T, dir string) (app.App, *extids.MapExternalIDs, func()) {
+	var logs []*wal.Log
+	open := func(name string) *wal.Log {
+		l, err := wal.Open(filepath.Join(dir, name), wal.Options{Sync: wal.SyncAlways})
+		assert.NoError(t, err)
+		logs = append(logs, l)
+		return l
+	}
+	repo, err := adrepo.NewPersistent(open("ads"))
+	assert.NoError(t, err)
+	users, err := customer.NewPersistent(open("users"))
+	assert.NoError(t, err)
+	ids, err := extids.NewPersistent(open("external_ids"))
+	assert.NoError(t, err)
+	a := app.NewApp(repo, users, adfilter.New(), app.WithAdminKey(bulkAdminKey), app.WithExternalIDs(ids))
+	return a, ids, func() {
+		for _, l := range logs {
+			assert.NoError(t, l.Close())
+		}
+	}
+}
+
+func TestImportAds_AfterRestart(t *testing.T) {
+	ctx := app.ContextWithAdminKey(context.Background(), bulkAdminKey)
+	dir := t.TempDir()
+	recs := []bulk.Record{
+		{ExternalID: "a-1", Title: "aba", Text: "caba", AuthorID: 1},
+		{ExternalID: "a-2", Title: "foo", Text: "bar", AuthorID: 1},
+	}
+
+	a, ids, closeLogs := openPersistentImports(t, dir)
+	_, _ = a.CreateUserByID(ctx, "author", "author@mail.ru", 1)
+	first, outcome, err := a.ImportAd(ctx, recs[0], false)
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.Created, outcome)
+	assert.NoError(t, ids.Snapshot())
+	second, outcome, err := a.ImportAd(ctx, recs[1], false)
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.Created, outcome)
+	closeLogs()
+
+	a, _, closeLogs = openPersistentImports(t, dir)
+	defer closeLogs()
+	for i, want := range []int64{first.ID, second.ID} {
+		ad, outcome, err := a.ImportAd(ctx, recs[i], false)
+		assert.NoError(t, err)
+		assert.Equal(t, bulk.Unchanged, outcome)
+		assert.Equal(t, want, ad.ID)
+	}
+	all, _ := a.GetAllAdsByTemplate(ctx, adpattern.AdPattern{})
+	assert.Len(t, all, 2)
+}
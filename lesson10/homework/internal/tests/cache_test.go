@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adcache"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adpattern"
+	"homework10/internal/app"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCachedRepo_Hits(t *testing.T) {
+	ctx := context.Background()
+	repo := adcache.New(adrepo.New(), 10, time.Minute)
+	adID, err := repo.Add(ctx, "aba", "caba", 1)
+	assert.NoError(t, err)
+
+	_, isFound := repo.Find(ctx, adID)
+	assert.True(t, isFound)
+	ad, isFound := repo.Find(ctx, adID)
+	assert.True(t, isFound)
+	assert.Equal(t, "aba", ad.Title)
+
+	_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: 1})
+	_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: 1})
+	_, _ = repo.GetByTitle(ctx, "ab")
+	_, _ = repo.GetByTitle(ctx, "ab")
+
+	stats := repo.Stats()
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(3), stats.Misses)
+}
+
+func TestCachedRepo_Invalidation(t *testing.T) {
+	type invalidationTest struct {
+		name   string
+		mutate func(ctx context.Context, repo app.Repository, adID int64) error
+		title  string
+		status bool
+		found  bool
+	}
+
+	invalidationTests := [...]invalidationTest{
+		{"SetTitle", func(ctx context.Context, repo app.Repository, adID int64) error {
+			return repo.SetTitle(ctx, adID, "abacaba")
+		}, "abacaba", false, true},
+		{"SetText", func(ctx context.Context, repo app.Repository, adID int64) error {
+			return repo.SetText(ctx, adID, "new text")
+		}, "aba", false, true},
+		{"SetStatus", func(ctx context.Context, repo app.Repository, adID int64) error {
+			return repo.SetStatus(ctx, adID, true)
+		}, "aba", true, true},
+		{"Delete", func(ctx context.Context, repo app.Repository, adID int64) error {
+			return repo.Delete(ctx, adID)
+		}, "", false, false},
+		{"DeleteByAuthor", func(ctx context.Context, repo app.Repository, adID int64) error {
+			return repo.DeleteByAuthor(ctx, 1)
+		}, "", false, false},
+	}
+
+	for _, test := range invalidationTests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := adcache.New(adrepo.New(), 10, time.Minute)
+			adID, _ := repo.Add(ctx, "aba", "caba", 1)
+			otherID, _ := repo.Add(ctx, "other", "caba", 2)
+
+			_, _ = repo.Find(ctx, adID)
+			_, _ = repo.Find(ctx, otherID)
+			_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{PublishedOnly: true})
+			_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: 1})
+			_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: 2})
+			_, _ = repo.GetByTitle(ctx, "ab")
+
+			assert.NoError(t, test.mutate(ctx, repo, adID))
+
+			ad, isFound := repo.Find(ctx, adID)
+			assert.Equal(t, test.found, isFound)
+			if test.found {
+				assert.Equal(t, test.title, ad.Title)
+				assert.Equal(t, test.status, ad.Published)
+			}
+
+			published, _ := repo.GetAllByTemplate(ctx, adpattern.AdPattern{PublishedOnly: true})
+			assert.Equal(t, test.status, len(published) == 1)
+
+			byAuthor, _ := repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: 1})
+			assert.Equal(t, test.found, len(byAuthor) == 1)
+
+			byTitle, _ := repo.GetByTitle(ctx, "ab")
+			assert.Equal(t, test.found, len(byTitle) == 1)
+			if test.found {
+				assert.Equal(t, test.title, byTitle[0].Title)
+			}
+
+			before := repo.Stats().Hits
+			_, _ = repo.Find(ctx, otherID)
+			_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: 2})
+			assert.Equal(t, before+2, repo.Stats().Hits)
+		})
+	}
+}
+
+func TestCachedRepo_TTL(t *testing.T) {
+	ctx := context.Background()
+	repo := adcache.New(adrepo.New(), 10, 10*time.Millisecond)
+	adID, _ := repo.Add(ctx, "aba", "caba", 1)
+
+	_, _ = repo.Find(ctx, adID)
+	time.Sleep(20 * time.Millisecond)
+	_, isFound := repo.Find(ctx, adID)
+	assert.True(t, isFound)
+
+	stats := repo.Stats()
+	assert.Equal(t, int64(0), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+}
+
+func TestCachedRepo_SizeBound(t *testing.T) {
+	ctx := context.Background()
+	repo := adcache.New(adrepo.New(), 2, time.Minute)
+	for i := 0; i < 3; i++ {
+		adID, _ := repo.Add(ctx, fmt.Sprint("ad", i), "text", 1)
+		_, _ = repo.Find(ctx, adID)
+	}
+	assert.Equal(t, int64(1), repo.Stats().Evictions)
+
+	_, _ = repo.Find(ctx, 0)
+	assert.Equal(t, int64(0), repo.Stats().Hits)
+	_, _ = repo.Find(ctx, 0)
+	assert.Equal(t, int64(1), repo.Stats().Hits)
+}
+
+func TestGetAdByID_ETag(t *testing.T) {
+	client := getTestClient(app.NewApp(adcache.New(adrepo.New(), 10, time.Minute),
+		customer.New(), adfilter.New()))
+
+	_, _ = client.createUser(3, "nickname", "example@mail.com")
+	ad, err := client.createAd(3, "aba", "caba")
+	assert.NoError(t, err)
+
+	resp, err := client.getAdWithETag(ad.Data.ID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	resp, err = client.getAdWithETag(ad.Data.ID, etag)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	_, err = client.changeAdStatus(3, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	resp, err = client.getAdWithETag(ad.Data.ID, etag)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/contentcheck"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/reports"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/policy"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContentCheck_Rules(t *testing.T) {
+	banned := contentcheck.NewBannedWords([]string{"casino", "Easy Money", "ass", "КАЗИНО"})
+	tests := []struct {
+		name     string
+		rule     contentcheck.Rule
+		text     string
+		violated bool
+	}{
+		{"banned word", banned, "the best Casino in town", true},
+		{"full width", banned, "ＣＡＳＩＮＯ", true},
+		{"cyrillic", banned, "Лучшее казино", true},
+		{"phrase", banned, "make EASY   money!", true},
+		{"phrase in other order", banned, "money is not easy", false},
+		{"longer word", banned, "casinos and classic cars", false},
+		{"clean", banned, "selling a bike", false},
+		{"url", contentcheck.NewLinks(), "see https://example.org/bike", true},
+		{"www", contentcheck.NewLinks(), "see www.bikes", true},
+		{"domain", contentcheck.NewLinks(), "write to bikes.example.com", true},
+		{"cyrillic domain", contentcheck.NewLinks(), "заходите на пример.рф!", true},
+		{"no link", contentcheck.NewLinks(), "e.g. 1.5 years old, see photos.", false},
+		{"phone", contentcheck.NewPhones(), "call +7 (999) 123-45-67", true},
+		{"dashed phone", contentcheck.NewPhones(), "8-999-123-45-67 after 6pm", true},
+		{"full width phone", contentcheck.NewPhones(), "８９９９１２３４５６７", true},
+		{"price", contentcheck.NewPhones(), "only 1 000 000 rub", false},
+		{"years", contentcheck.NewPhones(), "made in 2015-2020", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, err := tc.rule.Check(context.Background(), policy.Content{Title: "ad", Text: tc.text})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.violated, reason != "", reason)
+		})
+	}
+}
+
+func TestContentCheck_Duplicates(t *testing.T) {
+	text := "Selling my old mountain bike in good condition, new tires and brakes, pick up only"
+	siblings := []ads.Ad{{ID: 1, Title: "Guitar", Text: "Acoustic guitar with a case"},
+		{ID: 2, Title: "Bike", Text: text}}
+	loads := 0
+	c := policy.Content{Title: "BIKE", Text: text + "!", Siblings: func() ([]ads.Ad, error) {
+		loads++
+		return siblings, nil
+	}}
+
+	p := contentcheck.New(contentcheck.Step{Rule: contentcheck.NewDuplicates(0), Severity: policy.Reject},
+		contentcheck.Step{Rule: contentcheck.NewDuplicates(1), Severity: policy.Flag})
+	verdict, err := p.Check(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Equal(t, []policy.Violation{
+		{Rule: "duplicates", Severity: policy.Reject, Reason: "duplicates ad 2"},
+		{Rule: "duplicates", Severity: policy.Flag, Reason: "duplicates ad 2"},
+	}, verdict.Violations)
+	assert.True(t, verdict.Rejected())
+	assert.True(t, verdict.Flagged())
+	// the siblings are shared by rules
+	assert.Equal(t, 1, loads)
+
+	c.Text = "Selling a road bike, carbon frame, barely used, comes with pedals and a spare wheel"
+	verdict, err = p.Check(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Empty(t, verdict.Violations)
+
+	siblingsErr := fmt.Errorf("no siblings")
+	c.Siblings = func() ([]ads.Ad, error) {
+		return nil, siblingsErr
+	}
+	_, err = p.Check(context.Background(), c)
+	assert.ErrorIs(t, err, siblingsErr)
+}
+
+func newPolicyApp() app.App {
+	p := contentcheck.New(
+		contentcheck.Step{Rule: contentcheck.NewBannedWords([]string{"casino"}), Severity: policy.Reject},
+		contentcheck.Step{Rule: contentcheck.NewLinks(), Severity: policy.Flag},
+		contentcheck.Step{Rule: contentcheck.NewDuplicates(0), Severity: policy.Reject})
+	return app.NewApp(adrepo.New(), customer.New(), adfilter.New(), app.WithAdminKey(moderatorKey),
+		app.WithReports(reports.New(), 0), app.WithContentPolicy(p))
+}
+
+func TestContentPolicy_CreateAndUpdate(t *testing.T) {
+	client := getTestClient(newPolicyApp())
+	_, err := client.createUserAsAdmin(1, "tom", "tom@mail.ru", moderatorKey)
+	assert.NoError(t, err)
+	_, err = client.createUserAsAdmin(2, "cat", "cat@mail.ru", moderatorKey)
+	assert.NoError(t, err)
+
+	_, err = client.createAd(1, "Casino", "come and play")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	bike, err := client.createAd(1, "Bike", "mountain bike in good condition")
+	assert.NoError(t, err)
+	_, err = client.createAd(1, "bike", "Mountain bike, in good condition!")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	// other authors may sell the same
+	_, err = client.createAd(2, "bike", "Mountain bike, in good condition!")
+	assert.NoError(t, err)
+	// an ad isn't a duplicate of itself
+	_, err = client.updateAd(1, bike.Data.ID, "Bike", "mountain bike in good condition")
+	assert.NoError(t, err)
+	_, err = client.updateAd(1, bike.Data.ID, "Bike", "no casino")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	queue, err := client.listReports("open", moderatorKey)
+	assert.NoError(t, err)
+	assert.Empty(t, queue.Data)
+	_, err = client.updateAd(1, bike.Data.ID, "Bike", "photos at bikes.example.com")
+	assert.NoError(t, err)
+	queue, err = client.listReports("open", moderatorKey)
+	assert.NoError(t, err)
+	assert.Len(t, queue.Data, 1)
+	assert.Equal(t, bike.Data.ID, queue.Data[0].AdID)
+	assert.Equal(t, int64(0), queue.Data[0].ReporterID)
+	assert.Contains(t, queue.Data[0].Comment, "links: contains link")
+}
+
+func TestContentCheck_Load(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join([]string{
+		"rules:",
+		"  - rule: banned_words",
+		"    severity: reject",
+		"    words: [casino]",
+		"  - rule: phones",
+		"    severity: flag",
+	}, "\n")), 0o644))
+
+	p, err := contentcheck.Load(path)
+	assert.NoError(t, err)
+	verdict, err := p.Check(context.Background(), policy.Content{Title: "casino", Text: "call 89991234567"})
+	assert.NoError(t, err)
+	assert.Equal(t, "banned_words: contains banned word \"casino\"", verdict.Reasons(policy.Reject))
+	assert.Equal(t, "phones: contains phone number \"89991234567\"", verdict.Reasons(policy.Flag))
+
+	for _, bad := range []string{"rules:\n  - rule: links\n    severity: warn",
+		"rules:\n  - rule: emails\n    severity: flag", "rules: ["} {
+		assert.NoError(t, os.WriteFile(path, []byte(bad), 0o644))
+		_, err = contentcheck.Load(path)
+		assert.Error(t, err, bad)
+	}
+	_, err = contentcheck.Load(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adpattern"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBasicFilter_Immutable(t *testing.T) {
+	ctx := context.Background()
+	base := adfilter.New()
+
+	f, err := base.SetAuthor(ctx, 5)
+	assert.NoError(t, err)
+	f, err = f.SetLTime(ctx, time.Unix(100, 0).UTC())
+	assert.NoError(t, err)
+	order := []adpattern.OrderBy{{Field: adpattern.FieldCreationDate}}
+	f, err = f.SetSort(ctx, order)
+	assert.NoError(t, err)
+	order[0].Desc = true
+
+	adp, err := f.GetPattern(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), adp.AuthorID)
+	assert.True(t, adp.IsLTimeSet)
+	assert.Equal(t, []adpattern.OrderBy{{Field: adpattern.FieldCreationDate}}, adp.Sort)
+
+	adp, err = base.GetPattern(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, adpattern.AdPattern{}, adp)
+
+	reset, err := f.BasicConfig(ctx)
+	assert.NoError(t, err)
+	adp, err = reset.GetPattern(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, adpattern.AdPattern{PublishedOnly: true}, adp)
+}
+
+func TestBasicFilter_ConcurrentRequests(t *testing.T) {
+	const (
+		authors   = 4
+		perAuthor = 3
+		workers   = 16
+		rounds    = 20
+	)
+	ctx := context.Background()
+	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New())
+	client := getTestClient(a)
+	grpcClient, grpcCtx := getGRPCClient(t, a)
+	for u := int64(1); u <= authors; u++ {
+		_, err := client.createUser(u, fmt.Sprint("user", u), fmt.Sprintf("user%d@mail.ru", u))
+		assert.NoError(t, err)
+		for i := 0; i < perAuthor; i++ {
+			ad, err := client.createAd(u, fmt.Sprint("ad", i), "text")
+			assert.NoError(t, err)
+			_, err = client.changeAdStatus(u, ad.Data.ID, true)
+			assert.NoError(t, err)
+		}
+	}
+	future := time.Now().Add(time.Hour).UnixMicro()
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			author := int64(w%authors + 1)
+			// every other worker asks for ads from the future, so it must see none
+			var lTime int64
+			expected := perAuthor
+			if w%2 == 1 {
+				lTime = future
+				expected = 0
+			}
+			for r := 0; r < rounds; r++ {
+				resp, err := client.listAds(fmt.Sprint(author), "true", fmt.Sprint(lTime), "")
+				assert.NoError(t, err)
+				assert.Len(t, resp.Data, expected)
+				for _, ad := range resp.Data {
+					assert.Equal(t, author, ad.AuthorID)
+				}
+
+				list, err := grpcClient.ListAds(grpcCtx, &grpcPort.FilterRequest{AuthorId: author,
+					PublishedConfig: grpcPort.PublishedConfig_PublishedOnly})
+				assert.NoError(t, err)
+				assert.Len(t, list.List, perAuthor)
+				for _, ad := range list.List {
+					assert.Equal(t, author, ad.AuthorId)
+				}
+
+				f, err := a.GetNewFilter(ctx)
+				assert.NoError(t, err)
+				f, err = f.SetAuthor(ctx, author)
+				assert.NoError(t, err)
+				f, err = f.SetStatus(ctx, w%2 == 0)
+				assert.NoError(t, err)
+				adp, err := f.GetPattern(ctx)
+				assert.NoError(t, err)
+				assert.Equal(t, adpattern.AdPattern{AuthorID: author, PublishedOnly: w%2 == 0}, adp)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
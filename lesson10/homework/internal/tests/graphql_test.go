@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/dataloader"
+	"homework10/internal/graphql"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/user"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// countingApp counts batched lookups, to check queries don't look up users one by one.
+type countingApp struct {
+	app.App
+	findUsers int32
+	listAds   int32
+}
+
+func (d *countingApp) FindUsers(ctx context.Context, userIDs []int64) (map[int64]user.User, error) {
+	atomic.AddInt32(&d.findUsers, 1)
+	return d.App.FindUsers(ctx, userIDs)
+}
+
+func (d *countingApp) GetAllAdsByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error) {
+	atomic.AddInt32(&d.listAds, 1)
+	return d.App.GetAllAdsByTemplate(ctx, adp)
+}
+
+type graphqlResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, server *httptest.Server, query string, vars map[string]interface{}) (int, graphqlResponse) {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	assert.NoError(t, err)
+	resp, err := server.Client().Post(server.URL+"/api/v1/graphql", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var out graphqlResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	return resp.StatusCode, out
+}
+
+// newGraphQLServer has two authors with two published ads each and a draft.
+func newGraphQLServer(t *testing.T) (*countingApp, *httptest.Server, []user.User, []ads.Ad) {
+	a := &countingApp{App: getAccountsApp()}
+	server := httptest.NewServer(httpgin.NewHTTPServer(":18080", a).Handler)
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	var users []user.User
+	var published []ads.Ad
+	for i, nickname := range []string{"tom", "ann"} {
+		u, err := a.Register(ctx, nickname, nickname+"@mail.ru", "password")
+		assert.NoError(t, err)
+		users = append(users, u)
+		for j := 0; j < 2; j++ {
+			ad, err := a.CreateAd(ctx, fmt.Sprintf("Ad %d of %s", j, nickname), "Selling things in good condition", u.ID)
+			assert.NoError(t, err)
+			ad, err = a.ChangeAdStatus(ctx, ad.ID, u.ID, true)
+			assert.NoError(t, err)
+			published = append(published, ad)
+		}
+		_, err = a.CreateAd(ctx, fmt.Sprintf("Draft %d", i), "Not ready yet", u.ID)
+		assert.NoError(t, err)
+	}
+	atomic.StoreInt32(&a.findUsers, 0)
+	atomic.StoreInt32(&a.listAds, 0)
+	return a, server, users, published
+}
+
+func TestGraphQL_AdsWithAuthors(t *testing.T) {
+	a, server, users, _ := newGraphQLServer(t)
+
+	status, resp := postGraphQL(t, server, `{
+		ads(limit: 10) {
+			title
+			author {
+				nickname
+				ads(limit: 1) { title }
+			}
+		}
+	}`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Errors)
+	list := resp.Data["ads"].([]interface{})
+	assert.Len(t, list, 4)
+	for _, item := range list {
+		ad := item.(map[string]interface{})
+		author := ad["author"].(map[string]interface{})
+		assert.Contains(t, ad["title"], author["nickname"])
+		assert.Len(t, author["ads"], 1)
+	}
+	// one lookup of both authors and one listing of their ads, however many ads are listed
+	assert.Equal(t, int32(1), atomic.LoadInt32(&a.findUsers))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&a.listAds))
+
+	status, resp = postGraphQL(t, server, `query Users($ids: [ID!]!) {
+		users(ids: $ids) { nickname drafts: ads(filter: {publishedOnly: false, title: "Draft"}) { title } }
+	}`, map[string]interface{}{"ids": []interface{}{users[1].ID, fmt.Sprint(users[0].ID), 42}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Errors)
+	got := resp.Data["users"].([]interface{})
+	assert.Len(t, got, 3)
+	assert.Equal(t, "ann", got[0].(map[string]interface{})["nickname"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"title": "Draft 1"}}, got[0].(map[string]interface{})["drafts"])
+	assert.Equal(t, "tom", got[1].(map[string]interface{})["nickname"])
+	assert.Nil(t, got[2])
+	assert.Equal(t, int32(2), atomic.LoadInt32(&a.findUsers))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&a.listAds))
+}
+
+func TestGraphQL_Features(t *testing.T) {
+	_, server, users, published := newGraphQLServer(t)
+
+	status, resp := postGraphQL(t, server, `
+		query Ad($id: ID!, $missing: ID! = "1000") {
+			first: ad(id: $id) { ...adFields author { id } }
+			missing: ad(id: $missing) { id }
+		}
+		fragment adFields on Ad { __typename id title ... on Ad { published } }
+	`, map[string]interface{}{"id": published[1].ID})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"__typename": "Ad",
+		"id":         fmt.Sprint(published[1].ID),
+		"title":      published[1].Title,
+		"published":  true,
+		"author":     map[string]interface{}{"id": fmt.Sprint(users[0].ID)},
+	}, resp.Data["first"])
+	assert.Nil(t, resp.Data["missing"])
+
+	// fields failing to resolve are null and have their errors listed
+	status, resp = postGraphQL(t, server, `{ ok: ads(limit: 1) { id } bad: ads(limit: 1000) { id } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, resp.Data["ok"], 1)
+	assert.Nil(t, resp.Data["bad"])
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Equal(t, []interface{}{"bad"}, resp.Errors[0].Path)
+	}
+
+	// the same query in a GET request
+	query := url.Values{"query": {`query($id: ID!) { ad(id: $id) { title } }`},
+		"variables": {fmt.Sprintf(`{"id": %d}`, published[0].ID)}}
+	r, err := server.Client().Get(server.URL + "/api/v1/graphql?" + query.Encode())
+	assert.NoError(t, err)
+	defer r.Body.Close()
+	assert.NoError(t, json.NewDecoder(r.Body).Decode(&resp))
+	assert.Equal(t, http.StatusOK, r.StatusCode)
+	assert.Equal(t, map[string]interface{}{"title": published[0].Title}, resp.Data["ad"])
+}
+
+func TestGraphQL_RejectedQueries(t *testing.T) {
+	a, server, _, _ := newGraphQLServer(t)
+
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		error string
+	}{
+		{name: "syntax", query: `{ ads { id }`, error: "syntax error"},
+		{name: "unknown field", query: `{ ads { price } }`, error: `type Ad has no field "price"`},
+		{name: "missing subselection", query: `{ ads }`, error: "needs a selection of fields"},
+		{name: "unknown argument", query: `{ ads(page: 2) { id } }`, error: `unknown field "page"`},
+		{name: "missing variable", query: `query($id: ID!) { ad(id: $id) { id } }`, error: "$id of type ID! isn't given"},
+		{name: "undefined variable", query: `{ ad(id: $id) { id } }`, error: "$id isn't defined"},
+		{name: "bad variable", query: `query($limit: Int) { ads(limit: $limit) { id } }`,
+			vars: map[string]interface{}{"limit": "ten"}, error: "Int can't represent"},
+		{name: "fragment cycle", query: `{ ads { ...a } } fragment a on Ad { author { ads { ...a } } }`,
+			error: "spreads itself"},
+		{name: "mutation", query: `mutation { ads { id } }`, error: "mutation operations aren't supported"},
+		{name: "depth", query: `{ ads { author { ads { author { ads { author { nickname } } } } } } }`,
+			error: "query depth 7 exceeds the limit of 6"},
+		{name: "complexity", query: `{ ads(limit: 100) { author { ads(limit: 100) { id } } } }`,
+			error: "query complexity 10201 exceeds the limit of 5000"},
+		// nesting is checked while parsing, so that deep queries can't exhaust the stack
+		{name: "nested selections", query: "{" + strings.Repeat("a{", 5000) + "b" + strings.Repeat("}", 5001),
+			error: "nesting is deeper than 32"},
+		{name: "nested values", query: "{ ads(limit: " + strings.Repeat("[", 5000) + ") { id } }",
+			error: "nesting is deeper than 32"},
+		{name: "long query", query: "{ ads { id " + strings.Repeat(" ", graphql.MaxLen) + "} }",
+			error: "query is longer than 65536 bytes"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			status, resp := postGraphQL(t, server, tc.query, tc.vars)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Nil(t, resp.Data)
+			if assert.NotEmpty(t, resp.Errors) {
+				assert.Contains(t, resp.Errors[0].Message, tc.error)
+			}
+		})
+	}
+	// messages are translated
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/graphql?"+url.Values{"query": {
+		`{ ads { author { ads { author { ads { author { nickname } } } } } } }`}}.Encode(), nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept-Language", "ru")
+	r, err := server.Client().Do(req)
+	assert.NoError(t, err)
+	defer r.Body.Close()
+	var resp graphqlResponse
+	assert.NoError(t, json.NewDecoder(r.Body).Decode(&resp))
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Equal(t, "глубина запроса 7 превышает предел 6", resp.Errors[0].Message)
+	}
+
+	// bodies are limited before they are decoded
+	body := `{"query": "{ ads { id } }", "variables": {"pad": "` + strings.Repeat("a", 1<<20) + `"}}`
+	r, err = server.Client().Post(server.URL+"/api/v1/graphql", "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+	defer r.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, r.StatusCode)
+
+	// rejected queries aren't executed
+	assert.Equal(t, int32(0), atomic.LoadInt32(&a.findUsers))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&a.listAds))
+}
+
+func TestDataLoader(t *testing.T) {
+	var batches [][]int
+	l := dataloader.New(context.Background(), func(ctx context.Context, keys []int) (map[int]string, error) {
+		batches = append(batches, keys)
+		res := map[int]string{}
+		for _, k := range keys {
+			if k%2 == 0 {
+				res[k] = fmt.Sprint(k)
+			}
+		}
+		return res, nil
+	})
+
+	one, two, oneAgain := l.Load(1), l.Load(2), l.Load(1)
+	v, ok, err := two()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "2", v)
+	_, ok, err = one()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, ok, _ = oneAgain()
+	assert.False(t, ok)
+
+	// cached keys aren't looked up again
+	v, _, _ = l.Load(2)()
+	assert.Equal(t, "2", v)
+	v, _, _ = l.Load(4)()
+	assert.Equal(t, "4", v)
+	assert.Equal(t, [][]int{{1, 2}, {4}}, batches)
+}
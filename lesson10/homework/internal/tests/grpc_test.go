@@ -3,12 +3,20 @@ package tests
 import (
 	"context"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/adapters/accounts"
 	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/chat"
 	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/favorites"
+	"homework10/internal/adapters/notifier"
+	"homework10/internal/adapters/searches"
+	"homework10/internal/adapters/snowflake"
 	"net"
 	"testing"
 	"time"
@@ -42,7 +50,11 @@ func (suite *TestConfig) SetupTest() {
 
 	suite.srv = grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.UnaryInterceptor, grpcPort.RecoveryInterceptor))
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), customer.New(), adfilter.New()))
+	ids, _ := snowflake.New(1)
+	svc := grpcPort.NewService(app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithFavorites(favorites.New()), app.WithSavedSearches(searches.New(), notifier.NewOutbox(notifier.DefaultLimit)),
+		app.WithMessages(chat.New(), chat.NewBlocks()),
+		app.WithAccounts(ids, accounts.NewBcrypt(bcrypt.MinCost), accounts.NewCredentials(), accounts.NewSessions())))
 	grpcPort.RegisterAdServiceServer(suite.srv, svc)
 
 	go func() {
@@ -423,6 +435,181 @@ func (suite *TestConfig) TestGRPCFilterByTime() {
 	suite.Assert().Equal(ads.List[1].Published, c.Published)
 }
 
+func (suite *TestConfig) TestGRPCFavorites() {
+	a, _ := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "Tom", Email: "example@mail.com", UserId: 3})
+	b, _ := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "cat", Email: "cat@mail.com", UserId: 5})
+	ad, _ := suite.client.CreateAd(suite.ctx, &grpcPort.CreateAdRequest{Title: "aba", Text: "caba", UserId: a.UserId})
+
+	_, err := suite.client.AddFavorite(suite.ctx, &grpcPort.FavoriteRequest{UserId: b.UserId, AdId: ad.Id + 1})
+	suite.Assert().ErrorIs(err, ErrorBadRequest)
+	res, err := suite.client.AddFavorite(suite.ctx, &grpcPort.FavoriteRequest{UserId: b.UserId, AdId: ad.Id})
+	suite.Assert().NoError(err, "suite.client.AddFavorite")
+	suite.Assert().Equal(ad.Id, res.Id)
+
+	list, err := suite.client.ListFavorites(suite.ctx, &grpcPort.GetUserRequest{Id: b.UserId})
+	suite.Assert().NoError(err, "suite.client.ListFavorites")
+	suite.Assert().Len(list.List, 1)
+
+	_, err = suite.client.RemoveFavorite(suite.ctx, &grpcPort.FavoriteRequest{UserId: b.UserId, AdId: ad.Id})
+	suite.Assert().NoError(err, "suite.client.RemoveFavorite")
+	list, _ = suite.client.ListFavorites(suite.ctx, &grpcPort.GetUserRequest{Id: b.UserId})
+	suite.Assert().Len(list.List, 0)
+}
+
+func (suite *TestConfig) TestGRPCSavedSearches() {
+	a, _ := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "Tom", Email: "example@mail.com", UserId: 3})
+	b, _ := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "cat", Email: "cat@mail.com", UserId: 5})
+
+	lDate := timestamppb.New(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	s, err := suite.client.SaveSearch(suite.ctx, &grpcPort.SaveSearchRequest{UserId: b.UserId, Name: "Tom's ads",
+		Filter: &grpcPort.FilterRequest{AuthorId: a.UserId, PublishedConfig: grpcPort.PublishedConfig_PublishedOnly,
+			LDate: lDate}})
+	suite.Assert().NoError(err, "suite.client.SaveSearch")
+	suite.Assert().Equal("Tom's ads", s.Name)
+	suite.Assert().Equal(a.UserId, s.Filter.AuthorId)
+	suite.Assert().Equal(grpcPort.PublishedConfig_PublishedOnly, s.Filter.PublishedConfig)
+	suite.Assert().True(lDate.AsTime().Equal(s.Filter.LDate.AsTime()))
+	suite.Assert().Nil(s.Filter.RDate)
+
+	list, err := suite.client.ListSavedSearches(suite.ctx, &grpcPort.GetUserRequest{Id: b.UserId})
+	suite.Assert().NoError(err, "suite.client.ListSavedSearches")
+	suite.Assert().Len(list.List, 1)
+
+	_, err = suite.client.DeleteSavedSearch(suite.ctx, &grpcPort.DeleteSavedSearchRequest{UserId: a.UserId, SearchId: s.Id})
+	suite.Assert().ErrorIs(err, ErrorForbidden)
+	_, err = suite.client.DeleteSavedSearch(suite.ctx, &grpcPort.DeleteSavedSearchRequest{UserId: b.UserId, SearchId: s.Id})
+	suite.Assert().NoError(err, "suite.client.DeleteSavedSearch")
+	list, _ = suite.client.ListSavedSearches(suite.ctx, &grpcPort.GetUserRequest{Id: b.UserId})
+	suite.Assert().Len(list.List, 0)
+}
+
+// signIn registers and logs the user in, the returned context carries the token of the session.
+func (suite *TestConfig) signIn(nickname, email string) (*grpcPort.UniversalUser, context.Context) {
+	u, err := suite.client.Register(suite.ctx, &grpcPort.RegisterRequest{Nickname: nickname, Email: email, Password: "password"})
+	suite.Assert().NoError(err, "suite.client.Register")
+	s, err := suite.client.Login(suite.ctx, &grpcPort.LoginRequest{Email: email, Password: "password"})
+	suite.Assert().NoError(err, "suite.client.Login")
+	return u, metadata.AppendToOutgoingContext(suite.ctx, "authorization", "Bearer "+s.Token)
+}
+
+func (suite *TestConfig) TestGRPCMessaging() {
+	a, actx := suite.signIn("Tom", "example@mail.com")
+	b, bctx := suite.signIn("cat", "cat@mail.com")
+	ad, _ := suite.client.CreateAd(suite.ctx, &grpcPort.CreateAdRequest{Title: "aba", Text: "caba", UserId: a.UserId})
+
+	_, err := suite.client.OpenThread(actx, &grpcPort.OpenThreadRequest{AdId: ad.Id, UserId: a.UserId})
+	suite.Assert().ErrorIs(err, ErrorBadRequest)
+	thread, err := suite.client.OpenThread(bctx, &grpcPort.OpenThreadRequest{AdId: ad.Id, UserId: b.UserId})
+	suite.Assert().NoError(err, "suite.client.OpenThread")
+	suite.Assert().Equal(a.UserId, thread.SellerId)
+
+	m, err := suite.client.SendMessage(bctx, &grpcPort.SendMessageRequest{ThreadId: thread.Id, UserId: b.UserId, Text: "hello"})
+	suite.Assert().NoError(err, "suite.client.SendMessage")
+	suite.Assert().Nil(m.ReadDate)
+	res, err := suite.client.MarkRead(actx, &grpcPort.MarkReadRequest{ThreadId: thread.Id, UserId: a.UserId, UpToId: m.Id})
+	suite.Assert().NoError(err, "suite.client.MarkRead")
+	suite.Assert().Equal(int32(1), res.Marked)
+
+	list, err := suite.client.GetMessages(actx, &grpcPort.GetMessagesRequest{ThreadId: thread.Id, UserId: a.UserId})
+	suite.Assert().NoError(err, "suite.client.GetMessages")
+	suite.Assert().Len(list.List, 1)
+	suite.Assert().NotNil(list.List[0].ReadDate)
+
+	_, err = suite.client.BlockUser(actx, &grpcPort.BlockUserRequest{UserId: a.UserId, BlockedId: b.UserId})
+	suite.Assert().NoError(err, "suite.client.BlockUser")
+	_, err = suite.client.SendMessage(bctx, &grpcPort.SendMessageRequest{ThreadId: thread.Id, UserId: b.UserId, Text: "hello"})
+	suite.Assert().ErrorIs(err, ErrorForbidden)
+	threads, _ := suite.client.ListThreads(bctx, &grpcPort.GetUserRequest{Id: b.UserId})
+	suite.Assert().Len(threads.List, 0)
+	_, err = suite.client.UnblockUser(actx, &grpcPort.BlockUserRequest{UserId: a.UserId, BlockedId: b.UserId})
+	suite.Assert().NoError(err, "suite.client.UnblockUser")
+	threads, _ = suite.client.ListThreads(bctx, &grpcPort.GetUserRequest{Id: b.UserId})
+	suite.Assert().Len(threads.List, 1)
+}
+
+func (suite *TestConfig) TestGRPCMessagingAuthorization() {
+	a, actx := suite.signIn("Tom", "example@mail.com")
+	b, bctx := suite.signIn("cat", "cat@mail.com")
+	ad, _ := suite.client.CreateAd(suite.ctx, &grpcPort.CreateAdRequest{Title: "aba", Text: "caba", UserId: a.UserId})
+	thread, _ := suite.client.OpenThread(bctx, &grpcPort.OpenThreadRequest{AdId: ad.Id, UserId: b.UserId})
+
+	calls := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{name: "OpenThread", call: func(ctx context.Context) error {
+			_, err := suite.client.OpenThread(ctx, &grpcPort.OpenThreadRequest{AdId: ad.Id, UserId: b.UserId})
+			return err
+		}},
+		{name: "ListThreads", call: func(ctx context.Context) error {
+			_, err := suite.client.ListThreads(ctx, &grpcPort.GetUserRequest{Id: b.UserId})
+			return err
+		}},
+		{name: "SendMessage", call: func(ctx context.Context) error {
+			_, err := suite.client.SendMessage(ctx, &grpcPort.SendMessageRequest{ThreadId: thread.Id, UserId: b.UserId, Text: "hello"})
+			return err
+		}},
+		{name: "GetMessages", call: func(ctx context.Context) error {
+			_, err := suite.client.GetMessages(ctx, &grpcPort.GetMessagesRequest{ThreadId: thread.Id, UserId: b.UserId})
+			return err
+		}},
+		{name: "MarkRead", call: func(ctx context.Context) error {
+			_, err := suite.client.MarkRead(ctx, &grpcPort.MarkReadRequest{ThreadId: thread.Id, UserId: b.UserId, UpToId: 1})
+			return err
+		}},
+		{name: "BlockUser", call: func(ctx context.Context) error {
+			_, err := suite.client.BlockUser(ctx, &grpcPort.BlockUserRequest{UserId: b.UserId, BlockedId: a.UserId})
+			return err
+		}},
+		{name: "UnblockUser", call: func(ctx context.Context) error {
+			_, err := suite.client.UnblockUser(ctx, &grpcPort.BlockUserRequest{UserId: b.UserId, BlockedId: a.UserId})
+			return err
+		}},
+	}
+	sessions := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "no session", ctx: suite.ctx},
+		{name: "wrong token", ctx: metadata.AppendToOutgoingContext(suite.ctx, "authorization", "Bearer wrong")},
+		{name: "session of another user", ctx: actx},
+	}
+	for _, s := range sessions {
+		for _, c := range calls {
+			suite.Run(s.name+"/"+c.name, func() {
+				suite.Assert().ErrorIs(c.call(s.ctx), ErrorForbidden)
+			})
+		}
+	}
+}
+
+func (suite *TestConfig) TestGRPCDuplicateEmail() {
+	_, err := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "Tom", Email: "example@mail.com", UserId: 3})
+	suite.Assert().NoError(err, "suite.client.CreateUser")
+	_, err = suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "cat", Email: "Example@Mail.com", UserId: 5})
+	suite.Assert().ErrorIs(err, status.Error(codes.InvalidArgument, app.ErrEmailTaken.Error()))
+	_, err = suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "cat", Email: "cat", UserId: 5})
+	suite.Assert().ErrorIs(err, ErrorBadRequest)
+
+	_, err = suite.client.RequestVerification(suite.ctx, &grpcPort.GetUserRequest{Id: 3})
+	suite.Assert().ErrorIs(err, ErrorInternal)
+}
+
+func (suite *TestConfig) TestGRPCRegisterAndLogin() {
+	u, err := suite.client.Register(suite.ctx, &grpcPort.RegisterRequest{Nickname: "Tom", Email: "example@mail.com", Password: "password"})
+	suite.Assert().NoError(err, "suite.client.Register")
+	suite.Assert().NotZero(u.UserId)
+	_, err = suite.client.Register(suite.ctx, &grpcPort.RegisterRequest{Nickname: "cat", Email: "cat@mail.com", Password: "short"})
+	suite.Assert().ErrorIs(err, ErrorBadRequest)
+
+	s, err := suite.client.Login(suite.ctx, &grpcPort.LoginRequest{Email: "example@mail.com", Password: "password"})
+	suite.Assert().NoError(err, "suite.client.Login")
+	suite.Assert().Equal(u.UserId, s.UserId)
+	suite.Assert().NotEmpty(s.Token)
+	_, err = suite.client.Login(suite.ctx, &grpcPort.LoginRequest{Email: "example@mail.com", Password: "wrong password"})
+	suite.Assert().ErrorIs(err, ErrorForbidden)
+}
+
 func TestTestConfig(t *testing.T) {
 	suite.Run(t, new(TestConfig))
 }
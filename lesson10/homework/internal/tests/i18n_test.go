@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/i18n"
+	"homework10/internal/langdetect"
+	grpcPort "homework10/internal/ports/grpc"
+	"testing"
+)
+
+func TestI18n_Match(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		expected       language.Tag
+	}{
+		{"", language.English},
+		{"ru", language.Russian},
+		{"ru-RU,ru;q=0.9,en;q=0.8", language.Russian},
+		{"en-US,en;q=0.9,ru;q=0.8", language.English},
+		{"de-DE", language.English},
+		{"de-DE,ru;q=0.5", language.Russian},
+		{"not a language!", language.English},
+	}
+	for _, tc := range tests {
+		t.Run(tc.acceptLanguage, func(t *testing.T) {
+			assert.Equal(t, tc.expected, i18n.Match(tc.acceptLanguage))
+		})
+	}
+}
+
+func TestI18n_Translate(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      language.Tag
+		msg      string
+		expected string
+	}{
+		{"english", language.English, "wrong format", "wrong format"},
+		{"exact", language.Russian, "permission denied", "доступ запрещён"},
+		{"wrapped", language.Russian, "wrong format: email is already taken",
+			"неверный формат: адрес электронной почты уже занят"},
+		{"number", language.Russian, "wrong format: title is longer than 50 characters",
+			"неверный формат: заголовок длиннее 50 символов"},
+		{"quoted", language.Russian, `wrong format: banned_words: contains banned word "casino"`,
+			`неверный формат: banned_words: содержит запрещённое слово "casino"`},
+		{"unknown part", language.Russian, "wrong format: something else", "неверный формат: something else"},
+		{"unknown language", language.German, "wrong format", "wrong format"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, i18n.Translate(tc.tag, tc.msg))
+		})
+	}
+}
+
+func TestLangdetect_Parse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		isBad    bool
+	}{
+		{"ru", "ru", false},
+		{"RU", "ru", false},
+		{"ru-RU", "ru", false},
+		{"en_US", "en", false},
+		{"und", "", true},
+		{"russian", "", true},
+		{"", "", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			lang, err := langdetect.Parse(tc.input)
+			if tc.isBad {
+				assert.ErrorIs(t, err, langdetect.ErrBadLang)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, lang)
+		})
+	}
+}
+
+func TestLangdetect_Detect(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"russian", "Продаю велосипед в хорошем состоянии", "ru"},
+		{"ukrainian", "Продаю велосипед у гарному стані, їздив мало", "uk"},
+		{"english", "Selling my bike, it is in good condition and ready to ride", "en"},
+		{"german", "Ich verkaufe mein Fahrrad, es ist nicht alt und in gutem Zustand", "de"},
+		{"french", "Je vends mon vélo, il est dans un bon état pour la ville", "fr"},
+		{"japanese", "自転車を売ります。状態はとても良いです", "ja"},
+		{"chinese", "出售自行车，状态很好，价格便宜", "zh"},
+		{"too short", "Велик", ""},
+		{"no stop words", "Bike bike bike bike bike", ""},
+		{"mixed", "Bicycle велосипед", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, langdetect.Detect(tc.text))
+		})
+	}
+}
+
+func TestLang_CheckAdAndCodec(t *testing.T) {
+	ad := ads.Ad{Lang: "ru"}
+	assert.True(t, app.CheckAd(ad, adpattern.AdPattern{}))
+	assert.True(t, app.CheckAd(ad, adpattern.AdPattern{Lang: "ru"}))
+	assert.False(t, app.CheckAd(ad, adpattern.AdPattern{Lang: "en"}))
+
+	data, err := adpattern.Marshal(adpattern.AdPattern{AuthorID: 1, Lang: "ru"})
+	assert.NoError(t, err)
+	adp, err := adpattern.Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "ru", adp.Lang)
+}
+
+func TestLang_DetectAndDeclare(t *testing.T) {
+	client := getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New()))
+	_, err := client.createUser(1, "tom", "tom@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createUser(2, "ann", "ann@mail.ru")
+	assert.NoError(t, err)
+
+	ru, err := client.createAd(1, "Велосипед", "Продаю велосипед в хорошем состоянии")
+	assert.NoError(t, err)
+	assert.Equal(t, "ru", ru.Data.Lang)
+	assert.False(t, ru.Data.LangDeclared)
+
+	en, err := client.createAd(1, "Bike", "Selling my bike, it is in good condition")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", en.Data.Lang)
+
+	short, err := client.createAd(1, "Bike", "Cheap")
+	assert.NoError(t, err)
+	assert.Empty(t, short.Data.Lang)
+
+	// updating the text detects the language again
+	en, err = client.updateAd(1, en.Data.ID, "Велосипед", "Продаю велосипед, почти новый")
+	assert.NoError(t, err)
+	assert.Equal(t, "ru", en.Data.Lang)
+
+	short, err = client.setAdLanguage(1, short.Data.ID, "en-GB")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", short.Data.Lang)
+	assert.True(t, short.Data.LangDeclared)
+
+	// a declared language is kept on update
+	short, err = client.updateAd(1, short.Data.ID, "Велосипед", "Продаю велосипед в хорошем состоянии")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", short.Data.Lang)
+
+	// and an empty one makes it detected again
+	short, err = client.setAdLanguage(1, short.Data.ID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "ru", short.Data.Lang)
+	assert.False(t, short.Data.LangDeclared)
+
+	_, err = client.setAdLanguage(1, short.Data.ID, "russian")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.setAdLanguage(2, short.Data.ID, "en")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	list, err := client.listAdsByLang("ru")
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 3)
+	list, err = client.listAdsByLang("EN")
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 0)
+	_, err = client.listAdsByLang("russian")
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestI18n_HTTPErrors(t *testing.T) {
+	client := getTestClient(newPolicyApp())
+	_, err := client.createUserAsAdmin(1, "tom", "tom@mail.ru", moderatorKey)
+	assert.NoError(t, err)
+
+	msg, lang, err := client.withLanguage("ru-RU,en;q=0.5").createAdError(1, "Casino", "the best casino in town")
+	assert.NoError(t, err)
+	assert.Equal(t, `неверный формат: banned_words: содержит запрещённое слово "casino"`, msg)
+	assert.Equal(t, "ru", lang)
+
+	msg, lang, err = client.createAdError(1, "Casino", "the best casino in town")
+	assert.NoError(t, err)
+	assert.Equal(t, `wrong format: banned_words: contains banned word "casino"`, msg)
+	assert.Equal(t, "en", lang)
+
+	msg, _, err = client.withLanguage("ru").createAdError(5, "Bike", "a bike")
+	assert.NoError(t, err)
+	assert.Equal(t, "неверный формат", msg)
+}
+
+func TestI18n_GRPCErrors(t *testing.T) {
+	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New())
+	client, ctx := getGRPCClient(t, a)
+	_, err := a.CreateUserByID(context.Background(), "tom", "tom@mail.ru", 1)
+	assert.NoError(t, err)
+	ad, err := a.CreateAd(context.Background(), "Bike", "Selling my bike, it is in good condition", 1)
+	assert.NoError(t, err)
+
+	ruCtx := metadata.AppendToOutgoingContext(ctx, "accept-language", "ru")
+	_, err = client.UpdateAd(ruCtx, &grpcPort.UpdateAdRequest{AdId: ad.ID, UserId: 2, Title: "a", Text: "b"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "неверный формат", status.Convert(err).Message())
+
+	_, err = client.SetAdLanguage(ruCtx, &grpcPort.SetAdLanguageRequest{AdId: ad.ID, UserId: 1, Lang: "xx-!"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, `неверный формат: неверный код языка: "xx-!"`, status.Convert(err).Message())
+
+	_, err = client.SetAdLanguage(ctx, &grpcPort.SetAdLanguageRequest{AdId: ad.ID, UserId: 1, Lang: "xx-!"})
+	assert.Equal(t, `wrong format: bad language: "xx-!"`, status.Convert(err).Message())
+
+	res, err := client.SetAdLanguage(ctx, &grpcPort.SetAdLanguageRequest{AdId: ad.ID, UserId: 1, Lang: "de"})
+	assert.NoError(t, err)
+	assert.Equal(t, "de", res.Lang)
+
+	list, err := client.ListAds(ctx, &grpcPort.FilterRequest{Lang: "de", PublishedConfig: grpcPort.PublishedConfig_AllAds})
+	assert.NoError(t, err)
+	assert.Len(t, list.List, 1)
+	_, err = client.ListAds(ruCtx, &grpcPort.FilterRequest{Lang: "??"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, `неверный код языка: "??"`, status.Convert(err).Message())
+}
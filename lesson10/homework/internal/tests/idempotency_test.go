@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/idemkeys"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/adpattern"
+	"homework10/internal/app"
+	"homework10/internal/idempotency"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/tenant"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const idempotencyAdminKey = "idempotency admin key"
+
+func newIdempotentApp() app.App {
+	return app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithIdempotencyKeys(idemkeys.New(), time.Hour), app.WithAdminKey(idempotencyAdminKey))
+}
+
+func TestIdempotency_CreateAd(t *testing.T) {
+	client := getTestClient(newIdempotentApp())
+	_, _ = client.createUserAsAdmin(1, "tom", "tom@mail.ru", idempotencyAdminKey)
+	_, _ = client.createUserAsAdmin(2, "cat", "cat@mail.ru", idempotencyAdminKey)
+
+	retry := client.withIdempotencyKey("first")
+	ad, err := retry.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	again, err := retry.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	assert.Equal(t, ad, again)
+	_, err = retry.createAd(1, "foo", "bar")
+	assert.ErrorIs(t, err, ErrConflict)
+	// keys are scoped to the user
+	other, err := retry.createAd(2, "aba", "caba")
+	assert.NoError(t, err)
+	assert.NotEqual(t, ad.Data.ID, other.Data.ID)
+
+	_, _ = client.createAd(1, "aba", "caba")
+	list, _ := client.listAdsSorted("")
+	assert.Len(t, list.Data, 3)
+}
+
+func TestIdempotency_FailuresAreRetried(t *testing.T) {
+	client := getTestClient(newIdempotentApp())
+	retry := client.withIdempotencyKey("first")
+	_, err := retry.createAd(1, "aba", "caba")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, _ = client.createUserAsAdmin(1, "tom", "tom@mail.ru", idempotencyAdminKey)
+	_, err = retry.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+
+	_, err = client.withIdempotencyKey(strings.Repeat("k", idempotency.MaxKeyLen+1)).createAd(1, "aba", "caba")
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestIdempotency_Mutations(t *testing.T) {
+	client := getTestClient(newIdempotentApp())
+	u, err := client.withIdempotencyKey("user").createUserAsAdmin(1, "tom", "tom@mail.ru", idempotencyAdminKey)
+	assert.NoError(t, err)
+	again, err := client.withIdempotencyKey("user").createUserAsAdmin(1, "tom", "tom@mail.ru", idempotencyAdminKey)
+	assert.NoError(t, err)
+	assert.Equal(t, u, again)
+	_, err = client.createUserAsAdmin(1, "tom", "tom@mail.ru", idempotencyAdminKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.withIdempotencyKey("user").createUserAsAdmin(1, "tom", "other@mail.ru", idempotencyAdminKey)
+	assert.ErrorIs(t, err, ErrConflict)
+	// stored responses are returned to admins only
+	_, err = client.withIdempotencyKey("user").createUser(1, "tom", "tom@mail.ru")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	ad, _ := client.createAd(1, "aba", "caba")
+	status := client.withIdempotencyKey("status")
+	published, err := status.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+	_, _ = client.changeAdStatus(1, ad.Data.ID, false)
+	replayed, err := status.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, published, replayed)
+	current, _ := client.getAdByID(ad.Data.ID)
+	assert.False(t, current.Data.Published)
+	_, err = status.changeAdStatus(1, ad.Data.ID, false)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	update := client.withIdempotencyKey("update")
+	updated, err := update.updateAd(1, ad.Data.ID, "foo", "bar")
+	assert.NoError(t, err)
+	replayed, err = update.updateAd(1, ad.Data.ID, "foo", "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, updated, replayed)
+	_, err = update.updateAd(1, ad.Data.ID, "foo", "baz")
+	assert.ErrorIs(t, err, ErrConflict)
+	// the same key used for another operation is a conflict too
+	_, err = update.changeAdStatus(1, ad.Data.ID, true)
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestIdempotency_Scope(t *testing.T) {
+	a := newIdempotentApp()
+	admin := app.ContextWithAdminKey(context.Background(), idempotencyAdminKey)
+	ctx := idempotency.NewContext(context.Background(), "key")
+	acme := tenant.NewContext(ctx, "acme")
+	_, _ = a.CreateUserByID(admin, "tom", "tom@mail.ru", 1)
+
+	first, err := a.CreateAd(ctx, "aba", "caba", 1)
+	assert.NoError(t, err)
+	// the repository isn't scoped, so the ad is created again under the key of another tenant
+	second, err := a.CreateAd(acme, "aba", "caba", 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	expiring := app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithIdempotencyKeys(idemkeys.New(), 0), app.WithAdminKey(idempotencyAdminKey))
+	_, _ = expiring.CreateUserByID(admin, "tom", "tom@mail.ru", 1)
+	first, _ = expiring.CreateAd(ctx, "aba", "caba", 1)
+	second, _ = expiring.CreateAd(ctx, "aba", "caba", 1)
+	assert.NotEqual(t, first.ID, second.ID)
+	list, _ := expiring.GetAllAdsByTemplate(ctx, adpattern.AdPattern{})
+	assert.Len(t, list, 2)
+}
+
+func TestIdempotency_Concurrent(t *testing.T) {
+	a := newIdempotentApp()
+	admin := app.ContextWithAdminKey(context.Background(), idempotencyAdminKey)
+	ctx := idempotency.NewContext(context.Background(), "key")
+	_, _ = a.CreateUserByID(admin, "tom", "tom@mail.ru", 1)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.CreateAd(ctx, "aba", "caba", 1)
+			if err != nil {
+				assert.ErrorIs(t, err, app.ErrConflict)
+			}
+		}()
+	}
+	wg.Wait()
+	list, _ := a.GetAllAdsByTemplate(ctx, adpattern.AdPattern{})
+	assert.Len(t, list, 1)
+}
+
+func TestMapKeys(t *testing.T) {
+	ctx := context.Background()
+	keys := idemkeys.New()
+	key := idempotency.Key{Tenant: tenant.Default, UserID: 1, Value: "key"}
+	now := time.Now().UTC()
+
+	rec, isReserved, err := keys.Reserve(ctx, idempotency.Record{Key: key, Fingerprint: "a", ExpirationDate: now.Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.True(t, isReserved)
+	rec, isReserved, _ = keys.Reserve(ctx, idempotency.Record{Key: key, Fingerprint: "b", ExpirationDate: now.Add(time.Hour)})
+	assert.False(t, isReserved)
+	assert.Equal(t, "a", rec.Fingerprint)
+	assert.False(t, rec.IsDone())
+
+	assert.NoError(t, keys.Complete(ctx, key, []byte("{}")))
+	rec, _, _ = keys.Reserve(ctx, idempotency.Record{Key: key, Fingerprint: "a", ExpirationDate: now.Add(time.Hour)})
+	assert.True(t, rec.IsDone())
+	assert.Equal(t, []byte("{}"), rec.Response)
+
+	assert.NoError(t, keys.Release(ctx, key))
+	_, isReserved, _ = keys.Reserve(ctx, idempotency.Record{Key: key, Fingerprint: "b", ExpirationDate: now})
+	assert.True(t, isReserved)
+	// the record is already expired
+	_, isReserved, _ = keys.Reserve(ctx, idempotency.Record{Key: key, Fingerprint: "c", ExpirationDate: now.Add(time.Hour)})
+	assert.True(t, isReserved)
+
+	// the expired reservation of a released key doesn't drop the record it is reserved with again
+	other := idempotency.Key{Tenant: tenant.Default, UserID: 1, Value: "other"}
+	_, isReserved, _ = keys.Reserve(ctx, idempotency.Record{Key: other, Fingerprint: "a", ExpirationDate: now})
+	assert.True(t, isReserved)
+	assert.NoError(t, keys.Release(ctx, other))
+	_, isReserved, _ = keys.Reserve(ctx, idempotency.Record{Key: other, Fingerprint: "b", ExpirationDate: now.Add(time.Hour)})
+	assert.True(t, isReserved)
+	rec, isReserved, _ = keys.Reserve(ctx, idempotency.Record{Key: other, Fingerprint: "c", ExpirationDate: now.Add(time.Hour)})
+	assert.False(t, isReserved)
+	assert.Equal(t, "b", rec.Fingerprint)
+}
+
+func TestSQLIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	keys := sqlstore.NewIdempotencyKeys(db)
+	key := idempotency.Key{Tenant: "acme", UserID: 1, Value: "key"}
+	rec := idempotency.Record{Key: key, Fingerprint: "a", ExpirationDate: time.Now().UTC().Add(time.Hour)}
+
+	sqlMock.ExpectExec("DELETE FROM idempotency_keys WHERE expiration_date").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery("INSERT INTO idempotency_keys").WithArgs("acme", 1, "key", "a", rec.ExpirationDate, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint"}).AddRow("a"))
+	_, isReserved, err := keys.Reserve(ctx, rec)
+	assert.NoError(t, err)
+	assert.True(t, isReserved)
+
+	sqlMock.ExpectExec("DELETE FROM idempotency_keys WHERE expiration_date").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery("INSERT INTO idempotency_keys").WillReturnRows(sqlmock.NewRows([]string{"fingerprint"}))
+	sqlMock.ExpectQuery("SELECT fingerprint, response, expiration_date FROM idempotency_keys").WithArgs("acme", 1, "key").
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "response", "expiration_date"}).
+			AddRow("b", []byte("{}"), rec.ExpirationDate))
+	old, isReserved, err := keys.Reserve(ctx, rec)
+	assert.NoError(t, err)
+	assert.False(t, isReserved)
+	assert.Equal(t, "b", old.Fingerprint)
+	assert.True(t, old.IsDone())
+
+	sqlMock.ExpectExec("UPDATE idempotency_keys SET response").WithArgs("acme", 1, "key", []byte("{}")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, keys.Complete(ctx, key, []byte("{}")))
+	sqlMock.ExpectExec("DELETE FROM idempotency_keys WHERE tenant_id").WithArgs("acme", 1, "key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, keys.Release(ctx, key))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestIdempotency_GRPC(t *testing.T) {
+	client, ctx := getGRPCClient(t, newIdempotentApp())
+	admin := metadata.AppendToOutgoingContext(ctx, "x-admin-key", idempotencyAdminKey)
+	u, _ := client.CreateUser(admin, &grpcPort.UniversalUser{Nickname: "Tom", Email: "tom@mail.ru", UserId: 1})
+	retry := metadata.AppendToOutgoingContext(ctx, "idempotency-key", "key")
+
+	ad, err := client.CreateAd(retry, &grpcPort.CreateAdRequest{Title: "aba", Text: "caba", UserId: u.UserId})
+	assert.NoError(t, err)
+	again, err := client.CreateAd(retry, &grpcPort.CreateAdRequest{Title: "aba", Text: "caba", UserId: u.UserId})
+	assert.NoError(t, err)
+	assert.Equal(t, ad.Id, again.Id)
+	_, err = client.CreateAd(retry, &grpcPort.CreateAdRequest{Title: "foo", Text: "bar", UserId: u.UserId})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	list, _ := client.ListAds(ctx, &grpcPort.FilterRequest{PublishedConfig: grpcPort.PublishedConfig_AllAds})
+	assert.Len(t, list.List, 1)
+}
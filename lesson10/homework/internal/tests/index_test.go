@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"strings"
+	"testing"
+)
+
+func adIDs(list []ads.Ad) []int64 {
+	res := []int64{}
+	for _, ad := range list {
+		res = append(res, ad.ID)
+	}
+	return res
+}
+
+func TestRepo_IndexesMatchFullScan(t *testing.T) {
+	repos := map[string]func() app.Repository{
+		"MapRepo": adrepo.New,
+		"ShardedRepo": func() app.Repository {
+			return adrepo.NewSharded(4)
+		},
+	}
+	for name, newRepo := range repos {
+		t.Run(name, func(t *testing.T) {
+			testIndexesMatchFullScan(t, newRepo())
+		})
+	}
+}
+
+func testIndexesMatchFullScan(t *testing.T, repo app.Repository) {
+	const n = 1000
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		adID, _ := repo.Add(ctx, fmt.Sprint("ad", i%97), "test ad", int64(i%7+1))
+		if i%3 == 0 {
+			_ = repo.SetStatus(ctx, adID, true)
+		}
+		if i%6 == 0 {
+			_ = repo.SetStatus(ctx, adID, false)
+		}
+		if i%11 == 0 {
+			_ = repo.SetTitle(ctx, adID, fmt.Sprint("title", i))
+		}
+		if i%13 == 0 {
+			_ = repo.Delete(ctx, adID)
+		}
+	}
+	_ = repo.DeleteByAuthor(ctx, 5)
+
+	all := []ads.Ad{}
+	for adID := int64(0); adID < n; adID++ {
+		if ad, isFound := repo.Find(ctx, adID); isFound {
+			all = append(all, ad)
+		}
+	}
+
+	patterns := []adpattern.AdPattern{
+		{},
+		{AuthorID: 3},
+		{AuthorID: 5},
+		{PublishedOnly: true},
+		{AuthorID: 3, PublishedOnly: true},
+		{IsLTimeSet: true, LDate: all[100].CreationDate, IsRTimeSet: true, RDate: all[500].CreationDate},
+		{AuthorID: 2, IsLTimeSet: true, LDate: all[300].CreationDate},
+	}
+	for _, adp := range patterns {
+		given, err := repo.GetAllByTemplate(ctx, adp)
+		assert.NoError(t, err)
+		expected := fullScan(all, func(ad ads.Ad) bool {
+			return app.CheckAd(ad, adp)
+		})
+		assert.Equal(t, adIDs(expected), adIDs(given), "pattern %+v", adp)
+	}
+
+	for _, prefix := range []string{"", "ad", "ad1", "ad96", "title", "title1", "x"} {
+		given, err := repo.GetByTitle(ctx, prefix)
+		assert.NoError(t, err)
+		expected := fullScan(all, func(ad ads.Ad) bool {
+			return strings.HasPrefix(ad.Title, prefix)
+		})
+		assert.Equal(t, adIDs(expected), adIDs(given), "prefix %q", prefix)
+	}
+}
@@ -0,0 +1,423 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"homework10/internal/adapters/accounts"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/chat"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/snowflake"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/app"
+	"homework10/internal/message"
+	"homework10/internal/session"
+	"homework10/internal/tenant"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getMessagingClient returns a client acting with sessions of the users 1, 2 and 3,
+// which are added to the returned store directly as the users have no passwords.
+func getMessagingClient() (*testClient, app.Sessions) {
+	sessions := accounts.NewSessions()
+	ids, _ := snowflake.New(1)
+	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithMessages(chat.New(), chat.NewBlocks()),
+		app.WithAccounts(ids, accounts.NewBcrypt(bcrypt.MinCost), accounts.NewCredentials(), sessions))
+	client := getTestClient(a)
+	signIn(client, sessions, 1, 2, 3)
+	return client, sessions
+}
+
+func signIn(client *testClient, sessions app.Sessions, userIDs ...int64) {
+	if client.tokens == nil {
+		client.tokens = map[int64]string{}
+	}
+	for _, id := range userIDs {
+		token := fmt.Sprintf("token-%d", id)
+		_ = sessions.Add(context.Background(), session.Session{Token: token, Tenant: tenant.Default, UserID: id,
+			ExpirationDate: time.Now().UTC().Add(time.Hour)})
+		client.tokens[id] = token
+	}
+}
+
+func TestThreads(t *testing.T) {
+	client, _ := getMessagingClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+	_, _ = client.createUser(3, "other", "other@mail.ru")
+	ad, _ := client.createAd(1, "aba", "caba")
+
+	thread, err := client.openThread(2, ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, threadData{ID: thread.Data.ID, AdID: ad.Data.ID, BuyerID: 2, SellerID: 1}, thread.Data)
+	again, err := client.openThread(2, ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, thread.Data, again.Data)
+
+	_, err = client.openThread(1, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.openThread(2, 100)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.sendMessage(3, thread.Data.ID, "hello")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.sendMessage(2, thread.Data.ID, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.getMessages(3, thread.Data.ID, 0, 0)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	for _, userID := range []int64{1, 2, 3} {
+		list, err := client.listThreads(userID)
+		assert.NoError(t, err)
+		if userID == 3 {
+			assert.Len(t, list.Data, 0)
+		} else {
+			assert.Equal(t, []threadData{thread.Data}, list.Data)
+		}
+	}
+}
+
+func TestMessagesPagination(t *testing.T) {
+	client, _ := getMessagingClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+	ad, _ := client.createAd(1, "aba", "caba")
+	thread, _ := client.openThread(2, ad.Data.ID)
+
+	for i := 0; i < 5; i++ {
+		_, err := client.sendMessage(int64(1+i%2), thread.Data.ID, fmt.Sprintf("message %d", i))
+		assert.NoError(t, err)
+	}
+
+	page, err := client.getMessages(1, thread.Data.ID, 0, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, "message 3", page.Data[0].Text)
+	assert.Equal(t, "message 4", page.Data[1].Text)
+
+	page, err = client.getMessages(2, thread.Data.ID, page.Data[0].ID, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, "message 1", page.Data[0].Text)
+	assert.Equal(t, "message 2", page.Data[1].Text)
+
+	page, err = client.getMessages(2, thread.Data.ID, page.Data[0].ID, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, "message 0", page.Data[0].Text)
+
+	page, err = client.getMessages(2, thread.Data.ID, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, 5)
+	_, err = client.getMessages(2, thread.Data.ID, 0, 1000)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestReadReceipts(t *testing.T) {
+	client, _ := getMessagingClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+	ad, _ := client.createAd(1, "aba", "caba")
+	thread, _ := client.openThread(2, ad.Data.ID)
+
+	first, _ := client.sendMessage(2, thread.Data.ID, "first")
+	second, _ := client.sendMessage(2, thread.Data.ID, "second")
+	reply, _ := client.sendMessage(1, thread.Data.ID, "reply")
+
+	res, err := client.markRead(1, thread.Data.ID, first.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Data.Marked)
+	res, err = client.markRead(1, thread.Data.ID, reply.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Data.Marked)
+	res, err = client.markRead(1, thread.Data.ID, reply.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, res.Data.Marked)
+
+	page, _ := client.getMessages(2, thread.Data.ID, 0, 0)
+	assert.Len(t, page.Data, 3)
+	assert.Equal(t, second.Data.ID, page.Data[1].ID)
+	assert.NotNil(t, page.Data[0].ReadDate)
+	assert.NotNil(t, page.Data[1].ReadDate)
+	assert.Nil(t, page.Data[2].ReadDate)
+}
+
+func TestBlocks(t *testing.T) {
+	client, _ := getMessagingClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+	ad, _ := client.createAd(1, "aba", "caba")
+	thread, _ := client.openThread(2, ad.Data.ID)
+
+	_, err := client.blockUser(1, 1)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	u, err := client.blockUser(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), u.Data.ID)
+
+	_, err = client.sendMessage(2, thread.Data.ID, "hello")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.openThread(2, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	list, _ := client.listThreads(2)
+	assert.Len(t, list.Data, 0)
+	list, _ = client.listThreads(1)
+	assert.Len(t, list.Data, 0)
+
+	_, err = client.unblockUser(1, 2)
+	assert.NoError(t, err)
+	_, err = client.sendMessage(2, thread.Data.ID, "hello")
+	assert.NoError(t, err)
+	list, _ = client.listThreads(2)
+	assert.Len(t, list.Data, 1)
+}
+
+func TestMessagingAuthorization(t *testing.T) {
+	client, _ := getMessagingClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+	ad, _ := client.createAd(1, "aba", "caba")
+	thread, _ := client.openThread(2, ad.Data.ID)
+	m, _ := client.sendMessage(2, thread.Data.ID, "hello")
+	buyerToken := client.tokens[2]
+
+	calls := []struct {
+		name string
+		call func() error
+	}{
+		{name: "open thread", call: func() error {
+			_, err := client.openThread(2, ad.Data.ID)
+			return err
+		}},
+		{name: "list threads", call: func() error {
+			_, err := client.listThreads(2)
+			return err
+		}},
+		{name: "send message", call: func() error {
+			_, err := client.sendMessage(2, thread.Data.ID, "hello")
+			return err
+		}},
+		{name: "get messages", call: func() error {
+			_, err := client.getMessages(2, thread.Data.ID, 0, 0)
+			return err
+		}},
+		{name: "mark read", call: func() error {
+			_, err := client.markRead(2, thread.Data.ID, m.Data.ID)
+			return err
+		}},
+		{name: "block user", call: func() error {
+			_, err := client.blockUser(2, 1)
+			return err
+		}},
+		{name: "unblock user", call: func() error {
+			_, err := client.unblockUser(2, 1)
+			return err
+		}},
+	}
+	sessions := []struct {
+		name  string
+		token string
+	}{
+		{name: "no session"},
+		{name: "wrong token", token: "wrong"},
+		{name: "session of another user", token: client.tokens[1]},
+	}
+	for _, s := range sessions {
+		for _, c := range calls {
+			t.Run(s.name+"/"+c.name, func(t *testing.T) {
+				delete(client.tokens, 2)
+				if s.token != "" {
+					client.tokens[2] = s.token
+				}
+				assert.ErrorIs(t, c.call(), ErrForbidden)
+			})
+		}
+	}
+
+	client.tokens[2] = buyerToken
+	page, err := client.getMessages(2, thread.Data.ID, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, 1)
+}
+
+func TestMessagingCascade(t *testing.T) {
+	client, sessions := getMessagingClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+	_, _ = client.createUser(3, "other", "other@mail.ru")
+	first, _ := client.createAd(1, "aba", "caba")
+	second, _ := client.createAd(1, "foo", "bar")
+	firstThread, _ := client.openThread(2, first.Data.ID)
+	secondThread, _ := client.openThread(3, second.Data.ID)
+	_, _ = client.sendMessage(2, firstThread.Data.ID, "hello")
+
+	_, err := client.deleteAd(1, first.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.getMessages(2, firstThread.Data.ID, 0, 0)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	list, _ := client.listThreads(1)
+	assert.Equal(t, []threadData{secondThread.Data}, list.Data)
+
+	_, _ = client.blockUser(3, 1)
+	_, err = client.deleteUserByID(3)
+	assert.NoError(t, err)
+	_, err = client.sendMessage(1, secondThread.Data.ID, "hello")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	list, _ = client.listThreads(1)
+	assert.Len(t, list.Data, 0)
+
+	_, _ = client.createUser(3, "other", "other@mail.ru")
+	signIn(client, sessions, 3)
+	_, err = client.openThread(3, second.Data.ID)
+	assert.NoError(t, err)
+}
+
+func socketURL(client *testClient, userID int64) string {
+	return strings.Replace(client.baseURL, "http://", "ws://", 1) + fmt.Sprintf("/api/v1/users/%d/ws", userID)
+}
+
+func dialMessages(t *testing.T, client *testClient, userID int64, token string) net.Conn {
+	conn, _, _, err := ws.Dial(context.Background(), socketURL(client, userID)+"?token="+token)
+	assert.NoError(t, err)
+	return conn
+}
+
+func readEvent(t *testing.T, conn net.Conn) map[string]any {
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, err := wsutil.ReadServerText(conn)
+	assert.NoError(t, err)
+	var res map[string]any
+	assert.NoError(t, json.Unmarshal(data, &res))
+	return res
+}
+
+func TestMessagesSocket(t *testing.T) {
+	client := getTestClient(getAccountsApp(app.WithMessages(chat.New(), chat.NewBlocks())))
+	author, _ := client.register("author", "author@mail.ru", "password1")
+	buyer, _ := client.register("buyer", "buyer@mail.ru", "password2")
+	authorSession, _ := client.login("author@mail.ru", "password1")
+	buyerSession, _ := client.login("buyer@mail.ru", "password2")
+	client.tokens = map[int64]string{author.Data.ID: authorSession.Data.Token, buyer.Data.ID: buyerSession.Data.Token}
+	ad, _ := client.createAd(author.Data.ID, "aba", "caba")
+	thread, _ := client.openThread(buyer.Data.ID, ad.Data.ID)
+
+	tests := []struct {
+		name   string
+		userID int64
+		token  string
+	}{
+		{name: "no token", userID: author.Data.ID},
+		{name: "wrong token", userID: author.Data.ID, token: "wrong"},
+		{name: "session of another user", userID: author.Data.ID, token: buyerSession.Data.Token},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, _, err := ws.Dial(context.Background(), socketURL(client, tc.userID)+"?token="+tc.token)
+			assert.Error(t, err)
+		})
+	}
+	dialer := ws.Dialer{Header: ws.HandshakeHeaderHTTP(http.Header{
+		"Authorization": []string{"Bearer " + authorSession.Data.Token}})}
+	conn, _, _, err := dialer.Dial(context.Background(), socketURL(client, author.Data.ID))
+	assert.NoError(t, err)
+	_ = conn.Close()
+
+	seller := dialMessages(t, client, author.Data.ID, authorSession.Data.Token)
+	defer seller.Close()
+	buyerConn := dialMessages(t, client, buyer.Data.ID, buyerSession.Data.Token)
+	defer buyerConn.Close()
+
+	m, err := client.sendMessage(buyer.Data.ID, thread.Data.ID, "is it available?")
+	assert.NoError(t, err)
+	for _, conn := range []net.Conn{seller, buyerConn} {
+		e := readEvent(t, conn)
+		assert.Equal(t, "message", e["type"])
+		assert.Equal(t, float64(thread.Data.ID), e["thread_id"])
+		assert.Equal(t, "is it available?", e["message"].(map[string]any)["text"])
+	}
+
+	err = wsutil.WriteClientText(seller, []byte(fmt.Sprintf(`{"type":"read","thread_id":%d,"up_to_id":%d}`,
+		thread.Data.ID, m.Data.ID)))
+	assert.NoError(t, err)
+	e := readEvent(t, buyerConn)
+	assert.Equal(t, "read", e["type"])
+	assert.Equal(t, float64(author.Data.ID), e["reader_id"])
+	assert.Equal(t, float64(m.Data.ID), e["up_to_id"])
+	_ = readEvent(t, seller)
+
+	err = wsutil.WriteClientText(seller, []byte(fmt.Sprintf(`{"type":"send","thread_id":%d,"text":"yes"}`,
+		thread.Data.ID)))
+	assert.NoError(t, err)
+	e = readEvent(t, buyerConn)
+	assert.Equal(t, "message", e["type"])
+	assert.Equal(t, "yes", e["message"].(map[string]any)["text"])
+	_ = readEvent(t, seller)
+
+	err = wsutil.WriteClientText(buyerConn, []byte(`{"type":"send","thread_id":100,"text":"lost"}`))
+	assert.NoError(t, err)
+	e = readEvent(t, buyerConn)
+	assert.Equal(t, "error", e["type"])
+
+	page, _ := client.getMessages(buyer.Data.ID, thread.Data.ID, 0, 0)
+	assert.Len(t, page.Data, 2)
+	assert.NotNil(t, page.Data[0].ReadDate)
+}
+
+func TestSQLMessages(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	store := sqlstore.NewMessages(db)
+	now := time.Now().UTC()
+
+	// a message to a missing thread isn't added
+	sqlMock.ExpectQuery("WITH t AS \\(UPDATE threads SET update_date = \\$4 WHERE tenant_id = \\$1 AND id = \\$2 "+
+		"RETURNING id\\) INSERT INTO messages").
+		WithArgs("acme", 100, 2, sqlmock.AnyArg(), "lost").WillReturnError(sql.ErrNoRows)
+	m, err := store.AddMessage(ctx, 100, 2, "lost")
+	assert.NoError(t, err)
+	assert.Equal(t, message.Message{}, m)
+
+	sqlMock.ExpectQuery("WITH t AS (.+) INSERT INTO messages").
+		WithArgs("acme", 1, 2, sqlmock.AnyArg(), "aba").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	m, err = store.AddMessage(ctx, 1, 2, "aba")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), m.ID)
+	assert.Equal(t, "aba", m.Text)
+
+	sqlMock.ExpectQuery("SELECT (.+) FROM \\(SELECT (.+) FROM messages WHERE tenant_id = \\$1 AND thread_id = \\$2 "+
+		"(.+) ORDER BY id DESC LIMIT \\$4\\) m ORDER BY id").
+		WithArgs("acme", 1, 6, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "sender_id", "text", "creation_date", "read_date"}).
+			AddRow(4, 1, 1, "hi", now, now).AddRow(5, 1, 2, "aba", now, nil))
+	list, err := store.ListMessages(ctx, 1, 6, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []message.Message{{ID: 4, ThreadID: 1, SenderID: 1, Text: "hi", CreationDate: now, ReadDate: now},
+		{ID: 5, ThreadID: 1, SenderID: 2, Text: "aba", CreationDate: now}}, list)
+
+	sqlMock.ExpectExec("UPDATE messages SET read_date = \\$5 (.+) AND read_date IS NULL").
+		WithArgs("acme", 1, 1, 5, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	marked, err := store.MarkRead(ctx, 1, 1, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, marked)
+
+	sqlMock.ExpectExec("WITH t AS \\(DELETE FROM threads WHERE tenant_id = \\$1 AND ad_id = \\$2 RETURNING id\\) "+
+		"DELETE FROM messages").WithArgs("acme", 7).WillReturnResult(sqlmock.NewResult(0, 3))
+	assert.NoError(t, store.DeleteByAd(ctx, 7))
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
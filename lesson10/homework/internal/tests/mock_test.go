@@ -15,6 +15,7 @@ import (
 	"homework10/internal/ads"
 	"homework10/internal/app"
 	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/tenant"
 	"homework10/internal/tests/mocks"
 	"homework10/internal/user"
 	"net"
@@ -28,7 +29,8 @@ func getGRPCClient(t *testing.T, a app.App) (grpcPort.AdServiceClient, context.C
 		lis.Close()
 	})
 
-	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.UnaryInterceptor, grpcPort.RecoveryInterceptor))
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.UnaryInterceptor, grpcPort.RecoveryInterceptor,
+		grpcPort.LocaleInterceptor, grpcPort.IdempotencyInterceptor, grpcPort.RequestInterceptor))
 	t.Cleanup(func() {
 		srv.Stop()
 	})
@@ -75,6 +77,27 @@ func Test_CreateAd(t *testing.T) {
 	assert.ErrorIs(t, err, app.ErrApp)
 }
 
+func Test_CreateAdKeepsAdWithoutLang(t *testing.T) {
+	repo := &mocks.Repository{}
+	repo.On("Add", mock.AnythingOfType("*context.emptyCtx"),
+		mock.AnythingOfType("string"), mock.AnythingOfType("string"),
+		mock.AnythingOfType("int64")).
+		Return(int64(5), nil).Once()
+	repo.On("SetLang", mock.AnythingOfType("*context.emptyCtx"),
+		int64(5), "en", false).
+		Return(fmt.Errorf("set lang error")).Once()
+	repo.On("Find", mock.AnythingOfType("*context.emptyCtx"), int64(5)).
+		Return(ads.Ad{ID: 5, Title: "bicycle", Text: "an almost new bicycle for sale", AuthorID: 1}, true).Once()
+
+	a := app.NewApp(repo, customer.New(), adfilter.New())
+	ctx := context.Background()
+	_, _ = a.CreateUserByID(ctx, "first user", "example@mail.ru", 1)
+	ad, err := a.CreateAd(ctx, "bicycle", "an almost new bicycle for sale", int64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), ad.ID)
+	repo.AssertExpectations(t)
+}
+
 func Test_DeleteAd(t *testing.T) {
 	repo := &mocks.Repository{}
 	userId := int64(1)
@@ -117,6 +140,9 @@ func Test_UpdateAd(t *testing.T) {
 	repo.On("Find", mock.AnythingOfType("*context.emptyCtx"),
 		mock.AnythingOfType("int64")).
 		Return(ads.Ad{AuthorID: userId}, true)
+	// rollbacks put the ad back as it was
+	repo.On("Insert", mock.AnythingOfType("*context.emptyCtx"), ads.Ad{AuthorID: userId}).
+		Return(nil)
 	repo.On("SetText", mock.AnythingOfType("*context.emptyCtx"),
 		mock.AnythingOfType("int64"), mock.AnythingOfType("string")).
 		Return(fmt.Errorf("set text error")).Once()
@@ -138,6 +164,9 @@ func Test_UpdateAd(t *testing.T) {
 		Return(fmt.Errorf("set title error")).Once()
 	_, err = a.UpdateAd(ctx, 1, userId, "aba", "caba")
 	assert.ErrorIs(t, err, app.ErrApp)
+	repo.AssertNumberOfCalls(t, "SetText", 2)
+	repo.AssertNumberOfCalls(t, "SetTitle", 1)
+	repo.AssertNumberOfCalls(t, "Insert", 3)
 }
 
 func Test_ChangeUserInfo(t *testing.T) {
@@ -218,6 +247,10 @@ func Test_DeleteUserByID(t *testing.T) {
 	u.On("DeleteByID", mock.AnythingOfType("*context.emptyCtx"),
 		mock.AnythingOfType("int64")).
 		Return(user.User{}, fmt.Errorf("delete by id error")).Once()
+	u.On("CreateByID", mock.AnythingOfType("*context.emptyCtx"),
+		mock.AnythingOfType("string"), mock.AnythingOfType("string"),
+		mock.AnythingOfType("int64")).
+		Return(user.User{}, nil)
 
 	repo := &mocks.Repository{}
 	a := app.NewApp(repo, u, adfilter.New())
@@ -232,12 +265,23 @@ func Test_DeleteUserByID(t *testing.T) {
 	repo.On("DeleteByAuthor", mock.AnythingOfType("*context.emptyCtx"),
 		mock.AnythingOfType("int64")).
 		Return(fmt.Errorf("delete by author error")).Once()
+	repo.On("GetAllByTemplate", mock.AnythingOfType("*context.emptyCtx"),
+		mock.AnythingOfType("adpattern.AdPattern")).
+		Return([]ads.Ad{}, nil)
 	_, err = a.DeleteUserByID(ctx, userId)
 	assert.ErrorIs(t, err, app.ErrApp)
+	u.AssertNumberOfCalls(t, "CreateByID", 2)
+}
+
+// stubTenant lets the tenant middleware of the HTTP server pass requests to the default tenant.
+func stubTenant(a *mocks.App) {
+	a.On("ResolveTenant", mock.AnythingOfType("*gin.Context"), "", mock.AnythingOfType("string")).
+		Return(tenant.Default, nil)
 }
 
 func Test_BrokenApp(t *testing.T) {
 	testApp := mocks.App{}
+	stubTenant(&testApp)
 	testApp.On("CreateAd", mock.AnythingOfType("*gin.Context"),
 		mock.AnythingOfType("string"), mock.AnythingOfType("string"),
 		mock.AnythingOfType("int64")).
@@ -258,6 +302,7 @@ func Test_BrokenApp(t *testing.T) {
 
 func Test_changeUserInfo(t *testing.T) {
 	testApp := mocks.App{}
+	stubTenant(&testApp)
 	testApp.On("FindUser", mock.AnythingOfType("*gin.Context"),
 		mock.AnythingOfType("int64")).
 		Return(user.User{}, false, app.ErrApp).Once()
@@ -296,6 +341,7 @@ func Test_changeUserInfo(t *testing.T) {
 
 func Test_listAds(t *testing.T) {
 	testApp := mocks.App{}
+	stubTenant(&testApp)
 	f := mocks.Filter{}
 	f.On("BasicConfig", mock.AnythingOfType("*gin.Context")).
 		Return(adfilter.New(), app.ErrApp).Once()
@@ -324,6 +370,7 @@ func Test_listAds(t *testing.T) {
 
 func Test_changeAdStatus(t *testing.T) {
 	testApp := mocks.App{}
+	stubTenant(&testApp)
 	testApp.On("FindAd", mock.AnythingOfType("*gin.Context"),
 		mock.AnythingOfType("int64")).
 		Return(ads.Ad{}, app.ErrApp).Once()
@@ -362,6 +409,7 @@ func Test_changeAdStatus(t *testing.T) {
 
 func Test_getAdByID(t *testing.T) {
 	testApp := mocks.App{}
+	stubTenant(&testApp)
 	testApp.On("FindAd", mock.AnythingOfType("*gin.Context"),
 		mock.AnythingOfType("int64")).
 		Return(ads.Ad{}, app.ErrApp).Once()
@@ -373,6 +421,7 @@ func Test_getAdByID(t *testing.T) {
 
 func Test_createUser(t *testing.T) {
 	testApp := mocks.App{}
+	stubTenant(&testApp)
 	testApp.On("CreateUserByID", mock.AnythingOfType("*gin.Context"),
 		mock.AnythingOfType("string"), mock.AnythingOfType("string"),
 		mock.AnythingOfType("int64")).
@@ -385,6 +434,7 @@ func Test_createUser(t *testing.T) {
 
 func Test_deleteUserByID(t *testing.T) {
 	testApp := mocks.App{}
+	stubTenant(&testApp)
 	testApp.On("DeleteUserByID", mock.AnythingOfType("*gin.Context"),
 		mock.AnythingOfType("int64")).
 		Return(user.User{}, app.ErrApp).Once()
@@ -396,6 +446,7 @@ func Test_deleteUserByID(t *testing.T) {
 
 func Test_getUserByID(t *testing.T) {
 	testApp := mocks.App{}
+	stubTenant(&testApp)
 	testApp.On("FindUser", mock.AnythingOfType("*gin.Context"),
 		mock.AnythingOfType("int64")).
 		Return(user.User{}, false, app.ErrApp).Once()
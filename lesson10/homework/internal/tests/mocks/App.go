@@ -6,13 +6,33 @@ import (
 	adpattern "homework10/internal/adpattern"
 	ads "homework10/internal/ads"
 
+	analytics "homework10/internal/analytics"
+
 	app "homework10/internal/app"
 
+	audit "homework10/internal/audit"
+
+	bulk "homework10/internal/bulk"
+
 	context "context"
 
+	message "homework10/internal/message"
+
 	mock "github.com/stretchr/testify/mock"
 
+	report "homework10/internal/report"
+
+	search "homework10/internal/search"
+
+	session "homework10/internal/session"
+
+	tenant "homework10/internal/tenant"
+
+	time "time"
+
 	user "homework10/internal/user"
+
+	webhook "homework10/internal/webhook"
 )
 
 // App is an autogenerated mock type for the App type
@@ -20,6 +40,78 @@ type App struct {
 	mock.Mock
 }
 
+// AddFavorite provides a mock function with given fields: ctx, userID, adID
+func (_m *App) AddFavorite(ctx context.Context, userID int64, adID int64) (ads.Ad, error) {
+	ret := _m.Called(ctx, userID, adID)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (ads.Ad, error)); ok {
+		return rf(ctx, userID, adID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) ads.Ad); ok {
+		r0 = rf(ctx, userID, adID)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, adID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Authenticate provides a mock function with given fields: ctx, token
+func (_m *App) Authenticate(ctx context.Context, token string) (user.User, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (user.User, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) user.User); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(user.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BlockUser provides a mock function with given fields: ctx, userID, blockedID
+func (_m *App) BlockUser(ctx context.Context, userID int64, blockedID int64) (user.User, error) {
+	ret := _m.Called(ctx, userID, blockedID)
+
+	var r0 user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (user.User, error)); ok {
+		return rf(ctx, userID, blockedID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) user.User); ok {
+		r0 = rf(ctx, userID, blockedID)
+	} else {
+		r0 = ret.Get(0).(user.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, blockedID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ChangeAdStatus provides a mock function with given fields: ctx, adID, userID, published
 func (_m *App) ChangeAdStatus(ctx context.Context, adID int64, userID int64, published bool) (ads.Ad, error) {
 	ret := _m.Called(ctx, adID, userID, published)
@@ -116,6 +208,30 @@ func (_m *App) CreateUserByID(ctx context.Context, nickname string, email string
 	return r0, r1
 }
 
+// CreateWebhook provides a mock function with given fields: ctx, url, events, adp, secret
+func (_m *App) CreateWebhook(ctx context.Context, url string, events []webhook.Event, adp adpattern.AdPattern, secret string) (webhook.Subscription, error) {
+	ret := _m.Called(ctx, url, events, adp, secret)
+
+	var r0 webhook.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []webhook.Event, adpattern.AdPattern, string) (webhook.Subscription, error)); ok {
+		return rf(ctx, url, events, adp, secret)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []webhook.Event, adpattern.AdPattern, string) webhook.Subscription); ok {
+		r0 = rf(ctx, url, events, adp, secret)
+	} else {
+		r0 = ret.Get(0).(webhook.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []webhook.Event, adpattern.AdPattern, string) error); ok {
+		r1 = rf(ctx, url, events, adp, secret)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteAd provides a mock function with given fields: ctx, adID, userID
 func (_m *App) DeleteAd(ctx context.Context, adID int64, userID int64) (ads.Ad, error) {
 	ret := _m.Called(ctx, adID, userID)
@@ -140,6 +256,30 @@ func (_m *App) DeleteAd(ctx context.Context, adID int64, userID int64) (ads.Ad,
 	return r0, r1
 }
 
+// DeleteSavedSearch provides a mock function with given fields: ctx, userID, searchID
+func (_m *App) DeleteSavedSearch(ctx context.Context, userID int64, searchID int64) (search.SavedSearch, error) {
+	ret := _m.Called(ctx, userID, searchID)
+
+	var r0 search.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (search.SavedSearch, error)); ok {
+		return rf(ctx, userID, searchID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) search.SavedSearch); ok {
+		r0 = rf(ctx, userID, searchID)
+	} else {
+		r0 = ret.Get(0).(search.SavedSearch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, searchID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteUserByID provides a mock function with given fields: ctx, userID
 func (_m *App) DeleteUserByID(ctx context.Context, userID int64) (user.User, error) {
 	ret := _m.Called(ctx, userID)
@@ -164,6 +304,68 @@ func (_m *App) DeleteUserByID(ctx context.Context, userID int64) (user.User, err
 	return r0, r1
 }
 
+// DeleteWebhook provides a mock function with given fields: ctx, webhookID
+func (_m *App) DeleteWebhook(ctx context.Context, webhookID int64) (webhook.Subscription, error) {
+	ret := _m.Called(ctx, webhookID)
+
+	var r0 webhook.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (webhook.Subscription, error)); ok {
+		return rf(ctx, webhookID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) webhook.Subscription); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Get(0).(webhook.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, webhookID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DismissReport provides a mock function with given fields: ctx, reportID
+func (_m *App) DismissReport(ctx context.Context, reportID int64) (report.Report, error) {
+	ret := _m.Called(ctx, reportID)
+
+	var r0 report.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (report.Report, error)); ok {
+		return rf(ctx, reportID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) report.Report); ok {
+		r0 = rf(ctx, reportID)
+	} else {
+		r0 = ret.Get(0).(report.Report)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, reportID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportAds provides a mock function with given fields: ctx, adp, fn
+func (_m *App) ExportAds(ctx context.Context, adp adpattern.AdPattern, fn func(bulk.Record) error) error {
+	ret := _m.Called(ctx, adp, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, adpattern.AdPattern, func(bulk.Record) error) error); ok {
+		r0 = rf(ctx, adp, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // FindAd provides a mock function with given fields: ctx, adID
 func (_m *App) FindAd(ctx context.Context, adID int64) (ads.Ad, error) {
 	ret := _m.Called(ctx, adID)
@@ -219,6 +421,32 @@ func (_m *App) FindUser(ctx context.Context, userID int64) (user.User, bool, err
 	return r0, r1, r2
 }
 
+// FindUsers provides a mock function with given fields: ctx, userIDs
+func (_m *App) FindUsers(ctx context.Context, userIDs []int64) (map[int64]user.User, error) {
+	ret := _m.Called(ctx, userIDs)
+
+	var r0 map[int64]user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) (map[int64]user.User, error)); ok {
+		return rf(ctx, userIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) map[int64]user.User); ok {
+		r0 = rf(ctx, userIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64]user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64) error); ok {
+		r1 = rf(ctx, userIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAdsByTitle provides a mock function with given fields: ctx, title
 func (_m *App) GetAdsByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
 	ret := _m.Called(ctx, title)
@@ -271,6 +499,32 @@ func (_m *App) GetAllAdsByTemplate(ctx context.Context, adp adpattern.AdPattern)
 	return r0, r1
 }
 
+// GetMessages provides a mock function with given fields: ctx, threadID, userID, beforeID, limit
+func (_m *App) GetMessages(ctx context.Context, threadID int64, userID int64, beforeID int64, limit int) ([]message.Message, error) {
+	ret := _m.Called(ctx, threadID, userID, beforeID, limit)
+
+	var r0 []message.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64, int) ([]message.Message, error)); ok {
+		return rf(ctx, threadID, userID, beforeID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64, int) []message.Message); ok {
+		r0 = rf(ctx, threadID, userID, beforeID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]message.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64, int) error); ok {
+		r1 = rf(ctx, threadID, userID, beforeID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNewFilter provides a mock function with given fields: ctx
 func (_m *App) GetNewFilter(ctx context.Context) (app.Filter, error) {
 	ret := _m.Called(ctx)
@@ -297,23 +551,720 @@ func (_m *App) GetNewFilter(ctx context.Context) (app.Filter, error) {
 	return r0, r1
 }
 
-// UpdateAd provides a mock function with given fields: ctx, adID, userID, title, text
-func (_m *App) UpdateAd(ctx context.Context, adID int64, userID int64, title string, text string) (ads.Ad, error) {
-	ret := _m.Called(ctx, adID, userID, title, text)
+// GetUserStats provides a mock function with given fields: ctx, userID, from, to, step
+func (_m *App) GetUserStats(ctx context.Context, userID int64, from time.Time, to time.Time, step time.Duration) (analytics.Report, error) {
+	ret := _m.Called(ctx, userID, from, to, step)
 
-	var r0 ads.Ad
+	var r0 analytics.Report
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) (ads.Ad, error)); ok {
-		return rf(ctx, adID, userID, title, text)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time, time.Time, time.Duration) (analytics.Report, error)); ok {
+		return rf(ctx, userID, from, to, step)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) ads.Ad); ok {
-		r0 = rf(ctx, adID, userID, title, text)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time, time.Time, time.Duration) analytics.Report); ok {
+		r0 = rf(ctx, userID, from, to, step)
+	} else {
+		r0 = ret.Get(0).(analytics.Report)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time, time.Time, time.Duration) error); ok {
+		r1 = rf(ctx, userID, from, to, step)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportAd provides a mock function with given fields: ctx, rec, dryRun
+func (_m *App) ImportAd(ctx context.Context, rec bulk.Record, dryRun bool) (ads.Ad, bulk.Outcome, error) {
+	ret := _m.Called(ctx, rec, dryRun)
+
+	var r0 ads.Ad
+	var r1 bulk.Outcome
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, bulk.Record, bool) (ads.Ad, bulk.Outcome, error)); ok {
+		return rf(ctx, rec, dryRun)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bulk.Record, bool) ads.Ad); ok {
+		r0 = rf(ctx, rec, dryRun)
 	} else {
 		r0 = ret.Get(0).(ads.Ad)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
-		r1 = rf(ctx, adID, userID, title, text)
+	if rf, ok := ret.Get(1).(func(context.Context, bulk.Record, bool) bulk.Outcome); ok {
+		r1 = rf(ctx, rec, dryRun)
+	} else {
+		r1 = ret.Get(1).(bulk.Outcome)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, bulk.Record, bool) error); ok {
+		r2 = rf(ctx, rec, dryRun)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListAudit provides a mock function with given fields: ctx, target
+func (_m *App) ListAudit(ctx context.Context, target audit.Target) ([]audit.Entry, error) {
+	ret := _m.Called(ctx, target)
+
+	var r0 []audit.Entry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Target) ([]audit.Entry, error)); ok {
+		return rf(ctx, target)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Target) []audit.Entry); ok {
+		r0 = rf(ctx, target)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]audit.Entry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, audit.Target) error); ok {
+		r1 = rf(ctx, target)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListFavorites provides a mock function with given fields: ctx, userID
+func (_m *App) ListFavorites(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]ads.Ad, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []ads.Ad); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListReports provides a mock function with given fields: ctx, status
+func (_m *App) ListReports(ctx context.Context, status report.Status) ([]report.Report, error) {
+	ret := _m.Called(ctx, status)
+
+	var r0 []report.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, report.Status) ([]report.Report, error)); ok {
+		return rf(ctx, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, report.Status) []report.Report); ok {
+		r0 = rf(ctx, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]report.Report)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, report.Status) error); ok {
+		r1 = rf(ctx, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSavedSearches provides a mock function with given fields: ctx, userID
+func (_m *App) ListSavedSearches(ctx context.Context, userID int64) ([]search.SavedSearch, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []search.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]search.SavedSearch, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []search.SavedSearch); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]search.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListThreads provides a mock function with given fields: ctx, userID
+func (_m *App) ListThreads(ctx context.Context, userID int64) ([]message.Thread, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []message.Thread
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]message.Thread, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []message.Thread); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]message.Thread)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTrash provides a mock function with given fields: ctx, userID
+func (_m *App) ListTrash(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]ads.Ad, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []ads.Ad); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookDeliveries provides a mock function with given fields: ctx, webhookID, status
+func (_m *App) ListWebhookDeliveries(ctx context.Context, webhookID int64, status webhook.Status) ([]webhook.Delivery, error) {
+	ret := _m.Called(ctx, webhookID, status)
+
+	var r0 []webhook.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, webhook.Status) ([]webhook.Delivery, error)); ok {
+		return rf(ctx, webhookID, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, webhook.Status) []webhook.Delivery); ok {
+		r0 = rf(ctx, webhookID, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, webhook.Status) error); ok {
+		r1 = rf(ctx, webhookID, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhooks provides a mock function with given fields: ctx
+func (_m *App) ListWebhooks(ctx context.Context) ([]webhook.Subscription, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []webhook.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]webhook.Subscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []webhook.Subscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Login provides a mock function with given fields: ctx, email, password
+func (_m *App) Login(ctx context.Context, email string, password string) (session.Session, error) {
+	ret := _m.Called(ctx, email, password)
+
+	var r0 session.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (session.Session, error)); ok {
+		return rf(ctx, email, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) session.Session); ok {
+		r0 = rf(ctx, email, password)
+	} else {
+		r0 = ret.Get(0).(session.Session)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRead provides a mock function with given fields: ctx, threadID, userID, upToID
+func (_m *App) MarkRead(ctx context.Context, threadID int64, userID int64, upToID int64) (int, error) {
+	ret := _m.Called(ctx, threadID, userID, upToID)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) (int, error)); ok {
+		return rf(ctx, threadID, userID, upToID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) int); ok {
+		r0 = rf(ctx, threadID, userID, upToID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, threadID, userID, upToID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OpenThread provides a mock function with given fields: ctx, adID, buyerID
+func (_m *App) OpenThread(ctx context.Context, adID int64, buyerID int64) (message.Thread, error) {
+	ret := _m.Called(ctx, adID, buyerID)
+
+	var r0 message.Thread
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (message.Thread, error)); ok {
+		return rf(ctx, adID, buyerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) message.Thread); ok {
+		r0 = rf(ctx, adID, buyerID)
+	} else {
+		r0 = ret.Get(0).(message.Thread)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adID, buyerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeTrash provides a mock function with given fields: ctx
+func (_m *App) PurgeTrash(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RedeliverWebhook provides a mock function with given fields: ctx, deliveryID
+func (_m *App) RedeliverWebhook(ctx context.Context, deliveryID int64) (webhook.Delivery, error) {
+	ret := _m.Called(ctx, deliveryID)
+
+	var r0 webhook.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (webhook.Delivery, error)); ok {
+		return rf(ctx, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) webhook.Delivery); ok {
+		r0 = rf(ctx, deliveryID)
+	} else {
+		r0 = ret.Get(0).(webhook.Delivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, deliveryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, nickname, email, password
+func (_m *App) Register(ctx context.Context, nickname string, email string, password string) (user.User, error) {
+	ret := _m.Called(ctx, nickname, email, password)
+
+	var r0 user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (user.User, error)); ok {
+		return rf(ctx, nickname, email, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) user.User); ok {
+		r0 = rf(ctx, nickname, email, password)
+	} else {
+		r0 = ret.Get(0).(user.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, nickname, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFavorite provides a mock function with given fields: ctx, userID, adID
+func (_m *App) RemoveFavorite(ctx context.Context, userID int64, adID int64) (ads.Ad, error) {
+	ret := _m.Called(ctx, userID, adID)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (ads.Ad, error)); ok {
+		return rf(ctx, userID, adID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) ads.Ad); ok {
+		r0 = rf(ctx, userID, adID)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, adID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReportAd provides a mock function with given fields: ctx, adID, reporterID, reason, comment
+func (_m *App) ReportAd(ctx context.Context, adID int64, reporterID int64, reason report.Reason, comment string) (report.Report, error) {
+	ret := _m.Called(ctx, adID, reporterID, reason, comment)
+
+	var r0 report.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, report.Reason, string) (report.Report, error)); ok {
+		return rf(ctx, adID, reporterID, reason, comment)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, report.Reason, string) report.Report); ok {
+		r0 = rf(ctx, adID, reporterID, reason, comment)
+	} else {
+		r0 = ret.Get(0).(report.Report)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, report.Reason, string) error); ok {
+		r1 = rf(ctx, adID, reporterID, reason, comment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RequestVerification provides a mock function with given fields: ctx, userID
+func (_m *App) RequestVerification(ctx context.Context, userID int64) (user.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (user.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) user.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(user.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolveReport provides a mock function with given fields: ctx, reportID
+func (_m *App) ResolveReport(ctx context.Context, reportID int64) (report.Report, error) {
+	ret := _m.Called(ctx, reportID)
+
+	var r0 report.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (report.Report, error)); ok {
+		return rf(ctx, reportID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) report.Report); ok {
+		r0 = rf(ctx, reportID)
+	} else {
+		r0 = ret.Get(0).(report.Report)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, reportID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolveTenant provides a mock function with given fields: ctx, requested, host
+func (_m *App) ResolveTenant(ctx context.Context, requested string, host string) (tenant.ID, error) {
+	ret := _m.Called(ctx, requested, host)
+
+	var r0 tenant.ID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (tenant.ID, error)); ok {
+		return rf(ctx, requested, host)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) tenant.ID); ok {
+		r0 = rf(ctx, requested, host)
+	} else {
+		r0 = ret.Get(0).(tenant.ID)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, requested, host)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreAd provides a mock function with given fields: ctx, adID, userID
+func (_m *App) RestoreAd(ctx context.Context, adID int64, userID int64) (ads.Ad, error) {
+	ret := _m.Called(ctx, adID, userID)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (ads.Ad, error)); ok {
+		return rf(ctx, adID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) ads.Ad); ok {
+		r0 = rf(ctx, adID, userID)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveSearch provides a mock function with given fields: ctx, userID, name, adp
+func (_m *App) SaveSearch(ctx context.Context, userID int64, name string, adp adpattern.AdPattern) (search.SavedSearch, error) {
+	ret := _m.Called(ctx, userID, name, adp)
+
+	var r0 search.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, adpattern.AdPattern) (search.SavedSearch, error)); ok {
+		return rf(ctx, userID, name, adp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, adpattern.AdPattern) search.SavedSearch); ok {
+		r0 = rf(ctx, userID, name, adp)
+	} else {
+		r0 = ret.Get(0).(search.SavedSearch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, adpattern.AdPattern) error); ok {
+		r1 = rf(ctx, userID, name, adp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendMessage provides a mock function with given fields: ctx, threadID, senderID, text
+func (_m *App) SendMessage(ctx context.Context, threadID int64, senderID int64, text string) (message.Message, error) {
+	ret := _m.Called(ctx, threadID, senderID, text)
+
+	var r0 message.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) (message.Message, error)); ok {
+		return rf(ctx, threadID, senderID, text)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) message.Message); ok {
+		r0 = rf(ctx, threadID, senderID, text)
+	} else {
+		r0 = ret.Get(0).(message.Message)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, threadID, senderID, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetAdLanguage provides a mock function with given fields: ctx, adID, userID, lang
+func (_m *App) SetAdLanguage(ctx context.Context, adID int64, userID int64, lang string) (ads.Ad, error) {
+	ret := _m.Called(ctx, adID, userID, lang)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) (ads.Ad, error)); ok {
+		return rf(ctx, adID, userID, lang)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) ads.Ad); ok {
+		r0 = rf(ctx, adID, userID, lang)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, adID, userID, lang)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeMessages provides a mock function with given fields: ctx, userID
+func (_m *App) SubscribeMessages(ctx context.Context, userID int64) (<-chan message.Event, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 <-chan message.Event
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (<-chan message.Event, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) <-chan message.Event); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan message.Event)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnblockUser provides a mock function with given fields: ctx, userID, blockedID
+func (_m *App) UnblockUser(ctx context.Context, userID int64, blockedID int64) (user.User, error) {
+	ret := _m.Called(ctx, userID, blockedID)
+
+	var r0 user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (user.User, error)); ok {
+		return rf(ctx, userID, blockedID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) user.User); ok {
+		r0 = rf(ctx, userID, blockedID)
+	} else {
+		r0 = ret.Get(0).(user.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, blockedID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAd provides a mock function with given fields: ctx, adID, userID, title, text
+func (_m *App) UpdateAd(ctx context.Context, adID int64, userID int64, title string, text string) (ads.Ad, error) {
+	ret := _m.Called(ctx, adID, userID, title, text)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) (ads.Ad, error)); ok {
+		return rf(ctx, adID, userID, title, text)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) ads.Ad); ok {
+		r0 = rf(ctx, adID, userID, title, text)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
+		r1 = rf(ctx, adID, userID, title, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyEmail provides a mock function with given fields: ctx, userID, token
+func (_m *App) VerifyEmail(ctx context.Context, userID int64, token string) (user.User, error) {
+	ret := _m.Called(ctx, userID, token)
+
+	var r0 user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (user.User, error)); ok {
+		return rf(ctx, userID, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) user.User); ok {
+		r0 = rf(ctx, userID, token)
+	} else {
+		r0 = ret.Get(0).(user.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, userID, token)
 	} else {
 		r1 = ret.Error(1)
 	}
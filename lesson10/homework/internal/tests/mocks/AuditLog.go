@@ -0,0 +1,80 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	audit "homework10/internal/audit"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditLog is an autogenerated mock type for the AuditLog type
+type AuditLog struct {
+	mock.Mock
+}
+
+// Append provides a mock function with given fields: ctx, e
+func (_m *AuditLog) Append(ctx context.Context, e audit.Entry) (audit.Entry, error) {
+	ret := _m.Called(ctx, e)
+
+	var r0 audit.Entry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Entry) (audit.Entry, error)); ok {
+		return rf(ctx, e)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Entry) audit.Entry); ok {
+		r0 = rf(ctx, e)
+	} else {
+		r0 = ret.Get(0).(audit.Entry)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, audit.Entry) error); ok {
+		r1 = rf(ctx, e)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, target
+func (_m *AuditLog) List(ctx context.Context, target audit.Target) ([]audit.Entry, error) {
+	ret := _m.Called(ctx, target)
+
+	var r0 []audit.Entry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Target) ([]audit.Entry, error)); ok {
+		return rf(ctx, target)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Target) []audit.Entry); ok {
+		r0 = rf(ctx, target)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]audit.Entry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, audit.Target) error); ok {
+		r1 = rf(ctx, target)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewAuditLog interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAuditLog creates a new instance of AuditLog. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAuditLog(t mockConstructorTestingTNewAuditLog) *AuditLog {
+	mock := &AuditLog{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
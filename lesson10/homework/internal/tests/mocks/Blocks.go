@@ -0,0 +1,95 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Blocks is an autogenerated mock type for the Blocks type
+type Blocks struct {
+	mock.Mock
+}
+
+// Block provides a mock function with given fields: ctx, userID, blockedID
+func (_m *Blocks) Block(ctx context.Context, userID int64, blockedID int64) error {
+	ret := _m.Called(ctx, userID, blockedID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, blockedID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUser provides a mock function with given fields: ctx, userID
+func (_m *Blocks) DeleteByUser(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// IsBlocked provides a mock function with given fields: ctx, first, second
+func (_m *Blocks) IsBlocked(ctx context.Context, first int64, second int64) (bool, error) {
+	ret := _m.Called(ctx, first, second)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (bool, error)); ok {
+		return rf(ctx, first, second)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) bool); ok {
+		r0 = rf(ctx, first, second)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, first, second)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unblock provides a mock function with given fields: ctx, userID, blockedID
+func (_m *Blocks) Unblock(ctx context.Context, userID int64, blockedID int64) error {
+	ret := _m.Called(ctx, userID, blockedID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, blockedID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewBlocks interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewBlocks creates a new instance of Blocks. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewBlocks(t mockConstructorTestingTNewBlocks) *Blocks {
+	mock := &Blocks{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,54 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	policy "homework10/internal/policy"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ContentPolicy is an autogenerated mock type for the ContentPolicy type
+type ContentPolicy struct {
+	mock.Mock
+}
+
+// Check provides a mock function with given fields: ctx, c
+func (_m *ContentPolicy) Check(ctx context.Context, c policy.Content) (policy.Verdict, error) {
+	ret := _m.Called(ctx, c)
+
+	var r0 policy.Verdict
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, policy.Content) (policy.Verdict, error)); ok {
+		return rf(ctx, c)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, policy.Content) policy.Verdict); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Get(0).(policy.Verdict)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, policy.Content) error); ok {
+		r1 = rf(ctx, c)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewContentPolicy interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewContentPolicy creates a new instance of ContentPolicy. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewContentPolicy(t mockConstructorTestingTNewContentPolicy) *ContentPolicy {
+	mock := &ContentPolicy{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Credentials is an autogenerated mock type for the Credentials type
+type Credentials struct {
+	mock.Mock
+}
+
+// DeleteByUser provides a mock function with given fields: ctx, userID
+func (_m *Credentials) DeleteByUser(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: ctx, userID
+func (_m *Credentials) Get(ctx context.Context, userID int64) ([]byte, bool) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []byte
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]byte, bool)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []byte); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Set provides a mock function with given fields: ctx, userID, hash
+func (_m *Credentials) Set(ctx context.Context, userID int64, hash []byte) error {
+	ret := _m.Called(ctx, userID, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []byte) error); ok {
+		r0 = rf(ctx, userID, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewCredentials interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewCredentials creates a new instance of Credentials. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewCredentials(t mockConstructorTestingTNewCredentials) *Credentials {
+	mock := &Credentials{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,105 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ExternalIDs is an autogenerated mock type for the ExternalIDs type
+type ExternalIDs struct {
+	mock.Mock
+}
+
+// DeleteByAd provides a mock function with given fields: ctx, adID
+func (_m *ExternalIDs) DeleteByAd(ctx context.Context, adID int64) error {
+	ret := _m.Called(ctx, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Find provides a mock function with given fields: ctx, externalID
+func (_m *ExternalIDs) Find(ctx context.Context, externalID string) (int64, bool) {
+	ret := _m.Called(ctx, externalID)
+
+	var r0 int64
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, bool)); ok {
+		return rf(ctx, externalID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, externalID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, externalID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// FindByAd provides a mock function with given fields: ctx, adID
+func (_m *ExternalIDs) FindByAd(ctx context.Context, adID int64) (string, bool) {
+	ret := _m.Called(ctx, adID)
+
+	var r0 string
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (string, bool)); ok {
+		return rf(ctx, adID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) string); ok {
+		r0 = rf(ctx, adID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(ctx, adID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Set provides a mock function with given fields: ctx, externalID, adID
+func (_m *ExternalIDs) Set(ctx context.Context, externalID string, adID int64) error {
+	ret := _m.Called(ctx, externalID, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, externalID, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewExternalIDs interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewExternalIDs creates a new instance of ExternalIDs. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewExternalIDs(t mockConstructorTestingTNewExternalIDs) *ExternalIDs {
+	mock := &ExternalIDs{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
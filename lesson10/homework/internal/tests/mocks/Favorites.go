@@ -0,0 +1,97 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Favorites is an autogenerated mock type for the Favorites type
+type Favorites struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, userID, adID
+func (_m *Favorites) Add(ctx context.Context, userID int64, adID int64) error {
+	ret := _m.Called(ctx, userID, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUser provides a mock function with given fields: ctx, userID
+func (_m *Favorites) DeleteByUser(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// List provides a mock function with given fields: ctx, userID
+func (_m *Favorites) List(ctx context.Context, userID int64) ([]int64, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]int64, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []int64); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Remove provides a mock function with given fields: ctx, userID, adID
+func (_m *Favorites) Remove(ctx context.Context, userID int64, adID int64) error {
+	ret := _m.Called(ctx, userID, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewFavorites interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewFavorites creates a new instance of Favorites. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewFavorites(t mockConstructorTestingTNewFavorites) *Favorites {
+	mock := &Favorites{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
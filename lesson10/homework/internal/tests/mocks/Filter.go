@@ -3,7 +3,9 @@
 package mocks
 
 import (
+	adexpr "homework10/internal/adexpr"
 	adpattern "homework10/internal/adpattern"
+
 	app "homework10/internal/app"
 
 	context "context"
@@ -94,6 +96,32 @@ func (_m *Filter) SetAuthor(ctx context.Context, userID int64) (app.Filter, erro
 	return r0, r1
 }
 
+// SetExpr provides a mock function with given fields: ctx, expr
+func (_m *Filter) SetExpr(ctx context.Context, expr adexpr.Expr) (app.Filter, error) {
+	ret := _m.Called(ctx, expr)
+
+	var r0 app.Filter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, adexpr.Expr) (app.Filter, error)); ok {
+		return rf(ctx, expr)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, adexpr.Expr) app.Filter); ok {
+		r0 = rf(ctx, expr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(app.Filter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, adexpr.Expr) error); ok {
+		r1 = rf(ctx, expr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetLTime provides a mock function with given fields: ctx, l
 func (_m *Filter) SetLTime(ctx context.Context, l time.Time) (app.Filter, error) {
 	ret := _m.Called(ctx, l)
@@ -120,6 +148,32 @@ func (_m *Filter) SetLTime(ctx context.Context, l time.Time) (app.Filter, error)
 	return r0, r1
 }
 
+// SetLang provides a mock function with given fields: ctx, lang
+func (_m *Filter) SetLang(ctx context.Context, lang string) (app.Filter, error) {
+	ret := _m.Called(ctx, lang)
+
+	var r0 app.Filter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (app.Filter, error)); ok {
+		return rf(ctx, lang)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) app.Filter); ok {
+		r0 = rf(ctx, lang)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(app.Filter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, lang)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetRTime provides a mock function with given fields: ctx, r
 func (_m *Filter) SetRTime(ctx context.Context, r time.Time) (app.Filter, error) {
 	ret := _m.Called(ctx, r)
@@ -146,6 +200,32 @@ func (_m *Filter) SetRTime(ctx context.Context, r time.Time) (app.Filter, error)
 	return r0, r1
 }
 
+// SetSort provides a mock function with given fields: ctx, order
+func (_m *Filter) SetSort(ctx context.Context, order []adpattern.OrderBy) (app.Filter, error) {
+	ret := _m.Called(ctx, order)
+
+	var r0 app.Filter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []adpattern.OrderBy) (app.Filter, error)); ok {
+		return rf(ctx, order)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []adpattern.OrderBy) app.Filter); ok {
+		r0 = rf(ctx, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(app.Filter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []adpattern.OrderBy) error); ok {
+		r1 = rf(ctx, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetStatus provides a mock function with given fields: ctx, publishedOnly
 func (_m *Filter) SetStatus(ctx context.Context, publishedOnly bool) (app.Filter, error) {
 	ret := _m.Called(ctx, publishedOnly)
@@ -0,0 +1,49 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// IDGenerator is an autogenerated mock type for the IDGenerator type
+type IDGenerator struct {
+	mock.Mock
+}
+
+// NextID provides a mock function with given fields:
+func (_m *IDGenerator) NextID() (int64, error) {
+	ret := _m.Called()
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (int64, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewIDGenerator interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewIDGenerator creates a new instance of IDGenerator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewIDGenerator(t mockConstructorTestingTNewIDGenerator) *IDGenerator {
+	mock := &IDGenerator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,89 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	idempotency "homework10/internal/idempotency"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IdempotencyKeys is an autogenerated mock type for the IdempotencyKeys type
+type IdempotencyKeys struct {
+	mock.Mock
+}
+
+// Complete provides a mock function with given fields: ctx, key, response
+func (_m *IdempotencyKeys) Complete(ctx context.Context, key idempotency.Key, response []byte) error {
+	ret := _m.Called(ctx, key, response)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, idempotency.Key, []byte) error); ok {
+		r0 = rf(ctx, key, response)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Release provides a mock function with given fields: ctx, key
+func (_m *IdempotencyKeys) Release(ctx context.Context, key idempotency.Key) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, idempotency.Key) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reserve provides a mock function with given fields: ctx, rec
+func (_m *IdempotencyKeys) Reserve(ctx context.Context, rec idempotency.Record) (idempotency.Record, bool, error) {
+	ret := _m.Called(ctx, rec)
+
+	var r0 idempotency.Record
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, idempotency.Record) (idempotency.Record, bool, error)); ok {
+		return rf(ctx, rec)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, idempotency.Record) idempotency.Record); ok {
+		r0 = rf(ctx, rec)
+	} else {
+		r0 = ret.Get(0).(idempotency.Record)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, idempotency.Record) bool); ok {
+		r1 = rf(ctx, rec)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, idempotency.Record) error); ok {
+		r2 = rf(ctx, rec)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+type mockConstructorTestingTNewIdempotencyKeys interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewIdempotencyKeys creates a new instance of IdempotencyKeys. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewIdempotencyKeys(t mockConstructorTestingTNewIdempotencyKeys) *IdempotencyKeys {
+	mock := &IdempotencyKeys{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
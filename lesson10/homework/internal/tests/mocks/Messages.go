@@ -0,0 +1,230 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	message "homework10/internal/message"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Messages is an autogenerated mock type for the Messages type
+type Messages struct {
+	mock.Mock
+}
+
+// AddMessage provides a mock function with given fields: ctx, threadID, senderID, text
+func (_m *Messages) AddMessage(ctx context.Context, threadID int64, senderID int64, text string) (message.Message, error) {
+	ret := _m.Called(ctx, threadID, senderID, text)
+
+	var r0 message.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) (message.Message, error)); ok {
+		return rf(ctx, threadID, senderID, text)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) message.Message); ok {
+		r0 = rf(ctx, threadID, senderID, text)
+	} else {
+		r0 = ret.Get(0).(message.Message)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, threadID, senderID, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateThread provides a mock function with given fields: ctx, adID, buyerID, sellerID
+func (_m *Messages) CreateThread(ctx context.Context, adID int64, buyerID int64, sellerID int64) (message.Thread, error) {
+	ret := _m.Called(ctx, adID, buyerID, sellerID)
+
+	var r0 message.Thread
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) (message.Thread, error)); ok {
+		return rf(ctx, adID, buyerID, sellerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) message.Thread); ok {
+		r0 = rf(ctx, adID, buyerID, sellerID)
+	} else {
+		r0 = ret.Get(0).(message.Thread)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, adID, buyerID, sellerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteByAd provides a mock function with given fields: ctx, adID
+func (_m *Messages) DeleteByAd(ctx context.Context, adID int64) error {
+	ret := _m.Called(ctx, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUser provides a mock function with given fields: ctx, userID
+func (_m *Messages) DeleteByUser(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindThread provides a mock function with given fields: ctx, threadID
+func (_m *Messages) FindThread(ctx context.Context, threadID int64) (message.Thread, bool) {
+	ret := _m.Called(ctx, threadID)
+
+	var r0 message.Thread
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (message.Thread, bool)); ok {
+		return rf(ctx, threadID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) message.Thread); ok {
+		r0 = rf(ctx, threadID)
+	} else {
+		r0 = ret.Get(0).(message.Thread)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(ctx, threadID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// FindThreadByAd provides a mock function with given fields: ctx, adID, buyerID
+func (_m *Messages) FindThreadByAd(ctx context.Context, adID int64, buyerID int64) (message.Thread, bool) {
+	ret := _m.Called(ctx, adID, buyerID)
+
+	var r0 message.Thread
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (message.Thread, bool)); ok {
+		return rf(ctx, adID, buyerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) message.Thread); ok {
+		r0 = rf(ctx, adID, buyerID)
+	} else {
+		r0 = ret.Get(0).(message.Thread)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) bool); ok {
+		r1 = rf(ctx, adID, buyerID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// ListMessages provides a mock function with given fields: ctx, threadID, beforeID, limit
+func (_m *Messages) ListMessages(ctx context.Context, threadID int64, beforeID int64, limit int) ([]message.Message, error) {
+	ret := _m.Called(ctx, threadID, beforeID, limit)
+
+	var r0 []message.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) ([]message.Message, error)); ok {
+		return rf(ctx, threadID, beforeID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) []message.Message); ok {
+		r0 = rf(ctx, threadID, beforeID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]message.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int) error); ok {
+		r1 = rf(ctx, threadID, beforeID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListThreads provides a mock function with given fields: ctx, userID
+func (_m *Messages) ListThreads(ctx context.Context, userID int64) ([]message.Thread, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []message.Thread
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]message.Thread, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []message.Thread); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]message.Thread)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRead provides a mock function with given fields: ctx, threadID, readerID, upToID
+func (_m *Messages) MarkRead(ctx context.Context, threadID int64, readerID int64, upToID int64) (int, error) {
+	ret := _m.Called(ctx, threadID, readerID, upToID)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) (int, error)); ok {
+		return rf(ctx, threadID, readerID, upToID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) int); ok {
+		r0 = rf(ctx, threadID, readerID, upToID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, threadID, readerID, upToID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewMessages interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMessages creates a new instance of Messages. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMessages(t mockConstructorTestingTNewMessages) *Messages {
+	mock := &Messages{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,44 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	notification "homework10/internal/notification"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Notifier is an autogenerated mock type for the Notifier type
+type Notifier struct {
+	mock.Mock
+}
+
+// Notify provides a mock function with given fields: ctx, n
+func (_m *Notifier) Notify(ctx context.Context, n notification.Notification) error {
+	ret := _m.Called(ctx, n)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, notification.Notification) error); ok {
+		r0 = rf(ctx, n)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewNotifier interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewNotifier creates a new instance of Notifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewNotifier(t mockConstructorTestingTNewNotifier) *Notifier {
+	mock := &Notifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
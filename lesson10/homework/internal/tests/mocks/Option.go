@@ -0,0 +1,34 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	app "homework10/internal/app"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Option is an autogenerated mock type for the Option type
+type Option struct {
+	mock.Mock
+}
+
+// Execute provides a mock function with given fields: d
+func (_m *Option) Execute(d *app.SimpleApp) {
+	_m.Called(d)
+}
+
+type mockConstructorTestingTNewOption interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewOption creates a new instance of Option. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewOption(t mockConstructorTestingTNewOption) *Option {
+	mock := &Option{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,153 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	report "homework10/internal/report"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// Reports is an autogenerated mock type for the Reports type
+type Reports struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, r
+func (_m *Reports) Add(ctx context.Context, r report.Report) (report.Report, bool, error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 report.Report
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, report.Report) (report.Report, bool, error)); ok {
+		return rf(ctx, r)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, report.Report) report.Report); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(report.Report)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, report.Report) bool); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, report.Report) error); ok {
+		r2 = rf(ctx, r)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Find provides a mock function with given fields: ctx, reportID
+func (_m *Reports) Find(ctx context.Context, reportID int64) (report.Report, bool) {
+	ret := _m.Called(ctx, reportID)
+
+	var r0 report.Report
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (report.Report, bool)); ok {
+		return rf(ctx, reportID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) report.Report); ok {
+		r0 = rf(ctx, reportID)
+	} else {
+		r0 = ret.Get(0).(report.Report)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(ctx, reportID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, status
+func (_m *Reports) List(ctx context.Context, status report.Status) ([]report.Report, error) {
+	ret := _m.Called(ctx, status)
+
+	var r0 []report.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, report.Status) ([]report.Report, error)); ok {
+		return rf(ctx, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, report.Status) []report.Report); ok {
+		r0 = rf(ctx, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]report.Report)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, report.Status) error); ok {
+		r1 = rf(ctx, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListByAd provides a mock function with given fields: ctx, adID
+func (_m *Reports) ListByAd(ctx context.Context, adID int64) ([]report.Report, error) {
+	ret := _m.Called(ctx, adID)
+
+	var r0 []report.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]report.Report, error)); ok {
+		return rf(ctx, adID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []report.Report); ok {
+		r0 = rf(ctx, adID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]report.Report)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, adID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetStatus provides a mock function with given fields: ctx, reportID, status, date
+func (_m *Reports) SetStatus(ctx context.Context, reportID int64, status report.Status, date time.Time) error {
+	ret := _m.Called(ctx, reportID, status, date)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, report.Status, time.Time) error); ok {
+		r0 = rf(ctx, reportID, status, date)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewReports interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewReports creates a new instance of Reports. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewReports(t mockConstructorTestingTNewReports) *Reports {
+	mock := &Reports{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
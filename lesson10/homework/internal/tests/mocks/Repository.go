@@ -9,6 +9,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -92,6 +94,30 @@ func (_m *Repository) Find(ctx context.Context, adID int64) (ads.Ad, bool) {
 	return r0, r1
 }
 
+// FindDeleted provides a mock function with given fields: ctx, adID
+func (_m *Repository) FindDeleted(ctx context.Context, adID int64) (ads.Ad, bool) {
+	ret := _m.Called(ctx, adID)
+
+	var r0 ads.Ad
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (ads.Ad, bool)); ok {
+		return rf(ctx, adID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ads.Ad); ok {
+		r0 = rf(ctx, adID)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(ctx, adID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
 // GetAllByTemplate provides a mock function with given fields: ctx, adp
 func (_m *Repository) GetAllByTemplate(ctx context.Context, adp adpattern.AdPattern) ([]ads.Ad, error) {
 	ret := _m.Called(ctx, adp)
@@ -144,6 +170,114 @@ func (_m *Repository) GetByTitle(ctx context.Context, title string) ([]ads.Ad, e
 	return r0, r1
 }
 
+// Insert provides a mock function with given fields: ctx, ad
+func (_m *Repository) Insert(ctx context.Context, ad ads.Ad) error {
+	ret := _m.Called(ctx, ad)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, ads.Ad) error); ok {
+		r0 = rf(ctx, ad)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListDeleted provides a mock function with given fields: ctx, userID
+func (_m *Repository) ListDeleted(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]ads.Ad, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []ads.Ad); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, before
+func (_m *Repository) Purge(ctx context.Context, before time.Time) ([]int64, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, adID
+func (_m *Repository) Restore(ctx context.Context, adID int64) error {
+	ret := _m.Called(ctx, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetHidden provides a mock function with given fields: ctx, adID, hidden
+func (_m *Repository) SetHidden(ctx context.Context, adID int64, hidden bool) error {
+	ret := _m.Called(ctx, adID, hidden)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) error); ok {
+		r0 = rf(ctx, adID, hidden)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetLang provides a mock function with given fields: ctx, adID, lang, declared
+func (_m *Repository) SetLang(ctx context.Context, adID int64, lang string, declared bool) error {
+	ret := _m.Called(ctx, adID, lang, declared)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, bool) error); ok {
+		r0 = rf(ctx, adID, lang, declared)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetStatus provides a mock function with given fields: ctx, adID, status
 func (_m *Repository) SetStatus(ctx context.Context, adID int64, status bool) error {
 	ret := _m.Called(ctx, adID, status)
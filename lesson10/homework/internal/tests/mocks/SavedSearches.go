@@ -0,0 +1,158 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	search "homework10/internal/search"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SavedSearches is an autogenerated mock type for the SavedSearches type
+type SavedSearches struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, s
+func (_m *SavedSearches) Add(ctx context.Context, s search.SavedSearch) (search.SavedSearch, error) {
+	ret := _m.Called(ctx, s)
+
+	var r0 search.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, search.SavedSearch) (search.SavedSearch, error)); ok {
+		return rf(ctx, s)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, search.SavedSearch) search.SavedSearch); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Get(0).(search.SavedSearch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, search.SavedSearch) error); ok {
+		r1 = rf(ctx, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// All provides a mock function with given fields: ctx
+func (_m *SavedSearches) All(ctx context.Context) ([]search.SavedSearch, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []search.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]search.SavedSearch, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []search.SavedSearch); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]search.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, searchID
+func (_m *SavedSearches) Delete(ctx context.Context, searchID int64) error {
+	ret := _m.Called(ctx, searchID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, searchID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUser provides a mock function with given fields: ctx, userID
+func (_m *SavedSearches) DeleteByUser(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Find provides a mock function with given fields: ctx, searchID
+func (_m *SavedSearches) Find(ctx context.Context, searchID int64) (search.SavedSearch, bool) {
+	ret := _m.Called(ctx, searchID)
+
+	var r0 search.SavedSearch
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (search.SavedSearch, bool)); ok {
+		return rf(ctx, searchID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) search.SavedSearch); ok {
+		r0 = rf(ctx, searchID)
+	} else {
+		r0 = ret.Get(0).(search.SavedSearch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(ctx, searchID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// ListByUser provides a mock function with given fields: ctx, userID
+func (_m *SavedSearches) ListByUser(ctx context.Context, userID int64) ([]search.SavedSearch, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []search.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]search.SavedSearch, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []search.SavedSearch); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]search.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewSavedSearches interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewSavedSearches creates a new instance of SavedSearches. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewSavedSearches(t mockConstructorTestingTNewSavedSearches) *SavedSearches {
+	mock := &SavedSearches{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,82 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	session "homework10/internal/session"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Sessions is an autogenerated mock type for the Sessions type
+type Sessions struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, s
+func (_m *Sessions) Add(ctx context.Context, s session.Session) error {
+	ret := _m.Called(ctx, s)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, session.Session) error); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUser provides a mock function with given fields: ctx, userID
+func (_m *Sessions) DeleteByUser(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Find provides a mock function with given fields: ctx, token
+func (_m *Sessions) Find(ctx context.Context, token string) (session.Session, bool) {
+	ret := _m.Called(ctx, token)
+
+	var r0 session.Session
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) (session.Session, bool)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) session.Session); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(session.Session)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewSessions interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewSessions creates a new instance of Sessions. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewSessions(t mockConstructorTestingTNewSessions) *Sessions {
+	mock := &Sessions{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,113 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	analytics "homework10/internal/analytics"
+
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// Stats is an autogenerated mock type for the Stats type
+type Stats struct {
+	mock.Mock
+}
+
+// AddPublication provides a mock function with given fields: ctx, p
+func (_m *Stats) AddPublication(ctx context.Context, p analytics.Publication) error {
+	ret := _m.Called(ctx, p)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, analytics.Publication) error); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddViews provides a mock function with given fields: ctx, counts
+func (_m *Stats) AddViews(ctx context.Context, counts []analytics.ViewCount) error {
+	ret := _m.Called(ctx, counts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []analytics.ViewCount) error); ok {
+		r0 = rf(ctx, counts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publications provides a mock function with given fields: ctx, adIDs
+func (_m *Stats) Publications(ctx context.Context, adIDs []int64) ([]analytics.Publication, error) {
+	ret := _m.Called(ctx, adIDs)
+
+	var r0 []analytics.Publication
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) ([]analytics.Publication, error)); ok {
+		return rf(ctx, adIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) []analytics.Publication); ok {
+		r0 = rf(ctx, adIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]analytics.Publication)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64) error); ok {
+		r1 = rf(ctx, adIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ViewCounts provides a mock function with given fields: ctx, adIDs, from, to
+func (_m *Stats) ViewCounts(ctx context.Context, adIDs []int64, from time.Time, to time.Time) ([]analytics.ViewCount, error) {
+	ret := _m.Called(ctx, adIDs, from, to)
+
+	var r0 []analytics.ViewCount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64, time.Time, time.Time) ([]analytics.ViewCount, error)); ok {
+		return rf(ctx, adIDs, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64, time.Time, time.Time) []analytics.ViewCount); ok {
+		r0 = rf(ctx, adIDs, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]analytics.ViewCount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, adIDs, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewStats interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewStats creates a new instance of Stats. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewStats(t mockConstructorTestingTNewStats) *Stats {
+	mock := &Stats{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
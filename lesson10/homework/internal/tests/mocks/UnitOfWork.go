@@ -0,0 +1,44 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	app "homework10/internal/app"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UnitOfWork is an autogenerated mock type for the UnitOfWork type
+type UnitOfWork struct {
+	mock.Mock
+}
+
+// Do provides a mock function with given fields: ctx, fn
+func (_m *UnitOfWork) Do(ctx context.Context, fn func(context.Context, app.Repository, app.Users) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context, app.Repository, app.Users) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewUnitOfWork interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewUnitOfWork creates a new instance of UnitOfWork. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewUnitOfWork(t mockConstructorTestingTNewUnitOfWork) *UnitOfWork {
+	mock := &UnitOfWork{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
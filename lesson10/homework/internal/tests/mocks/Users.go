@@ -100,6 +100,70 @@ func (_m *Users) Find(ctx context.Context, userID int64) (user.User, bool) {
 	return r0, r1
 }
 
+// FindByEmail provides a mock function with given fields: ctx, email
+func (_m *Users) FindByEmail(ctx context.Context, email string) (user.User, bool) {
+	ret := _m.Called(ctx, email)
+
+	var r0 user.User
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) (user.User, bool)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) user.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(user.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// FindMany provides a mock function with given fields: ctx, userIDs
+func (_m *Users) FindMany(ctx context.Context, userIDs []int64) (map[int64]user.User, error) {
+	ret := _m.Called(ctx, userIDs)
+
+	var r0 map[int64]user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) (map[int64]user.User, error)); ok {
+		return rf(ctx, userIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) map[int64]user.User); ok {
+		r0 = rf(ctx, userIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64]user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64) error); ok {
+		r1 = rf(ctx, userIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetVerified provides a mock function with given fields: ctx, userID, verified
+func (_m *Users) SetVerified(ctx context.Context, userID int64, verified bool) error {
+	ret := _m.Called(ctx, userID, verified)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) error); ok {
+		r0 = rf(ctx, userID, verified)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewUsers interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,82 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	verification "homework10/internal/verification"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// VerificationTokens is an autogenerated mock type for the VerificationTokens type
+type VerificationTokens struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, t
+func (_m *VerificationTokens) Add(ctx context.Context, t verification.Token) error {
+	ret := _m.Called(ctx, t)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, verification.Token) error); ok {
+		r0 = rf(ctx, t)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUser provides a mock function with given fields: ctx, userID
+func (_m *VerificationTokens) DeleteByUser(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Take provides a mock function with given fields: ctx, value
+func (_m *VerificationTokens) Take(ctx context.Context, value string) (verification.Token, bool) {
+	ret := _m.Called(ctx, value)
+
+	var r0 verification.Token
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) (verification.Token, bool)); ok {
+		return rf(ctx, value)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) verification.Token); ok {
+		r0 = rf(ctx, value)
+	} else {
+		r0 = ret.Get(0).(verification.Token)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, value)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewVerificationTokens interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewVerificationTokens creates a new instance of VerificationTokens. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewVerificationTokens(t mockConstructorTestingTNewVerificationTokens) *VerificationTokens {
+	mock := &VerificationTokens{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,34 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	analytics "homework10/internal/analytics"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ViewCounter is an autogenerated mock type for the ViewCounter type
+type ViewCounter struct {
+	mock.Mock
+}
+
+// Record provides a mock function with given fields: v
+func (_m *ViewCounter) Record(v analytics.View) {
+	_m.Called(v)
+}
+
+type mockConstructorTestingTNewViewCounter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewViewCounter creates a new instance of ViewCounter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewViewCounter(t mockConstructorTestingTNewViewCounter) *ViewCounter {
+	mock := &ViewCounter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,118 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	webhook "homework10/internal/webhook"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WebhookDeliveries is an autogenerated mock type for the WebhookDeliveries type
+type WebhookDeliveries struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, d
+func (_m *WebhookDeliveries) Add(ctx context.Context, d webhook.Delivery) (webhook.Delivery, error) {
+	ret := _m.Called(ctx, d)
+
+	var r0 webhook.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, webhook.Delivery) (webhook.Delivery, error)); ok {
+		return rf(ctx, d)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, webhook.Delivery) webhook.Delivery); ok {
+		r0 = rf(ctx, d)
+	} else {
+		r0 = ret.Get(0).(webhook.Delivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, webhook.Delivery) error); ok {
+		r1 = rf(ctx, d)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Find provides a mock function with given fields: ctx, deliveryID
+func (_m *WebhookDeliveries) Find(ctx context.Context, deliveryID int64) (webhook.Delivery, bool) {
+	ret := _m.Called(ctx, deliveryID)
+
+	var r0 webhook.Delivery
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (webhook.Delivery, bool)); ok {
+		return rf(ctx, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) webhook.Delivery); ok {
+		r0 = rf(ctx, deliveryID)
+	} else {
+		r0 = ret.Get(0).(webhook.Delivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(ctx, deliveryID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, webhookID, status
+func (_m *WebhookDeliveries) List(ctx context.Context, webhookID int64, status webhook.Status) ([]webhook.Delivery, error) {
+	ret := _m.Called(ctx, webhookID, status)
+
+	var r0 []webhook.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, webhook.Status) ([]webhook.Delivery, error)); ok {
+		return rf(ctx, webhookID, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, webhook.Status) []webhook.Delivery); ok {
+		r0 = rf(ctx, webhookID, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, webhook.Status) error); ok {
+		r1 = rf(ctx, webhookID, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, d
+func (_m *WebhookDeliveries) Update(ctx context.Context, d webhook.Delivery) error {
+	ret := _m.Called(ctx, d)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, webhook.Delivery) error); ok {
+		r0 = rf(ctx, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewWebhookDeliveries interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewWebhookDeliveries creates a new instance of WebhookDeliveries. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewWebhookDeliveries(t mockConstructorTestingTNewWebhookDeliveries) *WebhookDeliveries {
+	mock := &WebhookDeliveries{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,34 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	webhook "homework10/internal/webhook"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WebhookSender is an autogenerated mock type for the WebhookSender type
+type WebhookSender struct {
+	mock.Mock
+}
+
+// Send provides a mock function with given fields: d
+func (_m *WebhookSender) Send(d webhook.Delivery) {
+	_m.Called(d)
+}
+
+type mockConstructorTestingTNewWebhookSender interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewWebhookSender creates a new instance of WebhookSender. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewWebhookSender(t mockConstructorTestingTNewWebhookSender) *WebhookSender {
+	mock := &WebhookSender{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,118 @@
+// Code generated by mockery v2.26.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	webhook "homework10/internal/webhook"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Webhooks is an autogenerated mock type for the Webhooks type
+type Webhooks struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, s
+func (_m *Webhooks) Add(ctx context.Context, s webhook.Subscription) (webhook.Subscription, error) {
+	ret := _m.Called(ctx, s)
+
+	var r0 webhook.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, webhook.Subscription) (webhook.Subscription, error)); ok {
+		return rf(ctx, s)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, webhook.Subscription) webhook.Subscription); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Get(0).(webhook.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, webhook.Subscription) error); ok {
+		r1 = rf(ctx, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, webhookID
+func (_m *Webhooks) Delete(ctx context.Context, webhookID int64) error {
+	ret := _m.Called(ctx, webhookID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Find provides a mock function with given fields: ctx, webhookID
+func (_m *Webhooks) Find(ctx context.Context, webhookID int64) (webhook.Subscription, bool) {
+	ret := _m.Called(ctx, webhookID)
+
+	var r0 webhook.Subscription
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (webhook.Subscription, bool)); ok {
+		return rf(ctx, webhookID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) webhook.Subscription); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Get(0).(webhook.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(ctx, webhookID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx
+func (_m *Webhooks) List(ctx context.Context) ([]webhook.Subscription, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []webhook.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]webhook.Subscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []webhook.Subscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewWebhooks interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewWebhooks creates a new instance of Webhooks. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewWebhooks(t mockConstructorTestingTNewWebhooks) *Webhooks {
+	mock := &Webhooks{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
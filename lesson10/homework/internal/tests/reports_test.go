@@ -0,0 +1,245 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/reports"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/report"
+	"homework10/internal/tenant"
+	"strings"
+	"testing"
+	"time"
+)
+
+const moderatorKey = "moderator"
+
+func newReportsApp(hideThreshold int) app.App {
+	return app.NewApp(adrepo.New(), customer.New(), adfilter.New(), app.WithAdminKey(moderatorKey),
+		app.WithReports(reports.New(), hideThreshold))
+}
+
+// newReportedAd creates a published ad of user 1 and users 2, 3 and 4 who may report it.
+func newReportedAd(t *testing.T, client *testClient) int64 {
+	for i, name := range []string{"tom", "cat", "dog", "fox"} {
+		_, err := client.createUserAsAdmin(int64(i+1), name, name+"@mail.ru", moderatorKey)
+		assert.NoError(t, err)
+	}
+	ad, err := client.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+	return ad.Data.ID
+}
+
+func TestReports_AutoHide(t *testing.T) {
+	client := getTestClient(newReportsApp(2))
+	adID := newReportedAd(t, client)
+
+	first, err := client.reportAd(adID, 2, "spam", "buy now")
+	assert.NoError(t, err)
+	assert.Equal(t, "open", first.Data.Status)
+	assert.Nil(t, first.Data.ResolutionDate)
+	// a second report of the same user doesn't count
+	again, err := client.reportAd(adID, 2, "fraud", "")
+	assert.NoError(t, err)
+	assert.Equal(t, first.Data, again.Data)
+	list, err := client.listAdsPublishedOnly(true)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+
+	_, err = client.reportAd(adID, 3, "offensive", "")
+	assert.NoError(t, err)
+	list, err = client.listAdsPublishedOnly(true)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Data)
+
+	// the ad is still there for its author and in unfiltered listings
+	list, err = client.listAdsPublishedOnly(false)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	ad, err := client.getAdByID(adID)
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Published)
+	assert.True(t, ad.Data.Hidden)
+}
+
+func TestReports_Moderation(t *testing.T) {
+	client := getTestClient(newReportsApp(0))
+	adID := newReportedAd(t, client)
+
+	r2, err := client.reportAd(adID, 2, "spam", "")
+	assert.NoError(t, err)
+	r3, err := client.reportAd(adID, 3, "spam", "")
+	assert.NoError(t, err)
+	queue, err := client.listReports("", moderatorKey)
+	assert.NoError(t, err)
+	assert.Len(t, queue.Data, 2)
+	list, err := client.listAdsPublishedOnly(true)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+
+	_, err = client.listReports("open", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.moderateReport(r2.Data.ID, "resolve", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	resolved, err := client.moderateReport(r2.Data.ID, "resolve", moderatorKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "resolved", resolved.Data.Status)
+	assert.NotNil(t, resolved.Data.ResolutionDate)
+	list, err = client.listAdsPublishedOnly(true)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Data)
+
+	// other reports about the ad are closed along with it
+	queue, err = client.listReports("open", moderatorKey)
+	assert.NoError(t, err)
+	assert.Empty(t, queue.Data)
+	queue, err = client.listReports("resolved", moderatorKey)
+	assert.NoError(t, err)
+	assert.Len(t, queue.Data, 2)
+	_, err = client.moderateReport(r3.Data.ID, "dismiss", moderatorKey)
+	assert.ErrorIs(t, err, ErrConflict)
+	queue, err = client.listReports("all", moderatorKey)
+	assert.NoError(t, err)
+	assert.Len(t, queue.Data, 2)
+}
+
+func TestReports_DismissShowsAd(t *testing.T) {
+	client := getTestClient(newReportsApp(1))
+	adID := newReportedAd(t, client)
+
+	r, err := client.reportAd(adID, 2, "other", "looks odd")
+	assert.NoError(t, err)
+	ad, err := client.getAdByID(adID)
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Hidden)
+
+	dismissed, err := client.moderateReport(r.Data.ID, "dismiss", moderatorKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "dismissed", dismissed.Data.Status)
+	list, err := client.listAdsPublishedOnly(true)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+
+	// the dismissed report stays, so reporting again doesn't hide the ad
+	_, err = client.reportAd(adID, 2, "spam", "")
+	assert.NoError(t, err)
+	list, err = client.listAdsPublishedOnly(true)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+}
+
+func TestReports_BadRequests(t *testing.T) {
+	client := getTestClient(newReportsApp(1))
+	adID := newReportedAd(t, client)
+
+	tests := []struct {
+		name    string
+		adID    int64
+		userID  int64
+		reason  string
+		comment string
+		err     error
+	}{
+		{"unknown reason", adID, 2, "boring", "", ErrBadRequest},
+		{"unknown ad", adID + 1, 2, "spam", "", ErrBadRequest},
+		{"unknown user", adID, 5, "spam", "", ErrBadRequest},
+		{"long comment", adID, 2, "spam", strings.Repeat("a", 500), ErrBadRequest},
+		{"own ad", adID, 1, "spam", "", ErrForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.reportAd(tc.adID, tc.userID, tc.reason, tc.comment)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	_, err := client.listReports("closed", moderatorKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.moderateReport(1, "resolve", moderatorKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New())).reportAd(adID, 2, "spam", "")
+	assert.ErrorIs(t, err, InternalServerErr)
+}
+
+func TestReports_GRPC(t *testing.T) {
+	a := newReportsApp(1)
+	client, ctx := getGRPCClient(t, a)
+	adID := newReportedAd(t, getTestClient(a))
+
+	_, err := client.ReportAd(ctx, &grpcPort.ReportAdRequest{AdId: adID, UserId: 2})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := client.ReportAd(ctx, &grpcPort.ReportAdRequest{AdId: adID, UserId: 2,
+		Reason: grpcPort.ReportReason_Fraud, Comment: "fake"})
+	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.ReportReason_Fraud, res.Reason)
+	assert.Equal(t, "open", res.Status)
+	assert.Nil(t, res.ResolutionDate)
+
+	list, err := client.ListAds(ctx, &grpcPort.FilterRequest{PublishedConfig: grpcPort.PublishedConfig_PublishedOnly})
+	assert.NoError(t, err)
+	assert.Empty(t, list.List)
+}
+
+func TestReports_CheckAd(t *testing.T) {
+	hidden := ads.Ad{Published: true, Hidden: true}
+	assert.False(t, app.CheckAd(hidden, adpattern.AdPattern{PublishedOnly: true}))
+	assert.True(t, app.CheckAd(hidden, adpattern.AdPattern{}))
+}
+
+func TestReports_SQL(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	store := sqlstore.NewReports(db)
+	now := time.Now().UTC()
+	r := report.Report{Tenant: "acme", AdID: 7, ReporterID: 2, Reason: report.ReasonSpam, Status: report.StatusOpen,
+		CreationDate: now}
+	columns := []string{"id", "tenant_id", "ad_id", "reporter_id", "reason", "comment", "status", "creation_date",
+		"resolution_date"}
+
+	sqlMock.ExpectQuery("INSERT INTO reports (.+) ON CONFLICT (.+) DO NOTHING RETURNING id").
+		WithArgs("acme", 7, 2, "spam", "", "open", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	res, isNew, err := store.Add(ctx, r)
+	assert.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, int64(1), res.ID)
+
+	// the reporter has already reported the ad
+	sqlMock.ExpectQuery("INSERT INTO reports").WillReturnError(sql.ErrNoRows)
+	sqlMock.ExpectQuery("SELECT (.+) FROM reports WHERE tenant_id = \\$1 AND ad_id = \\$2 AND reporter_id = \\$3").
+		WithArgs("acme", 7, 2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "acme", 7, 2, "spam", "", "resolved", now, now))
+	res, isNew, err = store.Add(ctx, r)
+	assert.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, report.StatusResolved, res.Status)
+	assert.Equal(t, now, res.ResolutionDate)
+
+	sqlMock.ExpectQuery("SELECT (.+) FROM reports WHERE tenant_id = \\$1 AND status = \\$2 ORDER BY id").
+		WithArgs("acme", "open").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "acme", 8, 2, "fraud", "fake", "open", now, nil))
+	list, err := store.List(ctx, report.StatusOpen)
+	assert.NoError(t, err)
+	assert.Equal(t, []report.Report{{ID: 3, Tenant: "acme", AdID: 8, ReporterID: 2, Reason: report.ReasonFraud,
+		Comment: "fake", Status: report.StatusOpen, CreationDate: now}}, list)
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
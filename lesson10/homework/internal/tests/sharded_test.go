@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adpattern"
+	"homework10/internal/app"
+	"sync"
+	"testing"
+)
+
+func TestShardedRepo_ConcurrentWrites(t *testing.T) {
+	const (
+		workers   = 8
+		perWorker = 500
+	)
+	ctx := context.Background()
+	repo := adrepo.NewSharded(4)
+
+	ids := make(chan int64, workers*perWorker)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				adID, err := repo.Add(ctx, fmt.Sprint("ad", i), "text", int64(w+1))
+				assert.NoError(t, err)
+				if i%2 == 0 {
+					assert.NoError(t, repo.SetStatus(ctx, adID, true))
+				}
+				_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: int64(w + 1)})
+				ids <- adID
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[int64]bool{}
+	for adID := range ids {
+		assert.False(t, seen[adID], "duplicate id %d", adID)
+		seen[adID] = true
+	}
+	assert.Len(t, seen, workers*perWorker)
+
+	published, err := repo.GetAllByTemplate(ctx, adpattern.AdPattern{PublishedOnly: true})
+	assert.NoError(t, err)
+	assert.Len(t, published, workers*perWorker/2)
+	for i := 1; i < len(published); i++ {
+		assert.False(t, published[i].CreationDate.Before(published[i-1].CreationDate))
+	}
+}
+
+func TestShardedRepo_App(t *testing.T) {
+	client := getTestClient(app.NewApp(adrepo.NewSharded(4), customer.New(), adfilter.New()))
+
+	_, _ = client.createUser(123, "nickname", "example@mail.com")
+	for i := 0; i < 3; i++ {
+		resp, err := client.createAd(123, "hello", "world")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), resp.Data.ID)
+		_, err = client.changeAdStatus(123, resp.Data.ID, true)
+		assert.NoError(t, err)
+	}
+
+	ads, err := client.listAdsAuthor(123)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0, 1, 2}, []int64{ads.Data[0].ID, ads.Data[1].ID, ads.Data[2].ID})
+
+	_, err = client.deleteUserByID(123)
+	assert.NoError(t, err)
+	ads, err = client.listAdsBasic()
+	assert.NoError(t, err)
+	assert.Empty(t, ads.Data)
+}
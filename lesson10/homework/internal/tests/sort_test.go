@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework10/internal/adapters/adcache"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec  string
+		order []adpattern.OrderBy
+		isErr bool
+	}{
+		{"", nil, false},
+		{"title", []adpattern.OrderBy{{Field: "title"}}, false},
+		{"-creation_date,title", []adpattern.OrderBy{{Field: "creation_date", Desc: true}, {Field: "title"}}, false},
+		{"update_date, -title", []adpattern.OrderBy{{Field: "update_date"}, {Field: "title", Desc: true}}, false},
+		{"author", nil, true},
+		{"title,-title", nil, true},
+		{"title,", nil, true},
+		{"--title", nil, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.spec, func(t *testing.T) {
+			order, err := adpattern.ParseSort(tc.spec)
+			if tc.isErr {
+				assert.ErrorIs(t, err, adpattern.ErrBadSort)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.order, order)
+			again, _ := adpattern.ParseSort(adpattern.FormatSort(order))
+			assert.Equal(t, order, again)
+		})
+	}
+}
+
+func TestSort_PatternCodec(t *testing.T) {
+	adp := adpattern.AdPattern{AuthorID: 3, Title: "ab",
+		Sort: []adpattern.OrderBy{{Field: "update_date", Desc: true}, {Field: "title"}}}
+	data, err := adpattern.Marshal(adp)
+	assert.NoError(t, err)
+	decoded, err := adpattern.Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, adp, decoded)
+}
+
+func TestSort_Repositories(t *testing.T) {
+	tests := []struct {
+		name string
+		repo func() app.Repository
+	}{
+		{"map", adrepo.New},
+		{"sharded", func() app.Repository { return adrepo.NewSharded(3) }},
+		{"cached", func() app.Repository { return adcache.New(adrepo.New(), 10, time.Minute) }},
+	}
+	titles := []string{"zebra", "Äpfel", "apple", "Banana", "apple"}
+	titlesOf := func(list []ads.Ad) []string {
+		res := []string{}
+		for _, ad := range list {
+			res = append(res, ad.Title)
+		}
+		return res
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := tc.repo()
+			ids := []int64{}
+			for _, title := range titles {
+				adID, _ := repo.Add(ctx, title, "text", 1)
+				ids = append(ids, adID)
+				time.Sleep(time.Millisecond)
+			}
+			_ = repo.SetText(ctx, ids[0], "updated")
+
+			list, err := repo.GetAllByTemplate(ctx, adpattern.AdPattern{})
+			assert.NoError(t, err)
+			assert.Equal(t, titles, titlesOf(list))
+
+			list, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{Sort: []adpattern.OrderBy{{Field: "creation_date", Desc: true}}})
+			assert.Equal(t, []string{"apple", "Banana", "apple", "Äpfel", "zebra"}, titlesOf(list))
+
+			list, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{Sort: []adpattern.OrderBy{{Field: "update_date", Desc: true}}})
+			assert.Equal(t, ids[0], list[0].ID)
+
+			list, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{Sort: []adpattern.OrderBy{{Field: "title"}}})
+			assert.Equal(t, []string{"Äpfel", "apple", "apple", "Banana", "zebra"}, titlesOf(list))
+			assert.Equal(t, []int64{ids[2], ids[4]}, []int64{list[1].ID, list[2].ID})
+
+			list, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{Title: "ap",
+				Sort: []adpattern.OrderBy{{Field: "title", Desc: true}, {Field: "creation_date", Desc: true}}})
+			assert.Equal(t, []int64{ids[4], ids[2]}, []int64{list[0].ID, list[1].ID})
+
+			list, _ = repo.GetByTitle(ctx, "apple")
+			assert.Equal(t, []int64{ids[2], ids[4]}, []int64{list[0].ID, list[1].ID})
+		})
+	}
+}
+
+func TestSort_SQL(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	repo := sqlstore.NewAdRepo(db)
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`WHERE tenant_id = $1 AND deleted_at IS NULL AND author_id = $2 `+
+		`ORDER BY title COLLATE "und-x-icu" DESC, update_date, creation_date, id`)).
+		WithArgs("default", 3).WillReturnRows(sqlmock.NewRows([]string{"id", "title", "text", "author_id", "published",
+		"hidden", "lang", "lang_declared", "creation_date", "update_date"}))
+	_, err = repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: 3,
+		Sort: []adpattern.OrderBy{{Field: "title", Desc: true}, {Field: "update_date"}}})
+	assert.NoError(t, err)
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`WHERE tenant_id = $1 AND deleted_at IS NULL AND title LIKE $2 ESCAPE '\' `+
+		`ORDER BY creation_date, id`)).
+		WithArgs("default", `50\%%`).WillReturnRows(sqlmock.NewRows([]string{"id", "title", "text", "author_id", "published",
+		"hidden", "lang", "lang_declared", "creation_date", "update_date"}))
+	_, err = repo.GetByTitle(ctx, "50%")
+	assert.NoError(t, err)
+
+	_, err = repo.GetAllByTemplate(ctx, adpattern.AdPattern{Sort: []adpattern.OrderBy{{Field: "id"}}})
+	assert.ErrorIs(t, err, adpattern.ErrBadSort)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSort_HTTP(t *testing.T) {
+	client := getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New()))
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	for _, title := range []string{"beta", "alpha", "alpine"} {
+		_, _ = client.createAd(1, title, "text")
+	}
+
+	list, err := client.listAdsSorted("-creation_date")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpine", "alpha", "beta"}, []string{list.Data[0].Title, list.Data[1].Title, list.Data[2].Title})
+	list, err = client.listAdsSorted("title")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "alpine", "beta"}, []string{list.Data[0].Title, list.Data[1].Title, list.Data[2].Title})
+	_, err = client.listAdsSorted("price")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	list, err = client.getAdsByTitleSorted("al", "-title")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpine", "alpha"}, []string{list.Data[0].Title, list.Data[1].Title})
+	_, err = client.getAdsByTitleSorted("al", "title,title")
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func (suite *TestConfig) TestGRPCSort() {
+	u, _ := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "Tom", Email: "example@mail.com", UserId: 3})
+	for i := 0; i < 3; i++ {
+		_, err := suite.client.CreateAd(suite.ctx, &grpcPort.CreateAdRequest{Title: fmt.Sprintf("ad %d", i), Text: "text", UserId: u.UserId})
+		suite.Assert().NoError(err, "suite.client.CreateAd")
+	}
+
+	list, err := suite.client.ListAds(suite.ctx, &grpcPort.FilterRequest{PublishedConfig: grpcPort.PublishedConfig_AllAds,
+		OrderBy: []*grpcPort.OrderBy{{Field: "title", Desc: true}}})
+	suite.Assert().NoError(err, "suite.client.ListAds")
+	suite.Assert().Len(list.List, 3)
+	suite.Assert().Equal("ad 2", list.List[0].Title)
+
+	list, err = suite.client.GetAdsByTitle(suite.ctx, &grpcPort.AdsByTitleRequest{Title: "ad",
+		OrderBy: []*grpcPort.OrderBy{{Field: "creation_date", Desc: true}}})
+	suite.Assert().NoError(err, "suite.client.GetAdsByTitle")
+	suite.Assert().Equal("ad 2", list.List[0].Title)
+
+	_, err = suite.client.ListAds(suite.ctx, &grpcPort.FilterRequest{OrderBy: []*grpcPort.OrderBy{{Field: "price"}}})
+	suite.Assert().Equal(codes.InvalidArgument, status.Code(err))
+	_, err = suite.client.GetAdsByTitle(suite.ctx, &grpcPort.AdsByTitleRequest{OrderBy: []*grpcPort.OrderBy{{Field: "price"}}})
+	suite.Assert().ErrorIs(err, ErrorBadRequest)
+}
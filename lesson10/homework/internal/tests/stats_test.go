@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/adstats"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/analytics"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/tenant"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newStatsApp returns an app counting views, they reach the stats only when the counter is flushed.
+func newStatsApp(t *testing.T) (app.App, *adstats.Counter) {
+	stats := adstats.New()
+	counter := adstats.NewCounter(stats, adstats.Options{FlushInterval: time.Hour})
+	t.Cleanup(counter.Close)
+	return app.NewApp(adrepo.New(), customer.New(), adfilter.New(), app.WithStats(stats, counter)), counter
+}
+
+func hourQuery(from, to time.Time) url.Values {
+	return url.Values{"from": {fmt.Sprint(from.UnixMicro())}, "to": {fmt.Sprint(to.UnixMicro())},
+		"bucket": {"hour"}}
+}
+
+func TestStats_ViewsAndPublications(t *testing.T) {
+	a, counter := newStatsApp(t)
+	client := getTestClient(a)
+	grpcClient, ctx := getGRPCClient(t, a)
+	_, _ = client.createUser(1, "tom", "tom@mail.ru")
+	_, _ = client.createUser(2, "cat", "cat@mail.ru")
+
+	first, err := client.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = client.createAd(1, "foo", "bar")
+	assert.NoError(t, err)
+	_, err = client.createAd(2, "other", "author")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, first.Data.ID, true)
+	assert.NoError(t, err)
+	// republishing doesn't change the time to publish
+	_, _ = client.changeAdStatus(1, first.Data.ID, false)
+	_, _ = client.changeAdStatus(1, first.Data.ID, true)
+
+	for i := 0; i < 3; i++ {
+		_, err = client.getAdByID(first.Data.ID)
+		assert.NoError(t, err)
+	}
+	_, err = grpcClient.GetAdByID(ctx, &grpcPort.GetAdRequest{Id: first.Data.ID})
+	assert.NoError(t, err)
+	// listings aren't views
+	_, _ = client.listAdsBasic()
+	counter.Flush()
+
+	now := time.Now().UTC()
+	resp, err := client.getUserStats(1, hourQuery(now.Add(-2*time.Hour), now.Add(time.Minute)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Data.UserID)
+	assert.Len(t, resp.Data.Buckets, 3)
+	var views int64
+	var created, published int
+	for _, b := range resp.Data.Buckets {
+		views += b.Views
+		created += b.AdsCreated
+		published += b.Published
+		assert.GreaterOrEqual(t, b.TimeToPublish, 0.0)
+	}
+	// the HTTP client and the gRPC one are two viewers
+	assert.Equal(t, int64(2), views)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 1, published)
+
+	resp, err = client.getUserStats(2, nil)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data.Buckets, 30)
+	assert.Equal(t, 1, resp.Data.Buckets[29].AdsCreated)
+}
+
+func TestStats_BadRequests(t *testing.T) {
+	a, _ := newStatsApp(t)
+	client := getTestClient(a)
+	_, _ = client.createUser(1, "tom", "tom@mail.ru")
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name  string
+		user  int64
+		query url.Values
+	}{
+		{"unknown user", 2, nil},
+		{"unknown bucket", 1, url.Values{"bucket": {"month"}}},
+		{"bad time", 1, url.Values{"from": {"yesterday"}}},
+		{"empty period", 1, hourQuery(now, now.Add(-time.Hour))},
+		{"too many buckets", 1, hourQuery(now.Add(-365*24*time.Hour), now)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.getUserStats(tc.user, tc.query)
+			assert.ErrorIs(t, err, ErrBadRequest)
+		})
+	}
+
+	_, err := getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New())).getUserStats(1, nil)
+	assert.ErrorIs(t, err, InternalServerErr)
+}
+
+func TestStatsCounter_Dedup(t *testing.T) {
+	ctx := context.Background()
+	stats := adstats.New()
+	counter := adstats.NewCounter(stats, adstats.Options{Window: 30 * time.Minute, FlushInterval: time.Hour})
+	defer counter.Close()
+	hour := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, v := range []analytics.View{
+		{Tenant: tenant.Default, AdID: 1, Viewer: "a", Time: hour.Add(time.Minute)},
+		{Tenant: tenant.Default, AdID: 1, Viewer: "a", Time: hour.Add(20 * time.Minute)},
+		{Tenant: tenant.Default, AdID: 1, Viewer: "a", Time: hour.Add(40 * time.Minute)},
+		{Tenant: tenant.Default, AdID: 1, Viewer: "b", Time: hour.Add(20 * time.Minute)},
+		{Tenant: tenant.Default, AdID: 2, Viewer: "a", Time: hour.Add(20 * time.Minute)},
+		{Tenant: "acme", AdID: 1, Viewer: "a", Time: hour.Add(20 * time.Minute)},
+		{Tenant: tenant.Default, AdID: 1, Viewer: "a", Time: hour.Add(70 * time.Minute)},
+	} {
+		counter.Record(v)
+	}
+	counter.Flush()
+
+	counts, err := stats.ViewCounts(ctx, []int64{1, 2}, hour, hour.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []analytics.ViewCount{
+		{Tenant: tenant.Default, AdID: 1, Hour: hour, Views: 3},
+		{Tenant: tenant.Default, AdID: 2, Hour: hour, Views: 1},
+		{Tenant: tenant.Default, AdID: 1, Hour: hour.Add(time.Hour), Views: 1},
+	}, counts)
+	counts, err = stats.ViewCounts(tenant.NewContext(ctx, "acme"), []int64{1}, hour, hour.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, counts, 1)
+}
+
+func TestStatsCounter_Batches(t *testing.T) {
+	ctx := context.Background()
+	stats := adstats.New()
+	counter := adstats.NewCounter(stats, adstats.Options{BatchSize: 2, FlushInterval: time.Hour})
+	defer counter.Close()
+	now := time.Now().UTC()
+
+	counter.Record(analytics.View{Tenant: tenant.Default, AdID: 1, Viewer: "a", Time: now})
+	counter.Record(analytics.View{Tenant: tenant.Default, AdID: 2, Viewer: "a", Time: now})
+	assert.Eventually(t, func() bool {
+		counts, _ := stats.ViewCounts(ctx, []int64{1, 2}, now.Add(-time.Hour), now.Add(time.Hour))
+		return len(counts) == 2
+	}, time.Second, time.Millisecond)
+
+	// the rest is flushed on close
+	counter.Record(analytics.View{Tenant: tenant.Default, AdID: 3, Viewer: "a", Time: now})
+	counter.Close()
+	counts, err := stats.ViewCounts(ctx, []int64{3}, now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, counts, 1)
+}
+
+func TestStatsCounter_Concurrent(t *testing.T) {
+	const (
+		workers   = 8
+		perWorker = 500
+	)
+	ctx := context.Background()
+	stats := adstats.New()
+	counter := adstats.NewCounter(stats, adstats.Options{BufferSize: 16, BatchSize: 3, FlushInterval: time.Millisecond})
+	now := time.Now().UTC()
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				counter.Record(analytics.View{Tenant: tenant.Default, AdID: int64(i % 5), Viewer: fmt.Sprint(w, i), Time: now})
+			}
+		}(w)
+	}
+	wg.Wait()
+	counter.Close()
+
+	counts, err := stats.ViewCounts(ctx, []int64{0, 1, 2, 3, 4}, now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, err)
+	var views int64
+	for _, c := range counts {
+		views += c.Views
+	}
+	// every view is either counted or dropped, none is lost
+	assert.Equal(t, int64(workers*perWorker), views+counter.Dropped())
+}
+
+func TestStats_FirstPublicationIsKept(t *testing.T) {
+	ctx := context.Background()
+	stats := adstats.New()
+	created := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	first := analytics.Publication{Tenant: tenant.Default, AdID: 1, CreationDate: created,
+		PublicationDate: created.Add(90 * time.Minute)}
+	assert.NoError(t, stats.AddPublication(ctx, first))
+	assert.NoError(t, stats.AddPublication(ctx, analytics.Publication{Tenant: tenant.Default, AdID: 1,
+		CreationDate: created, PublicationDate: created.Add(5 * time.Hour)}))
+
+	res, err := stats.Publications(ctx, []int64{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []analytics.Publication{first}, res)
+	assert.Equal(t, 90*time.Minute, res[0].TimeToPublish())
+}
+
+func TestStats_SQL(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	stats := sqlstore.NewAdStats(db)
+	hour := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	sqlMock.ExpectExec(`INSERT INTO ad_views (.+) VALUES \(\$1, \$2, \$3, \$4\), \(\$5, \$6, \$7, \$8\) ON CONFLICT`).
+		WithArgs("acme", 1, hour, 3, "acme", 2, hour, 1).WillReturnResult(sqlmock.NewResult(0, 2))
+	err = stats.AddViews(ctx, []analytics.ViewCount{{Tenant: "acme", AdID: 1, Hour: hour, Views: 3},
+		{Tenant: "acme", AdID: 2, Hour: hour, Views: 1}})
+	assert.NoError(t, err)
+
+	sqlMock.ExpectQuery("SELECT ad_id, hour, views FROM ad_views").
+		WithArgs("acme", sqlmock.AnyArg(), hour, hour.Add(time.Hour)).
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id", "hour", "views"}).AddRow(1, hour, 3))
+	counts, err := stats.ViewCounts(ctx, []int64{1, 2}, hour, hour.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []analytics.ViewCount{{Tenant: "acme", AdID: 1, Hour: hour, Views: 3}}, counts)
+
+	sqlMock.ExpectExec("INSERT INTO ad_publications (.+) ON CONFLICT (.+) DO NOTHING").
+		WithArgs("acme", 1, hour, hour.Add(time.Hour)).WillReturnResult(sqlmock.NewResult(0, 1))
+	err = stats.AddPublication(ctx, analytics.Publication{Tenant: "acme", AdID: 1, CreationDate: hour,
+		PublicationDate: hour.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/favorites"
+	"homework10/internal/adapters/notifier"
+	"homework10/internal/adapters/searches"
+	"homework10/internal/adpattern"
+	"homework10/internal/app"
+	"homework10/internal/notification"
+	"testing"
+	"time"
+)
+
+func getSubscriptionsClient() (*testClient, *notifier.Outbox) {
+	outbox := notifier.NewOutbox(notifier.DefaultLimit)
+	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithFavorites(favorites.New()), app.WithSavedSearches(searches.New(), outbox))
+	return getTestClient(a), outbox
+}
+
+func TestFavorites(t *testing.T) {
+	client, _ := getSubscriptionsClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+	first, _ := client.createAd(1, "aba", "caba")
+	second, _ := client.createAd(1, "foo", "bar")
+
+	_, err := client.addFavorite(2, second.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.addFavorite(2, first.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.addFavorite(2, first.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.addFavorite(2, 100)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.addFavorite(3, first.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	list, err := client.listFavorites(2)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 2)
+	assert.Equal(t, second.Data.ID, list.Data[0].ID)
+	assert.Equal(t, first.Data.ID, list.Data[1].ID)
+
+	_, err = client.removeFavorite(2, second.Data.ID)
+	assert.NoError(t, err)
+	_, _ = client.deleteAd(1, first.Data.ID)
+	list, err = client.listFavorites(2)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 0)
+}
+
+func TestSavedSearches(t *testing.T) {
+	client, _ := getSubscriptionsClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+
+	lTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).UnixMicro()
+	s, err := client.saveSearch(2, map[string]any{"name": "author's ads", "author_id": 1,
+		"published_only": true, "l_time": lTime})
+	assert.NoError(t, err)
+	assert.Equal(t, "author's ads", s.Data.Name)
+	assert.Equal(t, int64(1), s.Data.AuthorID)
+	assert.True(t, s.Data.PublishedOnly)
+	assert.Equal(t, lTime, *s.Data.LTime)
+	assert.Nil(t, s.Data.RTime)
+
+	_, err = client.saveSearch(2, map[string]any{"name": "wrong range", "l_time": 10, "r_time": 5})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.saveSearch(3, map[string]any{"name": "no user"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	list, err := client.listSavedSearches(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []savedSearchData{s.Data}, list.Data)
+
+	_, err = client.deleteSavedSearch(1, s.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.deleteSavedSearch(2, s.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.deleteSavedSearch(2, s.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestSavedSearches_NotifyOnPublish(t *testing.T) {
+	client, outbox := getSubscriptionsClient()
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "buyer", "buyer@mail.ru")
+	_, _ = client.createUser(3, "other", "other@mail.ru")
+
+	byAuthor, _ := client.saveSearch(2, map[string]any{"name": "by author", "author_id": 1, "published_only": true})
+	_, _ = client.saveSearch(3, map[string]any{"name": "by buyer", "author_id": 2})
+	_, _ = client.saveSearch(1, map[string]any{"name": "own ads", "author_id": 1})
+	future := time.Now().Add(time.Hour).UnixMicro()
+	_, _ = client.saveSearch(3, map[string]any{"name": "future", "l_time": future})
+
+	ad, _ := client.createAd(1, "aba", "caba")
+	assert.Len(t, outbox.Pending(), 0)
+
+	_, _ = client.changeAdStatus(1, ad.Data.ID, true)
+	// publishing an already published ad is not a new match
+	_, _ = client.changeAdStatus(1, ad.Data.ID, true)
+
+	sent := outbox.Drain()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, int64(2), sent[0].UserID)
+	assert.Equal(t, byAuthor.Data.ID, sent[0].SearchID)
+	assert.Equal(t, ad.Data.ID, sent[0].AdID)
+	assert.Len(t, outbox.Pending(), 0)
+
+	_, _ = client.changeAdStatus(1, ad.Data.ID, false)
+	_, _ = client.deleteUserByID(2)
+	_, _ = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.Len(t, outbox.Pending(), 0)
+}
+
+func TestOutbox_Limit(t *testing.T) {
+	outbox := notifier.NewOutbox(3)
+	for i := int64(1); i <= 5; i++ {
+		assert.NoError(t, outbox.Notify(context.Background(), notification.Notification{AdID: i}))
+	}
+	pending := outbox.Pending()
+	if assert.Len(t, pending, 3) {
+		// the oldest notifications are dropped
+		assert.Equal(t, int64(3), pending[0].AdID)
+		assert.Equal(t, int64(5), pending[2].AdID)
+	}
+	assert.Equal(t, 2, outbox.Dropped())
+
+	assert.Len(t, outbox.Drain(), 3)
+	assert.NoError(t, outbox.Notify(context.Background(), notification.Notification{AdID: 6}))
+	assert.Len(t, outbox.Pending(), 1)
+	assert.Equal(t, 2, outbox.Dropped())
+}
+
+func TestAdPatternCodec(t *testing.T) {
+	tests := []adpattern.AdPattern{
+		{},
+		{PublishedOnly: true, AuthorID: 42},
+		{IsLTimeSet: true, LDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{IsLTimeSet: true, IsRTimeSet: true, LDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			RDate: time.Date(2023, 2, 1, 12, 30, 0, 5, time.UTC), AuthorID: 1},
+	}
+	for _, adp := range tests {
+		data, err := adpattern.Marshal(adp)
+		assert.NoError(t, err)
+		res, err := adpattern.Unmarshal(data)
+		assert.NoError(t, err)
+		assert.Equal(t, adp, res)
+	}
+
+	_, err := adpattern.Unmarshal([]byte("not a pattern"))
+	assert.Error(t, err)
+}
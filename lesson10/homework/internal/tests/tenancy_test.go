@@ -0,0 +1,545 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"homework10/internal/adapters/accounts"
+	"homework10/internal/adapters/adcache"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/chat"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/extids"
+	"homework10/internal/adapters/favorites"
+	"homework10/internal/adapters/mailer"
+	"homework10/internal/adapters/notifier"
+	"homework10/internal/adapters/searches"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/adapters/tenancy"
+	"homework10/internal/adapters/tokens"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/bulk"
+	"homework10/internal/search"
+	"homework10/internal/tenant"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTenant_Resolve(t *testing.T) {
+	r, err := tenant.NewRegistry(map[tenant.ID]tenant.Config{
+		"acme":  {Hosts: []string{"ads.acme.com"}},
+		"globo": {Hosts: []string{"Globo.example"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []tenant.ID{"acme", tenant.Default, "globo"}, r.IDs())
+
+	tests := []struct {
+		name      string
+		requested string
+		host      string
+		want      tenant.ID
+		err       error
+	}{
+		{"header", "acme", "globo.example", "acme", nil},
+		{"default by header", "default", "", tenant.Default, nil},
+		{"unknown", "initech", "ads.acme.com", "", tenant.ErrUnknown},
+		{"host", "", "ads.acme.com", "acme", nil},
+		{"host with port", "", "globo.example:8080", "globo", nil},
+		{"host case", "", "ADS.ACME.COM", "acme", nil},
+		{"other host", "", "localhost:18080", tenant.Default, nil},
+		{"nothing", "", "", tenant.Default, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := r.Resolve(tc.requested, tc.host)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, id)
+		})
+	}
+
+	_, err = tenant.NewRegistry(map[tenant.ID]tenant.Config{"a": {Hosts: []string{"x"}}, "b": {Hosts: []string{"X"}}})
+	assert.Error(t, err)
+}
+
+func TestTenant_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("tenants:\n  acme:\n    hosts: [ads.acme.com]\n    max_title_len: 5\n"), 0o644))
+	r, err := tenant.Load(path)
+	assert.NoError(t, err)
+	c, ok := r.Config("acme")
+	assert.True(t, ok)
+	assert.Equal(t, 5, c.MaxTitleLen)
+	assert.Error(t, c.Check("abacaba", "text"))
+	assert.NoError(t, c.Check("абвгд", "text"))
+
+	assert.NoError(t, os.WriteFile(path, []byte("tenants: [acme]\n"), 0o644))
+	_, err = tenant.Load(path)
+	assert.Error(t, err)
+}
+
+func TestTenant_Context(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, tenant.Default, tenant.FromContext(ctx))
+	assert.Equal(t, tenant.ID("acme"), tenant.FromContext(tenant.NewContext(ctx, "acme")))
+	// values set by string key, like gin.Context.Set does
+	assert.Equal(t, tenant.ID("globo"), tenant.FromContext(context.WithValue(ctx, tenant.Key, tenant.ID("globo"))))
+}
+
+// seedTenant fills the tenant of ctx with ads of author 1, some published and one trashed.
+func seedTenant(t *testing.T, ctx context.Context, repo app.Repository) []int64 {
+	ids := []int64{}
+	for _, title := range []string{"aba", "abacaba", "foo"} {
+		adID, err := repo.Add(ctx, title, "text", 1)
+		assert.NoError(t, err)
+		ids = append(ids, adID)
+	}
+	assert.NoError(t, repo.SetStatus(ctx, ids[0], true))
+	assert.NoError(t, repo.Delete(ctx, ids[2]))
+	return ids
+}
+
+func TestTenancy_Repository(t *testing.T) {
+	tests := []struct {
+		name    string
+		newRepo func() app.Repository
+	}{
+		{"map", adrepo.New},
+		{"sharded", func() app.Repository { return adrepo.NewSharded(3) }},
+		{"cached", func() app.Repository { return adcache.New(adrepo.New(), 10, time.Minute) }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := tenancy.NewRepository(tc.newRepo)
+			acme := tenant.NewContext(context.Background(), "acme")
+			other := tenant.NewContext(context.Background(), "globo")
+			ids := seedTenant(t, acme, repo)
+			before, _ := repo.GetAllByTemplate(acme, adpattern.AdPattern{})
+			trashed, _ := repo.ListDeleted(acme, 1)
+
+			// every read of another tenant sees nothing
+			for _, adID := range ids {
+				_, isFound := repo.Find(other, adID)
+				assert.False(t, isFound)
+				_, isFound = repo.FindDeleted(other, adID)
+				assert.False(t, isFound)
+			}
+			list, err := repo.GetAllByTemplate(other, adpattern.AdPattern{})
+			assert.NoError(t, err)
+			assert.Empty(t, list)
+			list, _ = repo.GetAllByTemplate(other, adpattern.AdPattern{AuthorID: 1, Title: "aba"})
+			assert.Empty(t, list)
+			list, _ = repo.GetByTitle(other, "a")
+			assert.Empty(t, list)
+			list, _ = repo.ListDeleted(other, 1)
+			assert.Empty(t, list)
+
+			// and every write of another tenant leaves ads of acme alone
+			_ = repo.SetTitle(other, ids[0], "changed")
+			_ = repo.SetText(other, ids[0], "changed")
+			_ = repo.SetStatus(other, ids[1], true)
+			_ = repo.Restore(other, ids[2])
+			_ = repo.Delete(other, ids[0])
+			_ = repo.DeleteByAuthor(other, 1)
+			purged, _ := repo.Purge(other, time.Now().UTC().Add(time.Hour))
+			assert.Empty(t, purged)
+			_ = repo.Insert(other, ads.Ad{ID: ids[1], Title: "stolen", Text: "id", AuthorID: 2})
+
+			after, _ := repo.GetAllByTemplate(acme, adpattern.AdPattern{})
+			assert.Equal(t, before, after)
+			trashedAfter, _ := repo.ListDeleted(acme, 1)
+			assert.Equal(t, trashed, trashedAfter)
+			list, _ = repo.GetAllByTemplate(other, adpattern.AdPattern{})
+			assert.Len(t, list, 1)
+			assert.Equal(t, "stolen", list[0].Title)
+		})
+	}
+}
+
+func TestTenancy_Users(t *testing.T) {
+	users := tenancy.NewUsers(customer.New)
+	acme := tenant.NewContext(context.Background(), "acme")
+	other := tenant.NewContext(context.Background(), "globo")
+	_, err := users.CreateByID(acme, "tom", "tom@mail.ru", 1)
+	assert.NoError(t, err)
+
+	_, isFound := users.Find(other, 1)
+	assert.False(t, isFound)
+	_, isFound = users.FindByEmail(other, "tom@mail.ru")
+	assert.False(t, isFound)
+	_ = users.ChangeInfo(other, 1, "changed", "changed@mail.ru")
+	_ = users.SetVerified(other, 1, true)
+	_, _ = users.DeleteByID(other, 1)
+	// IDs and emails are unique within a tenant only
+	_, err = users.CreateByID(other, "cat", "tom@mail.ru", 1)
+	assert.NoError(t, err)
+
+	u, isFound := users.Find(acme, 1)
+	assert.True(t, isFound)
+	assert.Equal(t, "tom", u.Nickname)
+	assert.False(t, u.Verified)
+	u, _ = users.Find(other, 1)
+	assert.Equal(t, "cat", u.Nickname)
+}
+
+type statement struct {
+	query string
+	args  []driver.NamedValue
+}
+
+// recordingConn is a database/sql driver connection remembering statements it gets
+// and returning no rows.
+type recordingConn struct {
+	mx         *sync.Mutex
+	statements *[]statement
+}
+
+func (d recordingConn) Connect(context.Context) (driver.Conn, error) { return d, nil }
+func (d recordingConn) Driver() driver.Driver                        { return nil }
+func (d recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements aren't supported")
+}
+func (d recordingConn) Close() error              { return nil }
+func (d recordingConn) Begin() (driver.Tx, error) { return d, nil }
+func (d recordingConn) Commit() error             { return nil }
+func (d recordingConn) Rollback() error           { return nil }
+
+func (d recordingConn) record(query string, args []driver.NamedValue) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	*d.statements = append(*d.statements, statement{query: query, args: args})
+}
+
+func (d recordingConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	d.record(query, args)
+	return noRows{}, nil
+}
+
+func (d recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	d.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+type noRows struct{}
+
+func (noRows) Columns() []string         { return []string{} }
+func (noRows) Close() error              { return nil }
+func (noRows) Next([]driver.Value) error { return io.EOF }
+
+func TestTenancy_SQL(t *testing.T) {
+	for _, id := range []tenant.ID{"acme", tenant.Default} {
+		t.Run(string(id), func(t *testing.T) {
+			statements := []statement{}
+			db := sql.OpenDB(recordingConn{mx: &sync.Mutex{}, statements: &statements})
+			defer db.Close()
+			repo := sqlstore.NewAdRepo(db)
+			users := sqlstore.NewUsers(db)
+			ctx := context.Background()
+			if id != tenant.Default {
+				ctx = tenant.NewContext(ctx, id)
+			}
+
+			_, _ = repo.Find(ctx, 1)
+			_, _ = repo.GetByTitle(ctx, "aba")
+			_, _ = repo.Add(ctx, "aba", "caba", 1)
+			_ = repo.Delete(ctx, 1)
+			_ = repo.DeleteByAuthor(ctx, 1)
+			_, _ = repo.FindDeleted(ctx, 1)
+			_, _ = repo.ListDeleted(ctx, 1)
+			_ = repo.Restore(ctx, 1)
+			_, _ = repo.Purge(ctx, time.Now())
+			_ = repo.SetTitle(ctx, 1, "aba")
+			_ = repo.SetText(ctx, 1, "caba")
+			_ = repo.SetStatus(ctx, 1, true)
+			_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{})
+			_, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{IsLTimeSet: true, IsRTimeSet: true, PublishedOnly: true,
+				AuthorID: 1, Title: "a", Sort: []adpattern.OrderBy{{Field: adpattern.FieldTitle}}})
+			_ = repo.Insert(ctx, ads.Ad{ID: 1, Title: "aba", Text: "caba", AuthorID: 1})
+			_, _ = users.Find(ctx, 1)
+			_, _ = users.FindByEmail(ctx, "tom@mail.ru")
+			_, _ = users.CreateByID(ctx, "tom", "tom@mail.ru", 1)
+			_, _ = users.DeleteByID(ctx, 1)
+			_ = users.ChangeInfo(ctx, 1, "tom", "tom@mail.ru")
+			_ = users.SetVerified(ctx, 1, true)
+			favorites := sqlstore.NewFavorites(db)
+			_ = favorites.Add(ctx, 1, 1)
+			_ = favorites.Remove(ctx, 1, 1)
+			_, _ = favorites.List(ctx, 1)
+			_ = favorites.DeleteByUser(ctx, 1)
+			searches := sqlstore.NewSavedSearches(db)
+			_, _ = searches.Add(ctx, search.SavedSearch{UserID: 1, Name: "aba"})
+			_, _ = searches.Find(ctx, 1)
+			_ = searches.Delete(ctx, 1)
+			_, _ = searches.ListByUser(ctx, 1)
+			_ = searches.DeleteByUser(ctx, 1)
+			_, _ = searches.All(ctx)
+			externalIDs := sqlstore.NewExternalIDs(db)
+			_ = externalIDs.Set(ctx, "ext", 1)
+			_, _ = externalIDs.Find(ctx, "ext")
+			_, _ = externalIDs.FindByAd(ctx, 1)
+			_ = externalIDs.DeleteByAd(ctx, 1)
+			messages := sqlstore.NewMessages(db)
+			_, _ = messages.FindThread(ctx, 1)
+			_, _ = messages.FindThreadByAd(ctx, 1, 2)
+			_, _ = messages.CreateThread(ctx, 1, 2, 1)
+			_, _ = messages.ListThreads(ctx, 1)
+			_, _ = messages.AddMessage(ctx, 1, 2, "aba")
+			_, _ = messages.ListMessages(ctx, 1, 0, 10)
+			_, _ = messages.MarkRead(ctx, 1, 1, 1)
+			_ = messages.DeleteByAd(ctx, 1)
+			_ = messages.DeleteByUser(ctx, 1)
+			blocks := sqlstore.NewBlocks(db)
+			_ = blocks.Block(ctx, 1, 2)
+			_ = blocks.Unblock(ctx, 1, 2)
+			_, _ = blocks.IsBlocked(ctx, 1, 2)
+			_ = blocks.DeleteByUser(ctx, 1)
+			_ = sqlstore.NewUnitOfWork(db).Do(ctx, func(ctx context.Context, repo app.Repository, users app.Users) error {
+				_, _ = repo.Find(ctx, 1)
+				_, _ = users.Find(ctx, 1)
+				return nil
+			})
+
+			assert.Len(t, statements, 50)
+			for _, s := range statements {
+				assert.Contains(t, s.query, "tenant_id", s.query)
+				if assert.NotEmpty(t, s.args, s.query) {
+					assert.Equal(t, string(id), s.args[0].Value, s.query)
+				}
+				if strings.HasPrefix(s.query, "SELECT") || strings.HasPrefix(s.query, "UPDATE") ||
+					strings.HasPrefix(s.query, "DELETE") {
+					assert.Contains(t, s.query, "WHERE tenant_id = $1", s.query)
+				}
+			}
+		})
+	}
+}
+
+func newTenantApp(t *testing.T) app.App {
+	r, err := tenant.NewRegistry(map[tenant.ID]tenant.Config{
+		"acme": {Hosts: []string{"ads.acme.com"}, MaxTitleLen: 5},
+	})
+	assert.NoError(t, err)
+	return app.NewApp(tenancy.NewRepository(adrepo.New), tenancy.NewUsers(customer.New), adfilter.New(),
+		app.WithTenants(r))
+}
+
+func TestTenancy_HTTP(t *testing.T) {
+	client := getTestClient(newTenantApp(t))
+	acme := client.withTenant("acme")
+	_, err := acme.createUser(1, "tom", "tom@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createUser(1, "cat", "tom@mail.ru")
+	assert.NoError(t, err)
+
+	ad, err := acme.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = acme.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+	_, err = acme.createAd(1, "abacaba", "caba")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createAd(1, "abacaba", "caba")
+	assert.NoError(t, err)
+
+	list, _ := acme.listAdsBasic()
+	assert.Len(t, list.Data, 1)
+	list, _ = client.listAdsBasic()
+	assert.Empty(t, list.Data)
+	u, _ := client.getUserByID(1)
+	assert.Equal(t, "cat", u.Data.Nickname)
+
+	_, err = client.withTenant("initech").listAdsBasic()
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+// fixedIDs gives every registered user the same ID, as it happens in different tenants.
+type fixedIDs int64
+
+func (g fixedIDs) NextID() (int64, error) {
+	return int64(g), nil
+}
+
+func TestTenancy_Accounts(t *testing.T) {
+	r, err := tenant.NewRegistry(map[tenant.ID]tenant.Config{"acme": {}})
+	assert.NoError(t, err)
+	m := mailer.NewMemory()
+	client := getTestClient(app.NewApp(tenancy.NewRepository(adrepo.New), tenancy.NewUsers(customer.New), adfilter.New(),
+		app.WithTenants(r), app.WithAccounts(fixedIDs(7), accounts.NewBcrypt(bcrypt.MinCost),
+			accounts.NewCredentials(), accounts.NewSessions()), app.WithVerification(tokens.New(), m, time.Hour)))
+	acme := client.withTenant("acme")
+
+	u, err := acme.register("tom", "tom@mail.ru", "acme password")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), u.Data.ID)
+	acmeToken := lastToken(t, m, "tom@mail.ru")
+	u, err = client.register("tom", "tom@mail.ru", "default password")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), u.Data.ID)
+
+	// the same user ID has its own password in every tenant
+	_, err = client.login("tom@mail.ru", "acme password")
+	assert.ErrorIs(t, err, ErrForbidden)
+	s, err := acme.login("tom@mail.ru", "acme password")
+	assert.NoError(t, err)
+	_, err = acme.getAccount(s.Data.Token)
+	assert.NoError(t, err)
+	_, err = client.getAccount(s.Data.Token)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// verification tokens don't work in other tenants, nor are they used up there
+	_, err = client.verifyEmail(7, acmeToken)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	verified, err := acme.verifyEmail(7, acmeToken)
+	assert.NoError(t, err)
+	assert.True(t, verified.Data.Verified)
+
+	// deleting the user of a tenant ends sessions there only
+	other, err := client.login("tom@mail.ru", "default password")
+	assert.NoError(t, err)
+	_, err = client.deleteUserByID(7)
+	assert.NoError(t, err)
+	_, err = client.getAccount(other.Data.Token)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = acme.getAccount(s.Data.Token)
+	assert.NoError(t, err)
+	_, err = acme.login("tom@mail.ru", "acme password")
+	assert.NoError(t, err)
+}
+
+func TestTenancy_Stores(t *testing.T) {
+	r, err := tenant.NewRegistry(map[tenant.ID]tenant.Config{"acme": {}})
+	assert.NoError(t, err)
+	outbox := notifier.NewOutbox(notifier.DefaultLimit)
+	a := app.NewApp(tenancy.NewRepository(adrepo.New), tenancy.NewUsers(customer.New), adfilter.New(),
+		app.WithTenants(r), app.WithAdminKey("secret"), app.WithFavorites(tenancy.NewFavorites(favorites.New)),
+		app.WithSavedSearches(tenancy.NewSavedSearches(searches.New), outbox),
+		app.WithMessages(tenancy.NewMessages(chat.New), tenancy.NewBlocks(chat.NewBlocks)),
+		app.WithExternalIDs(tenancy.NewExternalIDs(extids.New)))
+	admin := app.ContextWithAdminKey(context.Background(), "secret")
+	acme := tenant.NewContext(admin, "acme")
+
+	// both tenants have the same users, a seller with an ad and a buyer
+	adIDs := map[tenant.ID]int64{}
+	for _, ctx := range []context.Context{admin, acme} {
+		_, err = a.CreateUserByID(ctx, "seller", "seller@mail.ru", 1)
+		assert.NoError(t, err)
+		_, err = a.CreateUserByID(ctx, "buyer", "buyer@mail.ru", 2)
+		assert.NoError(t, err)
+		ad, err := a.CreateAd(ctx, "aba", "caba", 1)
+		assert.NoError(t, err)
+		adIDs[tenant.FromContext(ctx)] = ad.ID
+	}
+	assert.Equal(t, adIDs[tenant.Default], adIDs["acme"])
+	adID := adIDs["acme"]
+
+	_, err = a.AddFavorite(acme, 2, adID)
+	assert.NoError(t, err)
+	_, err = a.SaveSearch(acme, 2, "aba", adpattern.AdPattern{Title: "aba"})
+	assert.NoError(t, err)
+	thread, err := a.OpenThread(acme, adID, 2)
+	assert.NoError(t, err)
+	_, err = a.SendMessage(acme, thread.ID, 2, "hello")
+	assert.NoError(t, err)
+	_, err = a.BlockUser(admin, 1, 2)
+	assert.NoError(t, err)
+
+	// the default tenant sees none of it
+	list, err := a.ListFavorites(admin, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+	saved, err := a.ListSavedSearches(admin, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, saved)
+	threads, err := a.ListThreads(admin, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, threads)
+	_, err = a.GetMessages(admin, thread.ID, 2, 0, 0)
+	assert.Error(t, err)
+
+	// and blocks of the default tenant don't stop the chat in acme
+	subCtx, cancel := context.WithCancel(acme)
+	defer cancel()
+	events, err := a.SubscribeMessages(subCtx, 1)
+	assert.NoError(t, err)
+	_, err = a.OpenThread(admin, adID, 2)
+	assert.ErrorIs(t, err, app.ErrNoAccess)
+	_, err = a.SendMessage(acme, thread.ID, 2, "still here")
+	assert.NoError(t, err)
+	select {
+	case e := <-events:
+		assert.Equal(t, "still here", e.Message.Text)
+	case <-time.After(time.Second):
+		t.Fatal("no event in acme")
+	}
+
+	// publishing in the default tenant notifies nobody in acme
+	_, err = a.ChangeAdStatus(admin, adID, 1, true)
+	assert.NoError(t, err)
+	assert.Empty(t, outbox.Pending())
+	// and messages there don't reach subscribers in acme, though user IDs are the same
+	_, err = a.UnblockUser(admin, 1, 2)
+	assert.NoError(t, err)
+	defaultThread, err := a.OpenThread(admin, adID, 2)
+	assert.NoError(t, err)
+	_, err = a.SendMessage(admin, defaultThread.ID, 2, "wrong tenant")
+	assert.NoError(t, err)
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event %v", e)
+	default:
+	}
+	_, err = a.ChangeAdStatus(acme, adID, 1, true)
+	assert.NoError(t, err)
+	if assert.Len(t, outbox.Pending(), 1) {
+		assert.Equal(t, int64(2), outbox.Pending()[0].UserID)
+	}
+
+	// external IDs are looked up within the tenant
+	rec := bulk.Record{ExternalID: "ext-1", Title: "imported", Text: "caba", AuthorID: 1}
+	_, outcome, err := a.ImportAd(acme, rec, false)
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.Created, outcome)
+	_, outcome, err = a.ImportAd(admin, rec, false)
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.Created, outcome)
+
+	// deleting the buyer of the default tenant leaves the buyer of acme alone
+	_, err = a.DeleteUserByID(admin, 2)
+	assert.NoError(t, err)
+	list, _ = a.ListFavorites(acme, 2)
+	assert.Len(t, list, 1)
+	saved, _ = a.ListSavedSearches(acme, 2)
+	assert.Len(t, saved, 1)
+	msgs, err := a.GetMessages(acme, thread.ID, 2, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 2)
+}
+
+func TestTenancy_GRPC(t *testing.T) {
+	r, _ := tenant.NewRegistry(map[tenant.ID]tenant.Config{"acme": {}})
+	env := newAdctlEnv(t, app.WithTenants(r))
+	_, err := env.run("--tenant", "acme", "users", "create", "--id", "1", "--nickname", "Tom", "--email", "tom@mail.ru")
+	assert.NoError(t, err)
+	_, err = env.run("--tenant", "acme", "ads", "create", "--user", "1", "--title", "aba", "--text", "caba")
+	assert.NoError(t, err)
+
+	out, err := env.run("--tenant", "acme", "ads", "list", "--all")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "aba")
+	out, err = env.run("ads", "list", "--all")
+	assert.NoError(t, err)
+	assert.Equal(t, "no results\n", out)
+	_, err = env.run("users", "get", "1")
+	assert.Error(t, err)
+	_, err = env.run("--tenant", "initech", "users", "get", "1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adcache"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/chat"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adpattern"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	client := getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New()))
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	_, _ = client.createUser(2, "other", "other@mail.ru")
+	first, _ := client.createAd(1, "aba", "caba")
+	second, _ := client.createAd(1, "foo", "bar")
+
+	_, err := client.deleteAd(1, first.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.deleteAd(1, second.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.getAdByID(first.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	list, _ := client.listAdsBasic()
+	assert.Empty(t, list.Data)
+	byTitle, _ := client.getAdsByTitle("ab")
+	assert.Empty(t, byTitle.Data)
+
+	trash, err := client.listTrash(1)
+	assert.NoError(t, err)
+	assert.Len(t, trash.Data, 2)
+	assert.Equal(t, second.Data.ID, trash.Data[0].ID)
+	assert.NotNil(t, trash.Data[0].DeletionDate)
+	trash, _ = client.listTrash(2)
+	assert.Empty(t, trash.Data)
+	_, err = client.listTrash(3)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.restoreAd(2, first.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	restored, err := client.restoreAd(1, first.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "aba", restored.Data.Title)
+	assert.Nil(t, restored.Data.DeletionDate)
+	_, err = client.restoreAd(1, first.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	ad, err := client.getAdByID(first.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Data.ID, ad.Data.ID)
+	trash, _ = client.listTrash(1)
+	assert.Len(t, trash.Data, 1)
+}
+
+func TestTrash_DeleteUser(t *testing.T) {
+	ctx := context.Background()
+	repo := adrepo.New()
+	a := app.NewApp(repo, customer.New(), adfilter.New())
+	_, _ = a.CreateUserByID(ctx, "author", "author@mail.ru", 1)
+	_, _ = a.CreateAd(ctx, "aba", "caba", 1)
+	_, _ = a.CreateAd(ctx, "foo", "bar", 1)
+
+	_, err := a.DeleteUserByID(ctx, 1)
+	assert.NoError(t, err)
+	list, _ := a.GetAllAdsByTemplate(ctx, adpattern.AdPattern{AuthorID: 1})
+	assert.Empty(t, list)
+	trash, err := repo.ListDeleted(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 2)
+	for _, ad := range trash {
+		assert.True(t, ad.IsDeleted())
+	}
+}
+
+func TestTrash_Retention(t *testing.T) {
+	ctx := context.Background()
+	repo := adrepo.New()
+	messages := chat.New()
+	a := app.NewApp(repo, customer.New(), adfilter.New(),
+		app.WithMessages(messages, chat.NewBlocks()), app.WithRetention(0))
+	_, _ = a.CreateUserByID(ctx, "author", "author@mail.ru", 1)
+	_, _ = a.CreateUserByID(ctx, "buyer", "buyer@mail.ru", 2)
+	ad, _ := a.CreateAd(ctx, "aba", "caba", 1)
+	thread, _ := a.OpenThread(ctx, ad.ID, 2)
+	_, _ = a.SendMessage(ctx, thread.ID, 2, "hello")
+	_, _ = a.DeleteAd(ctx, ad.ID, 1)
+
+	trash, err := a.ListTrash(ctx, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	_, err = a.RestoreAd(ctx, ad.ID, 1)
+	assert.ErrorIs(t, err, app.ErrWrongFormat)
+	_, err = a.GetMessages(ctx, thread.ID, 2, 0, 10)
+	assert.ErrorIs(t, err, app.ErrWrongFormat)
+
+	n, err := a.PurgeTrash(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, isFound := repo.FindDeleted(ctx, ad.ID)
+	assert.False(t, isFound)
+	_, isFound = messages.FindThread(ctx, thread.ID)
+	assert.False(t, isFound)
+	n, _ = a.PurgeTrash(ctx)
+	assert.Equal(t, 0, n)
+}
+
+func TestTrash_RestoreKeepsThreads(t *testing.T) {
+	ctx := context.Background()
+	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithMessages(chat.New(), chat.NewBlocks()))
+	_, _ = a.CreateUserByID(ctx, "author", "author@mail.ru", 1)
+	_, _ = a.CreateUserByID(ctx, "buyer", "buyer@mail.ru", 2)
+	ad, _ := a.CreateAd(ctx, "aba", "caba", 1)
+	thread, _ := a.OpenThread(ctx, ad.ID, 2)
+	_, _ = a.SendMessage(ctx, thread.ID, 2, "hello")
+
+	_, _ = a.DeleteAd(ctx, ad.ID, 1)
+	threads, _ := a.ListThreads(ctx, 1)
+	assert.Empty(t, threads)
+	n, _ := a.PurgeTrash(ctx)
+	assert.Equal(t, 0, n)
+
+	_, err := a.RestoreAd(ctx, ad.ID, 1)
+	assert.NoError(t, err)
+	threads, _ = a.ListThreads(ctx, 1)
+	assert.Len(t, threads, 1)
+	list, err := a.GetMessages(ctx, thread.ID, 1, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestTrash_Repositories(t *testing.T) {
+	tests := []struct {
+		name string
+		repo func() app.Repository
+	}{
+		{"map", adrepo.New},
+		{"sharded", func() app.Repository { return adrepo.NewSharded(3) }},
+		{"cached", func() app.Repository { return adcache.New(adrepo.New(), 10, time.Minute) }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := tc.repo()
+			ids := []int64{}
+			for i := 0; i < 4; i++ {
+				adID, _ := repo.Add(ctx, "aba", "caba", int64(i%2+1))
+				ids = append(ids, adID)
+			}
+			all, _ := repo.GetAllByTemplate(ctx, adpattern.AdPattern{})
+			assert.Len(t, all, 4)
+
+			assert.NoError(t, repo.Delete(ctx, ids[0]))
+			assert.NoError(t, repo.DeleteByAuthor(ctx, 2))
+			_, isFound := repo.Find(ctx, ids[0])
+			assert.False(t, isFound)
+			ad, isFound := repo.FindDeleted(ctx, ids[0])
+			assert.True(t, isFound)
+			assert.True(t, ad.IsDeleted())
+			all, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{})
+			assert.Len(t, all, 1)
+			byTitle, _ := repo.GetByTitle(ctx, "ab")
+			assert.Len(t, byTitle, 1)
+			trash, _ := repo.ListDeleted(ctx, 2)
+			assert.Len(t, trash, 2)
+
+			assert.NoError(t, repo.Restore(ctx, ids[1]))
+			ad, isFound = repo.Find(ctx, ids[1])
+			assert.True(t, isFound)
+			assert.False(t, ad.IsDeleted())
+			all, _ = repo.GetAllByTemplate(ctx, adpattern.AdPattern{AuthorID: 2})
+			assert.Len(t, all, 1)
+
+			purged, err := repo.Purge(ctx, time.Now().UTC().Add(-time.Hour))
+			assert.NoError(t, err)
+			assert.Empty(t, purged)
+			purged, err = repo.Purge(ctx, time.Now().UTC().Add(time.Hour))
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []int64{ids[0], ids[3]}, purged)
+			_, isFound = repo.FindDeleted(ctx, ids[3])
+			assert.False(t, isFound)
+
+			next, _ := repo.Add(ctx, "foo", "bar", 1)
+			assert.NotContains(t, ids[:3], next)
+		})
+	}
+}
+
+func TestPersistentMapRepo_Trash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	l := openAdsLog(t, dir)
+	repo, _ := adrepo.NewPersistent(l)
+	first, _ := repo.Add(ctx, "aba", "caba", 1)
+	second, _ := repo.Add(ctx, "foo", "bar", 2)
+	third, _ := repo.Add(ctx, "alpha", "beta", 2)
+	assert.NoError(t, repo.Delete(ctx, first))
+	assert.NoError(t, repo.DeleteByAuthor(ctx, 2))
+	assert.NoError(t, repo.Restore(ctx, third))
+	assert.NoError(t, l.Close())
+
+	for i := 0; i < 2; i++ {
+		l = openAdsLog(t, dir)
+		repo, err := adrepo.NewPersistent(l)
+		assert.NoError(t, err)
+		_, isFound := repo.Find(ctx, first)
+		assert.False(t, isFound)
+		_, isFound = repo.FindDeleted(ctx, first)
+		assert.True(t, isFound)
+		trash, _ := repo.ListDeleted(ctx, 2)
+		assert.Len(t, trash, 1)
+		assert.Equal(t, second, trash[0].ID)
+		_, isFound = repo.Find(ctx, third)
+		assert.True(t, isFound)
+		assert.NoError(t, repo.Snapshot())
+		assert.NoError(t, l.Close())
+	}
+}
+
+func (suite *TestConfig) TestGRPCTrash() {
+	a, _ := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "Tom", Email: "example@mail.com", UserId: 3})
+	b, _ := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "cat", Email: "cat@mail.com", UserId: 5})
+	ad, _ := suite.client.CreateAd(suite.ctx, &grpcPort.CreateAdRequest{Title: "aba", Text: "caba", UserId: a.UserId})
+	_, err := suite.client.DeleteAd(suite.ctx, &grpcPort.DeleteAdRequest{AdId: ad.Id, UserId: a.UserId})
+	suite.Assert().NoError(err, "suite.client.DeleteAd")
+
+	trash, err := suite.client.ListTrash(suite.ctx, &grpcPort.GetUserRequest{Id: a.UserId})
+	suite.Assert().NoError(err, "suite.client.ListTrash")
+	suite.Assert().Len(trash.List, 1)
+	suite.Assert().NotNil(trash.List[0].DeletionDate)
+
+	_, err = suite.client.RestoreAd(suite.ctx, &grpcPort.RestoreAdRequest{AdId: ad.Id, UserId: b.UserId})
+	suite.Assert().ErrorIs(err, ErrorForbidden)
+	restored, err := suite.client.RestoreAd(suite.ctx, &grpcPort.RestoreAdRequest{AdId: ad.Id, UserId: a.UserId})
+	suite.Assert().NoError(err, "suite.client.RestoreAd")
+	suite.Assert().Nil(restored.DeletionDate)
+	_, err = suite.client.GetAdByID(suite.ctx, &grpcPort.GetAdRequest{Id: ad.Id})
+	suite.Assert().NoError(err, "suite.client.GetAdByID")
+}
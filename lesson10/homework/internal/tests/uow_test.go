@@ -0,0 +1,316 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"homework10/internal/adapters/accounts"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/snowflake"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/adpattern"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/bulk"
+	"homework10/internal/tests/mocks"
+	"homework10/internal/user"
+	"testing"
+	"time"
+)
+
+func TestUnitOfWork_DeleteUserKeepsUserIfAdsAreNotDeleted(t *testing.T) {
+	ctx := context.Background()
+	userID := int64(1)
+	userAds := []ads.Ad{{ID: 1, AuthorID: userID, Title: "aba"}, {ID: 2, AuthorID: userID, Title: "caba"}}
+
+	repo := &mocks.Repository{}
+	repo.On("GetAllByTemplate", mock.Anything, adpattern.AdPattern{AuthorID: userID}).
+		Return(userAds, nil)
+	repo.On("DeleteByAuthor", mock.Anything, userID).
+		Return(fmt.Errorf("delete by author error"))
+	repo.On("Restore", mock.Anything, mock.AnythingOfType("int64")).
+		Return(nil)
+
+	a := app.NewApp(repo, customer.New(), adfilter.New())
+	_, _ = a.CreateUserByID(ctx, "test user", "example@mail.ru", userID)
+	_, err := a.DeleteUserByID(ctx, userID)
+	assert.ErrorIs(t, err, app.ErrApp)
+
+	u, isFound, _ := a.FindUser(ctx, userID)
+	assert.True(t, isFound)
+	assert.Equal(t, user.User{ID: userID, Nickname: "test user", Email: "example@mail.ru"}, u)
+	// the ads are restored from the trash, not overwritten with their copies
+	repo.AssertCalled(t, "Restore", mock.Anything, userAds[0].ID)
+	repo.AssertCalled(t, "Restore", mock.Anything, userAds[1].ID)
+	repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestUnitOfWork_UpdateAdRestoresText(t *testing.T) {
+	ctx := context.Background()
+	userID := int64(1)
+	ad := ads.Ad{ID: 5, AuthorID: userID, Title: "aba", Text: "caba"}
+
+	repo := &mocks.Repository{}
+	repo.On("Find", mock.Anything, ad.ID).Return(ad, true)
+	repo.On("SetText", mock.Anything, ad.ID, "new text").Return(nil).Once()
+	repo.On("SetTitle", mock.Anything, ad.ID, "new title").
+		Return(fmt.Errorf("set title error")).Once()
+	// the changed fields are put back as they were, without stamping a new UpdateDate
+	repo.On("Insert", mock.Anything, ad).Return(nil).Twice()
+
+	a := app.NewApp(repo, customer.New(), adfilter.New())
+	_, _ = a.CreateUserByID(ctx, "test user", "example@mail.ru", userID)
+	_, err := a.UpdateAd(ctx, ad.ID, userID, "new title", "new text")
+	assert.ErrorIs(t, err, app.ErrApp)
+	repo.AssertExpectations(t)
+}
+
+func TestUnitOfWork_DeleteUserKeepsUserIfDataIsNotDeleted(t *testing.T) {
+	ctx := context.Background()
+	favorites := &mocks.Favorites{}
+	favorites.On("DeleteByUser", mock.Anything, int64(1)).Return(fmt.Errorf("delete favorites error"))
+
+	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New(), app.WithFavorites(favorites))
+	_, _ = a.CreateUserByID(ctx, "test user", "example@mail.ru", 1)
+	_, _ = a.CreateAd(ctx, "aba", "caba", 1)
+	_, err := a.DeleteUserByID(ctx, 1)
+	assert.ErrorIs(t, err, app.ErrApp)
+
+	_, isFound, _ := a.FindUser(ctx, 1)
+	assert.True(t, isFound)
+	list, _ := a.GetAllAdsByTemplate(ctx, adpattern.AdPattern{AuthorID: 1})
+	assert.Len(t, list, 1)
+}
+
+func TestUnitOfWork_RegisterKeepsNoUserWithoutCredentials(t *testing.T) {
+	credentials := &mocks.Credentials{}
+	credentials.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(fmt.Errorf("set credentials error"))
+	ids, _ := snowflake.New(1)
+	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithAccounts(ids, accounts.NewBcrypt(bcrypt.MinCost), credentials, accounts.NewSessions()))
+
+	_, err := a.Register(context.Background(), "first", "first@mail.ru", "password1")
+	assert.ErrorIs(t, err, app.ErrApp)
+	// the email is free, as the user is rolled back
+	credentials.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Unset()
+	credentials.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	_, err = a.Register(context.Background(), "first", "first@mail.ru", "password1")
+	assert.NoError(t, err)
+}
+
+func TestJournalUnitOfWork_Rollback(t *testing.T) {
+	ctx := context.Background()
+	repo := adrepo.New()
+	users := customer.New()
+	_, _ = users.CreateByID(ctx, "first", "first@mail.ru", 1)
+	_, _ = users.CreateByID(ctx, "second", "second@mail.ru", 2)
+	first, _ := repo.Add(ctx, "aba", "caba", 1)
+	second, _ := repo.Add(ctx, "foo", "bar", 2)
+	before, _ := repo.GetAllByTemplate(ctx, adpattern.AdPattern{})
+
+	uow := app.NewJournalUnitOfWork(repo, users)
+	err := uow.Do(ctx, func(ctx context.Context, repo app.Repository, users app.Users) error {
+		_, _ = repo.Add(ctx, "new", "ad", 2)
+		_ = repo.SetTitle(ctx, second, "food")
+		_ = repo.SetStatus(ctx, second, true)
+		_ = repo.Delete(ctx, first)
+		_, _ = users.CreateByID(ctx, "third", "third@mail.ru", 3)
+		_ = users.ChangeInfo(ctx, 2, "changed", "changed@mail.ru")
+		_, _ = users.DeleteByID(ctx, 1)
+		_ = repo.DeleteByAuthor(ctx, 2)
+		return fmt.Errorf("unit of work error")
+	})
+	assert.Error(t, err)
+
+	after, _ := repo.GetAllByTemplate(ctx, adpattern.AdPattern{})
+	assert.Equal(t, before, after)
+	u, isFound := users.Find(ctx, 1)
+	assert.True(t, isFound)
+	assert.Equal(t, "first", u.Nickname)
+	u, _ = users.Find(ctx, 2)
+	assert.Equal(t, "second", u.Nickname)
+	_, isFound = users.Find(ctx, 3)
+	assert.False(t, isFound)
+}
+
+func TestJournalUnitOfWork_RollbackKeepsOtherWrites(t *testing.T) {
+	ctx := context.Background()
+	repo := adrepo.New()
+	users := customer.New()
+	_, _ = users.CreateByID(ctx, "first", "first@mail.ru", 1)
+	adID, _ := repo.Add(ctx, "aba", "caba", 1)
+
+	uow := app.NewJournalUnitOfWork(repo, users)
+	err := uow.Do(ctx, func(ctx context.Context, uowRepo app.Repository, uowUsers app.Users) error {
+		_ = uowRepo.SetTitle(ctx, adID, "changed")
+		_ = uowUsers.SetVerified(ctx, 1, true)
+		// writes made outside of the unit of work meanwhile
+		_ = repo.SetText(ctx, adID, "concurrent")
+		_ = repo.SetStatus(ctx, adID, true)
+		_ = users.ChangeInfo(ctx, 1, "renamed", "first@mail.ru")
+		return fmt.Errorf("unit of work error")
+	})
+	assert.Error(t, err)
+
+	ad, _ := repo.Find(ctx, adID)
+	assert.Equal(t, "aba", ad.Title)
+	assert.Equal(t, "concurrent", ad.Text)
+	assert.True(t, ad.Published)
+	u, _ := users.Find(ctx, 1)
+	assert.Equal(t, "renamed", u.Nickname)
+	assert.False(t, u.Verified)
+}
+
+func TestJournalUnitOfWork_Commit(t *testing.T) {
+	ctx := context.Background()
+	repo := adrepo.New()
+	users := customer.New()
+
+	uow := app.NewJournalUnitOfWork(repo, users)
+	var adID int64
+	err := uow.Do(ctx, func(ctx context.Context, repo app.Repository, users app.Users) error {
+		_, _ = users.CreateByID(ctx, "first", "first@mail.ru", 1)
+		adID, _ = repo.Add(ctx, "aba", "caba", 1)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	_, isFound := users.Find(ctx, 1)
+	assert.True(t, isFound)
+	_, isFound = repo.Find(ctx, adID)
+	assert.True(t, isFound)
+}
+
+func TestSQLUnitOfWork_DeleteUser(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	a := app.NewApp(sqlstore.NewAdRepo(db), sqlstore.NewUsers(db), adfilter.New(),
+		app.WithUnitOfWork(sqlstore.NewUnitOfWork(db)))
+	userRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "nickname", "email", "verified"}).
+			AddRow(1, "test user", "example@mail.ru", true)
+	}
+
+	sqlMock.ExpectQuery("SELECT id, nickname, email, verified FROM users").WithArgs("default", 1).WillReturnRows(userRows())
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("DELETE FROM users").WithArgs("default", 1).WillReturnRows(userRows())
+	sqlMock.ExpectExec("UPDATE ads SET deleted_at (.+) WHERE tenant_id (.+) AND author_id").WithArgs("default", sqlmock.AnyArg(), 1).
+		WillReturnError(fmt.Errorf("delete by author error"))
+	sqlMock.ExpectRollback()
+	_, err = a.DeleteUserByID(ctx, 1)
+	assert.ErrorIs(t, err, app.ErrApp)
+
+	sqlMock.ExpectQuery("SELECT id, nickname, email, verified FROM users").WithArgs("default", 1).WillReturnRows(userRows())
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("DELETE FROM users").WithArgs("default", 1).WillReturnRows(userRows())
+	sqlMock.ExpectExec("UPDATE ads SET deleted_at (.+) WHERE tenant_id (.+) AND author_id").WithArgs("default", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+	u, err := a.DeleteUserByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "test user", u.Nickname)
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSQLUnitOfWork_DeleteUserWithAccounts(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ids, _ := snowflake.New(1)
+	a := app.NewApp(sqlstore.NewAdRepo(db), sqlstore.NewUsers(db), adfilter.New(),
+		app.WithUnitOfWork(sqlstore.NewUnitOfWork(db)), app.WithAccounts(ids, accounts.NewBcrypt(bcrypt.MinCost),
+			sqlstore.NewCredentials(db), sqlstore.NewSessions(db)))
+
+	sqlMock.ExpectQuery("SELECT id, nickname, email, verified FROM users").WithArgs("default", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nickname", "email", "verified"}).
+			AddRow(1, "test user", "example@mail.ru", true))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("DELETE FROM users").WithArgs("default", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nickname", "email", "verified"}).
+			AddRow(1, "test user", "example@mail.ru", true))
+	sqlMock.ExpectExec("UPDATE ads SET deleted_at").WillReturnResult(sqlmock.NewResult(0, 0))
+	// sessions and credentials are deleted in the same transaction, so the user survives a failure
+	sqlMock.ExpectExec("DELETE FROM sessions").WithArgs("default", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("DELETE FROM credentials").WithArgs("default", 1).
+		WillReturnError(fmt.Errorf("delete credentials error"))
+	sqlMock.ExpectRollback()
+
+	_, err = a.DeleteUserByID(ctx, 1)
+	assert.ErrorIs(t, err, app.ErrApp)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSQLUnitOfWork_UpdateAd(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	a := app.NewApp(sqlstore.NewAdRepo(db), sqlstore.NewUsers(db), adfilter.New(),
+		app.WithUnitOfWork(sqlstore.NewUnitOfWork(db)))
+	now := time.Now().UTC()
+
+	sqlMock.ExpectQuery("SELECT id, nickname, email, verified FROM users").WithArgs("default", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nickname", "email", "verified"}).
+			AddRow(1, "test user", "example@mail.ru", true))
+	sqlMock.ExpectQuery("SELECT (.+) FROM ads WHERE tenant_id (.+) AND id").WithArgs("default", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "text", "author_id", "published",
+			"hidden", "lang", "lang_declared", "creation_date", "update_date"}).AddRow(7, "aba", "caba", 1, false, false, "", false, now, now))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("UPDATE ads SET text").WithArgs("default", "new text", sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("UPDATE ads SET title").WithArgs("default", "new title", sqlmock.AnyArg(), 7).
+		WillReturnError(fmt.Errorf("set title error"))
+	sqlMock.ExpectRollback()
+
+	_, err = a.UpdateAd(ctx, 7, 1, "new title", "new text")
+	assert.ErrorIs(t, err, app.ErrApp)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSQLUnitOfWork_ImportAd(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	a := app.NewApp(sqlstore.NewAdRepo(db), sqlstore.NewUsers(db), adfilter.New(),
+		app.WithUnitOfWork(sqlstore.NewUnitOfWork(db)), app.WithExternalIDs(sqlstore.NewExternalIDs(db)),
+		app.WithAdminKey("secret"))
+	ctx := app.ContextWithAdminKey(context.Background(), "secret")
+	now := time.Now().UTC()
+
+	sqlMock.ExpectQuery("SELECT id, nickname, email, verified FROM users").WithArgs("default", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nickname", "email", "verified"}).
+			AddRow(1, "test user", "example@mail.ru", true))
+	sqlMock.ExpectBegin()
+	// the external ID is locked and looked up in the transaction the ad is created in
+	sqlMock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs("default", "ext-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery("SELECT ad_id FROM external_ids").WithArgs("default", "ext-1").
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id"}))
+	sqlMock.ExpectQuery("INSERT INTO ads").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	sqlMock.ExpectExec("INSERT INTO external_ids").WithArgs("default", "ext-1", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectQuery("SELECT (.+) FROM ads WHERE tenant_id (.+) AND id").WithArgs("default", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "text", "author_id", "published",
+			"hidden", "lang", "lang_declared", "creation_date", "update_date"}).AddRow(7, "aba", "caba", 1, false, false, "", false, now, now))
+	sqlMock.ExpectCommit()
+
+	ad, outcome, err := a.ImportAd(ctx, bulk.Record{ExternalID: "ext-1", Title: "aba", Text: "caba", AuthorID: 1}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.Created, outcome)
+	assert.Equal(t, int64(7), ad.ID)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -5,24 +5,34 @@ import (
 	"encoding/json"
 	"fmt"
 	"homework10/internal/app"
+	"homework10/internal/bulk"
 	"homework10/internal/ports/httpgin"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type adData struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	AuthorID  int64  `json:"author_id"`
-	Published bool   `json:"published"`
+	ID           int64      `json:"id"`
+	Title        string     `json:"title"`
+	Text         string     `json:"text"`
+	AuthorID     int64      `json:"author_id"`
+	Published    bool       `json:"published"`
+	Hidden       bool       `json:"hidden"`
+	Lang         string     `json:"lang"`
+	LangDeclared bool       `json:"lang_declared"`
+	DeletionDate *time.Time `json:"deletion_date"`
 }
 
 type userData struct {
 	Nickname string `json:"nickname" binding:"required"`
 	Email    string `json:"email" binding:"required"`
 	ID       int64  `json:"user_id" binding:"required"`
+	Verified bool   `json:"verified"`
 }
 
 type adResponse struct {
@@ -37,15 +47,211 @@ type adsResponse struct {
 	Data []adData `json:"data"`
 }
 
+type savedSearchData struct {
+	ID            int64  `json:"id"`
+	UserID        int64  `json:"user_id"`
+	Name          string `json:"name"`
+	AuthorID      int64  `json:"author_id"`
+	PublishedOnly bool   `json:"published_only"`
+	LTime         *int64 `json:"l_time"`
+	RTime         *int64 `json:"r_time"`
+}
+
+type savedSearchResponse struct {
+	Data savedSearchData `json:"data"`
+}
+
+type savedSearchesResponse struct {
+	Data []savedSearchData `json:"data"`
+}
+
+type sessionData struct {
+	Token          string    `json:"token"`
+	UserID         int64     `json:"user_id"`
+	ExpirationDate time.Time `json:"expiration_date"`
+}
+
+type sessionResponse struct {
+	Data sessionData `json:"data"`
+}
+
+type threadData struct {
+	ID       int64 `json:"id"`
+	AdID     int64 `json:"ad_id"`
+	BuyerID  int64 `json:"buyer_id"`
+	SellerID int64 `json:"seller_id"`
+}
+
+type threadResponse struct {
+	Data threadData `json:"data"`
+}
+
+type threadsResponse struct {
+	Data []threadData `json:"data"`
+}
+
+type messageData struct {
+	ID       int64      `json:"id"`
+	ThreadID int64      `json:"thread_id"`
+	SenderID int64      `json:"sender_id"`
+	Text     string     `json:"text"`
+	ReadDate *time.Time `json:"read_date"`
+}
+
+type messageResponse struct {
+	Data messageData `json:"data"`
+}
+
+type messagesResponse struct {
+	Data []messageData `json:"data"`
+}
+
+type auditEntryData struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID string          `json:"request_id"`
+	Transport string          `json:"transport"`
+}
+
+type auditResponse struct {
+	Data []auditEntryData `json:"data"`
+}
+
+type statsBucketData struct {
+	Start         time.Time `json:"start"`
+	Views         int64     `json:"views"`
+	AdsCreated    int       `json:"ads_created"`
+	Published     int       `json:"published"`
+	TimeToPublish float64   `json:"avg_time_to_publish"`
+}
+
+type statsResponse struct {
+	Data struct {
+		UserID  int64             `json:"user_id"`
+		From    time.Time         `json:"from"`
+		To      time.Time         `json:"to"`
+		Buckets []statsBucketData `json:"buckets"`
+	} `json:"data"`
+}
+
+type reportData struct {
+	ID             int64      `json:"id"`
+	AdID           int64      `json:"ad_id"`
+	ReporterID     int64      `json:"reporter_id"`
+	Reason         string     `json:"reason"`
+	Comment        string     `json:"comment"`
+	Status         string     `json:"status"`
+	ResolutionDate *time.Time `json:"resolution_date"`
+}
+
+type reportResponse struct {
+	Data reportData `json:"data"`
+}
+
+type reportsResponse struct {
+	Data []reportData `json:"data"`
+}
+
+type webhookData struct {
+	ID       int64    `json:"id"`
+	URL      string   `json:"url"`
+	Events   []string `json:"events"`
+	AuthorID int64    `json:"author_id"`
+	Lang     string   `json:"lang"`
+	Q        string   `json:"q"`
+	Secret   string   `json:"secret"`
+}
+
+type webhookResponse struct {
+	Data webhookData `json:"data"`
+}
+
+type webhooksResponse struct {
+	Data []webhookData `json:"data"`
+}
+
+type deliveryData struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Event          string          `json:"event"`
+	AdID           int64           `json:"ad_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error"`
+	ResponseStatus int             `json:"response_status"`
+	NextAttempt    *time.Time      `json:"next_attempt"`
+}
+
+type deliveryResponse struct {
+	Data deliveryData `json:"data"`
+}
+
+type deliveriesResponse struct {
+	Data []deliveryData `json:"data"`
+}
+
+type markReadResponse struct {
+	Data struct {
+		ThreadID int64 `json:"thread_id"`
+		UpToID   int64 `json:"up_to_id"`
+		Marked   int   `json:"marked"`
+	} `json:"data"`
+}
+
 var (
 	ErrBadRequest     = fmt.Errorf("bad request")
 	ErrForbidden      = fmt.Errorf("forbidden")
 	InternalServerErr = fmt.Errorf("internal server error")
+	ErrConflict       = fmt.Errorf("conflict")
 )
 
 type testClient struct {
 	client  *http.Client
 	baseURL string
+	// tenant is sent in the X-Tenant-ID header if set
+	tenant string
+	// idempotencyKey is sent in the Idempotency-Key header if set
+	idempotencyKey string
+	// requestID is sent in the X-Request-ID header if set
+	requestID string
+	// language is sent in the Accept-Language header if set
+	language string
+	// tokens are the session tokens of users, the one of the acting user is sent
+	// in the Authorization header of chat requests
+	tokens map[int64]string
+}
+
+// withTenant returns a client of the same server sending requests on behalf of the tenant.
+func (tc *testClient) withTenant(id string) *testClient {
+	res := *tc
+	res.tenant = id
+	return &res
+}
+
+// withIdempotencyKey returns a client of the same server sending the key with its requests.
+func (tc *testClient) withIdempotencyKey(key string) *testClient {
+	res := *tc
+	res.idempotencyKey = key
+	return &res
+}
+
+// withRequestID returns a client of the same server sending the ID with its requests.
+func (tc *testClient) withRequestID(id string) *testClient {
+	res := *tc
+	res.requestID = id
+	return &res
+}
+
+// withLanguage returns a client of the same server asking for messages in the language.
+func (tc *testClient) withLanguage(acceptLanguage string) *testClient {
+	res := *tc
+	res.language = acceptLanguage
+	return &res
 }
 
 func getTestClient(a app.App) *testClient {
@@ -58,7 +264,25 @@ func getTestClient(a app.App) *testClient {
 	}
 }
 
+func (tc *testClient) authorize(req *http.Request, userID int64) {
+	if token, ok := tc.tokens[userID]; ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
 func (tc *testClient) getResponse(req *http.Request, out any) error {
+	if tc.tenant != "" {
+		req.Header.Set("X-Tenant-ID", tc.tenant)
+	}
+	if tc.idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", tc.idempotencyKey)
+	}
+	if tc.requestID != "" {
+		req.Header.Set("X-Request-ID", tc.requestID)
+	}
+	if tc.language != "" {
+		req.Header.Set("Accept-Language", tc.language)
+	}
 	resp, err := tc.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("unexpected error: %w", err)
@@ -74,6 +298,9 @@ func (tc *testClient) getResponse(req *http.Request, out any) error {
 		if resp.StatusCode == http.StatusInternalServerError {
 			return InternalServerErr
 		}
+		if resp.StatusCode == http.StatusConflict {
+			return ErrConflict
+		}
 		return fmt.Errorf("unexpected status code: %s", resp.Status)
 	}
 
@@ -584,3 +811,830 @@ func (tc *testClient) changeUserInfoWrongParams(nickname, email string) (userRes
 
 	return response, nil
 }
+
+func (tc *testClient) getAdWithETag(adID int64, etag string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	if etag != "" {
+		req.Header.Add("If-None-Match", etag)
+	}
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	return resp, nil
+}
+
+func (tc *testClient) addFavorite(userID, adID int64) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/favorites/%d", userID, adID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) removeFavorite(userID, adID int64) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/favorites/%d", userID, adID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listFavorites(userID int64) (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/favorites", userID), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) saveSearch(userID int64, body map[string]any) (savedSearchResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/searches", userID), bytes.NewReader(data))
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response savedSearchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listSavedSearches(userID int64) (savedSearchesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/searches", userID), nil)
+	if err != nil {
+		return savedSearchesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response savedSearchesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) deleteSavedSearch(userID, searchID int64) (savedSearchResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/searches/%d", userID, searchID), nil)
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response savedSearchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) openThread(userID, adID int64) (threadResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return threadResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/threads", adID), bytes.NewReader(data))
+	if err != nil {
+		return threadResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	tc.authorize(req, userID)
+
+	var response threadResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return threadResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listThreads(userID int64) (threadsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/threads", userID), nil)
+	if err != nil {
+		return threadsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response threadsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return threadsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) sendMessage(userID, threadID int64, text string) (messageResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"text":    text,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/threads/%d/messages", threadID), bytes.NewReader(data))
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	tc.authorize(req, userID)
+
+	var response messageResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messageResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) getMessages(userID, threadID, beforeID int64, limit int) (messagesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/threads/%d/messages", threadID), nil)
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Add("user_id", fmt.Sprint(userID))
+	q.Add("before_id", fmt.Sprint(beforeID))
+	q.Add("limit", fmt.Sprint(limit))
+	req.URL.RawQuery = q.Encode()
+
+	tc.authorize(req, userID)
+
+	var response messagesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) markRead(userID, threadID, upToID int64) (markReadResponse, error) {
+	body := map[string]any{
+		"user_id":  userID,
+		"up_to_id": upToID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return markReadResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/threads/%d/read", threadID), bytes.NewReader(data))
+	if err != nil {
+		return markReadResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	tc.authorize(req, userID)
+
+	var response markReadResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return markReadResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) blockUser(userID, blockedID int64) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/blocks/%d", userID, blockedID), nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) unblockUser(userID, blockedID int64) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/blocks/%d", userID, blockedID), nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) requestVerification(userID int64) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/verification", userID), nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) verifyEmail(userID int64, token string) (userResponse, error) {
+	body := map[string]any{
+		"token": token,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/verification", userID), bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) createUserAsAdmin(id int64, nickname, email, adminKey string) (userResponse, error) {
+	body := map[string]any{
+		"nickname": nickname,
+		"email":    email,
+		"user_id":  id,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/users", bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) register(nickname, email, password string) (userResponse, error) {
+	body := map[string]any{
+		"nickname": nickname,
+		"email":    email,
+		"password": password,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/accounts", bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) login(email, password string) (sessionResponse, error) {
+	body := map[string]any{
+		"email":    email,
+		"password": password,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return sessionResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/sessions", bytes.NewReader(data))
+	if err != nil {
+		return sessionResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response sessionResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return sessionResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) getAccount(token string) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/accounts/me", nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listTrash(userID int64) (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/trash", userID), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) restoreAd(userID, adID int64) (adResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/restore", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listAdsSorted(sort string) (adsResponse, error) {
+	query := url.Values{"published_only": {"false"}, "sort": {sort}}
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads?"+query.Encode(), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listAdsFiltered(q string) (adsResponse, error) {
+	query := url.Values{"published_only": {"false"}, "q": {q}}
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads?"+query.Encode(), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) getAdsByTitleSorted(title string, sort string) (adsResponse, error) {
+	query := url.Values{"title": {title}, "sort": {sort}}
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads/by_title?"+query.Encode(), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) exportAds(query url.Values) (string, string, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads/export?"+query.Encode(), nil)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to create request: %w", err)
+	}
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("unexpected error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusBadRequest {
+		return "", "", ErrBadRequest
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read response: %w", err)
+	}
+	return string(respBody), resp.Header.Get("Content-Type"), nil
+}
+
+func (tc *testClient) importAds(format string, body string, dryRun bool, adminKey string) (bulk.Report, error) {
+	query := url.Values{"format": {format}, "dry_run": {strconv.FormatBool(dryRun)}}
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/ads/import?"+query.Encode(), strings.NewReader(body))
+	if err != nil {
+		return bulk.Report{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response bulk.Report
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return bulk.Report{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listAudit(target string, adminKey string) (auditResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/audit?target="+url.QueryEscape(target), nil)
+	if err != nil {
+		return auditResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response auditResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return auditResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) getUserStats(userID int64, query url.Values) (statsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("%s/api/v1/users/%d/stats?%s", tc.baseURL, userID, query.Encode()), nil)
+	if err != nil {
+		return statsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response statsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return statsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) reportAd(adID int64, userID int64, reason string, comment string) (reportResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"reason":  reason,
+		"comment": comment,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return reportResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/reports", adID),
+		bytes.NewReader(data))
+	if err != nil {
+		return reportResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	var response reportResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return reportResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listReports(status string, adminKey string) (reportsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/reports?status="+url.QueryEscape(status), nil)
+	if err != nil {
+		return reportsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response reportsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return reportsResponse{}, err
+	}
+
+	return response, nil
+}
+
+// moderateReport resolves or dismisses the report depending on action.
+func (tc *testClient) moderateReport(reportID int64, action string, adminKey string) (reportResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/reports/%d/%s", reportID, action), nil)
+	if err != nil {
+		return reportResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response reportResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return reportResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) setAdLanguage(userID int64, adID int64, lang string) (adResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"lang":    lang,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/lang", adID),
+		bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listAdsByLang(lang string) (adsResponse, error) {
+	query := url.Values{"published_only": {"false"}, "lang": {lang}}
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads?"+query.Encode(), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+// createAdError creates an ad expecting it to fail, it returns the error message of the response
+// and its Content-Language header.
+func (tc *testClient) createAdError(userID int64, title string, text string) (string, string, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"title":   title,
+		"text":    text,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/ads", bytes.NewReader(data))
+	if err != nil {
+		return "", "", fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if tc.language != "" {
+		req.Header.Set("Accept-Language", tc.language)
+	}
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("unexpected error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", "", fmt.Errorf("unable to unmarshal: %w", err)
+	}
+	return response.Error, resp.Header.Get("Content-Language"), nil
+}
+
+func (tc *testClient) createWebhook(body map[string]any, adminKey string) (webhookResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return webhookResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/webhooks", bytes.NewReader(data))
+	if err != nil {
+		return webhookResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response webhookResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return webhookResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listWebhooks(adminKey string) (webhooksResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/webhooks", nil)
+	if err != nil {
+		return webhooksResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response webhooksResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return webhooksResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) deleteWebhook(webhookID int64, adminKey string) (webhookResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/webhooks/%d", webhookID), nil)
+	if err != nil {
+		return webhookResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response webhookResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return webhookResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listDeliveries(webhookID int64, status string, adminKey string) (deliveriesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf(tc.baseURL+"/api/v1/webhooks/%d/deliveries?status=%s", webhookID, url.QueryEscape(status)), nil)
+	if err != nil {
+		return deliveriesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response deliveriesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return deliveriesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listDeadLetters(adminKey string) (deliveriesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/webhooks/dead_letters", nil)
+	if err != nil {
+		return deliveriesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response deliveriesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return deliveriesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) redeliverWebhook(deliveryID int64, adminKey string) (deliveryResponse, error) {
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf(tc.baseURL+"/api/v1/webhooks/deliveries/%d/redeliver", deliveryID), nil)
+	if err != nil {
+		return deliveryResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("X-Admin-Key", adminKey)
+
+	var response deliveryResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return deliveryResponse{}, err
+	}
+
+	return response, nil
+}
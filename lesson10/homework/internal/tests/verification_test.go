@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/mailer"
+	"homework10/internal/adapters/tokens"
+	"homework10/internal/adapters/wal"
+	"homework10/internal/app"
+	"homework10/internal/email"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmailIsValid(t *testing.T) {
+	type Test struct {
+		address string
+		isValid bool
+	}
+
+	tests := []Test{
+		{"example@mail.ru", true},
+		{"first.last+tag@sub.mail.com", true},
+		{"Example@Mail.Ru", true},
+		{"examplemail.ru", false},
+		{"@mail.ru", false},
+		{"example@", false},
+		{"example@localhost", false},
+		{"example@mail..ru", false},
+		{"example@-mail.ru", false},
+		{"Example <example@mail.ru>", false},
+		{"<example@mail.ru>", false},
+		{"exa mple@mail.ru", false},
+		{strings.Repeat("a", 250) + "@mail.ru", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.isValid, email.IsValid(test.address), test.address)
+	}
+}
+
+func TestEmailUniqueness(t *testing.T) {
+	client := getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New()))
+
+	_, err := client.createUser(1, "first", "Example@Mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createUser(2, "second", "example@mail.RU")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createUser(2, "second", "examplemail.ru")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createUser(2, "second", "second@mail.ru")
+	assert.NoError(t, err)
+
+	_, err = client.changeUserInfo(2, "second", "EXAMPLE@mail.ru")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	u, err := client.changeUserInfo(1, "first", "example@mail.ru")
+	assert.NoError(t, err)
+	assert.Equal(t, "example@mail.ru", u.Data.Email)
+
+	_, err = client.deleteUserByID(1)
+	assert.NoError(t, err)
+	_, err = client.changeUserInfo(2, "second", "EXAMPLE@mail.ru")
+	assert.NoError(t, err)
+}
+
+func TestEmailUniqueness_Recovery(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	log, err := wal.Open(filepath.Join(dir, "users"), wal.Options{Sync: wal.SyncAlways})
+	assert.NoError(t, err)
+	users, err := customer.NewPersistent(log)
+	assert.NoError(t, err)
+	_, _ = users.CreateByID(ctx, "first", "first@mail.ru", 1)
+	_, _ = users.CreateByID(ctx, "second", "second@mail.ru", 2)
+	_ = users.ChangeInfo(ctx, 1, "first", "changed@mail.ru")
+	assert.NoError(t, log.Close())
+
+	log, err = wal.Open(filepath.Join(dir, "users"), wal.Options{Sync: wal.SyncAlways})
+	assert.NoError(t, err)
+	defer log.Close()
+	users, err = customer.NewPersistent(log)
+	assert.NoError(t, err)
+	_, err = users.CreateByID(ctx, "third", "Changed@mail.ru", 3)
+	assert.ErrorIs(t, err, app.ErrEmailTaken)
+	_, err = users.CreateByID(ctx, "third", "first@mail.ru", 3)
+	assert.NoError(t, err)
+}
+
+func getVerificationClient(ttl time.Duration) (*testClient, *mailer.MemoryMailer) {
+	m := mailer.NewMemory()
+	a := app.NewApp(adrepo.New(), customer.New(), adfilter.New(),
+		app.WithVerification(tokens.New(), m, ttl))
+	return getTestClient(a), m
+}
+
+// lastToken extracts the verification token from the latest letter sent to the address.
+func lastToken(t *testing.T, m *mailer.MemoryMailer, to string) string {
+	letter, isFound := m.Last(to)
+	assert.True(t, isFound)
+	line := strings.SplitN(letter.Body, "\n", 2)[0]
+	return line[strings.LastIndexByte(line, ' ')+1:]
+}
+
+func TestEmailVerification(t *testing.T) {
+	client, m := getVerificationClient(time.Hour)
+
+	u, err := client.createUser(1, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	assert.False(t, u.Data.Verified)
+	token := lastToken(t, m, "author@mail.ru")
+
+	ad, _ := client.createAd(1, "aba", "caba")
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.verifyEmail(1, "wrong token")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	u, err = client.verifyEmail(1, token)
+	assert.NoError(t, err)
+	assert.True(t, u.Data.Verified)
+	_, err = client.verifyEmail(1, token)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	u, err = client.changeUserInfo(1, "author", "Author@mail.ru")
+	assert.NoError(t, err)
+	assert.True(t, u.Data.Verified)
+	u, err = client.changeUserInfo(1, "author", "new@mail.ru")
+	assert.NoError(t, err)
+	assert.False(t, u.Data.Verified)
+	_, err = client.changeAdStatus(1, ad.Data.ID, false)
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	first := lastToken(t, m, "new@mail.ru")
+	_, err = client.requestVerification(1)
+	assert.NoError(t, err)
+	second := lastToken(t, m, "new@mail.ru")
+	assert.NotEqual(t, first, second)
+	_, err = client.verifyEmail(1, first)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	u, err = client.verifyEmail(1, second)
+	assert.NoError(t, err)
+	assert.True(t, u.Data.Verified)
+	assert.Len(t, m.Sent(), 3)
+}
+
+func TestEmailVerification_WrongUser(t *testing.T) {
+	client, m := getVerificationClient(time.Hour)
+	_, _ = client.createUser(1, "first", "first@mail.ru")
+	_, _ = client.createUser(2, "second", "second@mail.ru")
+
+	_, err := client.verifyEmail(2, lastToken(t, m, "first@mail.ru"))
+	assert.ErrorIs(t, err, ErrBadRequest)
+	u, _ := client.getUserByID(1)
+	assert.False(t, u.Data.Verified)
+}
+
+func TestEmailVerification_Expired(t *testing.T) {
+	client, m := getVerificationClient(time.Millisecond)
+	_, _ = client.createUser(1, "author", "author@mail.ru")
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := client.verifyEmail(1, lastToken(t, m, "author@mail.ru"))
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail")
+	m := mailer.NewFile(path)
+	ctx := context.Background()
+	assert.NoError(t, m.Send(ctx, email.Message{To: "first@mail.ru", Subject: "aba", Body: "caba"}))
+	assert.NoError(t, m.Send(ctx, email.Message{To: "second@mail.ru", Subject: "foo", Body: "bar"}))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"to":"first@mail.ru","subject":"aba","body":"caba"}`+"\n"+
+		`{"to":"second@mail.ru","subject":"foo","body":"bar"}`+"\n", string(data))
+}
@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adpattern"
+	"homework10/internal/app"
+	"homework10/internal/ports/httpgin"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// webClient is a browser of the web UI keeping cookies between requests.
+type webClient struct {
+	t       *testing.T
+	client  *http.Client
+	baseURL string
+}
+
+func newWebClient(t *testing.T, server *httptest.Server) *webClient {
+	jar, err := cookiejar.New(nil)
+	assert.NoError(t, err)
+	return &webClient{t: t, client: &http.Client{Jar: jar}, baseURL: server.URL + "/web"}
+}
+
+func (wc *webClient) get(path string) (int, string) {
+	resp, err := wc.client.Get(wc.baseURL + path)
+	assert.NoError(wc.t, err)
+	return wc.read(resp)
+}
+
+// post submits a form with the CSRF token of the client added, unless the form has its own.
+func (wc *webClient) post(path string, form url.Values) (int, string) {
+	if _, ok := form["csrf_token"]; !ok {
+		form.Set("csrf_token", wc.csrfToken())
+	}
+	resp, err := wc.client.PostForm(wc.baseURL+path, form)
+	assert.NoError(wc.t, err)
+	return wc.read(resp)
+}
+
+func (wc *webClient) read(resp *http.Response) (int, string) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(wc.t, err)
+	return resp.StatusCode, string(body)
+}
+
+func (wc *webClient) csrfToken() string {
+	u, err := url.Parse(wc.baseURL + "/")
+	assert.NoError(wc.t, err)
+	for _, c := range wc.client.Jar.Cookies(u) {
+		if c.Name == "csrf_token" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func newWebServer(t *testing.T) (app.App, *httptest.Server) {
+	a := getAccountsApp()
+	server := httptest.NewServer(httpgin.NewHTTPServer(":18080", a).Handler)
+	t.Cleanup(server.Close)
+	return a, server
+}
+
+func TestWeb_BrowseAds(t *testing.T) {
+	a, server := newWebServer(t)
+	ctx := context.Background()
+	u, err := a.Register(ctx, "tom", "tom@mail.ru", "password")
+	assert.NoError(t, err)
+	published, err := a.CreateAd(ctx, "Bike <script>", "Selling my bike, it is in good condition", u.ID)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ctx, published.ID, u.ID, true)
+	assert.NoError(t, err)
+	draft, err := a.CreateAd(ctx, "Draft", "not ready yet", u.ID)
+	assert.NoError(t, err)
+
+	wc := newWebClient(t, server)
+	status, body := wc.get("/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "Bike &lt;script&gt;")
+	assert.NotContains(t, body, "<script>")
+	assert.NotContains(t, body, "Draft")
+
+	status, body = wc.get("/?lang=en&sort=-creation_date")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "Bike &lt;script&gt;")
+	status, body = wc.get("/?lang=ru")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "No ads found.")
+	status, body = wc.get("/?q=" + url.QueryEscape(`title ~ "bike"`))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "Bike &lt;script&gt;")
+
+	status, body = wc.get("/?lang=" + url.QueryEscape("not a language"))
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "wrong format: bad language")
+
+	status, _ = wc.get("/ads/" + itoa(published.ID))
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = wc.get("/ads/" + itoa(draft.ID))
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = wc.get("/ads/abc")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, body = wc.get("/static/style.css")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "font-family")
+}
+
+func TestWeb_Dashboard(t *testing.T) {
+	a, server := newWebServer(t)
+	ctx := context.Background()
+	_, err := a.Register(ctx, "tom", "tom@mail.ru", "password")
+	assert.NoError(t, err)
+	ann, err := a.Register(ctx, "ann", "ann@mail.ru", "password")
+	assert.NoError(t, err)
+	other, err := a.CreateAd(ctx, "Guitar", "Acoustic guitar", ann.ID)
+	assert.NoError(t, err)
+
+	wc := newWebClient(t, server)
+	// the dashboard needs a login
+	status, body := wc.get("/dashboard")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `name="password"`)
+
+	status, body = wc.post("/login", url.Values{"email": {"tom@mail.ru"}, "password": {"wrong"}})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, body, "permission denied")
+
+	status, body = wc.post("/login", url.Values{"email": {"tom@mail.ru"}, "password": {"password"}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "You have no ads yet.")
+	assert.Contains(t, body, "tom")
+
+	status, body = wc.post("/dashboard/ads", url.Values{"title": {"Bike"}, "text": {"Red bike"}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "draft")
+	ads, err := a.GetAllAdsByTemplate(ctx, adPatternOfAuthor(t, a, "tom@mail.ru", "password"))
+	assert.NoError(t, err)
+	assert.Len(t, ads, 1)
+	adID := itoa(ads[0].ID)
+
+	// drafts are seen by their authors only
+	status, _ = wc.get("/ads/" + adID)
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = newWebClient(t, server).get("/ads/" + adID)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = wc.post("/dashboard/ads/"+adID, url.Values{"title": {"Bike"}, "text": {"Blue bike"}})
+	assert.Equal(t, http.StatusOK, status)
+	status, body = wc.post("/dashboard/ads/"+adID+"/status", url.Values{"published": {"true"}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "Unpublish")
+
+	status, body = newWebClient(t, server).get("/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "Blue bike")
+
+	status, body = wc.post("/dashboard/ads", url.Values{"title": {""}, "text": {"no title"}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "wrong format")
+	assert.Contains(t, body, "no title")
+
+	// ads of others can't be changed
+	status, _ = wc.get("/dashboard/ads/" + itoa(other.ID) + "/edit")
+	assert.Equal(t, http.StatusForbidden, status)
+	status, _ = wc.post("/dashboard/ads/"+itoa(other.ID), url.Values{"title": {"Mine"}, "text": {"now"}})
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, body = wc.post("/logout", url.Values{})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "Log in")
+	status, body = wc.get("/dashboard")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `name="password"`)
+}
+
+func TestWeb_CSRF(t *testing.T) {
+	a, server := newWebServer(t)
+	_, err := a.Register(context.Background(), "tom", "tom@mail.ru", "password")
+	assert.NoError(t, err)
+
+	wc := newWebClient(t, server)
+	credentials := url.Values{"email": {"tom@mail.ru"}, "password": {"password"}}
+
+	// a form posted from another site has no token
+	status, body := wc.post("/login", url.Values{"email": credentials["email"],
+		"password": credentials["password"], "csrf_token": {""}})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, body, "bad csrf token")
+
+	status, _ = wc.get("/login")
+	assert.Equal(t, http.StatusOK, status)
+	token := wc.csrfToken()
+	assert.NotEmpty(t, token)
+	status, _ = wc.post("/login", url.Values{"email": credentials["email"],
+		"password": credentials["password"], "csrf_token": {token + "x"}})
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, body = wc.post("/login", credentials)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "You have no ads yet.")
+	assert.Equal(t, token, wc.csrfToken())
+
+	status, _ = wc.post("/dashboard/ads", url.Values{"title": {"Bike"}, "text": {"Red bike"},
+		"csrf_token": {"forged"}})
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// error pages follow Accept-Language
+	req, err := http.NewRequest(http.MethodPost, wc.baseURL+"/logout", strings.NewReader(""))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept-Language", "ru")
+	resp, err := wc.client.Do(req)
+	assert.NoError(t, err)
+	status, body = wc.read(resp)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, body, `<html lang="ru">`)
+}
+
+func adPatternOfAuthor(t *testing.T, a app.App, email, password string) adpattern.AdPattern {
+	s, err := a.Login(context.Background(), email, password)
+	assert.NoError(t, err)
+	return adpattern.AdPattern{AuthorID: s.UserID}
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
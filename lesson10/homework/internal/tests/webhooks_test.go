@@ -0,0 +1,419 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/adfilter"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/customer"
+	"homework10/internal/adapters/sqlstore"
+	"homework10/internal/adapters/tenancy"
+	"homework10/internal/adapters/webhooks"
+	"homework10/internal/app"
+	"homework10/internal/tenant"
+	"homework10/internal/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const webhookSecret = "0123456789abcdef"
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver answers requests with statuses in order, the last one is repeated.
+type webhookReceiver struct {
+	mx       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, *httptest.Server) {
+	r := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mx.Lock()
+		r.received = append(r.received, receivedWebhook{header: req.Header.Clone(), body: body})
+		status := r.statuses[0]
+		if len(r.statuses) > 1 {
+			r.statuses = r.statuses[1:]
+		}
+		r.mx.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.statuses = []int{status}
+}
+
+func (r *webhookReceiver) requests() []receivedWebhook {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return append([]receivedWebhook{}, r.received...)
+}
+
+func newWebhooksApp(t *testing.T, opts webhooks.Options) app.App {
+	w := webhooks.New()
+	l := webhooks.NewDeliveries()
+	d := webhooks.NewDispatcher(w, l, opts)
+	t.Cleanup(d.Close)
+	return app.NewApp(adrepo.New(), customer.New(), adfilter.New(), app.WithAdminKey(moderatorKey),
+		app.WithWebhooks(w, l, d))
+}
+
+// waitDeliveries waits until all deliveries of the webhook have the status.
+func waitDeliveries(t *testing.T, client *testClient, webhookID int64, status string, count int) []deliveryData {
+	var list deliveriesResponse
+	assert.Eventually(t, func() bool {
+		var err error
+		list, err = client.listDeliveries(webhookID, "", moderatorKey)
+		if err != nil || len(list.Data) != count {
+			return false
+		}
+		for _, dl := range list.Data {
+			if dl.Status != status {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	return list.Data
+}
+
+func TestWebhooks_Delivery(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, http.StatusOK)
+	client := getTestClient(newWebhooksApp(t, webhooks.Options{}))
+	for i, name := range []string{"tom", "cat"} {
+		_, err := client.createUserAsAdmin(int64(i+1), name, name+"@mail.ru", moderatorKey)
+		assert.NoError(t, err)
+	}
+
+	hook, err := client.createWebhook(map[string]any{"url": server.URL, "secret": webhookSecret,
+		"events": []string{"ad.published", "ad.deleted"}, "author_id": 1, "q": `title ~ "aba"`}, moderatorKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ad.published", "ad.deleted"}, hook.Data.Events)
+	assert.Equal(t, int64(1), hook.Data.AuthorID)
+	assert.Empty(t, hook.Data.Secret)
+
+	ad, err := client.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+	// ads of other authors or with other titles don't match the webhook
+	other, err := client.createAd(2, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(2, other.Data.ID, true)
+	assert.NoError(t, err)
+	another, err := client.createAd(1, "dog", "caba")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, another.Data.ID, true)
+	assert.NoError(t, err)
+	_, err = client.deleteAd(1, ad.Data.ID)
+	assert.NoError(t, err)
+
+	list := waitDeliveries(t, client, hook.Data.ID, "delivered", 2)
+	assert.Equal(t, "ad.published", list[0].Event)
+	assert.Equal(t, "ad.deleted", list[1].Event)
+	for _, dl := range list {
+		assert.Equal(t, ad.Data.ID, dl.AdID)
+		assert.Equal(t, 1, dl.Attempts)
+		assert.Equal(t, http.StatusOK, dl.ResponseStatus)
+		assert.Nil(t, dl.NextAttempt)
+	}
+
+	requests := receiver.requests()
+	assert.Len(t, requests, 2)
+	// workers send deliveries at once, so requests may come in any order
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].header.Get(webhook.DeliveryHeader) < requests[j].header.Get(webhook.DeliveryHeader)
+	})
+	for i, r := range requests {
+		timestamp, err := strconv.ParseInt(r.header.Get(webhook.TimestampHeader), 10, 64)
+		assert.NoError(t, err)
+		assert.True(t, webhook.Verify(webhookSecret, timestamp, r.body, r.header.Get(webhook.SignatureHeader)))
+		assert.False(t, webhook.Verify("fedcba9876543210", timestamp, r.body, r.header.Get(webhook.SignatureHeader)))
+		assert.Equal(t, list[i].Event, r.header.Get(webhook.EventHeader))
+		assert.Equal(t, strconv.FormatInt(list[i].ID, 10), r.header.Get(webhook.DeliveryHeader))
+		assert.JSONEq(t, string(list[i].Payload), string(r.body))
+
+		var payload struct {
+			Event string `json:"event"`
+			Ad    struct {
+				ID       int64  `json:"id"`
+				Title    string `json:"title"`
+				AuthorID int64  `json:"author_id"`
+			} `json:"ad"`
+		}
+		assert.NoError(t, json.Unmarshal(r.body, &payload))
+		assert.Equal(t, list[i].Event, payload.Event)
+		assert.Equal(t, ad.Data.ID, payload.Ad.ID)
+		assert.Equal(t, "aba", payload.Ad.Title)
+		assert.Equal(t, int64(1), payload.Ad.AuthorID)
+	}
+}
+
+func TestWebhooks_DeletedUser(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, http.StatusNoContent)
+	client := getTestClient(newWebhooksApp(t, webhooks.Options{}))
+	_, err := client.createUserAsAdmin(1, "tom", "tom@mail.ru", moderatorKey)
+	assert.NoError(t, err)
+	hook, err := client.createWebhook(map[string]any{"url": server.URL, "secret": webhookSecret,
+		"events": []string{"ad.deleted"}}, moderatorKey)
+	assert.NoError(t, err)
+
+	published, err := client.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, published.Data.ID, true)
+	assert.NoError(t, err)
+	// drafts don't match the default pattern
+	_, err = client.createAd(1, "dog", "caba")
+	assert.NoError(t, err)
+	_, err = client.deleteUserByID(1)
+	assert.NoError(t, err)
+
+	list := waitDeliveries(t, client, hook.Data.ID, "delivered", 1)
+	assert.Equal(t, "ad.deleted", list[0].Event)
+	assert.Equal(t, published.Data.ID, list[0].AdID)
+	assert.Len(t, receiver.requests(), 1)
+}
+
+func TestWebhooks_Retries(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK)
+	client := getTestClient(newWebhooksApp(t, webhooks.Options{InitialBackoff: 10 * time.Millisecond}))
+	_, err := client.createUserAsAdmin(1, "tom", "tom@mail.ru", moderatorKey)
+	assert.NoError(t, err)
+	hook, err := client.createWebhook(map[string]any{"url": server.URL, "secret": webhookSecret,
+		"events": []string{"ad.published"}}, moderatorKey)
+	assert.NoError(t, err)
+
+	ad, err := client.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	list := waitDeliveries(t, client, hook.Data.ID, "delivered", 1)
+	assert.Equal(t, 3, list[0].Attempts)
+	assert.Empty(t, list[0].LastError)
+	requests := receiver.requests()
+	assert.Len(t, requests, 3)
+	// every attempt is the same delivery
+	for _, r := range requests {
+		assert.Equal(t, requests[0].body, r.body)
+		assert.Equal(t, requests[0].header.Get(webhook.DeliveryHeader), r.header.Get(webhook.DeliveryHeader))
+	}
+}
+
+func TestWebhooks_DeadLetters(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+	}{
+		{"client error", http.StatusBadRequest, 1},
+		{"attempts exceeded", http.StatusServiceUnavailable, 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			receiver, server := newWebhookReceiver(t, tc.status)
+			client := getTestClient(newWebhooksApp(t, webhooks.Options{MaxAttempts: 3,
+				InitialBackoff: 5 * time.Millisecond}))
+			_, err := client.createUserAsAdmin(1, "tom", "tom@mail.ru", moderatorKey)
+			assert.NoError(t, err)
+			hook, err := client.createWebhook(map[string]any{"url": server.URL, "secret": webhookSecret,
+				"events": []string{"ad.published"}}, moderatorKey)
+			assert.NoError(t, err)
+			ad, err := client.createAd(1, "aba", "caba")
+			assert.NoError(t, err)
+			_, err = client.changeAdStatus(1, ad.Data.ID, true)
+			assert.NoError(t, err)
+
+			list := waitDeliveries(t, client, hook.Data.ID, "dead", 1)
+			assert.Equal(t, tc.attempts, list[0].Attempts)
+			assert.Equal(t, tc.status, list[0].ResponseStatus)
+			assert.NotEmpty(t, list[0].LastError)
+			dead, err := client.listDeadLetters(moderatorKey)
+			assert.NoError(t, err)
+			assert.Equal(t, list, dead.Data)
+			assert.Len(t, receiver.requests(), tc.attempts)
+
+			receiver.setStatus(http.StatusOK)
+			res, err := client.redeliverWebhook(list[0].ID, moderatorKey)
+			assert.NoError(t, err)
+			assert.Equal(t, "pending", res.Data.Status)
+			list = waitDeliveries(t, client, hook.Data.ID, "delivered", 1)
+			assert.Equal(t, tc.attempts+1, list[0].Attempts)
+			dead, err = client.listDeadLetters(moderatorKey)
+			assert.NoError(t, err)
+			assert.Empty(t, dead.Data)
+			// only dead deliveries are sent again
+			_, err = client.redeliverWebhook(list[0].ID, moderatorKey)
+			assert.ErrorIs(t, err, ErrConflict)
+		})
+	}
+}
+
+func TestWebhooks_DeletedWebhook(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, http.StatusBadRequest)
+	client := getTestClient(newWebhooksApp(t, webhooks.Options{}))
+	_, err := client.createUserAsAdmin(1, "tom", "tom@mail.ru", moderatorKey)
+	assert.NoError(t, err)
+	hook, err := client.createWebhook(map[string]any{"url": server.URL, "secret": webhookSecret,
+		"events": []string{"ad.published"}}, moderatorKey)
+	assert.NoError(t, err)
+	ad, err := client.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+	dead := waitDeliveries(t, client, hook.Data.ID, "dead", 1)
+
+	deleted, err := client.deleteWebhook(hook.Data.ID, moderatorKey)
+	assert.NoError(t, err)
+	assert.Equal(t, hook.Data, deleted.Data)
+	list, err := client.listWebhooks(moderatorKey)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Data)
+	_, err = client.changeAdStatus(1, ad.Data.ID, false)
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+	assert.Len(t, receiver.requests(), 1)
+
+	_, err = client.redeliverWebhook(dead[0].ID, moderatorKey)
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = client.listDeliveries(hook.Data.ID, "", moderatorKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestWebhooks_Tenants(t *testing.T) {
+	r, err := tenant.NewRegistry(map[tenant.ID]tenant.Config{"acme": {}})
+	assert.NoError(t, err)
+	receiver, server := newWebhookReceiver(t, http.StatusOK)
+	w := webhooks.New()
+	l := webhooks.NewDeliveries()
+	d := webhooks.NewDispatcher(w, l, webhooks.Options{})
+	t.Cleanup(d.Close)
+	client := getTestClient(app.NewApp(tenancy.NewRepository(adrepo.New), tenancy.NewUsers(customer.New),
+		adfilter.New(), app.WithTenants(r), app.WithAdminKey(moderatorKey), app.WithWebhooks(w, l, d)))
+	acme := client.withTenant("acme")
+
+	_, err = acme.createUserAsAdmin(1, "tom", "tom@mail.ru", moderatorKey)
+	assert.NoError(t, err)
+	hook, err := acme.createWebhook(map[string]any{"url": server.URL, "secret": webhookSecret,
+		"events": []string{"ad.published"}}, moderatorKey)
+	assert.NoError(t, err)
+	ad, err := acme.createAd(1, "aba", "caba")
+	assert.NoError(t, err)
+	_, err = acme.changeAdStatus(1, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	// workers find the webhook and record the outcome in the tenant of the delivery
+	list := waitDeliveries(t, acme, hook.Data.ID, "delivered", 1)
+	assert.Equal(t, 1, list[0].Attempts)
+	assert.Len(t, receiver.requests(), 1)
+	others, err := client.listDeliveries(0, "", moderatorKey)
+	assert.NoError(t, err)
+	assert.Empty(t, others.Data)
+}
+
+func TestWebhooks_BadRequests(t *testing.T) {
+	_, server := newWebhookReceiver(t, http.StatusOK)
+	client := getTestClient(newWebhooksApp(t, webhooks.Options{}))
+	valid := func(key string, value any) map[string]any {
+		res := map[string]any{"url": server.URL, "secret": webhookSecret, "events": []string{"ad.published"}}
+		res[key] = value
+		return res
+	}
+
+	tests := []struct {
+		name     string
+		body     map[string]any
+		adminKey string
+		err      error
+	}{
+		{"no admin key", valid("url", server.URL), "", ErrForbidden},
+		{"wrong admin key", valid("url", server.URL), "user", ErrForbidden},
+		{"no url", valid("url", ""), moderatorKey, ErrBadRequest},
+		{"bad scheme", valid("url", "ftp://example.com/hook"), moderatorKey, ErrBadRequest},
+		{"no host", valid("url", "http:///hook"), moderatorKey, ErrBadRequest},
+		{"short secret", valid("secret", "secret"), moderatorKey, ErrBadRequest},
+		{"no events", valid("events", []string{}), moderatorKey, ErrBadRequest},
+		{"unknown event", valid("events", []string{"ad.updated"}), moderatorKey, ErrBadRequest},
+		{"bad language", valid("lang", "klingon"), moderatorKey, ErrBadRequest},
+		{"bad expression", valid("q", `title ~ (`), moderatorKey, ErrBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.createWebhook(tc.body, tc.adminKey)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	_, err := client.listWebhooks("")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.listDeadLetters("")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.deleteWebhook(1, moderatorKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.listDeliveries(1, "", moderatorKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.redeliverWebhook(1, moderatorKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	hook, err := client.createWebhook(valid("lang", "en"), moderatorKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "en", hook.Data.Lang)
+	_, err = client.listDeliveries(hook.Data.ID, "lost", moderatorKey)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.deleteWebhook(hook.Data.ID, "")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = getTestClient(app.NewApp(adrepo.New(), customer.New(), adfilter.New(), app.WithAdminKey(moderatorKey))).
+		listWebhooks(moderatorKey)
+	assert.ErrorIs(t, err, InternalServerErr)
+}
+
+func TestWebhooks_Signature(t *testing.T) {
+	body := []byte(`{"event":"ad.published"}`)
+	signature := webhook.Sign(webhookSecret, 1700000000, body)
+	assert.True(t, webhook.Verify(webhookSecret, 1700000000, body, signature))
+	assert.False(t, webhook.Verify(webhookSecret, 1700000001, body, signature))
+	assert.False(t, webhook.Verify(webhookSecret, 1700000000, []byte(`{"event":"ad.deleted"}`), signature))
+	assert.False(t, webhook.Verify(webhookSecret, 1700000000, body, "sha256=00"))
+}
+
+func TestWebhooks_SQL(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	store := sqlstore.NewWebhookDeliveries(db)
+	now := time.Now().UTC()
+	columns := []string{"id", "tenant_id", "webhook_id", "event", "ad_id", "payload", "status", "attempts",
+		"last_error", "response_status", "creation_date", "update_date", "next_attempt"}
+
+	sqlMock.ExpectQuery("SELECT .+ FROM webhook_deliveries").
+		WithArgs("acme", int64(0), webhook.StatusDead).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, "acme", 1, "ad.published", 7, []byte(`{}`), "dead", 6, "unexpected status 503", 503, now, now, nil))
+	list, err := store.List(ctx, 0, webhook.StatusDead)
+	assert.NoError(t, err)
+	assert.Equal(t, []webhook.Delivery{{ID: 3, Tenant: "acme", SubscriptionID: 1, Event: webhook.EventPublished,
+		AdID: 7, Payload: []byte(`{}`), Status: webhook.StatusDead, Attempts: 6, LastError: "unexpected status 503",
+		ResponseStatus: 503, CreationDate: now, UpdateDate: now}}, list)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,19 @@
+package verification
+
+import (
+	"homework10/internal/tenant"
+	"time"
+)
+
+// Token confirms that the user owns Email, it is valid only while the user still has this email.
+type Token struct {
+	Value          string
+	Tenant         tenant.ID
+	UserID         int64
+	Email          string
+	ExpirationDate time.Time
+}
+
+func (t Token) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpirationDate)
+}
@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"homework10/internal/ads"
+	"homework10/internal/tenant"
+	"strconv"
+	"time"
+)
+
+// Headers of delivery requests.
+const (
+	EventHeader    = "X-Webhook-Event"
+	DeliveryHeader = "X-Webhook-Delivery"
+	// TimestampHeader is the Unix time the request was signed at, receivers should reject
+	// old ones to stop replays
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Sign returns the signature of a request body sent at timestamp, sha256= followed by the
+// hex HMAC-SHA256 of the timestamp, a dot and the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature made by Sign in constant time.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+type payload struct {
+	Event      Event     `json:"event"`
+	Tenant     tenant.ID `json:"tenant"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Ad         payloadAd `json:"ad"`
+}
+
+type payloadAd struct {
+	ID           int64     `json:"id"`
+	Title        string    `json:"title"`
+	Text         string    `json:"text"`
+	AuthorID     int64     `json:"author_id"`
+	Published    bool      `json:"published"`
+	Lang         string    `json:"lang,omitempty"`
+	CreationDate time.Time `json:"creation_date"`
+	UpdateDate   time.Time `json:"update_date"`
+}
+
+// NewPayload makes the JSON body of requests about the event, ad is its state before
+// the event for deletions.
+func NewPayload(e Event, tenantID tenant.ID, ad ads.Ad, at time.Time) ([]byte, error) {
+	return json.Marshal(payload{Event: e, Tenant: tenantID, OccurredAt: at, Ad: payloadAd{ID: ad.ID, Title: ad.Title,
+		Text: ad.Text, AuthorID: ad.AuthorID, Published: ad.Published, Lang: ad.Lang,
+		CreationDate: ad.CreationDate, UpdateDate: ad.UpdateDate}})
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"fmt"
+	"homework10/internal/adpattern"
+	"homework10/internal/tenant"
+	"net/url"
+	"time"
+)
+
+// Event is a change of an ad subscribers are called back about.
+type Event string
+
+const (
+	EventPublished Event = "ad.published"
+	EventDeleted   Event = "ad.deleted"
+)
+
+var ErrBadEvent = fmt.Errorf("bad webhook event")
+
+func ParseEvent(s string) (Event, error) {
+	switch e := Event(s); e {
+	case EventPublished, EventDeleted:
+		return e, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrBadEvent, s)
+}
+
+var ErrBadURL = fmt.Errorf("bad webhook url")
+
+// CheckURL accepts absolute http and https URLs.
+func CheckURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrBadURL, s)
+	}
+	return nil
+}
+
+// MinSecretLen is the shortest secret a subscription is signed with.
+const MinSecretLen = 16
+
+// Subscription asks for POST requests to URL about Events of ads matching Pattern.
+type Subscription struct {
+	ID      int64
+	Tenant  tenant.ID
+	URL     string
+	Events  []Event
+	Pattern adpattern.AdPattern
+	// Secret is the key requests are signed with, see Sign
+	Secret       string
+	CreationDate time.Time
+}
+
+func (s Subscription) Wants(e Event) bool {
+	for _, want := range s.Events {
+		if want == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Status of a delivery, dead deliveries make the dead-letter list and are retried only on request.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusDead      Status = "dead"
+)
+
+var ErrBadStatus = fmt.Errorf("bad delivery status")
+
+func ParseStatus(s string) (Status, error) {
+	switch st := Status(s); st {
+	case StatusPending, StatusDelivered, StatusDead:
+		return st, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrBadStatus, s)
+}
+
+// Delivery is an event sent to a subscription, it is kept in the delivery log with the
+// outcome of its last attempt.
+type Delivery struct {
+	ID             int64
+	Tenant         tenant.ID
+	SubscriptionID int64
+	Event          Event
+	AdID           int64
+	// Payload is the body of requests, see NewPayload
+	Payload  []byte
+	Status   Status
+	Attempts int
+	// LastError is empty unless the last attempt failed
+	LastError string
+	// ResponseStatus is the HTTP status of the last attempt, 0 if there was no response
+	ResponseStatus int
+	CreationDate   time.Time
+	// UpdateDate is the time of the last attempt or status change
+	UpdateDate time.Time
+	// NextAttempt is the time of the next retry of a pending delivery, zero if not scheduled
+	NextAttempt time.Time
+}